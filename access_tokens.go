@@ -0,0 +1,339 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+// Scopes an access token can be granted. Provisioning and destroying clusters both need to read configs, so
+// either one implies read-only.
+const (
+	scopeReadOnly  = "read-only"
+	scopeProvision = "provision"
+	scopeDestroy   = "destroy"
+)
+
+var accessTokenScopes = []string{scopeReadOnly, scopeProvision, scopeDestroy}
+
+// Every token starts with this, so it's recognizable in a header or a secret scanner
+const accessTokenPrefix = "k1s_"
+
+// accessToken is an API credential for machine-to-machine use. Only a hash of the secret is stored; the secret
+// itself is shown once, when the token is created.
+type accessToken struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+	// Zero when the token never expires
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+func (t accessToken) expired() bool {
+	return !t.ExpiresAt.IsZero() && time.Now().After(t.ExpiresAt)
+}
+
+func (t accessToken) allows(scope string) bool {
+	if contains(t.Scopes, scope) {
+		return true
+	}
+	return scope == scopeReadOnly && (contains(t.Scopes, scopeProvision) || contains(t.Scopes, scopeDestroy))
+}
+
+type accessTokensFile struct {
+	Tokens []accessToken `json:"tokens"`
+}
+
+var (
+	errUnknownAccessToken = errors.New("unknown access token")
+	errExpiredAccessToken = errors.New("access token has expired")
+)
+
+func getAccessTokensPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "access_tokens.json")
+}
+
+func loadAccessTokens() (accessTokensFile, error) {
+	var tokens accessTokensFile
+	data, err := os.ReadFile(getAccessTokensPath())
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return tokens, fmt.Errorf("error reading access_tokens.json: %w", err)
+	}
+	err = json.Unmarshal(data, &tokens)
+	if err != nil {
+		return tokens, fmt.Errorf("error parsing access_tokens.json: %w", err)
+	}
+	return tokens, nil
+}
+
+// updateAccessTokens applies change to the saved tokens and writes them back, holding the file's lock throughout
+func updateAccessTokens(change func(tokens *accessTokensFile)) error {
+	return withFileLock(getAccessTokensPath(), func() error {
+		tokens, err := loadAccessTokens()
+		if err != nil {
+			return err
+		}
+		change(&tokens)
+		data, err := json.MarshalIndent(tokens, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding access tokens: %w", err)
+		}
+		return writeFileAtomic(getAccessTokensPath(), data, 0600)
+	})
+}
+
+func hashAccessToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// createAccessToken stores a new token and returns it with its secret, e.g. k1s_1a2b3c4d_<random>
+func createAccessToken(name string, scopes []string, ttl time.Duration) (accessToken, string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return accessToken{}, "", fmt.Errorf("error generating token: %w", err)
+	}
+	id := hex.EncodeToString(random[:4])
+	secret := accessTokenPrefix + id + "_" + hex.EncodeToString(random[4:])
+
+	token := accessToken{
+		ID:        id,
+		Name:      name,
+		Scopes:    scopes,
+		Hash:      hashAccessToken(secret),
+		CreatedAt: time.Now().UTC(),
+	}
+	if ttl > 0 {
+		token.ExpiresAt = token.CreatedAt.Add(ttl)
+	}
+
+	err := updateAccessTokens(func(tokens *accessTokensFile) {
+		tokens.Tokens = append(tokens.Tokens, token)
+	})
+	if err != nil {
+		return accessToken{}, "", err
+	}
+	return token, secret, nil
+}
+
+// authenticateAccessToken returns the token a secret belongs to if it's current and grants scope
+func authenticateAccessToken(secret, scope string) (accessToken, error) {
+	tokens, err := loadAccessTokens()
+	if err != nil {
+		return accessToken{}, err
+	}
+	hash := hashAccessToken(secret)
+	for _, token := range tokens.Tokens {
+		if !hmac.Equal([]byte(token.Hash), []byte(hash)) {
+			continue
+		}
+		if token.expired() {
+			return token, errExpiredAccessToken
+		}
+		if !token.allows(scope) {
+			return token, fmt.Errorf("access token %s does not have the %s scope", token.Name, scope)
+		}
+		return token, nil
+	}
+	return accessToken{}, errUnknownAccessToken
+}
+
+// requireAccessToken wraps an HTTP handler so it only answers requests carrying a current access token with
+// scope, sent as "Authorization: Bearer k1s_..."
+func requireAccessToken(scope string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !strings.HasPrefix(secret, accessTokenPrefix) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="k1space"`)
+			http.Error(w, "an access token is required", http.StatusUnauthorized)
+			return
+		}
+		token, err := authenticateAccessToken(strings.TrimSpace(secret), scope)
+		switch {
+		case errors.Is(err, errUnknownAccessToken), errors.Is(err, errExpiredAccessToken):
+			w.Header().Set("WWW-Authenticate", `Bearer realm="k1space", error="invalid_token"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		case err != nil && token.ID != "":
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		case err != nil:
+			log.Error("Error checking access token", "path", r.URL.Path, "error", err)
+			http.Error(w, "access tokens could not be checked", http.StatusInternalServerError)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func revokeAccessToken(id string) error {
+	return updateAccessTokens(func(tokens *accessTokensFile) {
+		kept := tokens.Tokens[:0]
+		for _, token := range tokens.Tokens {
+			if token.ID != id {
+				kept = append(kept, token)
+			}
+		}
+		tokens.Tokens = kept
+	})
+}
+
+func manageAccessTokens() {
+	log.Info("Starting manageAccessTokens function")
+
+	for {
+		tokens, err := loadAccessTokens()
+		if err != nil {
+			log.Error("Error loading access tokens", "error", err)
+			fmt.Println("Failed to load access tokens:", err)
+			return
+		}
+		printAccessTokens(tokens)
+
+		var action string
+		err = runField(newSelect("Access Tokens", &action,
+			huh.NewOption("Create Token", "Create Token"),
+			huh.NewOption("Revoke Token", "Revoke Token"),
+			huh.NewOption("Back", "Back"),
+		))
+		if err != nil {
+			log.Error("Error in access tokens menu", "error", err)
+			return
+		}
+
+		switch action {
+		case "Create Token":
+			promptCreateAccessToken()
+		case "Revoke Token":
+			promptRevokeAccessToken(tokens)
+		case "Back":
+			return
+		}
+	}
+}
+
+func printAccessTokens(tokens accessTokensFile) {
+	if len(tokens.Tokens) == 0 {
+		fmt.Println("No access tokens.")
+		return
+	}
+	summary := [][]string{{"Name", "ID", "Scopes", "Created", "Expires", "Status"}}
+	for _, token := range tokens.Tokens {
+		expires := "never"
+		if !token.ExpiresAt.IsZero() {
+			expires = token.ExpiresAt.Local().Format("2006-01-02 15:04")
+		}
+		status := "active"
+		if token.expired() {
+			status = "expired"
+		}
+		summary = append(summary, []string{token.Name, token.ID, strings.Join(token.Scopes, ", "), token.CreatedAt.Local().Format("2006-01-02 15:04"), expires, status})
+	}
+	printSummaryTable("Access Tokens", summary)
+}
+
+func promptCreateAccessToken() {
+	var name string
+	scopes := []string{scopeReadOnly}
+	ttl := "720h"
+
+	scopeOptions := make([]huh.Option[string], len(accessTokenScopes))
+	for i, scope := range accessTokenScopes {
+		scopeOptions[i] = huh.NewOption(scope, scope)
+	}
+
+	err := runForm(newForm(
+		newGroup(
+			newInput("Token name", &name).
+				Description("What the token is for, e.g. ci-nightly").
+				Validate(func(s string) error {
+					if strings.TrimSpace(s) == "" {
+						return fmt.Errorf("name cannot be empty")
+					}
+					return nil
+				}),
+			newMultiSelect("Scopes", &scopes, scopeOptions...).
+				Description("provision and destroy include read-only").
+				Validate(func(s []string) error {
+					if len(s) == 0 {
+						return fmt.Errorf("select at least one scope")
+					}
+					return nil
+				}),
+			newSelect("Expires after", &ttl,
+				huh.NewOption("7 days", "168h"),
+				huh.NewOption("30 days", "720h"),
+				huh.NewOption("90 days", "2160h"),
+				huh.NewOption("1 year", "8760h"),
+				huh.NewOption("Never", "0"),
+			),
+		),
+	))
+	if err != nil {
+		log.Error("Error in access token form", "error", err)
+		return
+	}
+
+	duration, _ := time.ParseDuration(ttl)
+	token, secret, err := createAccessToken(strings.TrimSpace(name), scopes, duration)
+	if err != nil {
+		log.Error("Error creating access token", "error", err)
+		fmt.Println("Failed to create the access token:", err)
+		return
+	}
+	log.Info("Created access token", "name", token.Name, "id", token.ID, "scopes", token.Scopes)
+	fmt.Println(style.Render(fmt.Sprintf("Created access token %s. Copy it now, it won't be shown again:", token.Name)))
+	fmt.Println(secret)
+}
+
+func promptRevokeAccessToken(tokens accessTokensFile) {
+	if len(tokens.Tokens) == 0 {
+		return
+	}
+	options := make([]huh.Option[string], len(tokens.Tokens))
+	for i, token := range tokens.Tokens {
+		options[i] = huh.NewOption(fmt.Sprintf("%s (%s)", token.Name, token.ID), token.ID)
+	}
+
+	var id string
+	confirm := false
+	err := runForm(newForm(
+		newGroup(
+			newSelect("Select the token to revoke", &id, options...),
+			newConfirm("Revoke it? Anything using it will lose access.", &confirm),
+		),
+	))
+	if err != nil {
+		log.Error("Error in revoke token form", "error", err)
+		return
+	}
+	if !confirm {
+		fmt.Println("Token not revoked.")
+		return
+	}
+
+	err = revokeAccessToken(id)
+	if err != nil {
+		log.Error("Error revoking access token", "id", id, "error", err)
+		fmt.Println("Failed to revoke the access token:", err)
+		return
+	}
+	fmt.Println("Access token revoked.")
+}
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	airgapManifestFile  = "manifest.json"
+	airgapImagesFile    = "images.txt"
+	airgapChartsFile    = "charts.txt"
+	airgapProvidersDir  = "terraform-providers"
+	airgapTerraformRC   = "terraform.rc"
+	airgapKubefirstPath = "bin/kubefirst"
+)
+
+// Terraform providers used by kubefirst's gitops templates for every cloud
+var airgapCommonProviders = []string{
+	"hashicorp/vault",
+	"hashicorp/kubernetes",
+	"hashicorp/random",
+	"integrations/github",
+	"gitlabhq/gitlab",
+}
+
+var airgapCloudProviders = map[string][]string{
+	"akamai":       {"linode/linode"},
+	"civo":         {"civo/civo"},
+	"digitalocean": {"digitalocean/digitalocean"},
+	"google":       {"hashicorp/google"},
+	"vultr":        {"vultr/vultr"},
+}
+
+// airgapManifest describes what a bundle contains and what it was built for
+type airgapManifest struct {
+	Config             string    `json:"config"`
+	Cloud              string    `json:"cloud"`
+	Platform           string    `json:"platform"`
+	CreatedAt          time.Time `json:"created_at"`
+	KubefirstVersion   string    `json:"kubefirst_version"`
+	TerraformProviders []string  `json:"terraform_providers"`
+	Images             []string  `json:"images"`
+	// Helm charts the gitops template installs; the images they pull aren't in Images
+	Charts []string `json:"charts,omitempty"`
+}
+
+func getAirgapBundleDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".bundles")
+}
+
+func getAirgapImportDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".airgap")
+}
+
+// activeAirgapBundle returns the imported bundle directory from settings.hcl, or "" when online
+func activeAirgapBundle() string {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings, ignoring air-gapped bundle", "error", err)
+		return ""
+	}
+	return settings.AirgapBundle
+}
+
+// airgapEnv points terraform at the bundle's provider mirror instead of the public registry
+func airgapEnv() []string {
+	bundleDir := activeAirgapBundle()
+	if bundleDir == "" {
+		return nil
+	}
+	return []string{"TF_CLI_CONFIG_FILE=" + filepath.Join(bundleDir, airgapTerraformRC)}
+}
+
+func createAirgapBundle() {
+	log.Info("Starting createAirgapBundle function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations. Please ensure that the config.hcl file exists and is correctly formatted.")
+		return
+	}
+
+	selectedConfig, err := promptConfigSelection(indexFile, "Select a configuration to bundle")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations found.")
+		return
+	}
+
+	parts := strings.Split(selectedConfig, "_")
+	if len(parts) != 3 {
+		log.Error("Invalid config name format", "config", selectedConfig)
+		fmt.Println("Invalid configuration name format. Bundle cancelled.")
+		return
+	}
+	cloud := parts[0]
+
+	kubefirstPath := indexFile.Configs[selectedConfig].Flags["KUBEFIRST_PATH"]
+	if kubefirstPath == "" {
+		fmt.Println("No kubefirst binary is set for this configuration. Use 'Edit Kubefirst Binary Used for Config' first.")
+		return
+	}
+	if _, err := exec.LookPath("terraform"); err != nil {
+		fmt.Println("terraform could not be found. It is needed to mirror the terraform providers.")
+		return
+	}
+
+	stagingDir, err := os.MkdirTemp("", "k1space-airgap-*")
+	if err != nil {
+		log.Error("Error creating staging directory", "error", err)
+		return
+	}
+	defer os.RemoveAll(stagingDir)
+
+	manifest := airgapManifest{
+		Config:             selectedConfig,
+		Cloud:              cloud,
+		Platform:           runtime.GOOS + "_" + runtime.GOARCH,
+		CreatedAt:          time.Now().UTC(),
+		TerraformProviders: append(append([]string{}, airgapCommonProviders...), airgapCloudProviders[cloud]...),
+		Images:             append([]string{}, kubefirstComponentImages...),
+	}
+
+	s := startSpinner("Copying kubefirst binary...")
+	err = copyFile(kubefirstPath, filepath.Join(stagingDir, airgapKubefirstPath), 0755)
+	stopSpinner(s, err == nil)
+	if err != nil {
+		fmt.Println("Failed to copy kubefirst binary:", err)
+		return
+	}
+	if output, err := exec.Command(kubefirstPath, "version").Output(); err == nil {
+		manifest.KubefirstVersion = strings.TrimSpace(string(output))
+	}
+
+	s = startSpinner("Listing the images kubefirst's gitops template deploys...")
+	templateDir, err := fetchGitopsTemplate()
+	var images, charts []string
+	if err == nil {
+		images, charts, err = templateImages(templateDir, kubefirstCloudCommand(cloud))
+	}
+	stopSpinner(s, err == nil)
+	if err != nil {
+		log.Warn("Error reading images from the gitops template", "error", err)
+		fmt.Println("Could not read the gitops template, so images.txt only lists kubefirst's own images:", err)
+	}
+	for _, image := range images {
+		if !contains(manifest.Images, image) {
+			manifest.Images = append(manifest.Images, image)
+		}
+	}
+	manifest.Charts = charts
+
+	s = startSpinner(fmt.Sprintf("Mirroring %d terraform providers...", len(manifest.TerraformProviders)))
+	err = mirrorTerraformProviders(manifest.TerraformProviders, filepath.Join(stagingDir, airgapProvidersDir), manifest.Platform)
+	stopSpinner(s, err == nil)
+	if err != nil {
+		fmt.Println("Failed to mirror terraform providers:", err)
+		return
+	}
+
+	err = os.WriteFile(filepath.Join(stagingDir, airgapImagesFile), []byte(strings.Join(manifest.Images, "\n")+"\n"), 0644)
+	if err != nil {
+		log.Error("Error writing image list", "error", err)
+		return
+	}
+	if len(manifest.Charts) > 0 {
+		err = os.WriteFile(filepath.Join(stagingDir, airgapChartsFile), []byte(strings.Join(manifest.Charts, "\n")+"\n"), 0644)
+		if err != nil {
+			log.Error("Error writing chart list", "error", err)
+			return
+		}
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		log.Error("Error encoding bundle manifest", "error", err)
+		return
+	}
+	err = os.WriteFile(filepath.Join(stagingDir, airgapManifestFile), manifestData, 0644)
+	if err != nil {
+		log.Error("Error writing bundle manifest", "error", err)
+		return
+	}
+
+	err = os.MkdirAll(getAirgapBundleDir(), 0755)
+	if err != nil {
+		log.Error("Error creating bundle directory", "error", err)
+		return
+	}
+	bundlePath := filepath.Join(getAirgapBundleDir(), fmt.Sprintf("%s-%s.tar.gz", selectedConfig, time.Now().Format("20060102-150405")))
+	err = writeTarGz(bundlePath, stagingDir)
+	if err != nil {
+		log.Error("Error writing air-gapped bundle", "error", err)
+		fmt.Println("Failed to write air-gapped bundle:", err)
+		return
+	}
+
+	fmt.Println(style.Render("📦 Air-gapped bundle created"))
+	fmt.Printf("Bundle: %s\n", bundlePath)
+	fmt.Printf("Platform: %s\n", manifest.Platform)
+	fmt.Println("Copy it to the restricted network and use 'Cluster' -> 'Use Air-Gapped Bundle' there.")
+	fmt.Printf("Container images to mirror into your registry are listed in %s inside the bundle.\n", airgapImagesFile)
+	printAirgapChartsNote(manifest.Charts)
+	log.Info("createAirgapBundle function completed successfully", "bundle", bundlePath)
+}
+
+// mirrorTerraformProviders downloads providers into dir in the layout terraform's filesystem_mirror expects
+func mirrorTerraformProviders(providers []string, dir, platform string) error {
+	workDir, err := os.MkdirTemp("", "k1space-providers-*")
+	if err != nil {
+		return fmt.Errorf("error creating terraform working directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	var tf strings.Builder
+	tf.WriteString("terraform {\n  required_providers {\n")
+	for _, provider := range providers {
+		name := provider[strings.LastIndex(provider, "/")+1:]
+		tf.WriteString(fmt.Sprintf("    %s = {\n      source = %q\n    }\n", name, provider))
+	}
+	tf.WriteString("  }\n}\n")
+	err = os.WriteFile(filepath.Join(workDir, "providers.tf"), []byte(tf.String()), 0644)
+	if err != nil {
+		return fmt.Errorf("error writing providers.tf: %w", err)
+	}
+
+	cmd := exec.Command("terraform", "providers", "mirror", "-platform="+platform, dir)
+	cmd.Dir = workDir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("terraform providers mirror failed: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+func useAirgapBundle() {
+	if current := activeAirgapBundle(); current != "" {
+		fmt.Printf("Currently using air-gapped bundle: %s\n", current)
+
+		var disable bool
+		err := runField(newConfirm("Stop using this bundle and go back to downloading from the internet?", &disable))
+		if err != nil {
+			log.Error("Error in air-gapped bundle prompt", "error", err)
+			return
+		}
+		if disable {
+			err = saveSetting("airgap_bundle", cty.StringVal(""))
+			if err != nil {
+				log.Error("Error saving settings", "error", err)
+				return
+			}
+			fmt.Println("Air-gapped mode disabled.")
+			return
+		}
+	}
+
+	var bundlePath string
+	err := runField(newInput("Enter the path to the air-gapped bundle (.tar.gz)", &bundlePath).
+		Validate(func(path string) error {
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("bundle not found at %s", path)
+			}
+			return nil
+		}))
+	if err != nil {
+		log.Error("Error in bundle path prompt", "error", err)
+		return
+	}
+
+	bundleDir := filepath.Join(getAirgapImportDir(), strings.TrimSuffix(filepath.Base(bundlePath), ".tar.gz"))
+	err = os.RemoveAll(bundleDir)
+	if err != nil {
+		log.Error("Error clearing previous import", "path", bundleDir, "error", err)
+		return
+	}
+
+	s := startSpinner("Extracting air-gapped bundle...")
+	err = extractTarGz(bundlePath, bundleDir)
+	stopSpinner(s, err == nil)
+	if err != nil {
+		fmt.Println("Failed to extract bundle:", err)
+		return
+	}
+
+	manifestData, err := os.ReadFile(filepath.Join(bundleDir, airgapManifestFile))
+	if err != nil {
+		fmt.Println("This does not look like a k1space air-gapped bundle:", err)
+		return
+	}
+	var manifest airgapManifest
+	err = json.Unmarshal(manifestData, &manifest)
+	if err != nil {
+		fmt.Println("Failed to read bundle manifest:", err)
+		return
+	}
+	if platform := runtime.GOOS + "_" + runtime.GOARCH; manifest.Platform != platform {
+		fmt.Printf("Warning: bundle was built for %s but this machine is %s; terraform will not find matching providers.\n", manifest.Platform, platform)
+	}
+
+	terraformRC := fmt.Sprintf(`provider_installation {
+  filesystem_mirror {
+    path    = %q
+    include = ["*/*"]
+  }
+  direct {
+    exclude = ["*/*"]
+  }
+}
+`, filepath.Join(bundleDir, airgapProvidersDir))
+	err = os.WriteFile(filepath.Join(bundleDir, airgapTerraformRC), []byte(terraformRC), 0644)
+	if err != nil {
+		log.Error("Error writing terraform.rc", "error", err)
+		return
+	}
+
+	err = saveSetting("airgap_bundle", cty.StringVal(bundleDir))
+	if err != nil {
+		log.Error("Error saving settings", "error", err)
+		return
+	}
+
+	fmt.Println(style.Render("🔒 Air-gapped mode enabled"))
+	fmt.Printf("Bundle for %s extracted to %s\n", manifest.Config, bundleDir)
+	fmt.Printf("Kubefirst binary: %s\n", filepath.Join(bundleDir, airgapKubefirstPath))
+	fmt.Println("Terraform providers will be installed from the bundle during provisioning.")
+	fmt.Println("Make sure these images are available in your registry:")
+	for _, image := range manifest.Images {
+		fmt.Printf("  - %s\n", image)
+	}
+	printAirgapChartsNote(manifest.Charts)
+}
+
+// printAirgapChartsNote warns that images.txt is incomplete when the template installs Helm charts
+func printAirgapChartsNote(charts []string) {
+	if len(charts) == 0 {
+		return
+	}
+	fmt.Printf("The list isn't complete: the %d Helm charts in %s pull images of their own that aren't listed.\n", len(charts), airgapChartsFile)
+	fmt.Println("Render them with `helm template` to find those images, or mirror the charts' registries as well.")
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	err = os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+func writeTarGz(bundlePath, srcDir string) error {
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		return fmt.Errorf("error creating bundle file: %w", err)
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	err = filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		err = tw.WriteHeader(header)
+		if err != nil {
+			return fmt.Errorf("error adding %s to bundle: %w", rel, err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func extractTarGz(bundlePath, destDir string) error {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return fmt.Errorf("error opening bundle: %w", err)
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("error reading bundle: %w", err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading bundle: %w", err)
+		}
+
+		target := filepath.Join(destDir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(destDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("bundle entry %s escapes the destination directory", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			err = os.MkdirAll(filepath.Dir(target), 0755)
+			if err == nil {
+				err = writeTarEntry(tr, target, os.FileMode(header.Mode))
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("error extracting %s: %w", header.Name, err)
+		}
+	}
+}
+
+func writeTarEntry(r io.Reader, target string, mode os.FileMode) error {
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, r)
+	return err
+}
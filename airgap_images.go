@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"gopkg.in/yaml.v2"
+)
+
+// templateImages lists what kubefirst's gitops template deploys for a cloud, e.g. civo for civo-github and
+// civo-gitlab: the images its manifests reference directly, and the Helm charts its Argo CD applications install.
+// Charts bring images of their own, which can only be found by rendering them.
+func templateImages(templateDir, cloud string) ([]string, []string, error) {
+	images, charts := make(map[string]bool), make(map[string]bool)
+
+	dirs, err := filepath.Glob(filepath.Join(templateDir, cloud+"-*"))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, dir := range dirs {
+		err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || (filepath.Ext(path) != ".yaml" && filepath.Ext(path) != ".yml") {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("error reading %s: %w", path, err)
+			}
+			decoder := yaml.NewDecoder(bytes.NewReader(data))
+			for {
+				var doc interface{}
+				err := decoder.Decode(&doc)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					// Placeholders kubefirst fills in later leave some files invalid until then
+					log.Debug("Skipping unparseable template file", "path", path, "error", err)
+					break
+				}
+				collectImages(doc, images)
+				collectCharts(doc, charts)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return sortedKeys(images), sortedKeys(charts), nil
+}
+
+// collectImages finds image: values anywhere in a manifest, skipping ones that are still placeholders
+func collectImages(node interface{}, images map[string]bool) {
+	switch node := node.(type) {
+	case map[interface{}]interface{}:
+		for key, value := range node {
+			if image, ok := value.(string); ok && key == "image" && image != "" && !strings.ContainsAny(image, "<>{}$ ") {
+				images[image] = true
+			}
+			collectImages(value, images)
+		}
+	case []interface{}:
+		for _, item := range node {
+			collectImages(item, images)
+		}
+	}
+}
+
+// collectCharts finds the Helm charts an Argo CD Application installs, as <repo>/<chart>@<version>
+func collectCharts(node interface{}, charts map[string]bool) {
+	doc, ok := node.(map[interface{}]interface{})
+	if !ok || doc["kind"] != "Application" {
+		return
+	}
+	spec, _ := doc["spec"].(map[interface{}]interface{})
+	sources, _ := spec["sources"].([]interface{})
+	if source, ok := spec["source"]; ok {
+		sources = append(sources, source)
+	}
+	for _, source := range sources {
+		source, _ := source.(map[interface{}]interface{})
+		chart, _ := source["chart"].(string)
+		if chart == "" {
+			continue
+		}
+		repo, _ := source["repoURL"].(string)
+		version, _ := source["targetRevision"].(string)
+		charts[fmt.Sprintf("%s/%s@%s", strings.TrimSuffix(repo, "/"), chart, version)] = true
+	}
+}
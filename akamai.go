@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+const linodeAPI = "https://api.linode.com/v4"
+
+// GPU plan labels name the card, e.g. "Dedicated 32GB + RTX6000 GPU x1"
+var linodeGPULabelPattern = regexp.MustCompile(`\+\s*(\S+)\s+GPU`)
+
+type linodeClient struct {
+	token string
+}
+
+func getLinodeClient() (*linodeClient, error) {
+	token := lookupToken("LINODE_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("LINODE_TOKEN not found in environment or keychain. Please set it and try again")
+	}
+	return &linodeClient{token: token}, nil
+}
+
+func (c *linodeClient) request(method, path string, payload interface{}) ([]byte, error) {
+	return c.requestContext(context.Background(), method, path, payload)
+}
+
+func (c *linodeClient) requestContext(ctx context.Context, method, path string, payload interface{}) ([]byte, error) {
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, linodeAPI+"/"+path, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := newHTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return readResponseBody(resp)
+}
+
+// getAll requests every page of a list call and passes each page's data array to handlePage
+func (c *linodeClient) getAll(ctx context.Context, path string, handlePage func(json.RawMessage) error) error {
+	for page := 1; ; page++ {
+		body, err := c.requestContext(ctx, http.MethodGet, path+"?page_size=500&page="+strconv.Itoa(page), nil)
+		if err != nil {
+			return err
+		}
+
+		var result struct {
+			Data  json.RawMessage `json:"data"`
+			Pages int             `json:"pages"`
+		}
+		err = json.Unmarshal(body, &result)
+		if err != nil {
+			return err
+		}
+
+		err = handlePage(result.Data)
+		if err != nil {
+			return err
+		}
+		if page >= result.Pages {
+			return nil
+		}
+	}
+}
+
+func updateAkamaiRegions(ctx context.Context, cloudsFile *CloudsFile) error {
+	client, err := getLinodeClient()
+	if err != nil {
+		return err
+	}
+
+	var regionIDs []string
+	err = client.getAll(ctx, "regions", func(data json.RawMessage) error {
+		var regions []struct {
+			ID           string   `json:"id"`
+			Status       string   `json:"status"`
+			Capabilities []string `json:"capabilities"`
+		}
+		err := json.Unmarshal(data, &regions)
+		if err != nil {
+			return err
+		}
+		for _, region := range regions {
+			// Only regions where LKE is available can host a kubefirst cluster
+			if region.Status == "ok" && contains(region.Capabilities, "Kubernetes") {
+				regionIDs = append(regionIDs, region.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	cloudsFile.CloudRegions["Akamai"] = regionIDs
+	return nil
+}
+
+func updateAkamaiNodeTypes(ctx context.Context, cloudsFile *CloudsFile) error {
+	client, err := getLinodeClient()
+	if err != nil {
+		return err
+	}
+
+	var sizeInfos []InstanceSizeInfo
+	err = client.getAll(ctx, "linode/types", func(data json.RawMessage) error {
+		var types []struct {
+			ID     string `json:"id"`
+			Label  string `json:"label"`
+			VCPUs  int    `json:"vcpus"`
+			Memory int    `json:"memory"`
+			Disk   int    `json:"disk"`
+			GPUs   int    `json:"gpus"`
+			Price  struct {
+				Hourly  float64 `json:"hourly"`
+				Monthly float64 `json:"monthly"`
+			} `json:"price"`
+		}
+		err := json.Unmarshal(data, &types)
+		if err != nil {
+			return err
+		}
+		for _, linodeType := range types {
+			info := InstanceSizeInfo{
+				Name:          linodeType.ID,
+				CPUCores:      linodeType.VCPUs,
+				RAMMegabytes:  linodeType.Memory,
+				DiskGigabytes: linodeType.Disk / 1024,
+				Architecture:  detectArchitecture(linodeType.ID),
+				PriceHourly:   linodeType.Price.Hourly,
+				PriceMonthly:  linodeType.Price.Monthly,
+			}
+			if linodeType.GPUs > 0 {
+				info.GPUCount = linodeType.GPUs
+				if match := linodeGPULabelPattern.FindStringSubmatch(linodeType.Label); match != nil {
+					info.GPUModel = match[1]
+					info.GPUVRAMGigabytes = gpuVRAMGigabytes(match[1])
+				}
+			}
+			sizeInfos = append(sizeInfos, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	cloudsFile.CloudNodeTypes["Akamai"] = sizeInfos
+	return nil
+}
+
+// findDomainID looks up the ID of a domain managed by Linode DNS
+func (c *linodeClient) findDomainID(domain string) (int, error) {
+	domainID := 0
+	err := c.getAll(context.Background(), "domains", func(data json.RawMessage) error {
+		var domains []struct {
+			ID     int    `json:"id"`
+			Domain string `json:"domain"`
+		}
+		err := json.Unmarshal(data, &domains)
+		if err != nil {
+			return err
+		}
+		for _, d := range domains {
+			if d.Domain == domain {
+				domainID = d.ID
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if domainID == 0 {
+		return 0, fmt.Errorf("domain %s not found in Linode DNS", domain)
+	}
+	return domainID, nil
+}
+
+// createDNSRecord adds a record to a domain managed by Linode DNS and returns its ID
+func (c *linodeClient) createDNSRecord(domainID int, recordType, name, target string, ttl int) (int, error) {
+	body, err := c.request(http.MethodPost, fmt.Sprintf("domains/%d/records", domainID), map[string]interface{}{
+		"type":    recordType,
+		"name":    name,
+		"target":  target,
+		"ttl_sec": ttl,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var record struct {
+		ID int `json:"id"`
+	}
+	err = json.Unmarshal(body, &record)
+	return record.ID, err
+}
+
+func (c *linodeClient) deleteDNSRecord(domainID, recordID int) error {
+	_, err := c.request(http.MethodDelete, fmt.Sprintf("domains/%d/records/%d", domainID, recordID), nil)
+	return err
+}
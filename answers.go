@@ -0,0 +1,375 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+	"gopkg.in/yaml.v2"
+)
+
+// answersFile answers prompts for 'k1space --answers answers.yaml', so config creation and provisioning can run
+// unattended in CI. Answers are keyed by the prompt's title. A list answers a prompt that's shown more than once,
+// like a menu, one item per showing; for a multi-select that's a list of lists.
+type answersFile struct {
+	path    string
+	answers map[string]interface{}
+	// used counts the answers taken from each list
+	used map[string]int
+}
+
+// promptAnswers is set when k1space runs with --answers, and every prompt is answered from it instead of the terminal
+var promptAnswers *answersFile
+
+func loadAnswersFile(path string) (*answersFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading answers file: %w", err)
+	}
+	answers := make(map[string]interface{})
+	err = yaml.Unmarshal(data, &answers)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing answers file %s: %w", path, err)
+	}
+	return &answersFile{path: path, answers: answers, used: make(map[string]int)}, nil
+}
+
+func isHeadless() bool {
+	return promptAnswers != nil
+}
+
+// prompt is what answering a field from the answers file takes. huh doesn't expose a field's title, options or
+// value, so the constructors below record them as each field is built.
+type prompt struct {
+	title       string
+	options     []string
+	multiSelect bool
+	secret      bool
+	// answer sets the field's value through its accessor and returns the field's validation error
+	answer func(answer interface{}) error
+}
+
+// Only filled in when headless; entries are removed once their form or field has been answered
+var (
+	prompts     = make(map[huh.Field]*prompt)
+	groupFields = make(map[*huh.Group][]huh.Field)
+	groupHidden = make(map[*huh.Group]func() bool)
+	formGroups  = make(map[*huh.Form][]*huh.Group)
+)
+
+func registerPrompt(field huh.Field, p *prompt) {
+	if isHeadless() {
+		prompts[field] = p
+	}
+}
+
+func newInput(title string, value *string) *huh.Input {
+	field := huh.NewInput().Title(title).Value(value)
+	registerPrompt(field, &prompt{
+		title: title,
+		answer: func(answer interface{}) error {
+			*value = answerString(answer)
+			// Rebinding the value updates the text the field holds, which Blur validates and stores
+			field.Accessor(huh.NewPointerAccessor(value))
+			return blurForError(field)
+		},
+	})
+	return field
+}
+
+// newPasswordInput is newInput with its text hidden, in the terminal and in answered prompts
+func newPasswordInput(title string, value *string) *huh.Input {
+	field := newInput(title, value).EchoMode(huh.EchoModePassword)
+	if p, ok := prompts[field]; ok {
+		p.secret = true
+	}
+	return field
+}
+
+func newConfirm(title string, value *bool) *huh.Confirm {
+	field := huh.NewConfirm().Title(title).Value(value)
+	registerPrompt(field, &prompt{
+		title: title,
+		answer: func(answer interface{}) error {
+			confirmed, err := answerBool(answer)
+			if err != nil {
+				return err
+			}
+			*value = confirmed
+			return blurForError(field)
+		},
+	})
+	return field
+}
+
+func newSelect[T comparable](title string, value *T, options ...huh.Option[T]) *huh.Select[T] {
+	field := huh.NewSelect[T]().Title(title).Options(options...).Value(value)
+	registerPrompt(field, &prompt{
+		title:   title,
+		options: optionLabels(options),
+		answer: func(answer interface{}) error {
+			option, err := matchOption(options, answer)
+			if err != nil {
+				return err
+			}
+			*value = option
+			field.Accessor(huh.NewPointerAccessor(value))
+			return blurForError(field)
+		},
+	})
+	return field
+}
+
+func newMultiSelect[T comparable](title string, value *[]T, options ...huh.Option[T]) *huh.MultiSelect[T] {
+	field := huh.NewMultiSelect[T]().Title(title).Options(options...).Value(value)
+	registerPrompt(field, &prompt{
+		title:       title,
+		options:     optionLabels(options),
+		multiSelect: true,
+		answer: func(answer interface{}) error {
+			list, ok := answer.([]interface{})
+			if !ok {
+				list = []interface{}{answer}
+			}
+			values := make([]T, 0, len(list))
+			for _, item := range list {
+				option, err := matchOption(options, item)
+				if err != nil {
+					return err
+				}
+				values = append(values, option)
+			}
+			chosen := make([]huh.Option[T], len(options))
+			for i, option := range options {
+				chosen[i] = option.Selected(containsValue(values, option.Value))
+			}
+			*value = values
+			// Focusing stores the selected options and validates them
+			field.Options(chosen...)
+			field.Focus()
+			return blurForError(field)
+		},
+	})
+	return field
+}
+
+func newGroup(fields ...huh.Field) *huh.Group {
+	group := huh.NewGroup(fields...)
+	if isHeadless() {
+		groupFields[group] = fields
+	}
+	return group
+}
+
+// hideGroupWhen is WithHideFunc, with the group's prompts skipped in answers files too
+func hideGroupWhen(group *huh.Group, hide func() bool) *huh.Group {
+	if isHeadless() {
+		groupHidden[group] = hide
+	}
+	return group.WithHideFunc(hide)
+}
+
+func newForm(groups ...*huh.Group) *huh.Form {
+	form := huh.NewForm(groups...)
+	if isHeadless() {
+		formGroups[form] = groups
+	}
+	return form
+}
+
+// blurForError runs a field's validation the way leaving it in the terminal does
+func blurForError(field huh.Field) error {
+	field.Blur()
+	return field.Error()
+}
+
+// runForm runs a form, or answers it from the answers file when headless
+func runForm(form *huh.Form) error {
+	if !isHeadless() {
+		return form.Run()
+	}
+	exitOnAnswerError(answerForm(form))
+	return nil
+}
+
+// runField runs a single field, or answers it from the answers file when headless
+func runField(field huh.Field) error {
+	if !isHeadless() {
+		return field.Run()
+	}
+	exitOnAnswerError(promptAnswers.answerField(field))
+	return nil
+}
+
+// exitOnAnswerError stops a headless run at a prompt that can't be answered. Callers would log the error and
+// carry on at the next menu, taking answers meant for later prompts, and a pipeline needs the run to fail.
+func exitOnAnswerError(err error) {
+	if err != nil {
+		log.Error("Error answering prompt", "error", err)
+		os.Exit(1)
+	}
+}
+
+func answerForm(form *huh.Form) error {
+	groups, ok := formGroups[form]
+	if !ok {
+		return fmt.Errorf("a form wasn't built with newForm, so it can't be answered from %s", promptAnswers.path)
+	}
+	delete(formGroups, form)
+	for _, group := range groups {
+		fields, hide := groupFields[group], groupHidden[group]
+		delete(groupFields, group)
+		delete(groupHidden, group)
+		// Groups hidden by earlier answers are skipped, as they are in the terminal
+		if hide != nil && hide() {
+			continue
+		}
+		for _, field := range fields {
+			err := promptAnswers.answerField(field)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lookup returns the answer for a prompt, taking the next one from a list of answers
+func (a *answersFile) lookup(title string, multiSelect bool) (interface{}, error) {
+	name, ok := a.match(title)
+	if !ok {
+		return nil, fmt.Errorf("no answer for %q in %s", title, a.path)
+	}
+	answer := a.answers[name]
+	list, isList := answer.([]interface{})
+	if !isList {
+		return answer, nil
+	}
+	if multiSelect && (len(list) == 0 || !isAnswerList(list[0])) {
+		return list, nil
+	}
+	if a.used[name] >= len(list) {
+		return nil, fmt.Errorf("all %d answers for %q in %s were used", len(list), title, a.path)
+	}
+	a.used[name]++
+	return list[a.used[name]-1], nil
+}
+
+func isAnswerList(answer interface{}) bool {
+	_, ok := answer.([]interface{})
+	return ok
+}
+
+// match finds the answer's name for a prompt's title, ignoring case and surrounding spaces
+func (a *answersFile) match(title string) (string, bool) {
+	want := strings.TrimSpace(title)
+	if _, ok := a.answers[want]; ok {
+		return want, true
+	}
+	for name := range a.answers {
+		if strings.EqualFold(strings.TrimSpace(name), want) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// answerField sets a field's value from its answer, checked by the field's own validation
+func (a *answersFile) answerField(field huh.Field) error {
+	if wrapped, ok := field.(*flagDocsField); ok {
+		field = wrapped.Field
+	}
+	p, ok := prompts[field]
+	if !ok {
+		return fmt.Errorf("a %T prompt wasn't built with the answerable constructors in answers.go", field)
+	}
+	delete(prompts, field)
+
+	answer, err := a.lookup(p.title, p.multiSelect)
+	if err != nil {
+		return p.withOptions(err)
+	}
+	shown := answerString(answer)
+	if list, ok := answer.([]interface{}); ok {
+		labels := make([]string, len(list))
+		for i, item := range list {
+			labels[i] = answerString(item)
+		}
+		shown = strings.Join(labels, ", ")
+	}
+	if p.secret {
+		shown = strings.Repeat("*", 8)
+	}
+
+	err = p.answer(answer)
+	if err != nil {
+		return p.withOptions(fmt.Errorf("answer %q for %q: %w", shown, p.title, err))
+	}
+	fmt.Printf("? %s %s\n", p.title, shown)
+	return nil
+}
+
+// withOptions adds a select's options to an error about its answer
+func (p *prompt) withOptions(err error) error {
+	if len(p.options) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w; the options are %s", err, strings.Join(p.options, ", "))
+}
+
+// matchOption finds the option an answer names, by its label or its value
+func matchOption[T comparable](options []huh.Option[T], answer interface{}) (T, error) {
+	want := answerString(answer)
+	for _, option := range options {
+		if option.Key == want || fmt.Sprint(option.Value) == want {
+			return option.Value, nil
+		}
+	}
+	for _, option := range options {
+		if strings.EqualFold(option.Key, want) {
+			return option.Value, nil
+		}
+	}
+	var zero T
+	return zero, fmt.Errorf("no option %q", want)
+}
+
+func optionLabels[T comparable](options []huh.Option[T]) []string {
+	labels := make([]string, len(options))
+	for i, option := range options {
+		labels[i] = strconv.Quote(option.Key)
+	}
+	return labels
+}
+
+func containsValue[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func answerString(answer interface{}) string {
+	if answer == nil {
+		return ""
+	}
+	return fmt.Sprint(answer)
+}
+
+func answerBool(answer interface{}) (bool, error) {
+	if b, ok := answer.(bool); ok {
+		return b, nil
+	}
+	switch strings.ToLower(answerString(answer)) {
+	case "y", "yes", "true":
+		return true, nil
+	case "n", "no", "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q isn't yes or no", answerString(answer))
+}
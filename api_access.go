@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+const (
+	apiAccessPublic    = "public"
+	apiAccessAllowlist = "allowlist"
+	apiAccessPrivate   = "private"
+)
+
+// apiAccessSupport lists the exact kubefirst create flags that restrict a provider's cluster API endpoint
+type apiAccessSupport struct {
+	// AllowlistFlags take a comma separated list of CIDRs
+	AllowlistFlags []string
+	// PrivateFlags keep the endpoint on the cluster's private network
+	PrivateFlags []string
+}
+
+// Providers whose managed Kubernetes can restrict the API endpoint. Each option is only offered when the selected
+// binary has one of its flags; k1space doesn't restrict access through terraform overrides kubefirst's templates
+// don't read.
+var apiAccessProviders = map[string]apiAccessSupport{
+	"Google": {
+		AllowlistFlags: []string{"master-authorized-networks"},
+		PrivateFlags:   []string{"enable-private-endpoint"},
+	},
+}
+
+// Public services that echo the caller's IP address, tried in order
+var publicIPServices = []string{
+	"https://api.ipify.org",
+	"https://checkip.amazonaws.com",
+}
+
+func detectPublicIP() (string, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	var lastErr error
+	for _, service := range publicIPServices {
+		resp, err := client.Get(service)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ip := strings.TrimSpace(string(body))
+		if net.ParseIP(ip) == nil {
+			lastErr = fmt.Errorf("%s returned an invalid IP address: %q", service, ip)
+			continue
+		}
+		return ip, nil
+	}
+	return "", fmt.Errorf("unable to detect public IP: %w", lastErr)
+}
+
+// findAPIAllowlistFlag returns the kubefirst flag that restricts API access for a provider, if the binary exposes one
+func findAPIAllowlistFlag(cloudProvider string, flags map[string]string) string {
+	return findKubefirstFlag(flags, apiAccessProviders[cloudProvider].AllowlistFlags)
+}
+
+// findAPIPrivateFlag returns the kubefirst flag that makes the API endpoint private, if the binary exposes one
+func findAPIPrivateFlag(cloudProvider string, flags map[string]string) string {
+	return findKubefirstFlag(flags, apiAccessProviders[cloudProvider].PrivateFlags)
+}
+
+func validateCIDRList(value string) error {
+	for _, cidr := range strings.Split(value, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("%q is not a valid CIDR (e.g. 203.0.113.7/32)", cidr)
+		}
+	}
+	return nil
+}
+
+// promptAPIAccess asks how the cluster API endpoint should be exposed and sets the kubefirst flags for it.
+// It returns the flags it asked about, so the caller can leave them out of the flag form.
+func promptAPIAccess(config *CloudConfig, flags map[string]string) ([]string, error) {
+	allowlistFlag := findAPIAllowlistFlag(config.CloudPrefix, flags)
+	privateFlag := findAPIPrivateFlag(config.CloudPrefix, flags)
+	if allowlistFlag == "" && privateFlag == "" {
+		return nil, nil
+	}
+
+	var handled []string
+	options := []huh.Option[string]{huh.NewOption("Public (no restriction)", apiAccessPublic)}
+	if allowlistFlag != "" {
+		options = append(options, huh.NewOption("Restrict to an IP allowlist", apiAccessAllowlist))
+		handled = append(handled, allowlistFlag)
+	}
+	if privateFlag != "" {
+		options = append(options, huh.NewOption("Private networking only", apiAccessPrivate))
+		handled = append(handled, privateFlag)
+	}
+
+	var mode string
+	err := runField(newSelect("How should the cluster API endpoint be reachable?", &mode, options...))
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case apiAccessAllowlist:
+		defaultCIDR := ""
+		ip, err := detectPublicIP()
+		if err != nil {
+			log.Warn("Could not detect public IP", "error", err)
+		} else {
+			defaultCIDR = ip + "/32"
+		}
+
+		allowlist := defaultCIDR
+		err = runField(newInput("Enter the allowed CIDRs (comma separated)", &allowlist).
+			Description(fmt.Sprintf("Defaults to your current public IP: %s", defaultCIDR)).
+			Placeholder(defaultCIDR).
+			Validate(validateCIDRList))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cidr := range strings.Split(allowlist, ",") {
+			if cidr = strings.TrimSpace(cidr); cidr != "" {
+				config.APIAllowlist = append(config.APIAllowlist, cidr)
+			}
+		}
+		if len(config.APIAllowlist) == 0 {
+			fmt.Println("No CIDRs entered; the cluster API endpoint will stay public.")
+			return handled, nil
+		}
+		config.Flags.Store(allowlistFlag, strings.Join(config.APIAllowlist, ","))
+	case apiAccessPrivate:
+		config.PrivateAPI = true
+		config.Flags.Store(privateFlag, "true")
+		fmt.Println("The cluster API will only be reachable from the cluster's private network (VPN or bastion required).")
+	}
+
+	return handled, nil
+}
+
+func setEnvOverride(config *CloudConfig, name, value string) {
+	if config.EnvOverrides == nil {
+		config.EnvOverrides = make(map[string]string)
+	}
+	config.EnvOverrides[name] = value
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+const (
+	archAMD64 = "amd64"
+	archARM64 = "arm64"
+)
+
+// Instance families that run on ARM CPUs (Ampere, Graviton, Axion)
+var armInstancePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)arm`),
+	regexp.MustCompile(`(?i)ampere`),
+	regexp.MustCompile(`^(t2a|c4a)-`),
+	regexp.MustCompile(`^[a-z]\d+g[a-z]*\.`),
+}
+
+// Images deployed by kubefirst that must be available for the node architecture
+var kubefirstComponentImages = []string{
+	"ghcr.io/konstructio/kubefirst-api:latest",
+	"ghcr.io/konstructio/console:latest",
+}
+
+func detectArchitecture(instanceName string) string {
+	for _, pattern := range armInstancePatterns {
+		if pattern.MatchString(instanceName) {
+			return archARM64
+		}
+	}
+	return archAMD64
+}
+
+func findInstanceSize(cloudProvider, name string, cloudsFile CloudsFile) (InstanceSizeInfo, bool) {
+	for _, nodeType := range cloudsFile.CloudNodeTypes[cloudProvider] {
+		if nodeType.Name == name {
+			return nodeType, true
+		}
+	}
+	return InstanceSizeInfo{}, false
+}
+
+// findImagesMissingArch inspects each image's manifest list and returns those without a build for arch
+func findImagesMissingArch(images []string, arch string) ([]string, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, fmt.Errorf("docker is required to inspect image manifests: %w", err)
+	}
+
+	var missing []string
+	for _, image := range images {
+		output, err := exec.Command("docker", "manifest", "inspect", image).Output()
+		if err != nil {
+			return nil, fmt.Errorf("error inspecting manifest for %s: %w", image, err)
+		}
+
+		var manifest struct {
+			Manifests []struct {
+				Platform struct {
+					Architecture string `json:"architecture"`
+					OS           string `json:"os"`
+				} `json:"platform"`
+			} `json:"manifests"`
+		}
+		err = json.Unmarshal(output, &manifest)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing manifest for %s: %w", image, err)
+		}
+
+		found := false
+		for _, m := range manifest.Manifests {
+			if m.Platform.OS == "linux" && m.Platform.Architecture == arch {
+				found = true
+				break
+			}
+		}
+		// A plain manifest (not a list) is a single-arch image, which we treat as amd64
+		if !found && (len(manifest.Manifests) > 0 || arch != archAMD64) {
+			missing = append(missing, image)
+		}
+	}
+	return missing, nil
+}
+
+func warnIfArchitectureUnsupported(arch string) {
+	if arch != archARM64 {
+		return
+	}
+
+	fmt.Println(style.Render("⚠️  ARM64 node type selected"))
+	s := startSpinner("Checking kubefirst component images for arm64 support...")
+	missing, err := findImagesMissingArch(kubefirstComponentImages, arch)
+	stopSpinner(s, err == nil && len(missing) == 0)
+
+	if err != nil {
+		fmt.Printf("Could not verify arm64 image availability: %v\n", err)
+		fmt.Println("Make sure every kubefirst component you plan to run publishes linux/arm64 images.")
+		return
+	}
+
+	if len(missing) > 0 {
+		fmt.Println("The following images have no linux/arm64 build and will fail to schedule on ARM nodes:")
+		for _, image := range missing {
+			fmt.Printf("  - %s\n", image)
+		}
+		fmt.Println("Consider an amd64 node type, or a mixed-architecture cluster.")
+		return
+	}
+
+	fmt.Printf("All checked images publish linux/arm64 builds: %s\n", strings.Join(kubefirstComponentImages, ", "))
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// Normalized commit status states, shared by GitHub and GitLab
+const (
+	statusSuccess = "success"
+	statusPending = "pending"
+	statusFailed  = "failed"
+)
+
+// terraformPR is an open pull request on the gitops repository that Atlantis has planned or applied
+type terraformPR struct {
+	Number int
+	Title  string
+	Author string
+	URL    string
+	// Combined state of the atlantis/plan and atlantis/apply statuses, or "" when Atlantis hasn't reported one
+	Plan  string
+	Apply string
+}
+
+// Ordered by how urgently they need attention
+var terraformPRStates = []string{"Apply failed", "Plan failed", "Waiting for apply", "Applying", "Planning", "Applied, not merged"}
+
+func (pr terraformPR) State() string {
+	switch {
+	case pr.Apply == statusFailed:
+		return "Apply failed"
+	case pr.Plan == statusFailed:
+		return "Plan failed"
+	case pr.Plan == statusPending:
+		return "Planning"
+	case pr.Apply == statusPending:
+		return "Applying"
+	case pr.Apply == statusSuccess:
+		return "Applied, not merged"
+	case pr.Plan == statusSuccess:
+		return "Waiting for apply"
+	}
+	return ""
+}
+
+// commitStatus is a single status reported on a commit, e.g. "atlantis/plan: terraform/civo"
+type commitStatus struct {
+	Name  string
+	State string
+}
+
+// atlantisStage combines the statuses Atlantis reported for a stage ("plan" or "apply"). With several projects
+// each gets its own status, e.g. "atlantis/plan: terraform/github", so any failure or pending one wins.
+func atlantisStage(statuses []commitStatus, stage string) string {
+	prefix := "atlantis/" + stage
+	var states []string
+	for _, status := range statuses {
+		if status.Name == prefix || strings.HasPrefix(status.Name, prefix+":") {
+			states = append(states, status.State)
+		}
+	}
+	for _, state := range []string{statusFailed, statusPending, statusSuccess} {
+		if contains(states, state) {
+			return state
+		}
+	}
+	return ""
+}
+
+func (repo gitopsRepo) webURL() string {
+	if repo.Provider == "gitlab" {
+		return fmt.Sprintf("%s/%s/%s", repo.BaseURL, repo.Owner, gitopsRepoName)
+	}
+	return fmt.Sprintf("https://github.com/%s/%s", repo.Owner, gitopsRepoName)
+}
+
+func listGitHubTerraformPRs(ctx context.Context, repo gitopsRepo) ([]terraformPR, error) {
+	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s", url.PathEscape(repo.Owner), gitopsRepoName)
+	headers := map[string]string{"Authorization": "Bearer " + repo.Token, "Accept": "application/vnd.github+json"}
+
+	_, body, err := scopedGet(ctx, apiURL+"/pulls?state=open&per_page=50", headers)
+	if err != nil {
+		return nil, err
+	}
+	var pulls []struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+		User    struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	}
+	err = json.Unmarshal(body, &pulls)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing pull requests: %w", err)
+	}
+
+	var prs []terraformPR
+	for _, pull := range pulls {
+		// The combined status holds the latest status for each context
+		_, body, err := scopedGet(ctx, fmt.Sprintf("%s/commits/%s/status", apiURL, pull.Head.SHA), headers)
+		if err != nil {
+			return nil, fmt.Errorf("error reading statuses of #%d: %w", pull.Number, err)
+		}
+		var combined struct {
+			Statuses []struct {
+				Context string `json:"context"`
+				State   string `json:"state"`
+			} `json:"statuses"`
+		}
+		err = json.Unmarshal(body, &combined)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing statuses of #%d: %w", pull.Number, err)
+		}
+
+		statuses := make([]commitStatus, 0, len(combined.Statuses))
+		for _, status := range combined.Statuses {
+			state := status.State
+			if state == "failure" || state == "error" {
+				state = statusFailed
+			}
+			statuses = append(statuses, commitStatus{Name: status.Context, State: state})
+		}
+		prs = append(prs, terraformPR{
+			Number: pull.Number,
+			Title:  pull.Title,
+			Author: pull.User.Login,
+			URL:    pull.HTMLURL,
+			Plan:   atlantisStage(statuses, "plan"),
+			Apply:  atlantisStage(statuses, "apply"),
+		})
+	}
+	return prs, nil
+}
+
+func listGitLabTerraformPRs(ctx context.Context, repo gitopsRepo) ([]terraformPR, error) {
+	apiURL := fmt.Sprintf("%s/api/v4/projects/%s", repo.BaseURL, url.PathEscape(repo.Owner+"/"+gitopsRepoName))
+	headers := map[string]string{"PRIVATE-TOKEN": repo.Token}
+
+	_, body, err := scopedGet(ctx, apiURL+"/merge_requests?state=opened&per_page=50", headers)
+	if err != nil {
+		return nil, err
+	}
+	var mergeRequests []struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		WebURL string `json:"web_url"`
+		SHA    string `json:"sha"`
+		Author struct {
+			Username string `json:"username"`
+		} `json:"author"`
+	}
+	err = json.Unmarshal(body, &mergeRequests)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing merge requests: %w", err)
+	}
+
+	var prs []terraformPR
+	for _, mr := range mergeRequests {
+		// Without all=true only the latest status for each name is returned
+		_, body, err := scopedGet(ctx, fmt.Sprintf("%s/repository/commits/%s/statuses", apiURL, mr.SHA), headers)
+		if err != nil {
+			return nil, fmt.Errorf("error reading statuses of !%d: %w", mr.IID, err)
+		}
+		var gitlabStatuses []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+		}
+		err = json.Unmarshal(body, &gitlabStatuses)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing statuses of !%d: %w", mr.IID, err)
+		}
+
+		statuses := make([]commitStatus, 0, len(gitlabStatuses))
+		for _, status := range gitlabStatuses {
+			state := status.Status
+			switch state {
+			case "failed", "canceled":
+				state = statusFailed
+			case "created", "running", "pending":
+				state = statusPending
+			}
+			statuses = append(statuses, commitStatus{Name: status.Name, State: state})
+		}
+		prs = append(prs, terraformPR{
+			Number: mr.IID,
+			Title:  mr.Title,
+			Author: mr.Author.Username,
+			URL:    mr.WebURL,
+			Plan:   atlantisStage(statuses, "plan"),
+			Apply:  atlantisStage(statuses, "apply"),
+		})
+	}
+	return prs, nil
+}
+
+// listTerraformPRs returns the open pull requests Atlantis has reported on, most urgent first
+func listTerraformPRs(ctx context.Context, repo gitopsRepo) ([]terraformPR, error) {
+	var prs []terraformPR
+	var err error
+	if repo.Provider == "gitlab" {
+		prs, err = listGitLabTerraformPRs(ctx, repo)
+	} else {
+		prs, err = listGitHubTerraformPRs(ctx, repo)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var atlantisPRs []terraformPR
+	for _, pr := range prs {
+		if pr.State() != "" {
+			atlantisPRs = append(atlantisPRs, pr)
+		}
+	}
+	sort.SliceStable(atlantisPRs, func(i, j int) bool {
+		return indexOf(terraformPRStates, atlantisPRs[i].State()) < indexOf(terraformPRStates, atlantisPRs[j].State())
+	})
+	return atlantisPRs, nil
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return len(values)
+}
+
+func showTerraformPRStatus() {
+	log.Info("Starting showTerraformPRStatus function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations:", err)
+		return
+	}
+	selectedConfig, err := promptConfigSelection(indexFile, "Select the cluster to show terraform pull requests for")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations available. Please create a configuration first.")
+		return
+	}
+
+	repo, err := resolveGitopsRepo(indexFile.Configs[selectedConfig])
+	if err != nil {
+		log.Error("Error preparing git provider client", "error", err)
+		fmt.Println("Cannot read the gitops repository:", err)
+		return
+	}
+
+	var prs []terraformPR
+	err = runCancellable("Checking Atlantis pull requests on "+repo.webURL()+"...", func(ctx context.Context) (err error) {
+		prs, err = listTerraformPRs(ctx, repo)
+		return err
+	})
+	if err != nil {
+		err = describeNetworkError("Checking Atlantis pull requests", err)
+		log.Error("Error listing terraform pull requests", "repo", repo.webURL(), "error", err)
+		fmt.Println(err)
+		return
+	}
+	if len(prs) == 0 {
+		fmt.Println("No open pull requests with Atlantis plans or applies on " + repo.webURL())
+		return
+	}
+
+	summary := [][]string{{"PR", "State", "Title", "Author", "URL"}}
+	for _, pr := range prs {
+		summary = append(summary, []string{fmt.Sprint(pr.Number), pr.State(), pr.Title, pr.Author, pr.URL})
+	}
+	printSummaryTable("Terraform Pull Requests", summary)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// clusterBookmark is a saved link to a service running on a cluster. Passwords are never stored; they're read
+// from the cluster each time they're needed.
+type clusterBookmark struct {
+	Cluster  string    `json:"cluster"`
+	Name     string    `json:"name"`
+	URL      string    `json:"url"`
+	Username string    `json:"username,omitempty"`
+	SavedAt  time.Time `json:"saved_at"`
+}
+
+type bookmarksFile struct {
+	Bookmarks []clusterBookmark `json:"bookmarks"`
+}
+
+func getBookmarksPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "bookmarks.json")
+}
+
+func loadBookmarks() (bookmarksFile, error) {
+	var bookmarks bookmarksFile
+	data, err := os.ReadFile(getBookmarksPath())
+	if os.IsNotExist(err) {
+		return bookmarks, nil
+	}
+	if err != nil {
+		return bookmarks, fmt.Errorf("error reading bookmarks.json: %w", err)
+	}
+	err = json.Unmarshal(data, &bookmarks)
+	if err != nil {
+		return bookmarks, fmt.Errorf("error parsing bookmarks.json: %w", err)
+	}
+	return bookmarks, nil
+}
+
+// saveBookmark stores a bookmark, replacing any earlier one with the same cluster and name
+func saveBookmark(bookmark clusterBookmark) error {
+	bookmark.SavedAt = time.Now().UTC()
+	return withFileLock(getBookmarksPath(), func() error {
+		bookmarks, err := loadBookmarks()
+		if err != nil {
+			return err
+		}
+
+		replaced := false
+		for i, existing := range bookmarks.Bookmarks {
+			if existing.Cluster == bookmark.Cluster && existing.Name == bookmark.Name {
+				bookmarks.Bookmarks[i] = bookmark
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			bookmarks.Bookmarks = append(bookmarks.Bookmarks, bookmark)
+		}
+
+		data, err := json.MarshalIndent(bookmarks, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(getBookmarksPath(), data, 0644)
+	})
+}
+
+// clusterBookmarks returns the bookmarks saved for a cluster
+func clusterBookmarks(bookmarks bookmarksFile, cluster string) []clusterBookmark {
+	var result []clusterBookmark
+	for _, bookmark := range bookmarks.Bookmarks {
+		if bookmark.Cluster == cluster {
+			result = append(result, bookmark)
+		}
+	}
+	return result
+}
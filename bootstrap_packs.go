@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+const packDefinitionFile = "pack.hcl"
+
+// BootstrapPacks is a config's bootstrap block in config.hcl: the packs applied after kubefirst finishes, and when
+// each was last applied
+type BootstrapPacks struct {
+	Packs   []string          `hcl:"packs,optional"`
+	Applied map[string]string `hcl:"applied,optional"`
+}
+
+// packDefinition is a pack's optional pack.hcl
+type packDefinition struct {
+	Description string      `hcl:"description,optional"`
+	Charts      []packChart `hcl:"chart,block"`
+}
+
+// packChart is a helm release a pack installs before applying its manifests, so manifests can use its CRDs
+type packChart struct {
+	Name      string `hcl:"name,label"`
+	Repo      string `hcl:"repo"`
+	Chart     string `hcl:"chart"`
+	Version   string `hcl:"version,optional"`
+	Namespace string `hcl:"namespace,optional"`
+	// Values are files relative to the pack directory, passed to helm with -f
+	Values []string `hcl:"values,optional"`
+}
+
+// bootstrapPack is a directory under ~/.ssot/k1space/packs. Every .yaml or .yml file in it, other than chart
+// values files, is a manifest.
+type bootstrapPack struct {
+	Name        string
+	Dir         string
+	Description string
+	Charts      []packChart
+	Manifests   []string
+}
+
+// packResult is the outcome of applying one pack to a cluster
+type packResult struct {
+	Pack   string `json:"pack" yaml:"pack"`
+	Status string `json:"status" yaml:"status"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+func packsDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "packs")
+}
+
+func (p *BootstrapPacks) selected() []string {
+	if p == nil {
+		return nil
+	}
+	return p.Packs
+}
+
+func (p *BootstrapPacks) appliedAt(pack string) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	at, ok := p.Applied[pack]
+	return at, ok
+}
+
+// describe formats the packs for listings, e.g. "netpol (applied 2024-08-01), base-policies (not applied)"
+func (p *BootstrapPacks) describe() string {
+	var described []string
+	for _, pack := range p.selected() {
+		applied, err := time.Parse(time.RFC3339, p.Applied[pack])
+		if err != nil {
+			described = append(described, pack+" (not applied)")
+			continue
+		}
+		described = append(described, fmt.Sprintf("%s (applied %s)", pack, applied.Local().Format("2006-01-02 15:04")))
+	}
+	return strings.Join(described, ", ")
+}
+
+func loadBootstrapPack(name string) (bootstrapPack, error) {
+	pack := bootstrapPack{Name: name, Dir: filepath.Join(packsDir(), name)}
+	info, err := os.Stat(pack.Dir)
+	if err != nil {
+		return pack, fmt.Errorf("pack %s not found: %w", name, err)
+	}
+	if !info.IsDir() {
+		return pack, fmt.Errorf("pack %s is not a directory", name)
+	}
+
+	definitionPath := filepath.Join(pack.Dir, packDefinitionFile)
+	data, err := os.ReadFile(definitionPath)
+	if err != nil && !os.IsNotExist(err) {
+		return pack, fmt.Errorf("error reading %s: %w", definitionPath, err)
+	}
+	if err == nil {
+		file, diags := hclsyntax.ParseConfig(data, definitionPath, hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return pack, fmt.Errorf("error parsing %s: %s", definitionPath, diags)
+		}
+		var definition packDefinition
+		diags = gohcl.DecodeBody(file.Body, nil, &definition)
+		if diags.HasErrors() {
+			return pack, fmt.Errorf("error decoding %s: %s", definitionPath, diags)
+		}
+		pack.Description = definition.Description
+		pack.Charts = definition.Charts
+	}
+
+	valuesFiles := map[string]bool{}
+	for _, chart := range pack.Charts {
+		for _, values := range chart.Values {
+			valuesFiles[filepath.Join(pack.Dir, values)] = true
+		}
+	}
+	err = filepath.WalkDir(pack.Dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != pack.Dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if (ext == ".yaml" || ext == ".yml") && !valuesFiles[path] {
+			pack.Manifests = append(pack.Manifests, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return pack, fmt.Errorf("error reading pack %s: %w", name, err)
+	}
+	sort.Strings(pack.Manifests)
+	if len(pack.Manifests) == 0 && len(pack.Charts) == 0 {
+		return pack, fmt.Errorf("pack %s has no manifests or charts", name)
+	}
+	return pack, nil
+}
+
+// listBootstrapPacks returns the packs in the workspace, skipping (and logging) ones that can't be read
+func listBootstrapPacks() ([]bootstrapPack, error) {
+	entries, err := os.ReadDir(packsDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading packs directory: %w", err)
+	}
+	var packs []bootstrapPack
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		pack, err := loadBootstrapPack(entry.Name())
+		if err != nil {
+			log.Warn("Skipping bootstrap pack", "pack", entry.Name(), "error", err)
+			continue
+		}
+		packs = append(packs, pack)
+	}
+	return packs, nil
+}
+
+// runPackCommand runs helm or kubectl, returning its output in the error when it fails
+func runPackCommand(ctx context.Context, name string, args ...string) error {
+	output, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w: %s", name, args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// apply installs the pack's charts, then applies its manifests in one kubectl call
+func (p bootstrapPack) apply(ctx context.Context, kubeconfig string) error {
+	for _, chart := range p.Charts {
+		namespace := chart.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+		args := []string{"upgrade", "--install", chart.Name, chart.Chart, "--repo", chart.Repo,
+			"--namespace", namespace, "--create-namespace", "--kubeconfig", kubeconfig}
+		if chart.Version != "" {
+			args = append(args, "--version", chart.Version)
+		}
+		for _, values := range chart.Values {
+			args = append(args, "-f", filepath.Join(p.Dir, values))
+		}
+		err := runPackCommand(ctx, "helm", args...)
+		if err != nil {
+			return err
+		}
+	}
+	if len(p.Manifests) == 0 {
+		return nil
+	}
+	args := []string{"apply", "--kubeconfig", kubeconfig}
+	for _, manifest := range p.Manifests {
+		args = append(args, "-f", manifest)
+	}
+	return runPackCommand(ctx, "kubectl", args...)
+}
+
+// applyBootstrapPacks applies a config's selected packs to its cluster and records when each one succeeded
+func applyBootstrapPacks(configName string, config Config) []packResult {
+	selected := config.Bootstrap.selected()
+	if len(selected) == 0 {
+		return nil
+	}
+	kubeconfig := healthCheckKubeconfig(configName, findConfigFlag(config.Flags, "cluster-name"))
+	if kubeconfig == "" {
+		return []packResult{{Pack: strings.Join(selected, ", "), Status: "skipped", Detail: "no kubeconfig found, fetch it with 'Fetch Kubeconfig'"}}
+	}
+
+	var results []packResult
+	applied := map[string]string{}
+	for _, name := range selected {
+		pack, err := loadBootstrapPack(name)
+		if err == nil {
+			err = runCancellable(fmt.Sprintf("Applying bootstrap pack %s...", name), func(ctx context.Context) error {
+				return pack.apply(ctx, kubeconfig)
+			})
+		}
+		if err != nil {
+			log.Error("Error applying bootstrap pack", "config", configName, "pack", name, "error", err)
+			results = append(results, packResult{Pack: name, Status: "failed", Detail: err.Error()})
+			continue
+		}
+		applied[name] = time.Now().UTC().Format(time.RFC3339)
+		detail := fmt.Sprintf("%d manifests, %d charts", len(pack.Manifests), len(pack.Charts))
+		results = append(results, packResult{Pack: name, Status: "applied", Detail: detail})
+	}
+
+	if len(applied) > 0 {
+		err := recordPacksApplied(configName, applied)
+		if err != nil {
+			log.Error("Error recording applied bootstrap packs", "config", configName, "error", err)
+		}
+	}
+	return results
+}
+
+// recordPacksApplied stores when packs were applied in the config's bootstrap block
+func recordPacksApplied(configName string, applied map[string]string) error {
+	return updateIndex(func(indexFile *IndexFile) error {
+		config, ok := indexFile.Configs[configName]
+		if !ok || config.Bootstrap == nil {
+			return fmt.Errorf("config %s has no bootstrap packs", configName)
+		}
+		if config.Bootstrap.Applied == nil {
+			config.Bootstrap.Applied = map[string]string{}
+		}
+		for pack, at := range applied {
+			config.Bootstrap.Applied[pack] = at
+		}
+		indexFile.Configs[configName] = config
+		return nil
+	})
+}
+
+func printPackResults(results []packResult) {
+	summary := [][]string{{"Pack", "Status", "Detail"}}
+	for _, result := range results {
+		summary = append(summary, []string{result.Pack, result.Status, result.Detail})
+	}
+	printSummaryTable("Bootstrap Packs", summary)
+}
+
+// configureBootstrapPacks picks the packs applied to a cluster after provisioning, and offers to apply them to a
+// cluster that's already provisioned
+func configureBootstrapPacks() {
+	log.Info("Starting configureBootstrapPacks function")
+
+	packs, err := listBootstrapPacks()
+	if err != nil {
+		log.Error("Error listing bootstrap packs", "error", err)
+		fmt.Println("Failed to list bootstrap packs:", err)
+		return
+	}
+	if len(packs) == 0 {
+		fmt.Printf("No bootstrap packs found. Create a directory of manifests under %s to add one.\n", packsDir())
+		return
+	}
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations:", err)
+		return
+	}
+	selectedConfig, err := promptConfigSelection(indexFile, "Select the cluster to choose bootstrap packs for")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations available. Please create a configuration first.")
+		return
+	}
+	config := indexFile.Configs[selectedConfig]
+
+	options := make([]huh.Option[string], len(packs))
+	for i, pack := range packs {
+		label := pack.Name
+		if pack.Description != "" {
+			label += " - " + pack.Description
+		}
+		options[i] = huh.NewOption(label, pack.Name)
+	}
+	selected := append([]string(nil), config.Bootstrap.selected()...)
+	err = runField(newMultiSelect(fmt.Sprintf("Bootstrap packs for %s", selectedConfig), &selected, options...).
+		Description("Applied in the order listed, right after kubefirst finishes"))
+	if err != nil {
+		log.Error("Error in bootstrap pack selection", "error", err)
+		return
+	}
+
+	bootstrap := &BootstrapPacks{Packs: selected, Applied: map[string]string{}}
+	for _, pack := range selected {
+		if at, ok := config.Bootstrap.appliedAt(pack); ok {
+			bootstrap.Applied[pack] = at
+		}
+	}
+	config.Bootstrap = bootstrap
+	err = updateIndex(func(indexFile *IndexFile) error {
+		latest, ok := indexFile.Configs[selectedConfig]
+		if !ok {
+			return fmt.Errorf("config %s not found", selectedConfig)
+		}
+		latest.Bootstrap = bootstrap
+		indexFile.Configs[selectedConfig] = latest
+		return nil
+	})
+	if err != nil {
+		log.Error("Error saving bootstrap packs", "error", err)
+		fmt.Println("Failed to save bootstrap packs:", err)
+		return
+	}
+	if len(selected) == 0 {
+		fmt.Printf("%s has no bootstrap packs.\n", selectedConfig)
+		return
+	}
+	fmt.Printf("Bootstrap packs of %s: %s\n", selectedConfig, strings.Join(selected, ", "))
+
+	if config.State == nil || config.State.Current != stateProvisioned {
+		return
+	}
+	applyNow := true
+	err = runField(newConfirm("The cluster is already provisioned. Apply the packs now?", &applyNow))
+	if err != nil || !applyNow {
+		return
+	}
+	printPackResults(applyBootstrapPacks(selectedConfig, config))
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+// Branches without commits for this long are offered for deletion even if unmerged
+const staleBranchAge = 90 * 24 * time.Hour
+
+// branchCandidate is a local branch that looks safe to delete, with the reason shown to the user
+type branchCandidate struct {
+	Repo   string
+	Branch string
+	Reason string
+	// Merged or upstream gone; stale-only branches may still hold unpushed work
+	Safe bool
+}
+
+// defaultRemoteBranch returns origin's default branch (e.g. origin/main), falling back to origin/main
+func defaultRemoteBranch(repoPath string) string {
+	output, err := exec.Command("git", "-C", repoPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD").Output()
+	if err != nil {
+		return "origin/main"
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// findBranchCandidates lists local branches merged into origin's default branch, whose upstream was deleted,
+// or that haven't seen a commit in staleBranchAge; the checked-out and default branches are never listed
+func findBranchCandidates(repo, repoPath string) ([]branchCandidate, error) {
+	defaultBranch := defaultRemoteBranch(repoPath)
+	current, _ := getCurrentBranch(repoPath)
+
+	output, err := exec.Command("git", "-C", repoPath, "branch", "--format=%(refname:short)", "--merged", defaultBranch).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing merged branches: %w", err)
+	}
+	merged := make(map[string]bool)
+	for _, branch := range strings.Fields(string(output)) {
+		merged[branch] = true
+	}
+
+	output, err = exec.Command("git", "-C", repoPath, "for-each-ref", "--format=%(refname:short)|%(committerdate:unix)|%(upstream:track)", "refs/heads").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing branches: %w", err)
+	}
+
+	var candidates []branchCandidate
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(line, "|", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		branch := fields[0]
+		if branch == current || "origin/"+branch == defaultBranch {
+			continue
+		}
+
+		switch {
+		case merged[branch]:
+			candidates = append(candidates, branchCandidate{Repo: repo, Branch: branch, Reason: "merged into " + defaultBranch, Safe: true})
+		case fields[2] == "[gone]":
+			candidates = append(candidates, branchCandidate{Repo: repo, Branch: branch, Reason: "upstream deleted", Safe: true})
+		default:
+			unix, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			if age := time.Since(time.Unix(unix, 0)); age > staleBranchAge {
+				candidates = append(candidates, branchCandidate{Repo: repo, Branch: branch, Reason: fmt.Sprintf("no commits for %d days", int(age.Hours()/24))})
+			}
+		}
+	}
+	return candidates, nil
+}
+
+func cleanupBranches() {
+	log.Info("Starting cleanupBranches function")
+
+	repoDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".repositories")
+	entries, err := os.ReadDir(repoDir)
+	if err != nil {
+		log.Error("Error reading repositories directory", "error", err)
+		fmt.Println("No repositories found. Run 'Clone Repositories' first.")
+		return
+	}
+
+	summary := [][]string{{"Repository", "Branch", "Reason", "Status"}}
+	var candidates []branchCandidate
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		repoPath := filepath.Join(repoDir, entry.Name())
+
+		// Drop remote-tracking refs for branches deleted on origin, which also marks their local branches [gone]
+		s := startSpinner(fmt.Sprintf("Pruning remote-tracking branches in %s...", entry.Name()))
+		output, err := exec.Command("git", "-C", repoPath, "fetch", "--prune", "origin").CombinedOutput()
+		stopSpinner(s, err == nil)
+		if err != nil {
+			log.Error("Error pruning repository", "repo", entry.Name(), "error", err, "output", string(output))
+			summary = append(summary, []string{entry.Name(), "-", "", "Failed to prune remote-tracking branches"})
+		} else if pruned := strings.Count(string(output), "[deleted]"); pruned > 0 {
+			summary = append(summary, []string{entry.Name(), "-", "", fmt.Sprintf("Pruned %d remote-tracking branches", pruned)})
+		}
+
+		repoCandidates, err := findBranchCandidates(entry.Name(), repoPath)
+		if err != nil {
+			log.Error("Error finding branches", "repo", entry.Name(), "error", err)
+			summary = append(summary, []string{entry.Name(), "-", "", "Failed to list branches"})
+			continue
+		}
+		candidates = append(candidates, repoCandidates...)
+	}
+
+	if len(candidates) == 0 {
+		printSummaryTable("Branch Cleanup", summary)
+		fmt.Println("\nNo merged or stale branches found.")
+		return
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Repo != candidates[j].Repo {
+			return candidates[i].Repo < candidates[j].Repo
+		}
+		return candidates[i].Branch < candidates[j].Branch
+	})
+
+	options := make([]huh.Option[int], 0, len(candidates))
+	var selected []int
+	for i, candidate := range candidates {
+		options = append(options, huh.NewOption(fmt.Sprintf("%s: %s (%s)", candidate.Repo, candidate.Branch, candidate.Reason), i))
+		if candidate.Safe {
+			selected = append(selected, i)
+		}
+	}
+
+	err = runField(newMultiSelect("Select branches to delete (merged and upstream-deleted branches are preselected)", &selected, options...))
+	if err != nil {
+		log.Error("Error in branch selection", "error", err)
+		return
+	}
+	if len(selected) == 0 {
+		printSummaryTable("Branch Cleanup", summary)
+		fmt.Println("\nNo branches deleted.")
+		return
+	}
+
+	var confirm bool
+	err = runField(newConfirm(fmt.Sprintf("Delete %d local branches? Unmerged commits on them will be lost.", len(selected)), &confirm))
+	if err != nil || !confirm {
+		fmt.Println("Branch cleanup cancelled.")
+		return
+	}
+
+	sort.Ints(selected)
+	for _, i := range selected {
+		candidate := candidates[i]
+		output, err := exec.Command("git", "-C", filepath.Join(repoDir, candidate.Repo), "branch", "-D", candidate.Branch).CombinedOutput()
+		if err != nil {
+			log.Error("Error deleting branch", "repo", candidate.Repo, "branch", candidate.Branch, "error", err, "output", string(output))
+			summary = append(summary, []string{candidate.Repo, candidate.Branch, candidate.Reason, "Failed to delete"})
+			continue
+		}
+		summary = append(summary, []string{candidate.Repo, candidate.Branch, candidate.Reason, "Deleted"})
+	}
+	printSummaryTable("Branch Cleanup", summary)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// catalogExport is the machine-readable form of clouds.hcl, for tools and dashboards that reuse k1space's
+// provider catalog
+type catalogExport struct {
+	LastUpdated string            `json:"last_updated" yaml:"last_updated"`
+	Providers   []catalogProvider `json:"providers" yaml:"providers"`
+}
+
+type catalogProvider struct {
+	Name string `json:"name" yaml:"name"`
+	// FetchedAt is when the provider's data was last fetched, and Stale whether that's longer ago than the TTL
+	FetchedAt string            `json:"fetched_at,omitempty" yaml:"fetched_at,omitempty"`
+	Stale     bool              `json:"stale" yaml:"stale"`
+	Regions   []string          `json:"regions" yaml:"regions"`
+	Zones     []string          `json:"zones,omitempty" yaml:"zones,omitempty"`
+	NodeTypes []catalogNodeType `json:"node_types" yaml:"node_types"`
+}
+
+type catalogNodeType struct {
+	Name          string `json:"name" yaml:"name"`
+	CPUCores      int    `json:"cpu_cores" yaml:"cpu_cores"`
+	RAMMegabytes  int    `json:"ram_mb" yaml:"ram_mb"`
+	DiskGigabytes int    `json:"disk_gb" yaml:"disk_gb"`
+	Architecture  string `json:"architecture,omitempty" yaml:"architecture,omitempty"`
+	GPUCount      int    `json:"gpu_count,omitempty" yaml:"gpu_count,omitempty"`
+	GPUModel      string `json:"gpu_model,omitempty" yaml:"gpu_model,omitempty"`
+	GPUVRAM       int    `json:"gpu_vram_gb,omitempty" yaml:"gpu_vram_gb,omitempty"`
+	// Prices are in USD and left out when the provider's API doesn't report them
+	PriceHourly  float64 `json:"price_hourly,omitempty" yaml:"price_hourly,omitempty"`
+	PriceMonthly float64 `json:"price_monthly,omitempty" yaml:"price_monthly,omitempty"`
+}
+
+// buildCatalogExport converts the cached catalog, limited to providers when any are given
+func buildCatalogExport(cloudsFile CloudsFile, providers []string) (catalogExport, error) {
+	known := make(map[string]bool)
+	for provider := range cloudsFile.CloudRegions {
+		known[provider] = true
+	}
+	for provider := range cloudsFile.CloudNodeTypes {
+		known[provider] = true
+	}
+
+	names := sortedKeys(known)
+	if len(providers) > 0 {
+		names = nil
+		for _, provider := range providers {
+			name, ok := matchCatalogProvider(known, provider)
+			if !ok {
+				return catalogExport{}, fmt.Errorf("no cached data for provider %q; cached providers are %s", provider, strings.Join(sortedKeys(known), ", "))
+			}
+			names = append(names, name)
+		}
+	}
+
+	export := catalogExport{LastUpdated: cloudsFile.LastUpdated, Providers: []catalogProvider{}}
+	ttl := getCloudDataTTL()
+	for _, name := range names {
+		provider := catalogProvider{
+			Name:      name,
+			FetchedAt: cloudsFile.CloudLastUpdated[name],
+			Regions:   append([]string{}, cloudsFile.CloudRegions[name]...),
+			Zones:     cloudsFile.CloudZones[name],
+			NodeTypes: []catalogNodeType{},
+		}
+		age, cached := cloudDataAge(cloudsFile, name)
+		provider.Stale = !cached || age >= ttl
+		for _, nodeType := range cloudsFile.CloudNodeTypes[name] {
+			provider.NodeTypes = append(provider.NodeTypes, catalogNodeType{
+				Name:          nodeType.Name,
+				CPUCores:      nodeType.CPUCores,
+				RAMMegabytes:  nodeType.RAMMegabytes,
+				DiskGigabytes: nodeType.DiskGigabytes,
+				Architecture:  nodeType.Architecture,
+				GPUCount:      nodeType.GPUCount,
+				GPUModel:      nodeType.GPUModel,
+				GPUVRAM:       nodeType.GPUVRAMGigabytes,
+				PriceHourly:   nodeType.PriceHourly,
+				PriceMonthly:  nodeType.PriceMonthly,
+			})
+		}
+		sort.Strings(provider.Regions)
+		export.Providers = append(export.Providers, provider)
+	}
+	return export, nil
+}
+
+// matchCatalogProvider finds a provider by name regardless of case, so --provider digitalocean works
+func matchCatalogProvider(known map[string]bool, provider string) (string, bool) {
+	for name := range known {
+		if strings.EqualFold(name, strings.TrimSpace(provider)) {
+			return name, true
+		}
+	}
+	return "", false
+}
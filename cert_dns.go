@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/civo/civogo"
+	"github.com/digitalocean/godo"
+	"github.com/vultr/govultr/v2"
+)
+
+// Record created and removed again to prove the DNS token can answer a DNS-01 challenge
+const dryRunChallengeRecord = "_acme-challenge.k1space-dryrun"
+
+// findConfigFlag looks up a kubefirst flag in an index entry, whose keys are prefixed with the config name
+func findConfigFlag(flags map[string]string, flag string) string {
+	suffix := "_" + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+	for name, value := range flags {
+		if strings.HasSuffix(name, suffix) {
+			return value
+		}
+	}
+	return ""
+}
+
+// validateCertPrerequisites checks everything cert-manager needs to get a Let's Encrypt certificate,
+// returning a list of problems that would otherwise only surface once the cluster is up.
+func validateCertPrerequisites(cloud string, config Config) []string {
+	var problems []string
+
+	email := findConfigFlag(config.Flags, "alerts-email")
+	switch {
+	case email == "":
+		problems = append(problems, "alerts-email is not set; Let's Encrypt requires a contact email for the ACME account")
+	case strings.HasPrefix(email, "op://"):
+		log.Info("Skipping alerts-email validation for 1Password reference", "email", email)
+	default:
+		if err := validateACMEEmail(email); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	domain := findConfigFlag(config.Flags, "domain-name")
+	if domain == "" {
+		return append(problems, "domain-name is not set; cert-manager cannot request certificates without it")
+	}
+
+	if _, err := net.LookupNS(domain); err != nil {
+		problems = append(problems, fmt.Sprintf("%s has no public NS records (%v); DNS-01 challenges cannot be validated", domain, err))
+	}
+
+	dnsProvider := findConfigFlag(config.Flags, "dns-provider")
+	if dnsProvider == "" || dnsProvider == "cloud" {
+		dnsProvider = cloud
+	}
+
+	if err := dryRunDNSChallenge(strings.ToLower(dnsProvider), domain); err != nil {
+		problems = append(problems, fmt.Sprintf("DNS challenge dry run failed for %s via %s: %v", domain, dnsProvider, err))
+	}
+
+	return problems
+}
+
+func validateACMEEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return fmt.Errorf("alerts-email %q is not a valid email address", email)
+	}
+
+	// Let's Encrypt rejects contact addresses on domains that cannot receive mail
+	domain := email[strings.LastIndex(email, "@")+1:]
+	if mx, err := net.LookupMX(domain); err != nil || len(mx) == 0 {
+		return fmt.Errorf("alerts-email domain %s has no MX records; Let's Encrypt will reject the ACME registration", domain)
+	}
+	return nil
+}
+
+// dryRunDNSChallenge creates and deletes a TXT record the way cert-manager's DNS-01 solver does
+func dryRunDNSChallenge(dnsProvider, domain string) error {
+	value := fmt.Sprintf("k1space-dryrun-%d", time.Now().Unix())
+
+	switch dnsProvider {
+	case "civo":
+		client, err := getCivoClient()
+		if err != nil {
+			return err
+		}
+		var dnsDomain *civogo.DNSDomain
+		err = awaitContext(context.Background(), func() (err error) {
+			dnsDomain, err = client.GetDNSDomain(domain)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("domain not found in Civo DNS: %w", err)
+		}
+		var record *civogo.DNSRecord
+		err = awaitContext(context.Background(), func() (err error) {
+			record, err = client.CreateDNSRecord(dnsDomain.ID, &civogo.DNSRecordConfig{
+				Type:  civogo.DNSRecordTypeTXT,
+				Name:  dryRunChallengeRecord,
+				Value: value,
+				TTL:   600,
+			})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("token cannot create TXT records: %w", err)
+		}
+		err = awaitContext(context.Background(), func() error {
+			_, err := client.DeleteDNSRecord(record)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("token cannot delete TXT records: %w", err)
+		}
+		return nil
+	case "digitalocean":
+		client, err := getDigitalOceanClient()
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		record, _, err := client.Domains.CreateRecord(ctx, domain, &godo.DomainRecordEditRequest{
+			Type: "TXT",
+			Name: dryRunChallengeRecord,
+			Data: value,
+			TTL:  60,
+		})
+		if err != nil {
+			return fmt.Errorf("token cannot create TXT records: %w", err)
+		}
+		_, err = client.Domains.DeleteRecord(ctx, domain, record.ID)
+		if err != nil {
+			return fmt.Errorf("token cannot delete TXT records: %w", err)
+		}
+		return nil
+	case "vultr":
+		client, err := getVultrClient()
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		record, err := client.DomainRecord.Create(ctx, domain, &govultr.DomainRecordReq{
+			Type: "TXT",
+			Name: dryRunChallengeRecord,
+			Data: value,
+			TTL:  60,
+		})
+		if err != nil {
+			return fmt.Errorf("token cannot create TXT records: %w", vultrError(err))
+		}
+		err = client.DomainRecord.Delete(ctx, domain, record.ID)
+		if err != nil {
+			return fmt.Errorf("token cannot delete TXT records: %w", vultrError(err))
+		}
+		return nil
+	case "akamai":
+		client, err := getLinodeClient()
+		if err != nil {
+			return err
+		}
+		domainID, err := client.findDomainID(domain)
+		if err != nil {
+			return err
+		}
+		recordID, err := client.createDNSRecord(domainID, "TXT", dryRunChallengeRecord, value, 300)
+		if err != nil {
+			return fmt.Errorf("token cannot create TXT records: %w", err)
+		}
+		err = client.deleteDNSRecord(domainID, recordID)
+		if err != nil {
+			return fmt.Errorf("token cannot delete TXT records: %w", err)
+		}
+		return nil
+	case "cloudflare":
+		return dryRunCloudflareChallenge(domain, value)
+	default:
+		log.Warn("DNS challenge dry run not supported for provider", "provider", dnsProvider)
+		return nil
+	}
+}
+
+func dryRunCloudflareChallenge(domain, value string) error {
+	token := os.Getenv("CF_API_TOKEN")
+	if token == "" {
+		return fmt.Errorf("CF_API_TOKEN not found in environment")
+	}
+
+	cloudflare := func(method, path string, payload interface{}) (json.RawMessage, error) {
+		var body bytes.Buffer
+		if payload != nil {
+			if err := json.NewEncoder(&body).Encode(payload); err != nil {
+				return nil, err
+			}
+		}
+		req, err := http.NewRequest(method, "https://api.cloudflare.com/client/v4"+path, &body)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := newHTTPClient().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		data, err := readResponseBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		var envelope struct {
+			Result json.RawMessage `json:"result"`
+		}
+		err = json.Unmarshal(data, &envelope)
+		return envelope.Result, err
+	}
+
+	// The domain may be a subdomain of the Cloudflare zone, so walk up until a zone matches
+	var zoneID string
+	labels := strings.Split(domain, ".")
+	for i := 0; i < len(labels)-1 && zoneID == ""; i++ {
+		result, err := cloudflare(http.MethodGet, "/zones?name="+strings.Join(labels[i:], "."), nil)
+		if err != nil {
+			return fmt.Errorf("token cannot list zones: %w", err)
+		}
+		var zones []struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(result, &zones); err != nil {
+			return err
+		}
+		if len(zones) > 0 {
+			zoneID = zones[0].ID
+		}
+	}
+	if zoneID == "" {
+		return fmt.Errorf("no Cloudflare zone found for %s", domain)
+	}
+
+	result, err := cloudflare(http.MethodPost, "/zones/"+zoneID+"/dns_records", map[string]interface{}{
+		"type":    "TXT",
+		"name":    dryRunChallengeRecord + "." + domain,
+		"content": value,
+		"ttl":     60,
+	})
+	if err != nil {
+		return fmt.Errorf("token cannot create TXT records: %w", err)
+	}
+	var record struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(result, &record); err != nil {
+		return err
+	}
+
+	_, err = cloudflare(http.MethodDelete, "/zones/"+zoneID+"/dns_records/"+record.ID, nil)
+	if err != nil {
+		return fmt.Errorf("token cannot delete TXT records: %w", err)
+	}
+	return nil
+}
+
+// confirmCertPrerequisites runs the cert checks and asks whether to continue when any fail
+func confirmCertPrerequisites(cloud string, config Config) bool {
+	s := startSpinner("Validating cert-manager DNS challenge prerequisites...")
+	problems := validateCertPrerequisites(cloud, config)
+	stopSpinner(s, len(problems) == 0)
+
+	if len(problems) == 0 {
+		return true
+	}
+
+	fmt.Println(style.Render("⚠️  Certificate issuance is likely to fail"))
+	for _, problem := range problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+
+	var proceed bool
+	err := runField(newConfirm("Continue provisioning anyway?", &proceed))
+	if err != nil {
+		log.Error("Error in confirmation prompt", "error", err)
+		return false
+	}
+	return proceed
+}
@@ -8,23 +8,27 @@ import (
 )
 
 func runMainMenu() string {
+	options := []huh.Option[string]{
+		huh.NewOption("Config", "Config"),
+		huh.NewOption("Kubefirst", "Kubefirst"),
+		huh.NewOption("Cluster", "Cluster"),
+		huh.NewOption("View Logs", "View Logs"),
+		huh.NewOption("k1space", "k1space"),
+		huh.NewOption("Exit", "Exit"),
+	}
+	// Tokens a provider rejected are flagged before anything else, and fixing them comes first
+	if printInvalidTokenBanner() {
+		options = append([]huh.Option[string]{huh.NewOption("Replace Invalid Tokens", "Replace Invalid Tokens")}, options...)
+	}
+
 	var selected string
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Title("K1Space Main Menu").
-				Options(
-					huh.NewOption("Config", "Config"),
-					huh.NewOption("Kubefirst", "Kubefirst"),
-					huh.NewOption("Cluster", "Cluster"),
-					huh.NewOption("k1space", "k1space"),
-					huh.NewOption("Exit", "Exit"),
-				).
-				Value(&selected),
+	form := newForm(
+		newGroup(
+			newSelect("K1Space Main Menu", &selected, options...),
 		),
 	)
 
-	err := form.Run()
+	err := runForm(form)
 	if err != nil {
 		log.Error("Error running main menu", "error", err)
 		os.Exit(1)
@@ -36,23 +40,34 @@ func runMainMenu() string {
 func runConfigMenu() {
 	for {
 		var selected string
-		form := huh.NewForm(
-			huh.NewGroup(
-				huh.NewSelect[string]().
-					Title("Config Menu").
-					Options(
-						huh.NewOption("List Configs", "List Configs"),
-						huh.NewOption("Create Config", "Create Config"),
-						huh.NewOption("Delete Config", "Delete Config"),
-						huh.NewOption("Delete All Configs", "Delete All Configs"),
-						huh.NewOption("Edit Kubefirst Binary Used for Config", "Edit Kubefirst Binary"),
-						huh.NewOption("Back", "Back"),
-					).
-					Value(&selected),
+		form := newForm(
+			newGroup(
+				newSelect("Config Menu", &selected,
+					huh.NewOption("List Configs", "List Configs"),
+					huh.NewOption("Create Config", "Create Config"),
+					huh.NewOption("Create Config in Multiple Regions", "Create Config in Multiple Regions"),
+					huh.NewOption("Compare Node Types", "Compare Node Types"),
+					huh.NewOption("Duplicate Config", "Duplicate Config"),
+					huh.NewOption("Create Failover Pair", "Create Failover Pair"),
+					huh.NewOption("Rename Config", "Rename Config"),
+					huh.NewOption("Manage Config Templates", "Manage Config Templates"),
+					huh.NewOption("Diff Configs", "Diff Configs"),
+					huh.NewOption("Validate Config", "Validate Config"),
+					huh.NewOption("Export Compliance Report", "Export Compliance Report"),
+					huh.NewOption("Open Config in Editor", "Open Config in Editor"),
+					huh.NewOption("Refresh Cloud Data", "Refresh Cloud Data"),
+					huh.NewOption("Refresh All Providers", "Refresh All Providers"),
+					huh.NewOption("Manage 1Password Secrets", "Manage 1Password Secrets"),
+					huh.NewOption("Scan Generated Files for Secrets", "Scan Generated Files for Secrets"),
+					huh.NewOption("Delete Config", "Delete Config"),
+					huh.NewOption("Delete All Configs", "Delete All Configs"),
+					huh.NewOption("Edit Kubefirst Binary Used for Config", "Edit Kubefirst Binary"),
+					huh.NewOption("Back", "Back"),
+				),
 			),
 		)
 
-		err := form.Run()
+		err := runForm(form)
 		if err != nil {
 			log.Error("Error running config menu", "error", err)
 			return
@@ -63,6 +78,34 @@ func runConfigMenu() {
 			listConfigs()
 		case "Create Config":
 			createConfig(&CloudConfig{})
+		case "Create Config in Multiple Regions":
+			createMultiRegionConfig()
+		case "Compare Node Types":
+			compareNodeTypesMenu()
+		case "Duplicate Config":
+			duplicateConfig()
+		case "Create Failover Pair":
+			createFailoverPair()
+		case "Rename Config":
+			renameConfig()
+		case "Manage Config Templates":
+			manageTemplates()
+		case "Diff Configs":
+			diffConfigs()
+		case "Validate Config":
+			validateConfig()
+		case "Export Compliance Report":
+			exportComplianceReport()
+		case "Open Config in Editor":
+			openConfigInEditor()
+		case "Refresh Cloud Data":
+			refreshCloudDataMenu()
+		case "Refresh All Providers":
+			refreshAllProviders()
+		case "Manage 1Password Secrets":
+			manageOnePasswordSecrets()
+		case "Scan Generated Files for Secrets":
+			scanGeneratedSecrets()
 		case "Delete Config":
 			deleteConfig()
 		case "Delete All Configs":
@@ -78,20 +121,39 @@ func runConfigMenu() {
 func runClusterMenu() {
 	for {
 		var selected string
-		form := huh.NewForm(
-			huh.NewGroup(
-				huh.NewSelect[string]().
-					Title("Cluster Menu").
-					Options(
-						huh.NewOption("Provision Cluster", "Provision Cluster"),
-						huh.NewOption("Deprovision Cluster", "Deprovision Cluster"),
-						huh.NewOption("Back", "Back"),
-					).
-					Value(&selected),
+		form := newForm(
+			newGroup(
+				newSelect("Cluster Menu", &selected,
+					huh.NewOption("Provision Cluster", "Provision Cluster"),
+					huh.NewOption("Deprovision Cluster", "Deprovision Cluster"),
+					huh.NewOption("Scan for Orphaned Resources", "Scan for Orphaned Resources"),
+					huh.NewOption(provisionQueueLabel(), "Provisioning Queue"),
+					huh.NewOption("Reattach Detached Run", "Reattach Detached Run"),
+					huh.NewOption("Export Operation Logs", "Export Operation Logs"),
+					huh.NewOption("Cache Terraform Providers", "Cache Terraform Providers"),
+					huh.NewOption("Create Air-Gapped Bundle", "Create Air-Gapped Bundle"),
+					huh.NewOption("Use Air-Gapped Bundle", "Use Air-Gapped Bundle"),
+					huh.NewOption("Manage Local DNS", "Manage Local DNS"),
+					huh.NewOption("Create Gitops Deploy Key", "Create Gitops Deploy Key"),
+					huh.NewOption("Check Token Permissions", "Check Token Permissions"),
+					huh.NewOption("Verify Cluster Health", "Verify Cluster Health"),
+					huh.NewOption("Fetch Kubeconfig", "Fetch Kubeconfig"),
+					huh.NewOption("Resource Usage Snapshot", "Resource Usage Snapshot"),
+					huh.NewOption("Configure Cluster Alerts", "Configure Cluster Alerts"),
+					huh.NewOption("Maintenance Windows", "Maintenance Windows"),
+					huh.NewOption("Bootstrap Packs", "Bootstrap Packs"),
+					huh.NewOption("Open Cluster in k9s/OpenLens", "Open Cluster in k9s/OpenLens"),
+					huh.NewOption("Open Grafana", "Open Grafana"),
+					huh.NewOption("Terraform Pull Requests", "Terraform Pull Requests"),
+					huh.NewOption("List Live Clusters", "List Live Clusters"),
+					huh.NewOption("Discover Cloud Clusters", "Discover Cloud Clusters"),
+					huh.NewOption("Imported Clusters", "Imported Clusters"),
+					huh.NewOption("Back", "Back"),
+				),
 			),
 		)
 
-		err := form.Run()
+		err := runForm(form)
 		if err != nil {
 			log.Error("Error running cluster menu", "error", err)
 			return
@@ -100,8 +162,53 @@ func runClusterMenu() {
 		switch selected {
 		case "Provision Cluster":
 			provisionCluster()
+			exitIfHungUp()
 		case "Deprovision Cluster":
 			deprovisionCluster()
+		case "Scan for Orphaned Resources":
+			scanOrphanedResourcesForConfig()
+		case "Provisioning Queue":
+			showProvisionQueue()
+		case "Reattach Detached Run":
+			reattachDetachedRun()
+		case "Export Operation Logs":
+			exportOperationLogs()
+		case "Cache Terraform Providers":
+			cacheTerraformProviders()
+		case "Create Air-Gapped Bundle":
+			createAirgapBundle()
+		case "Use Air-Gapped Bundle":
+			useAirgapBundle()
+		case "Manage Local DNS":
+			manageLocalDNS()
+		case "Create Gitops Deploy Key":
+			createGitopsDeployKey()
+		case "Check Token Permissions":
+			checkTokenPermissionsForConfig()
+		case "Verify Cluster Health":
+			verifyClusterHealthForConfig()
+		case "Fetch Kubeconfig":
+			fetchKubeconfigForConfig()
+		case "Resource Usage Snapshot":
+			resourceSnapshotForConfig()
+		case "Configure Cluster Alerts":
+			configureClusterAlerts()
+		case "Maintenance Windows":
+			configureMaintenanceWindows()
+		case "Bootstrap Packs":
+			configureBootstrapPacks()
+		case "Open Cluster in k9s/OpenLens":
+			openClusterTool()
+		case "Open Grafana":
+			openGrafana()
+		case "Terraform Pull Requests":
+			showTerraformPRStatus()
+		case "List Live Clusters":
+			listLiveClusters()
+		case "Discover Cloud Clusters":
+			discoverCloudClusters()
+		case "Imported Clusters":
+			manageImportedClusters()
 		case "Back":
 			return
 		}
@@ -111,24 +218,29 @@ func runClusterMenu() {
 func runKubefirstMenu() {
 	for {
 		var selected string
-		form := huh.NewForm(
-			huh.NewGroup(
-				huh.NewSelect[string]().
-					Title("Kubefirst Menu").
-					Options(
-						huh.NewOption("Clone Repositories", "Clone Repositories"),
-						huh.NewOption("Sync Repositories", "Sync Repositories"),
-						huh.NewOption("Setup Kubefirst", "Setup Kubefirst"),
-						huh.NewOption("Run Kubefirst Repositories", "Run Kubefirst Repositories"),
-						huh.NewOption("Revert to Main", "Revert to Main"),
-						huh.NewOption("Print Local Setup", "Print Local Setup"), // Add this line
-						huh.NewOption("Back", "Back"),
-					).
-					Value(&selected),
+		form := newForm(
+			newGroup(
+				newSelect("Kubefirst Menu", &selected,
+					huh.NewOption("Clone Repositories", "Clone Repositories"),
+					huh.NewOption("Sync Repositories", "Sync Repositories"),
+					huh.NewOption("Clean Up Branches", "Clean Up Branches"),
+					huh.NewOption("Open Repository in Editor", "Open Repository in Editor"),
+					huh.NewOption("Setup Kubefirst", "Setup Kubefirst"),
+					huh.NewOption("Run Kubefirst Repositories", "Run Kubefirst Repositories"),
+					huh.NewOption("Push Images to Local Registry", "Push Images to Local Registry"),
+					huh.NewOption("Show Swagger Changes", "Show Swagger Changes"),
+					huh.NewOption("Verify Before Push", "Verify Before Push"),
+					huh.NewOption("Select Local State Store", "Select Local State Store"),
+					huh.NewOption("Setup Local TLS", "Setup Local TLS"),
+					huh.NewOption("Bootstrap Console OAuth App", "Bootstrap Console OAuth App"),
+					huh.NewOption("Revert to Main", "Revert to Main"),
+					huh.NewOption("Print Local Setup", "Print Local Setup"), // Add this line
+					huh.NewOption("Back", "Back"),
+				),
 			),
 		)
 
-		err := form.Run()
+		err := runForm(form)
 		if err != nil {
 			log.Error("Error running Kubefirst menu", "error", err)
 			return
@@ -139,10 +251,26 @@ func runKubefirstMenu() {
 			setupKubefirstRepositories()
 		case "Sync Repositories":
 			syncKubefirstRepositories()
+		case "Clean Up Branches":
+			cleanupBranches()
+		case "Open Repository in Editor":
+			openRepositoryInEditor()
 		case "Setup Kubefirst":
 			runKubefirstSetup()
 		case "Run Kubefirst Repositories":
 			runKubefirstRepositories()
+		case "Push Images to Local Registry":
+			pushLocalImages()
+		case "Show Swagger Changes":
+			showSwaggerChanges()
+		case "Verify Before Push":
+			verifyBeforePush()
+		case "Select Local State Store":
+			promptLocalStateStore()
+		case "Setup Local TLS":
+			setupLocalTLS()
+		case "Bootstrap Console OAuth App":
+			bootstrapOAuthApp()
 		case "Revert to Main":
 			revertKubefirstToMain()
 		case "Print Local Setup":
@@ -153,15 +281,13 @@ func runKubefirstMenu() {
 
 		// Prompt user to continue or return to main menu
 		var continueAction bool
-		continueForm := huh.NewForm(
-			huh.NewGroup(
-				huh.NewConfirm().
-					Title("Do you want to perform another Kubefirst action?").
-					Value(&continueAction),
+		continueForm := newForm(
+			newGroup(
+				newConfirm("Do you want to perform another Kubefirst action?", &continueAction),
 			),
 		)
 
-		err = continueForm.Run()
+		err = runForm(continueForm)
 		if err != nil {
 			log.Error("Error in continue prompt", "error", err)
 			return
@@ -176,21 +302,23 @@ func runKubefirstMenu() {
 func runK1spaceMenu() {
 	for {
 		var selected string
-		form := huh.NewForm(
-			huh.NewGroup(
-				huh.NewSelect[string]().
-					Title("k1space Menu").
-					Options(
-						huh.NewOption("Upgrade k1space", "Upgrade k1space"),
-						huh.NewOption("Print Config Paths", "Print Config Paths"),
-						huh.NewOption("Print Version Info", "Print Version Info"),
-						huh.NewOption("Back", "Back"),
-					).
-					Value(&selected),
+		form := newForm(
+			newGroup(
+				newSelect("k1space Menu", &selected,
+					huh.NewOption("Upgrade k1space", "Upgrade k1space"),
+					huh.NewOption("Manage Credentials", "Manage Credentials"),
+					huh.NewOption("Access Tokens", "Access Tokens"),
+					huh.NewOption("Verify Binaries", "Verify Binaries"),
+					huh.NewOption("Doctor", "Doctor"),
+					huh.NewOption("Print Config Paths", "Print Config Paths"),
+					huh.NewOption("Clean Logs", "Clean Logs"),
+					huh.NewOption("Print Version Info", "Print Version Info"),
+					huh.NewOption("Back", "Back"),
+				),
 			),
 		)
 
-		err := form.Run()
+		err := runForm(form)
 		if err != nil {
 			log.Error("Error running k1space menu", "error", err)
 			return
@@ -199,8 +327,18 @@ func runK1spaceMenu() {
 		switch selected {
 		case "Upgrade k1space":
 			upgradeK1space(log.Default())
+		case "Manage Credentials":
+			manageCredentials()
+		case "Access Tokens":
+			manageAccessTokens()
+		case "Verify Binaries":
+			verifyBinaries()
+		case "Doctor":
+			runDoctor()
 		case "Print Config Paths":
 			printConfigPaths(log.Default())
+		case "Clean Logs":
+			cleanLogs()
 		case "Print Version Info":
 			printVersionInfo(log.Default())
 		case "Back":
@@ -209,15 +347,13 @@ func runK1spaceMenu() {
 
 		// Prompt user to continue or return to main menu
 		var continueAction bool
-		continueForm := huh.NewForm(
-			huh.NewGroup(
-				huh.NewConfirm().
-					Title("Do you want to perform another k1space action?").
-					Value(&continueAction),
+		continueForm := newForm(
+			newGroup(
+				newConfirm("Do you want to perform another k1space action?", &continueAction),
 			),
 		)
 
-		err = continueForm.Run()
+		err = runForm(continueForm)
 		if err != nil {
 			log.Error("Error in continue prompt", "error", err)
 			return
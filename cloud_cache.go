@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// How long fetched regions and node types are reused unless cloud_data_ttl is set in settings.hcl
+const defaultCloudDataTTL = 24 * time.Hour
+
+// Providers whose regions and node types are fetched from their APIs and cached in clouds.hcl
+var cloudDataProviders = []string{"Akamai", "Civo", "DigitalOcean", "Google", "Vultr"}
+
+// getCloudDataTTL returns cloud_data_ttl from settings.hcl (e.g. "12h"). "0" fetches on every config creation.
+func getCloudDataTTL() time.Duration {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings, using the default cloud data TTL", "error", err)
+		return defaultCloudDataTTL
+	}
+	if settings.CloudDataTTL == "" {
+		return defaultCloudDataTTL
+	}
+	ttl, err := time.ParseDuration(settings.CloudDataTTL)
+	if err != nil || ttl < 0 {
+		log.Warn("Invalid cloud_data_ttl in settings.hcl, using the default", "cloud_data_ttl", settings.CloudDataTTL)
+		return defaultCloudDataTTL
+	}
+	return ttl
+}
+
+// cloudDataAge returns how long ago a provider's regions and node types were fetched, or false if they never were
+func cloudDataAge(cloudsFile CloudsFile, cloudProvider string) (time.Duration, bool) {
+	if len(cloudsFile.CloudRegions[cloudProvider]) == 0 || len(cloudsFile.CloudNodeTypes[cloudProvider]) == 0 {
+		return 0, false
+	}
+	updated, err := time.Parse(time.RFC3339, cloudsFile.CloudLastUpdated[cloudProvider])
+	if err != nil {
+		return 0, false
+	}
+	return time.Since(updated), true
+}
+
+func formatCloudDataAge(age time.Duration) string {
+	switch {
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(age.Hours()/24))
+	}
+}
+
+// ensureCloudData fetches a provider's regions and node types unless clouds.hcl has them from within the TTL,
+// saving fresh results to clouds.hcl straight away so they're reused even if config creation is abandoned
+func ensureCloudData(cloudProvider string, cloudsFile *CloudsFile) error {
+	if !contains(cloudDataProviders, cloudProvider) {
+		return nil
+	}
+	age, cached := cloudDataAge(*cloudsFile, cloudProvider)
+	if cached && age < getCloudDataTTL() {
+		log.Info("Using cached cloud data", "cloud", cloudProvider, "age", age)
+		fmt.Println(lipgloss.NewStyle().Faint(true).Render(fmt.Sprintf("Using %s regions and node types fetched %s ('Config' -> 'Refresh Cloud Data' to update)", cloudProvider, formatCloudDataAge(age))))
+		return nil
+	}
+	return fetchCloudData(cloudProvider, cloudsFile, cached)
+}
+
+// fetchCloudData refreshes a provider's regions and node types and saves them. With allowStale, a failed fetch
+// falls back to what clouds.hcl already has, since outdated options beat none.
+func fetchCloudData(cloudProvider string, cloudsFile *CloudsFile, allowStale bool) error {
+	err := runCancellable(fmt.Sprintf("Fetching %s regions and node types...", cloudProvider), func(ctx context.Context) error {
+		return refreshCloudData(ctx, cloudProvider, cloudsFile)
+	})
+	if err != nil {
+		err = describeNetworkError("Fetching "+cloudProvider+" regions and node types", err)
+		if allowStale && !errors.Is(err, errCancelled) {
+			log.Warn("Could not refresh cloud data, using the cached copy", "cloud", cloudProvider, "error", err)
+			age, _ := cloudDataAge(*cloudsFile, cloudProvider)
+			fmt.Printf("Could not refresh %s regions and node types, using the ones fetched %s: %v\n", cloudProvider, formatCloudDataAge(age), err)
+			return nil
+		}
+		return err
+	}
+
+	err = updateClouds(func(latest *CloudsFile) error {
+		mergeCloudData(latest, *cloudsFile, cloudProvider)
+		return nil
+	})
+	if err != nil {
+		// The data is still usable for this run
+		log.Warn("Could not save cloud data to clouds.hcl", "cloud", cloudProvider, "error", err)
+	}
+	return nil
+}
+
+func newCloudsFile() CloudsFile {
+	return CloudsFile{
+		CloudRegions:     make(map[string][]string),
+		CloudZones:       make(map[string][]string),
+		CloudNodeTypes:   make(map[string][]InstanceSizeInfo),
+		CloudLastUpdated: make(map[string]string),
+	}
+}
+
+// refreshCloudDataConcurrently fetches several providers at once. Each provider fills its own CloudsFile, which
+// is merged into cloudsFile when it succeeds, since the providers would otherwise write to the same maps.
+func refreshCloudDataConcurrently(ctx context.Context, providers []string, cloudsFile *CloudsFile) map[string]error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make(map[string]error)
+	for _, provider := range providers {
+		wg.Add(1)
+		go func(provider string) {
+			defer wg.Done()
+			fetched := newCloudsFile()
+			err := refreshCloudData(ctx, provider, &fetched)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[provider] = err
+				return
+			}
+			mergeCloudData(cloudsFile, fetched, provider)
+		}(provider)
+	}
+	wg.Wait()
+	return errs
+}
+
+// mergeCloudData copies one provider's regions, zones and node types from src, leaving the others alone
+func mergeCloudData(dst *CloudsFile, src CloudsFile, provider string) {
+	dst.CloudRegions[provider] = src.CloudRegions[provider]
+	dst.CloudNodeTypes[provider] = src.CloudNodeTypes[provider]
+	dst.CloudLastUpdated[provider] = src.CloudLastUpdated[provider]
+	if zones, ok := src.CloudZones[provider]; ok {
+		dst.CloudZones[provider] = zones
+	}
+}
+
+func refreshCloudDataMenu() {
+	log.Info("Starting refreshCloudDataMenu function")
+
+	cloudsFile, err := loadCloudsFile()
+	if err != nil {
+		log.Error("Error loading clouds file", "error", err)
+		fmt.Println("Failed to load clouds.hcl:", err)
+		return
+	}
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		return
+	}
+
+	options := make([]huh.Option[string], 0, len(cloudDataProviders))
+	for _, provider := range cloudDataProviders {
+		label := provider + " (never fetched)"
+		if age, cached := cloudDataAge(cloudsFile, provider); cached {
+			label = fmt.Sprintf("%s (fetched %s)", provider, formatCloudDataAge(age))
+		}
+		options = append(options, huh.NewOption(label, provider))
+	}
+
+	// Start with every provider there are credentials for, so refreshing all of them is a single Enter
+	var selected []string
+	for _, provider := range cloudDataProviders {
+		if lookupToken(cloudCredentialVar(provider)) != "" {
+			selected = append(selected, provider)
+		}
+	}
+	err = runField(newMultiSelect("Select the cloud providers to refresh", &selected, options...).
+		Description("Providers with credentials set are selected"))
+	if err != nil {
+		log.Error("Error in cloud provider selection", "error", err)
+		return
+	}
+	if len(selected) == 0 {
+		fmt.Println("No cloud providers selected.")
+		return
+	}
+
+	// Prompts come first, so the fetches can then all run at once
+	var ready []string
+	for _, provider := range selected {
+		profile, err := promptCredentialProfile(provider, settings, "")
+		if err != nil {
+			log.Error("Error in credential profile selection", "error", err)
+			return
+		}
+		useCredentialProfile(provider, profile)
+
+		tokenExists, message := checkRequiredTokens(provider)
+		if !tokenExists {
+			log.Error("Missing required token", "cloud", provider)
+			fmt.Println(message)
+			continue
+		}
+		ready = append(ready, provider)
+	}
+	if len(ready) == 0 {
+		return
+	}
+
+	refreshProviders(&cloudsFile, ready, nil)
+}
+
+// cloudCredentialVar is the variable holding the credentials a provider's catalog is fetched with
+func cloudCredentialVar(provider string) string {
+	if provider == "Google" {
+		return "GOOGLE_APPLICATION_CREDENTIALS"
+	}
+	return cloudTokenVar(provider)
+}
+
+// refreshAllProviders is 'Config' -> 'Refresh All Providers'. It refreshes every provider there are credentials
+// for at once, without prompting, using each cloud's default credential profile.
+func refreshAllProviders() {
+	cloudsFile, err := loadCloudsFile()
+	if err != nil {
+		log.Error("Error loading clouds file", "error", err)
+		fmt.Println("Failed to load clouds.hcl:", err)
+		return
+	}
+	var ready []string
+	skipped := make(map[string]string)
+	for _, provider := range cloudDataProviders {
+		useCredentialProfile(provider, "")
+		tokenVar := cloudCredentialVar(provider)
+		found := lookupToken(tokenVar) != ""
+		if !found && provider != "Google" {
+			found = loadTokenFromOnePassword(tokenVar) == nil
+		}
+		if !found {
+			skipped[provider] = "no " + tokenVar
+			continue
+		}
+		ready = append(ready, provider)
+	}
+	if len(ready) == 0 {
+		fmt.Println("No cloud credentials found. Set a provider's token, or save it with 'k1space' -> 'Manage Credentials'.")
+		return
+	}
+
+	refreshProviders(&cloudsFile, ready, skipped)
+}
+
+// refreshProviders fetches the providers' regions and node types in parallel and reports how each went. clouds.hcl
+// is saved when any provider succeeds; a provider that fails keeps its previous data. Skipped providers are
+// listed in the summary with the reason they weren't fetched.
+func refreshProviders(cloudsFile *CloudsFile, ready []string, skipped map[string]string) {
+	before := cloneCloudData(*cloudsFile)
+	var errs map[string]error
+	err := runCancellable(fmt.Sprintf("Fetching regions and node types for %s...", strings.Join(ready, ", ")), func(ctx context.Context) error {
+		errs = refreshCloudDataConcurrently(ctx, ready, cloudsFile)
+		if len(errs) > 0 {
+			return fmt.Errorf("%d of %d providers failed", len(errs), len(ready))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("Error updating cloud data", "error", err)
+	}
+
+	if len(errs) < len(ready) {
+		// Merge into clouds.hcl as it is now, so only the providers fetched here change even if another k1space
+		// saved it in the meantime
+		saveErr := updateClouds(func(latest *CloudsFile) error {
+			for _, provider := range ready {
+				if _, failed := errs[provider]; !failed {
+					mergeCloudData(latest, *cloudsFile, provider)
+				}
+			}
+			return nil
+		})
+		if saveErr != nil {
+			log.Error("Error saving clouds file", "error", saveErr)
+			fmt.Println("Failed to save clouds.hcl:", saveErr)
+		}
+	}
+
+	summary := [][]string{{"Provider", "Regions", "Node Types", "Added", "Removed"}}
+	for _, provider := range ready {
+		if _, failed := errs[provider]; failed {
+			summary = append(summary, []string{provider, "Failed", "Failed", "", ""})
+			continue
+		}
+		addedRegions, removedRegions := diffNames(before.CloudRegions[provider], cloudsFile.CloudRegions[provider])
+		addedTypes, removedTypes := diffNames(nodeTypeNames(before.CloudNodeTypes[provider]), nodeTypeNames(cloudsFile.CloudNodeTypes[provider]))
+		summary = append(summary, []string{
+			provider,
+			countChange(len(cloudsFile.CloudRegions[provider]), len(addedRegions), len(removedRegions)),
+			countChange(len(cloudsFile.CloudNodeTypes[provider]), len(addedTypes), len(removedTypes)),
+			summarizeNames(append(addedRegions, addedTypes...)),
+			summarizeNames(append(removedRegions, removedTypes...)),
+		})
+	}
+	for _, provider := range sortedKeys(skipped) {
+		summary = append(summary, []string{provider, fmt.Sprintf("Skipped (%s)", skipped[provider]), "Skipped", "", ""})
+	}
+	printSummaryTable("Cloud Data Changes", summary)
+
+	for _, provider := range ready {
+		if fetchErr, failed := errs[provider]; failed {
+			fmt.Println(describeNetworkError("Fetching "+provider+" regions and node types", fetchErr))
+		}
+	}
+}
+
+// cloneCloudData copies the region and node type lists so they can be compared after a refresh
+func cloneCloudData(cloudsFile CloudsFile) CloudsFile {
+	clone := newCloudsFile()
+	for provider, regions := range cloudsFile.CloudRegions {
+		clone.CloudRegions[provider] = append([]string(nil), regions...)
+	}
+	for provider, nodeTypes := range cloudsFile.CloudNodeTypes {
+		clone.CloudNodeTypes[provider] = append([]InstanceSizeInfo(nil), nodeTypes...)
+	}
+	return clone
+}
+
+func nodeTypeNames(nodeTypes []InstanceSizeInfo) []string {
+	names := make([]string, len(nodeTypes))
+	for i, nodeType := range nodeTypes {
+		names[i] = nodeType.Name
+	}
+	return names
+}
+
+// diffNames returns the names only in after and the names only in before, sorted
+func diffNames(before, after []string) (added, removed []string) {
+	for _, name := range after {
+		if !contains(before, name) {
+			added = append(added, name)
+		}
+	}
+	for _, name := range before {
+		if !contains(after, name) {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// countChange formats a count with what changed, e.g. "14 (+2, -1)"
+func countChange(total, added, removed int) string {
+	if added == 0 && removed == 0 {
+		return fmt.Sprintf("%d (unchanged)", total)
+	}
+	return fmt.Sprintf("%d (+%d, -%d)", total, added, removed)
+}
+
+// summarizeNames lists up to five names, so a provider's first fetch doesn't flood the table
+func summarizeNames(names []string) string {
+	const limit = 5
+	if len(names) <= limit {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:limit], ", "), len(names)-limit)
+}
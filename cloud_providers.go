@@ -3,30 +3,36 @@ package main
 import (
 	"context"
 	"fmt"
-	"os"
+	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/huh"
 	"github.com/civo/civogo"
 	"github.com/digitalocean/godo"
+	"golang.org/x/oauth2"
 )
 
 func getCivoClient() (*civogo.Client, error) {
-	token := os.Getenv("CIVO_TOKEN")
+	token := lookupToken("CIVO_TOKEN")
 	if token == "" {
-		return nil, fmt.Errorf("CIVO_TOKEN not found in environment. Please set it and try again")
+		return nil, fmt.Errorf("CIVO_TOKEN not found in environment or keychain. Please set it and try again")
 	}
 	return civogo.NewClient(token, "")
 }
 
-func updateCivoRegions(cloudsFile *CloudsFile) error {
+func updateCivoRegions(ctx context.Context, cloudsFile *CloudsFile) error {
 	client, err := getCivoClient()
 	if err != nil {
 		return err
 	}
 
-	regions, err := client.ListRegions()
+	var regions []civogo.Region
+	err = awaitContext(ctx, func() (err error) {
+		regions, err = client.ListRegions()
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -40,13 +46,17 @@ func updateCivoRegions(cloudsFile *CloudsFile) error {
 	return nil
 }
 
-func updateCivoNodeTypes(cloudsFile *CloudsFile) error {
+func updateCivoNodeTypes(ctx context.Context, cloudsFile *CloudsFile) error {
 	client, err := getCivoClient()
 	if err != nil {
 		return err
 	}
 
-	sizes, err := client.ListInstanceSizes()
+	var sizes []civogo.InstanceSize
+	err = awaitContext(ctx, func() (err error) {
+		sizes, err = client.ListInstanceSizes()
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -54,10 +64,14 @@ func updateCivoNodeTypes(cloudsFile *CloudsFile) error {
 	var sizeInfos []InstanceSizeInfo
 	for _, size := range sizes {
 		sizeInfos = append(sizeInfos, InstanceSizeInfo{
-			Name:          size.Name,
-			CPUCores:      size.CPUCores,
-			RAMMegabytes:  size.RAMMegabytes,
-			DiskGigabytes: size.DiskGigabytes,
+			Name:             size.Name,
+			CPUCores:         size.CPUCores,
+			RAMMegabytes:     size.RAMMegabytes,
+			DiskGigabytes:    size.DiskGigabytes,
+			GPUCount:         size.GPUCount,
+			GPUModel:         size.GPUType,
+			GPUVRAMGigabytes: gpuVRAMGigabytes(size.GPUType),
+			Architecture:     detectArchitecture(size.Name),
 		})
 	}
 
@@ -66,20 +80,22 @@ func updateCivoNodeTypes(cloudsFile *CloudsFile) error {
 }
 
 func getDigitalOceanClient() (*godo.Client, error) {
-	token := os.Getenv("DO_TOKEN")
+	token := lookupToken("DO_TOKEN")
 	if token == "" {
-		return nil, fmt.Errorf("DO_TOKEN not found in environment. Please set it and try again")
+		return nil, fmt.Errorf("DO_TOKEN not found in environment or keychain. Please set it and try again")
 	}
-	return godo.NewFromToken(token), nil
+	// Like godo.NewFromToken, but with the HTTP timeout on every request
+	httpClient := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(&oauth2.Token{AccessToken: strings.TrimSpace(token)}))
+	httpClient.Timeout = getHTTPTimeout()
+	return godo.NewClient(httpClient), nil
 }
 
-func updateDigitalOceanRegions(cloudsFile *CloudsFile) error {
+func updateDigitalOceanRegions(ctx context.Context, cloudsFile *CloudsFile) error {
 	client, err := getDigitalOceanClient()
 	if err != nil {
 		return err
 	}
 
-	ctx := context.TODO()
 	opt := &godo.ListOptions{
 		Page:    1,
 		PerPage: 200,
@@ -99,13 +115,12 @@ func updateDigitalOceanRegions(cloudsFile *CloudsFile) error {
 	return nil
 }
 
-func updateDigitalOceanNodeTypes(cloudsFile *CloudsFile) error {
+func updateDigitalOceanNodeTypes(ctx context.Context, cloudsFile *CloudsFile) error {
 	client, err := getDigitalOceanClient()
 	if err != nil {
 		return err
 	}
 
-	ctx := context.TODO()
 	opt := &godo.ListOptions{
 		Page:    1,
 		PerPage: 200,
@@ -118,12 +133,32 @@ func updateDigitalOceanNodeTypes(cloudsFile *CloudsFile) error {
 
 	var sizeInfos []InstanceSizeInfo
 	for _, size := range sizes {
+		// GPU slugs (e.g. gpu-h100x1-80gb) don't follow the vcpu/ram naming scheme, so use the API values
+		if gpuCount, gpuModel, ok := parseDigitalOceanGPUSize(size.Slug); ok {
+			sizeInfos = append(sizeInfos, InstanceSizeInfo{
+				Name:             size.Slug,
+				CPUCores:         size.Vcpus,
+				RAMMegabytes:     size.Memory,
+				DiskGigabytes:    size.Disk,
+				GPUCount:         gpuCount,
+				GPUModel:         gpuModel,
+				GPUVRAMGigabytes: gpuVRAMGigabytes(gpuModel),
+				Architecture:     detectArchitecture(size.Slug),
+				PriceHourly:      size.PriceHourly,
+				PriceMonthly:     size.PriceMonthly,
+			})
+			continue
+		}
+
 		cpuCores, ramMB, diskGB := parseDigitalOceanSize(size.Slug)
 		sizeInfos = append(sizeInfos, InstanceSizeInfo{
 			Name:          size.Slug,
 			CPUCores:      cpuCores,
 			RAMMegabytes:  ramMB,
 			DiskGigabytes: diskGB,
+			Architecture:  detectArchitecture(size.Slug),
+			PriceHourly:   size.PriceHourly,
+			PriceMonthly:  size.PriceMonthly,
 		})
 	}
 
@@ -152,56 +187,135 @@ func parseDigitalOceanSize(size string) (cpuCores, ramMB, diskGB int) {
 	return cpuCores, ramMB, diskGB
 }
 
+// Display names for providers whose config value differs from how users know them
+var cloudProviderLabels = map[string]string{
+	"Akamai": "Akamai (Linode)",
+	"Google": "Google Cloud",
+}
+
 func getCloudProviderOptions() []huh.Option[string] {
 	options := make([]huh.Option[string], len(cloudProviders))
 	for i, provider := range cloudProviders {
-		options[i] = huh.Option[string]{Key: provider, Value: provider}
+		label := provider
+		if l, ok := cloudProviderLabels[provider]; ok {
+			label = l
+		}
+		options[i] = huh.Option[string]{Key: label, Value: provider}
 	}
 	return options
 }
 
-func getRegionOptions(cloudProvider string, cloudsFile CloudsFile) []huh.Option[string] {
+// kubefirstCloudCommand returns the kubefirst subcommand for a provider, e.g. "google" for `kubefirst google create`
+func kubefirstCloudCommand(cloudProvider string) string {
+	return strings.ToLower(cloudProvider)
+}
+
+func getRegionOptions(cloudProvider string, cloudsFile CloudsFile, latencies map[string]time.Duration) []huh.Option[string] {
 	regions := cloudsFile.CloudRegions[cloudProvider]
 	options := make([]huh.Option[string], len(regions))
 	for i, region := range regions {
-		options[i] = huh.Option[string]{Key: region, Value: region}
+		label := region + formatLatency(latencies, region)
+		if zones := regionZones(region, cloudsFile.CloudZones[cloudProvider]); len(zones) > 0 {
+			label += fmt.Sprintf(" [zones: %s]", strings.Join(zones, ", "))
+		}
+		options[i] = huh.Option[string]{Key: label, Value: region}
 	}
 	return options
 }
 
-func getNodeTypeOptions(cloudProvider string, cloudsFile CloudsFile) []huh.Option[string] {
+// regionZones returns the zone suffixes (a, b, c...) of zones named <region>-<suffix>
+func regionZones(region string, zones []string) []string {
+	var suffixes []string
+	for _, zone := range zones {
+		if strings.HasPrefix(zone, region+"-") {
+			suffixes = append(suffixes, strings.TrimPrefix(zone, region+"-"))
+		}
+	}
+	return suffixes
+}
+
+func getNodeTypeOptions(cloudProvider string, cloudsFile CloudsFile, filter func(InstanceSizeInfo) bool) []huh.Option[string] {
 	nodeTypes := cloudsFile.CloudNodeTypes[cloudProvider]
-	options := make([]huh.Option[string], len(nodeTypes))
-	for i, nodeType := range nodeTypes {
-		displayName := fmt.Sprintf("%s (CPU Cores: %d, RAM: %d MB, Disk: %d GB)",
-			nodeType.Name,
-			nodeType.CPUCores,
-			nodeType.RAMMegabytes,
-			nodeType.DiskGigabytes)
-		options[i] = huh.Option[string]{
-			Key:   nodeType.Name,
-			Value: displayName,
+	options := make([]huh.Option[string], 0, len(nodeTypes))
+	for _, nodeType := range nodeTypes {
+		if filter != nil && !filter(nodeType) {
+			continue
+		}
+		key := nodeType.Name
+		if nodeType.IsARM() {
+			key += " [ARM64]"
 		}
+		if nodeType.HasGPU() {
+			key += " " + formatGPU(nodeType)
+		}
+		if price := formatNodePrice(nodeType); price != "" {
+			key += " " + price
+		}
+		options = append(options, huh.Option[string]{
+			Key:   key,
+			Value: nodeTypeOptionValue(nodeType),
+		})
 	}
 	return options
 }
 
+// nodeTypeOptionValue is what a node type's option stores; createConfig keeps the name from it
+func nodeTypeOptionValue(nodeType InstanceSizeInfo) string {
+	value := fmt.Sprintf("%s (CPU Cores: %d, RAM: %d MB, Disk: %d GB)",
+		nodeType.Name,
+		nodeType.CPUCores,
+		nodeType.RAMMegabytes,
+		nodeType.DiskGigabytes)
+	if nodeType.IsARM() {
+		value += " [ARM64]"
+	}
+	if nodeType.HasGPU() {
+		value += " " + formatGPU(nodeType)
+	}
+	return value
+}
+
+func hasGPUNodeTypes(cloudProvider string, cloudsFile CloudsFile) bool {
+	for _, nodeType := range cloudsFile.CloudNodeTypes[cloudProvider] {
+		if nodeType.HasGPU() {
+			return true
+		}
+	}
+	return false
+}
+
+func formatGPU(nodeType InstanceSizeInfo) string {
+	if nodeType.GPUVRAMGigabytes > 0 {
+		return fmt.Sprintf("[GPU: %dx %s, %d GB VRAM]", nodeType.GPUCount, nodeType.GPUModel, nodeType.GPUVRAMGigabytes)
+	}
+	return fmt.Sprintf("[GPU: %dx %s]", nodeType.GPUCount, nodeType.GPUModel)
+}
+
 func checkRequiredTokens(cloudProvider string) (bool, string) {
     var tokenName, instructions string
     var tokenExists bool
 
     switch cloudProvider {
-    case "Civo":
-        tokenName = "CIVO_TOKEN"
-        instructions = "You can create a new Civo API token at https://www.civo.com/account/security"
-    case "DigitalOcean":
-        tokenName = "DO_TOKEN"
-        instructions = "You can create a new DigitalOcean API token at https://cloud.digitalocean.com/account/api/tokens"
+    case "Akamai", "Civo", "DigitalOcean", "Google", "Vultr":
+        tokenName = cloudCredentialVar(cloudProvider)
+        instructions = cloudTokenInstructions[cloudProvider]
     default:
         return true, ""
     }
 
-    tokenExists = os.Getenv(tokenName) != ""
+    // With a credential profile selected, the token is read from e.g. CIVO_TOKEN_WORK instead
+    tokenName = activeTokenVar(tokenName)
+    tokenExists = lookupToken(tokenName) != ""
+    // Fall back to the item 'Manage 1Password Secrets' stored, once a vault is configured
+    if !tokenExists && cloudProvider != "Google" {
+        tokenExists = loadTokenFromOnePassword(tokenName) == nil
+    }
+    // GOOGLE_APPLICATION_CREDENTIALS is a path, so it must also point at a readable file
+    if tokenExists && cloudProvider == "Google" {
+        if _, err := loadGoogleCredentials(); err != nil {
+            tokenExists = false
+        }
+    }
     message := fmt.Sprintf(`
 ╔════════════════════════════════════════════════════════════════════════════╗
 ║ Missing Required Token: %s                                                 
@@ -213,9 +327,95 @@ func checkRequiredTokens(cloudProvider string) (bool, string) {
 ║ 
 ║ %s
 ║ 
+║ Or save it once with 'k1space' -> 'Manage Credentials' to keep it in your OS keychain.
+║ 
 ║ After setting the token, please restart k1space.
 ╚════════════════════════════════════════════════════════════════════════════╝
 `, tokenName, tokenName, tokenName, instructions)
+    if !tokenExists {
+        return false, message
+    }
 
-    return tokenExists, message
+    // A token that is set can still be expired or revoked, so try it before the user fills out the config form
+    err := runCancellable(fmt.Sprintf("Checking %s with %s...", tokenName, cloudProvider), func(ctx context.Context) error {
+        return validateCloudToken(ctx, cloudProvider)
+    })
+    // Connection failures say nothing about the token itself
+    if err != nil && isConnectionError(err) {
+        return false, fmt.Sprintf(`
+╔════════════════════════════════════════════════════════════════════════════╗
+║ Token Check Failed: %s                                                 
+║────────────────────────────────────────────────────────────────────────────
+║ Could not reach %s to check the token:
+║ %v
+║ 
+║ Check your network connection and try again.
+╚════════════════════════════════════════════════════════════════════════════╝
+`, tokenName, cloudProvider, describeNetworkError("Checking "+tokenName, err))
+    }
+    recordTokenError(cloudProvider, err)
+    // A rejected token can be replaced right away, rather than after restarting k1space
+    if isAuthError(err) && !isHeadless() && !isStructuredOutput() && promptReplaceCloudToken(cloudProvider) {
+        return true, ""
+    }
+    if err != nil {
+        return false, fmt.Sprintf(`
+╔════════════════════════════════════════════════════════════════════════════╗
+║ Token Check Failed: %s                                                 
+║────────────────────────────────────────────────────────────────────────────
+║ %s did not accept the token:
+║ %v
+║ 
+║ The token may be expired, revoked or mistyped. %s
+║ 
+║ Update it in your environment or with 'k1space' -> 'Manage Credentials'.
+╚════════════════════════════════════════════════════════════════════════════╝
+`, tokenName, cloudProvider, err, instructions)
+    }
+
+    clearTokenError(cloudProvider)
+    return true, ""
+}
+
+// validateCloudToken makes a cheap authenticated call, so an invalid token fails with the provider's own error
+func validateCloudToken(ctx context.Context, cloudProvider string) error {
+	switch cloudProvider {
+	case "Akamai":
+		client, err := getLinodeClient()
+		if err != nil {
+			return err
+		}
+		_, err = client.requestContext(ctx, http.MethodGet, "profile", nil)
+		return err
+	case "Civo":
+		client, err := getCivoClient()
+		if err != nil {
+			return err
+		}
+		return awaitContext(ctx, func() error {
+			_, err := client.GetQuota()
+			return err
+		})
+	case "DigitalOcean":
+		client, err := getDigitalOceanClient()
+		if err != nil {
+			return err
+		}
+		_, _, err = client.Account.Get(ctx)
+		return err
+	case "Google":
+		client, err := getGoogleComputeClient()
+		if err != nil {
+			return err
+		}
+		return client.getProject(ctx)
+	case "Vultr":
+		client, err := getVultrClient()
+		if err != nil {
+			return err
+		}
+		_, err = client.Account.Get(ctx)
+		return vultrError(err)
+	}
+	return nil
 }
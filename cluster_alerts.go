@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+const (
+	defaultAlertInterval  = 5 * time.Minute
+	defaultCertExpiryDays = 14
+)
+
+// AlertSettings tune the daemon's checks, from the alerts block of settings.hcl
+type AlertSettings struct {
+	Interval       string `hcl:"interval,optional"`
+	CertExpiryDays int    `hcl:"cert_expiry_days,optional"`
+}
+
+// ClusterAlerts is a config's alerts block in config.hcl. Provisioned clusters without one are monitored.
+type ClusterAlerts struct {
+	Enabled bool `hcl:"enabled,optional"`
+}
+
+// clusterAlert is a problem the daemon found on a cluster; it's identified by config and check so it's only
+// announced when it starts and when it clears
+type clusterAlert struct {
+	Config  string `json:"config" yaml:"config"`
+	Check   string `json:"check" yaml:"check"`
+	Message string `json:"message" yaml:"message"`
+}
+
+func (a clusterAlert) key() string {
+	return a.Config + "/" + a.Check
+}
+
+type kubernetesVersion struct {
+	ServerVersion struct {
+		GitVersion string `json:"gitVersion"`
+	} `json:"serverVersion"`
+}
+
+type certificateList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Status struct {
+			NotAfter string `json:"notAfter"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+func alertsEnabled(config Config) bool {
+	return config.Alerts == nil || config.Alerts.Enabled
+}
+
+// alertSchedule returns how often the daemon checks and how close to expiry a certificate has to be to alert
+func alertSchedule() (time.Duration, time.Duration) {
+	interval, certDays := defaultAlertInterval, defaultCertExpiryDays
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings, using the default alert schedule", "error", err)
+		return interval, time.Duration(certDays) * 24 * time.Hour
+	}
+	if settings.Alerts != nil {
+		if settings.Alerts.Interval != "" {
+			parsed, err := time.ParseDuration(settings.Alerts.Interval)
+			if err != nil || parsed <= 0 {
+				log.Warn("Invalid alerts interval in settings.hcl, using the default", "interval", settings.Alerts.Interval)
+			} else {
+				interval = parsed
+			}
+		}
+		if settings.Alerts.CertExpiryDays > 0 {
+			certDays = settings.Alerts.CertExpiryDays
+		}
+	}
+	return interval, time.Duration(certDays) * 24 * time.Hour
+}
+
+// trackedClusters returns the provisioned configs with alerts enabled, sorted by name
+func trackedClusters(indexFile IndexFile) []string {
+	var names []string
+	for name, config := range indexFile.Configs {
+		if config.State != nil && config.State.Current == stateProvisioned && alertsEnabled(config) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isMissingResourceType reports kubectl errors for CRDs that aren't installed, e.g. before Argo CD is
+func isMissingResourceType(err error) bool {
+	return strings.Contains(err.Error(), "the server doesn't have a resource type")
+}
+
+func degradedApplications(ctx context.Context, kubeconfig string) ([]string, error) {
+	var apps argoApplicationList
+	err := kubectlJSON(ctx, kubeconfig, "", &apps, "get", "applications.argoproj.io", "-n", "argocd")
+	if err != nil {
+		if isMissingResourceType(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var degraded []string
+	for _, app := range apps.Items {
+		if app.Status.Health.Status == "Degraded" {
+			degraded = append(degraded, app.Metadata.Name)
+		}
+	}
+	sort.Strings(degraded)
+	return degraded, nil
+}
+
+// expiringCertificates lists cert-manager certificates that expire within window
+func expiringCertificates(ctx context.Context, kubeconfig string, window time.Duration) ([]string, error) {
+	var certificates certificateList
+	err := kubectlJSON(ctx, kubeconfig, "", &certificates, "get", "certificates.cert-manager.io", "--all-namespaces")
+	if err != nil {
+		if isMissingResourceType(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var expiring []string
+	for _, certificate := range certificates.Items {
+		notAfter, err := time.Parse(time.RFC3339, certificate.Status.NotAfter)
+		if err != nil {
+			continue
+		}
+		if left := time.Until(notAfter); left < window {
+			expiring = append(expiring, fmt.Sprintf("%s/%s (%s)", certificate.Metadata.Namespace, certificate.Metadata.Name, notAfter.Local().Format("2006-01-02")))
+		}
+	}
+	sort.Strings(expiring)
+	return expiring, nil
+}
+
+// checkClusterAlerts runs the daemon's checks against one cluster and returns the alerts that are firing
+func checkClusterAlerts(ctx context.Context, configName string, config Config, certWindow time.Duration) []clusterAlert {
+	alert := func(check, message string) clusterAlert {
+		return clusterAlert{Config: configName, Check: check, Message: message}
+	}
+	clusterName := findConfigFlag(config.Flags, "cluster-name")
+	kubeconfig := healthCheckKubeconfig(configName, clusterName)
+	if kubeconfig == "" {
+		return []clusterAlert{alert("api", "no kubeconfig found, fetch it with 'Fetch Kubeconfig'")}
+	}
+
+	// Nothing else can be checked while the API is down
+	var version kubernetesVersion
+	err := kubectlJSON(ctx, kubeconfig, "", &version, "version")
+	if err != nil {
+		return []clusterAlert{alert("api", "Kubernetes API unreachable: "+err.Error())}
+	}
+
+	var alerts []clusterAlert
+	degraded, err := degradedApplications(ctx, kubeconfig)
+	switch {
+	case err != nil:
+		alerts = append(alerts, alert("argocd", "could not list Argo CD applications: "+err.Error()))
+	case len(degraded) > 0:
+		alerts = append(alerts, alert("argocd", "degraded Argo CD applications: "+summarizeWaiting(degraded)))
+	}
+	expiring, err := expiringCertificates(ctx, kubeconfig, certWindow)
+	switch {
+	case err != nil:
+		alerts = append(alerts, alert("certificates", "could not list certificates: "+err.Error()))
+	case len(expiring) > 0:
+		alerts = append(alerts, alert("certificates", fmt.Sprintf("certificates expiring within %d days: %s", int(certWindow.Hours()/24), summarizeWaiting(expiring))))
+	}
+	return alerts
+}
+
+// checkTrackedClusters runs the checks against every tracked cluster outside its maintenance windows, and returns
+// the alerts found along with the clusters that were skipped for maintenance
+func checkTrackedClusters(ctx context.Context) ([]clusterAlert, map[string]bool, error) {
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		return nil, nil, err
+	}
+	_, certWindow := alertSchedule()
+	var alerts []clusterAlert
+	maintenance := map[string]bool{}
+	for _, name := range trackedClusters(indexFile) {
+		config := indexFile.Configs[name]
+		if inMaintenance(config, time.Now()) {
+			log.Info("Skipping cluster in maintenance window", "config", name)
+			maintenance[name] = true
+			continue
+		}
+		useCredentialProfile(strings.Split(name, "_")[0], configCredentialProfile(config))
+		alerts = append(alerts, checkClusterAlerts(ctx, name, config, certWindow)...)
+	}
+	return alerts, maintenance, nil
+}
+
+// announceAlertChanges notifies about alerts that started or cleared since the previous check, and returns the
+// alerts now active. Alerts of clusters in maintenance are carried over as they were, so they're neither resolved
+// nor announced again when the window closes.
+func announceAlertChanges(ctx context.Context, active map[string]clusterAlert, current []clusterAlert, maintenance map[string]bool) map[string]clusterAlert {
+	next := make(map[string]clusterAlert, len(current))
+	for key, alert := range active {
+		if maintenance[alert.Config] {
+			next[key] = alert
+		}
+	}
+	var messages []string
+	for _, alert := range current {
+		next[alert.key()] = alert
+		if _, ok := active[alert.key()]; !ok {
+			log.Warn("Cluster alert", "config", alert.Config, "check", alert.Check, "message", alert.Message)
+			messages = append(messages, fmt.Sprintf("🔴 %s: %s", alert.Config, alert.Message))
+		}
+	}
+	for key, alert := range active {
+		if _, ok := next[key]; !ok {
+			log.Info("Cluster alert resolved", "config", alert.Config, "check", alert.Check)
+			messages = append(messages, fmt.Sprintf("✅ %s: %s check is passing again", alert.Config, alert.Check))
+		}
+	}
+	sort.Strings(messages)
+	for _, message := range messages {
+		if err := sendNotification(ctx, "[k1space] "+message); err != nil {
+			log.Error("Error sending alert notification", "error", err)
+		}
+	}
+	return next
+}
+
+// runAlertDaemon checks the tracked clusters every alerts interval until interrupted. With once set it checks a
+// single time, which suits running it from cron, and returns 1 if any alert is firing.
+func runAlertDaemon(once bool) int {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		return 1
+	}
+	if len(configuredChannels(settings.Notifications)) == 0 {
+		log.Warn("No notification channels are configured in settings.hcl, alerts will only be logged")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	active := map[string]clusterAlert{}
+	interval, _ := alertSchedule()
+	log.Info("Starting cluster alert daemon", "interval", interval)
+	for {
+		alerts, maintenance, err := checkTrackedClusters(ctx)
+		if err != nil {
+			log.Error("Error checking clusters", "error", err)
+		} else {
+			active = announceAlertChanges(ctx, active, alerts, maintenance)
+		}
+		if once {
+			if len(active) > 0 {
+				return 1
+			}
+			return 0
+		}
+		select {
+		case <-ctx.Done():
+			log.Info("Stopping cluster alert daemon")
+			return 0
+		case <-time.After(interval):
+		}
+	}
+}
+
+// configureClusterAlerts picks which provisioned clusters the daemon watches
+func configureClusterAlerts() {
+	log.Info("Starting configureClusterAlerts function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations:", err)
+		return
+	}
+	if len(indexFile.Configs) == 0 {
+		fmt.Println("No configurations available. Please create a configuration first.")
+		return
+	}
+
+	names := make([]string, 0, len(indexFile.Configs))
+	for name := range indexFile.Configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var options []huh.Option[string]
+	var enabled []string
+	for _, name := range names {
+		config := indexFile.Configs[name]
+		options = append(options, huh.NewOption(configOptionLabel(name, config), name))
+		if alertsEnabled(config) {
+			enabled = append(enabled, name)
+		}
+	}
+
+	err = runField(newMultiSelect("Clusters to alert on", &enabled, options...).
+		Description("The daemon (k1space daemon) only checks the selected configs while they're provisioned"))
+	if err != nil {
+		log.Error("Error in alert selection", "error", err)
+		return
+	}
+
+	err = updateIndex(func(indexFile *IndexFile) error {
+		for _, name := range names {
+			config, ok := indexFile.Configs[name]
+			if !ok {
+				continue
+			}
+			config.Alerts = &ClusterAlerts{Enabled: contains(enabled, name)}
+			indexFile.Configs[name] = config
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("Error saving alert settings", "error", err)
+		fmt.Println("Failed to save alert settings:", err)
+		return
+	}
+	fmt.Printf("Alerts enabled for %d of %d configs.\n", len(enabled), len(names))
+}
@@ -0,0 +1,622 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+	"github.com/civo/civogo"
+	"github.com/digitalocean/godo"
+)
+
+// Providers whose Kubernetes clusters can be discovered, and the token each needs
+var inventoryProviders = map[string]string{
+	"Civo":         "CIVO_TOKEN",
+	"DigitalOcean": "DO_TOKEN",
+}
+
+// cloudCluster is a Kubernetes cluster found in a cloud account. Imported ones are kept in imported_clusters.json.
+type cloudCluster struct {
+	Provider   string    `json:"provider"`
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Region     string    `json:"region"`
+	Size       string    `json:"size"`
+	Nodes      int       `json:"nodes"`
+	Status     string    `json:"status"`
+	ImportedAt time.Time `json:"imported_at,omitempty"`
+}
+
+type importedClustersFile struct {
+	Clusters []cloudCluster `json:"clusters"`
+}
+
+func getImportedClustersPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "imported_clusters.json")
+}
+
+// importedKubeconfigPath is where an imported cluster's kubeconfig is saved; ~/.k1 is left to kubefirst
+func importedKubeconfigPath(cluster cloudCluster) string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "imported", strings.ToLower(cluster.Provider), cluster.Name, "kubeconfig")
+}
+
+func loadImportedClusters() (importedClustersFile, error) {
+	var imported importedClustersFile
+	data, err := os.ReadFile(getImportedClustersPath())
+	if os.IsNotExist(err) {
+		return imported, nil
+	}
+	if err != nil {
+		return imported, fmt.Errorf("error reading imported_clusters.json: %w", err)
+	}
+	err = json.Unmarshal(data, &imported)
+	if err != nil {
+		return imported, fmt.Errorf("error parsing imported_clusters.json: %w", err)
+	}
+	return imported, nil
+}
+
+// updateImportedClusters applies change to the saved clusters and writes them back, holding the file's lock
+// throughout
+func updateImportedClusters(change func(imported *importedClustersFile)) error {
+	return withFileLock(getImportedClustersPath(), func() error {
+		imported, err := loadImportedClusters()
+		if err != nil {
+			return err
+		}
+		change(&imported)
+		data, err := json.MarshalIndent(imported, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding imported clusters: %w", err)
+		}
+		return writeFileAtomic(getImportedClustersPath(), data, 0644)
+	})
+}
+
+func findImportedCluster(imported importedClustersFile, provider, id string) int {
+	for i, cluster := range imported.Clusters {
+		if cluster.Provider == provider && cluster.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// civoRegionClient returns a Civo client for one region; Civo's API only lists the clusters of the client's region
+func civoRegionClient(region string) (*civogo.Client, error) {
+	token := lookupToken("CIVO_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("CIVO_TOKEN not found in environment or keychain. Please set it and try again")
+	}
+	return civogo.NewClient(token, region)
+}
+
+func civoCluster(cluster civogo.KubernetesCluster, region string) cloudCluster {
+	return cloudCluster{
+		Provider: "Civo",
+		ID:       cluster.ID,
+		Name:     cluster.Name,
+		Region:   region,
+		Size:     cluster.TargetNodeSize,
+		Nodes:    cluster.NumTargetNode,
+		Status:   cluster.Status,
+	}
+}
+
+func listCivoClusters(ctx context.Context) ([]cloudCluster, error) {
+	client, err := getCivoClient()
+	if err != nil {
+		return nil, err
+	}
+	var regions []civogo.Region
+	err = awaitContext(ctx, func() (err error) {
+		regions, err = client.ListRegions()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters []cloudCluster
+	for _, region := range regions {
+		regionClient, err := civoRegionClient(region.Code)
+		if err != nil {
+			return nil, err
+		}
+		var page *civogo.PaginatedKubernetesClusters
+		err = awaitContext(ctx, func() (err error) {
+			page, err = regionClient.ListKubernetesClusters()
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing clusters in %s: %w", region.Code, err)
+		}
+		for _, cluster := range page.Items {
+			clusters = append(clusters, civoCluster(cluster, region.Code))
+		}
+	}
+	return clusters, nil
+}
+
+func digitalOceanCluster(cluster *godo.KubernetesCluster) cloudCluster {
+	found := cloudCluster{
+		Provider: "DigitalOcean",
+		ID:       cluster.ID,
+		Name:     cluster.Name,
+		Region:   cluster.RegionSlug,
+	}
+	var sizes []string
+	for _, pool := range cluster.NodePools {
+		found.Nodes += pool.Count
+		if !contains(sizes, pool.Size) {
+			sizes = append(sizes, pool.Size)
+		}
+	}
+	found.Size = strings.Join(sizes, ", ")
+	if cluster.Status != nil {
+		found.Status = string(cluster.Status.State)
+	}
+	return found
+}
+
+func listDigitalOceanClusters(ctx context.Context) ([]cloudCluster, error) {
+	client, err := getDigitalOceanClient()
+	if err != nil {
+		return nil, err
+	}
+	doClusters, _, err := client.Kubernetes.List(ctx, &godo.ListOptions{Page: 1, PerPage: 200})
+	if err != nil {
+		return nil, err
+	}
+	clusters := make([]cloudCluster, 0, len(doClusters))
+	for _, cluster := range doClusters {
+		clusters = append(clusters, digitalOceanCluster(cluster))
+	}
+	return clusters, nil
+}
+
+// refreshCloudCluster reads an imported cluster's current size and status
+func refreshCloudCluster(ctx context.Context, cluster cloudCluster) (cloudCluster, error) {
+	var current cloudCluster
+	switch cluster.Provider {
+	case "Civo":
+		client, err := civoRegionClient(cluster.Region)
+		if err != nil {
+			return cluster, err
+		}
+		var civo *civogo.KubernetesCluster
+		err = awaitContext(ctx, func() (err error) {
+			civo, err = client.GetKubernetesCluster(cluster.ID)
+			return err
+		})
+		if err != nil {
+			return cluster, err
+		}
+		current = civoCluster(*civo, cluster.Region)
+	case "DigitalOcean":
+		client, err := getDigitalOceanClient()
+		if err != nil {
+			return cluster, err
+		}
+		do, _, err := client.Kubernetes.Get(ctx, cluster.ID)
+		if err != nil {
+			return cluster, err
+		}
+		current = digitalOceanCluster(do)
+	default:
+		return cluster, fmt.Errorf("cluster discovery is not supported for %s", cluster.Provider)
+	}
+	current.ImportedAt = cluster.ImportedAt
+	return current, nil
+}
+
+// fetchCloudKubeconfig downloads an imported cluster's kubeconfig from its provider
+func fetchCloudKubeconfig(ctx context.Context, cluster cloudCluster) ([]byte, error) {
+	switch cluster.Provider {
+	case "Civo":
+		client, err := civoRegionClient(cluster.Region)
+		if err != nil {
+			return nil, err
+		}
+		var civo *civogo.KubernetesCluster
+		err = awaitContext(ctx, func() (err error) {
+			civo, err = client.GetKubernetesCluster(cluster.ID)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if civo.KubeConfig == "" {
+			return nil, fmt.Errorf("Civo has no kubeconfig for %s yet; is it still being created?", cluster.Name)
+		}
+		return []byte(civo.KubeConfig), nil
+	case "DigitalOcean":
+		client, err := getDigitalOceanClient()
+		if err != nil {
+			return nil, err
+		}
+		config, _, err := client.Kubernetes.GetKubeConfig(ctx, cluster.ID)
+		if err != nil {
+			return nil, err
+		}
+		return config.KubeconfigYAML, nil
+	}
+	return nil, fmt.Errorf("cluster discovery is not supported for %s", cluster.Provider)
+}
+
+// k1spaceClusterNames returns the cluster names of all configs, which discovery leaves out
+func k1spaceClusterNames(indexFile IndexFile) []string {
+	var names []string
+	for _, config := range indexFile.Configs {
+		if name := findConfigFlag(config.Flags, "cluster-name"); name != "" {
+			names = append(names, strings.ToLower(name))
+		}
+	}
+	return names
+}
+
+// inventoryProvidersWithTokens returns the providers clusters can be listed on with the tokens that are set
+func inventoryProvidersWithTokens() []string {
+	var providers []string
+	for provider, tokenVar := range inventoryProviders {
+		if lookupToken(tokenVar) != "" {
+			providers = append(providers, provider)
+		}
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// listCloudClusters lists the clusters on each provider. Providers that fail are returned in providerErrors
+// and left out; err is only set when the listing is cancelled.
+func listCloudClusters(providers []string) (clusters []cloudCluster, providerErrors map[string]error, err error) {
+	providerErrors = make(map[string]error)
+	err = runCancellable("Listing Kubernetes clusters on "+strings.Join(providers, ", ")+"...", func(ctx context.Context) error {
+		for _, provider := range providers {
+			var found []cloudCluster
+			var err error
+			if provider == "Civo" {
+				found, err = listCivoClusters(ctx)
+			} else {
+				found, err = listDigitalOceanClusters(ctx)
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				providerErrors[provider] = err
+				continue
+			}
+			clusters = append(clusters, found...)
+		}
+		return nil
+	})
+	for provider, err := range providerErrors {
+		recordTokenError(provider, err)
+		err = describeNetworkError("Listing "+provider+" clusters", err)
+		log.Error("Error listing clusters", "provider", provider, "error", err)
+		fmt.Println(err)
+	}
+	return clusters, providerErrors, err
+}
+
+func discoverCloudClusters() {
+	log.Info("Starting discoverCloudClusters function")
+
+	providers := inventoryProvidersWithTokens()
+	if len(providers) == 0 {
+		fmt.Println("Cluster discovery needs CIVO_TOKEN or DO_TOKEN. Set one in the environment or with 'Manage Credentials'.")
+		return
+	}
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations:", err)
+		return
+	}
+	managed := k1spaceClusterNames(indexFile)
+
+	found, _, err := listCloudClusters(providers)
+	if err != nil {
+		fmt.Println(describeNetworkError("Listing clusters", err))
+		return
+	}
+
+	imported, err := loadImportedClusters()
+	if err != nil {
+		log.Error("Error loading imported clusters", "error", err)
+		fmt.Println(err)
+		return
+	}
+
+	var unmanaged []cloudCluster
+	for _, cluster := range found {
+		if !contains(managed, strings.ToLower(cluster.Name)) {
+			unmanaged = append(unmanaged, cluster)
+		}
+	}
+	if len(unmanaged) == 0 {
+		fmt.Println("No clusters found that weren't created by k1space.")
+		return
+	}
+	sort.Slice(unmanaged, func(i, j int) bool {
+		if unmanaged[i].Provider != unmanaged[j].Provider {
+			return unmanaged[i].Provider < unmanaged[j].Provider
+		}
+		return unmanaged[i].Name < unmanaged[j].Name
+	})
+
+	summary := [][]string{{"Provider", "Name", "Region", "Size", "Nodes", "Status", "Imported"}}
+	var options []huh.Option[int]
+	for i, cluster := range unmanaged {
+		isImported := "no"
+		if findImportedCluster(imported, cluster.Provider, cluster.ID) >= 0 {
+			isImported = "yes"
+		} else {
+			options = append(options, huh.NewOption(fmt.Sprintf("%s (%s, %s)", cluster.Name, cluster.Provider, cluster.Region), i))
+		}
+		summary = append(summary, []string{cluster.Provider, cluster.Name, cluster.Region, cluster.Size, fmt.Sprint(cluster.Nodes), cluster.Status, isImported})
+	}
+	printSummaryTable("Clusters Not Created by k1space", summary)
+	if isStructuredOutput() || len(options) == 0 {
+		return
+	}
+
+	var selected []int
+	err = runField(newMultiSelect("Select clusters to import", &selected, options...).
+		Description("Imported clusters can be checked and their kubeconfigs downloaded from 'Imported Clusters'"))
+	if err != nil {
+		log.Error("Error in cluster import selection", "error", err)
+		return
+	}
+	if len(selected) == 0 {
+		return
+	}
+
+	err = updateImportedClusters(func(imported *importedClustersFile) {
+		for _, i := range selected {
+			cluster := unmanaged[i]
+			if findImportedCluster(*imported, cluster.Provider, cluster.ID) >= 0 {
+				continue
+			}
+			cluster.ImportedAt = time.Now().UTC()
+			imported.Clusters = append(imported.Clusters, cluster)
+		}
+	})
+	if err != nil {
+		log.Error("Error saving imported clusters", "error", err)
+		fmt.Println("Failed to save imported clusters:", err)
+		return
+	}
+	fmt.Printf("Imported %d clusters.\n", len(selected))
+}
+
+func manageImportedClusters() {
+	log.Info("Starting manageImportedClusters function")
+
+	imported, err := loadImportedClusters()
+	if err != nil {
+		log.Error("Error loading imported clusters", "error", err)
+		fmt.Println(err)
+		return
+	}
+	if len(imported.Clusters) == 0 {
+		fmt.Println("No imported clusters. Use 'Discover Cloud Clusters' to find and import them.")
+		return
+	}
+
+	options := make([]huh.Option[int], len(imported.Clusters))
+	for i, cluster := range imported.Clusters {
+		options[i] = huh.NewOption(fmt.Sprintf("%s (%s, %s)", cluster.Name, cluster.Provider, cluster.Region), i)
+	}
+	var index int
+	var action string
+	err = runForm(newForm(
+		newGroup(
+			newSelect("Select an imported cluster", &index, options...),
+			newSelect("Action", &action,
+				huh.NewOption("Show status", "Show Status"),
+				huh.NewOption("Download kubeconfig", "Download Kubeconfig"),
+				huh.NewOption("Forget cluster", "Forget"),
+			),
+		),
+	))
+	if err != nil {
+		log.Error("Error in imported cluster selection", "error", err)
+		return
+	}
+	cluster := imported.Clusters[index]
+
+	switch action {
+	case "Show Status":
+		var current cloudCluster
+		err = runCancellable(fmt.Sprintf("Reading %s from %s...", cluster.Name, cluster.Provider), func(ctx context.Context) (err error) {
+			current, err = refreshCloudCluster(ctx, cluster)
+			return err
+		})
+		if err != nil {
+			err = describeNetworkError("Reading cluster status", err)
+			log.Error("Error reading cluster status", "cluster", cluster.Name, "error", err)
+			fmt.Println(err)
+			return
+		}
+		err = updateImportedClusters(func(imported *importedClustersFile) {
+			if i := findImportedCluster(*imported, cluster.Provider, cluster.ID); i >= 0 {
+				imported.Clusters[i] = current
+			}
+		})
+		if err != nil {
+			log.Warn("Could not save cluster status", "error", err)
+		}
+		printSummaryTable(fmt.Sprintf("%s (%s)", current.Name, current.Provider), [][]string{
+			{"Region", "Size", "Nodes", "Status", "Imported"},
+			{current.Region, current.Size, fmt.Sprint(current.Nodes), current.Status, current.ImportedAt.Local().Format("2006-01-02 15:04")},
+		})
+	case "Download Kubeconfig":
+		var kubeconfig []byte
+		err = runCancellable(fmt.Sprintf("Downloading the kubeconfig of %s...", cluster.Name), func(ctx context.Context) (err error) {
+			kubeconfig, err = fetchCloudKubeconfig(ctx, cluster)
+			return err
+		})
+		if err != nil {
+			err = describeNetworkError("Downloading the kubeconfig", err)
+			log.Error("Error downloading kubeconfig", "cluster", cluster.Name, "error", err)
+			fmt.Println(err)
+			return
+		}
+		path := importedKubeconfigPath(cluster)
+		err = os.MkdirAll(filepath.Dir(path), 0700)
+		if err == nil {
+			err = os.WriteFile(path, kubeconfig, 0600)
+		}
+		if err != nil {
+			log.Error("Error saving kubeconfig", "path", path, "error", err)
+			fmt.Println("Failed to save the kubeconfig:", err)
+			return
+		}
+		fmt.Printf("Saved the kubeconfig to %s\n", path)
+		fmt.Printf("Use it with: export KUBECONFIG=%s\n", path)
+	case "Forget":
+		err = updateImportedClusters(func(imported *importedClustersFile) {
+			if i := findImportedCluster(*imported, cluster.Provider, cluster.ID); i >= 0 {
+				imported.Clusters = append(imported.Clusters[:i], imported.Clusters[i+1:]...)
+			}
+		})
+		if err != nil {
+			log.Error("Error saving imported clusters", "error", err)
+			fmt.Println("Failed to save imported clusters:", err)
+			return
+		}
+		fmt.Printf("Forgot %s. The cluster itself is untouched.\n", cluster.Name)
+	}
+}
+
+// liveClusterRow is one line of the live inventory: a cloud cluster, a config, or both when they match
+type liveClusterRow struct {
+	Provider string
+	Cluster  string
+	Region   string
+	Status   string
+	Config   string
+	State    string
+	Finding  string
+}
+
+// crossReferenceClusters pairs cloud clusters with the configs of providers that were listed, by provider and
+// cluster-name, flagging clusters without a config and configs without a cluster
+func crossReferenceClusters(clusters []cloudCluster, indexFile IndexFile, imported importedClustersFile, listed []string) []liveClusterRow {
+	var rows []liveClusterRow
+	matched := make(map[string]bool)
+	for configName, config := range indexFile.Configs {
+		provider := cloudProviderName(strings.Split(configName, "_")[0])
+		if !contains(listed, provider) {
+			continue
+		}
+		clusterName := findConfigFlag(config.Flags, "cluster-name")
+		row := liveClusterRow{Provider: provider, Cluster: clusterName, Config: configName, State: config.State.describe()}
+		for _, cluster := range clusters {
+			if cluster.Provider == provider && strings.EqualFold(cluster.Name, clusterName) {
+				row.Region, row.Status, row.Finding = cluster.Region, cluster.Status, "OK"
+				matched[cluster.Provider+"/"+cluster.ID] = true
+				break
+			}
+		}
+		if row.Finding == "" {
+			row.Finding = "No cluster"
+			if config.State != nil && (config.State.Current == stateProvisioned || config.State.Current == stateProvisioning) {
+				row.Finding = fmt.Sprintf("No cluster, but the config is %s", config.State.Current)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	for _, cluster := range clusters {
+		if matched[cluster.Provider+"/"+cluster.ID] {
+			continue
+		}
+		finding := "No config"
+		if findImportedCluster(imported, cluster.Provider, cluster.ID) >= 0 {
+			finding = "No config (imported)"
+		}
+		rows = append(rows, liveClusterRow{Provider: cluster.Provider, Cluster: cluster.Name, Region: cluster.Region, Status: cluster.Status, Finding: finding})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if (rows[i].Finding == "OK") != (rows[j].Finding == "OK") {
+			return rows[j].Finding == "OK"
+		}
+		if rows[i].Provider != rows[j].Provider {
+			return rows[i].Provider < rows[j].Provider
+		}
+		return rows[i].Cluster < rows[j].Cluster
+	})
+	return rows
+}
+
+func listLiveClusters() {
+	log.Info("Starting listLiveClusters function")
+
+	providers := inventoryProvidersWithTokens()
+	if len(providers) == 0 {
+		fmt.Println("Listing live clusters needs CIVO_TOKEN or DO_TOKEN. Set one in the environment or with 'Manage Credentials'.")
+		return
+	}
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations:", err)
+		return
+	}
+	imported, err := loadImportedClusters()
+	if err != nil {
+		log.Warn("Error loading imported clusters", "error", err)
+	}
+
+	clusters, providerErrors, err := listCloudClusters(providers)
+	if err != nil {
+		fmt.Println(describeNetworkError("Listing clusters", err))
+		return
+	}
+	// Configs of a provider that couldn't be listed would all look like they have no cluster
+	var listed []string
+	for _, provider := range providers {
+		if providerErrors[provider] == nil {
+			listed = append(listed, provider)
+		}
+	}
+	if len(listed) == 0 {
+		return
+	}
+
+	rows := crossReferenceClusters(clusters, indexFile, imported, listed)
+	if len(rows) == 0 {
+		fmt.Printf("No clusters or configs found on %s.\n", strings.Join(listed, ", "))
+		return
+	}
+	summary := [][]string{{"Provider", "Cluster", "Region", "Status", "Config", "Config State", "Finding"}}
+	mismatched := 0
+	for _, row := range rows {
+		if row.Finding != "OK" {
+			mismatched++
+		}
+		summary = append(summary, []string{row.Provider, row.Cluster, row.Region, row.Status, row.Config, row.State, row.Finding})
+	}
+	printSummaryTable("Live Clusters on "+strings.Join(listed, ", "), summary)
+	if isStructuredOutput() {
+		return
+	}
+	if mismatched > 0 {
+		fmt.Printf("\n%d clusters or configs don't have a counterpart. Use 'Discover Cloud Clusters' to import clusters without a config.\n", mismatched)
+	} else {
+		fmt.Println("\nEvery cluster has a config and every config has a cluster.")
+	}
+}
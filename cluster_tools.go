@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+	"gopkg.in/yaml.v2"
+)
+
+// clusterTool is a Kubernetes UI k1space can open on a provisioned cluster
+type clusterTool struct {
+	Name string
+	// find returns the command to run, or "" when the tool isn't installed
+	find    func() string
+	install map[string]string
+	// Interactive tools take over the terminal until they exit; the others are started in the background
+	interactive bool
+	args        func(kubeconfig, context string) []string
+}
+
+var clusterTools = []clusterTool{
+	{
+		Name: "k9s",
+		find: func() string {
+			path, _ := exec.LookPath("k9s")
+			return path
+		},
+		install: map[string]string{
+			"darwin":  "brew install derailed/k9s/k9s",
+			"windows": "winget install -e --id Derailed.k9s",
+			"linux":   "see https://k9scli.io/topics/install/ (e.g. brew install derailed/k9s/k9s or your distribution's package)",
+		},
+		interactive: true,
+		args: func(kubeconfig, context string) []string {
+			args := []string{"--kubeconfig", kubeconfig}
+			if context != "" {
+				args = append(args, "--context", context)
+			}
+			return args
+		},
+	},
+	{
+		Name: "OpenLens",
+		find: findOpenLens,
+		install: map[string]string{
+			"darwin":  "brew install --cask openlens",
+			"windows": "download the installer from https://github.com/MuhammedKalkan/OpenLens/releases",
+			"linux":   "download the AppImage, .deb or .rpm from https://github.com/MuhammedKalkan/OpenLens/releases",
+		},
+		// OpenLens has no flag for a kubeconfig, but picks up KUBECONFIG from its environment
+		args: func(kubeconfig, context string) []string { return nil },
+	},
+}
+
+func findOpenLens() string {
+	for _, name := range []string{"open-lens", "openlens", "OpenLens"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	var candidates []string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = []string{
+			"/Applications/OpenLens.app/Contents/MacOS/OpenLens",
+			filepath.Join(os.Getenv("HOME"), "Applications", "OpenLens.app", "Contents", "MacOS", "OpenLens"),
+		}
+	case "windows":
+		candidates = []string{filepath.Join(os.Getenv("LOCALAPPDATA"), "Programs", "OpenLens", "OpenLens.exe")}
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// clusterKubeconfigPath is where kubefirst writes a cluster's kubeconfig
+func clusterKubeconfigPath(clusterName string) string {
+	return filepath.Join(os.Getenv("HOME"), ".k1", clusterName, "kubeconfig")
+}
+
+// kubeconfigCurrentContext reads current-context from a kubeconfig file
+func kubeconfigCurrentContext(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var kubeconfig struct {
+		CurrentContext string `yaml:"current-context"`
+	}
+	err = yaml.Unmarshal(data, &kubeconfig)
+	if err != nil {
+		return "", fmt.Errorf("error parsing kubeconfig %s: %w", path, err)
+	}
+	return kubeconfig.CurrentContext, nil
+}
+
+// selectClusterKubeconfig prompts for a config and returns its cluster's name, kubeconfig path and context.
+// ok is false when there is nothing to connect to, after telling the user why.
+func selectClusterKubeconfig(title string) (clusterName, kubeconfig, context string, ok bool) {
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations:", err)
+		return
+	}
+	selectedConfig, err := promptConfigSelection(indexFile, title)
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations available. Please create a configuration first.")
+		return
+	}
+
+	clusterName = findConfigFlag(indexFile.Configs[selectedConfig].Flags, "cluster-name")
+	if clusterName == "" {
+		fmt.Printf("%s has no cluster name set, so its kubeconfig can't be found.\n", selectedConfig)
+		return
+	}
+	kubeconfig = clusterKubeconfigPath(clusterName)
+	context, err = kubeconfigCurrentContext(kubeconfig)
+	if os.IsNotExist(err) {
+		fmt.Printf("No kubeconfig found at %s. Has %s been provisioned?\n", kubeconfig, clusterName)
+		return
+	}
+	if err != nil {
+		log.Error("Error reading kubeconfig", "path", kubeconfig, "error", err)
+		fmt.Println(err)
+		return
+	}
+	return clusterName, kubeconfig, context, true
+}
+
+func openClusterTool() {
+	log.Info("Starting openClusterTool function")
+
+	clusterName, kubeconfig, context, ok := selectClusterKubeconfig("Select the cluster to open")
+	if !ok {
+		return
+	}
+
+	options := make([]huh.Option[int], 0, len(clusterTools))
+	for i, tool := range clusterTools {
+		label := tool.Name
+		if tool.find() == "" {
+			label += " (not installed)"
+		}
+		options = append(options, huh.NewOption(label, i))
+	}
+	var selected int
+	err := runField(newSelect(fmt.Sprintf("Open %s in", clusterName), &selected, options...))
+	if err != nil {
+		log.Error("Error in tool selection", "error", err)
+		return
+	}
+	tool := clusterTools[selected]
+
+	path := tool.find()
+	if path == "" {
+		fmt.Printf("%s is not installed. To install it, %s\n", tool.Name, tool.install[runtime.GOOS])
+		fmt.Printf("Then open %s with its kubeconfig: %s (context %s)\n", clusterName, kubeconfig, context)
+		return
+	}
+
+	cmd := exec.Command(path, tool.args(kubeconfig, context)...)
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeconfig)
+	if tool.interactive {
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		err = cmd.Run()
+	} else {
+		err = cmd.Start()
+	}
+	if err != nil {
+		log.Error("Error launching cluster tool", "tool", tool.Name, "error", err)
+		fmt.Printf("Failed to start %s: %v\n", tool.Name, err)
+		return
+	}
+	if !tool.interactive {
+		fmt.Printf("Started %s with KUBECONFIG=%s. If the cluster doesn't show up, add that file under Preferences -> Kubernetes -> Kubeconfig Syncs.\n", tool.Name, kubeconfig)
+	}
+}
@@ -2,12 +2,16 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/huh"
@@ -17,10 +21,6 @@ import (
 func provisionCluster() {
 	log.Info("Starting provisionCluster function")
 
-	// Check if the K1_CONSOLE_REMOTE_URL environment variable is set; re: kubefirst.dev issue
-	value := os.Getenv("K1_CONSOLE_REMOTE_URL")
-	log.Info("K1_CONSOLE_REMOTE_URL:", value)
-
 	indexFile, err := loadIndexFile()
 	if err != nil {
 		log.Error("Error loading index file", "error", err)
@@ -35,7 +35,7 @@ func provisionCluster() {
 	configOptions := make([]huh.Option[string], 0, len(indexFile.Configs))
 	for config, details := range indexFile.Configs {
 		log.Info("Config found", "name", config, "fileCount", len(details.Files))
-		configOptions = append(configOptions, huh.NewOption(config, config))
+		configOptions = append(configOptions, huh.NewOption(configOptionLabel(config, details), config))
 	}
 
 	if len(configOptions) == 0 {
@@ -46,16 +46,13 @@ func provisionCluster() {
 	}
 
 	log.Info("Presenting config selection to user", "optionCount", len(configOptions))
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Title("Select a configuration").
-				Options(configOptions...).
-				Value(&selectedConfig),
+	form := newForm(
+		newGroup(
+			newSelect("Select a configuration", &selectedConfig, configOptions...),
 		),
 	)
 
-	err = form.Run()
+	err = runForm(form)
 	if err != nil {
 		log.Error("Error in config selection", "error", err)
 		return
@@ -90,15 +87,13 @@ func provisionCluster() {
 
 	// Confirmation to provision
 	var confirmProvision bool
-	confirmForm := huh.NewForm(
-		huh.NewGroup(
-			huh.NewConfirm().
-				Title("Do you want to proceed with provisioning the cluster?").
-				Value(&confirmProvision),
+	confirmForm := newForm(
+		newGroup(
+			newConfirm("Do you want to proceed with provisioning the cluster?", &confirmProvision),
 		),
 	)
 
-	err = confirmForm.Run()
+	err = runForm(confirmForm)
 	if err != nil {
 		log.Error("Error in confirmation prompt", "error", err)
 		return
@@ -132,13 +127,154 @@ func provisionCluster() {
 		}
 		cloud, region, prefix := parts[0], parts[1], parts[2]
 
-		// Run the provisioning script
-		err := runProvisioningScript(initScriptPath, cloud, region, prefix)
+		// The trace runs from the pre-flight checks to the health checks; err is how the script ended
+		ctx, provisionSpan := startSpan(context.Background(), "provision cluster", "config", selectedConfig, "cloud", cloud, "region", region)
+		defer func() { provisionSpan.end(err) }()
+
+		_, policySpan := startSpan(ctx, "check policies")
+		allowed := enforceConfigPolicies(selectedConfig, indexFile.Configs[selectedConfig])
+		policySpan.setAttribute("allowed", strconv.FormatBool(allowed))
+		policySpan.end(nil)
+		if !allowed {
+			fmt.Println("Cluster provisioning cancelled.")
+			return
+		}
+
+		_, secretsSpan := startSpan(ctx, "resolve secrets")
+		secretEnv, ok := prepareProvisionSecrets(filepath.Join(filepath.Dir(initScriptPath), ".local.cloud.env"))
+		secretsSpan.end(nil)
+		if !ok {
+			fmt.Println("Cluster provisioning cancelled.")
+			return
+		}
+
+		if !confirmDomainNotInUse(indexFile.Configs[selectedConfig]) {
+			fmt.Println("Cluster provisioning cancelled.")
+			return
+		}
+
+		warnDeprecatedConfigFlags(selectedConfig, indexFile.Configs[selectedConfig])
+
+		_, permissionsSpan := startSpan(ctx, "check token permissions")
+		ok = confirmTokenPermissions(cloud, indexFile.Configs[selectedConfig])
+		permissionsSpan.end(nil)
+		if !ok {
+			fmt.Println("Cluster provisioning cancelled.")
+			return
+		}
+
+		if !confirmCertPrerequisites(cloud, indexFile.Configs[selectedConfig]) {
+			fmt.Println("Cluster provisioning cancelled.")
+			return
+		}
+
+		// kubefirst.dev resolves to 127.0.0.1 publicly, but resolvers that block loopback answers break the k3d flow
+		if cloud == "k3d" {
+			domain := localDNSDomain(selectedConfig, indexFile.Configs[selectedConfig])
+			err = ensureLocalDNS(domain)
+			if err != nil {
+				log.Error("Error setting up local DNS", "domain", domain, "error", err)
+				fmt.Println("Warning: failed to set up local DNS:", err)
+			}
+			if os.Getenv("K1_CONSOLE_REMOTE_URL") == "" {
+				os.Setenv("K1_CONSOLE_REMOTE_URL", "https://kubefirst."+domain)
+			}
+			log.Info("Using console URL", "K1_CONSOLE_REMOTE_URL", os.Getenv("K1_CONSOLE_REMOTE_URL"))
+		}
+
+		// Export tokens kept in the keychain so kubefirst, started by 00-init.sh, inherits them
+		for _, tokenVar := range configTokenVars(cloud, indexFile.Configs[selectedConfig]) {
+			lookupToken(tokenVar)
+		}
+
+		// Wait our turn so parallel runs against the same provider don't trip its rate limits
+		_, queueSpan := startSpan(ctx, "wait for provisioning slot")
+		var entry *provisionQueueEntry
+		entry, err = enqueueProvision(selectedConfig, cloud)
+		if err != nil {
+			log.Error("Error joining provisioning queue", "error", err)
+			fmt.Println("Error joining provisioning queue:", err)
+			return
+		}
+		defer entry.release()
+		err = waitForProvisionSlot(entry)
+		queueSpan.end(err)
 		if err != nil {
+			log.Error("Error waiting for provisioning slot", "error", err)
+			fmt.Println("Error waiting for provisioning slot:", err)
+			return
+		}
+
+		// Run the provisioning script, retrying known transient failures
+		startedAt := time.Now()
+		startMetricsServer()
+		metrics.provisionStarted(selectedConfig)
+		recordConfigState(selectedConfig, stateProvisioning)
+		notifyProvisioningStarted(selectedConfig, indexFile.Configs[selectedConfig])
+		emitWebhookEvent(newWebhookEvent(eventProvisionStarted, selectedConfig, findConfigFlag(indexFile.Configs[selectedConfig].Flags, "cluster-name")))
+		_, scriptSpan := startSpan(ctx, "run provisioning script", "script", initScriptPath)
+		err = runProvisioningWithRetry(initScriptPath, cloud, region, prefix, secretEnv)
+		scriptSpan.setAttribute("status", provisioningStatus(err))
+		scriptSpan.end(err)
+		metrics.provisionFinished(selectedConfig, provisioningStatus(err), time.Since(startedAt))
+		logDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".logs", cloud, region, prefix)
+		notifyProvisioningFinished(selectedConfig, indexFile.Configs[selectedConfig], logDir, startedAt, err)
+		emitProvisionFinished(selectedConfig, indexFile.Configs[selectedConfig], logDir, startedAt, err)
+		var failure *provisioningFailure
+		switch {
+		case errors.Is(err, errProvisioningCancelled):
+			log.Warn("Cluster provisioning cancelled", "config", selectedConfig)
+			recordConfigState(selectedConfig, stateCancelled)
+		case errors.As(err, &failure) && failure.TimedOut != "":
+			log.Error("Cluster provisioning timed out", "config", selectedConfig, "reason", failure.TimedOut)
+			recordConfigState(selectedConfig, stateTimedOut)
+		case err != nil:
 			log.Error("Error provisioning cluster", "error", err)
+			recordConfigState(selectedConfig, stateFailed)
+		default:
+			recordConfigState(selectedConfig, stateProvisioned)
+		}
+		// Even a failed run may have created the cluster, and its ID is what finds anything it leaves behind
+		recordClusterID(selectedConfig, indexFile.Configs[selectedConfig])
+		var health []healthCheck
+		var resources *resourceSnapshot
+		var packs []packResult
+		if err == nil {
+			_, kubeconfigSpan := startSpan(ctx, "fetch kubeconfig")
+			saveConfigKubeconfig(selectedConfig, indexFile.Configs[selectedConfig])
+			kubeconfigSpan.end(nil)
+			_, packsSpan := startSpan(ctx, "apply bootstrap packs")
+			packs = applyBootstrapPacks(selectedConfig, indexFile.Configs[selectedConfig])
+			packsSpan.end(nil)
+			_, healthSpan := startSpan(ctx, "verify cluster health")
+			health = verifyClusterHealth(selectedConfig, indexFile.Configs[selectedConfig])
+			healthSpan.end(nil)
+			resources = recordResourceSnapshot(selectedConfig, indexFile.Configs[selectedConfig])
+		}
+		if isStructuredOutput() {
+			result := newProvisioningResult(selectedConfig, startedAt, err)
+			result.Health = health
+			result.Resources = resources
+			result.BootstrapPacks = packs
+			printStructured(result)
+		} else if errors.Is(err, errProvisioningCancelled) {
+			fmt.Println("Cluster provisioning cancelled:", err)
+			offerCancelledProvisionCleanup(selectedConfig, indexFile)
+		} else if err != nil {
 			fmt.Println("Error provisioning cluster:", err)
 		} else {
-			fmt.Println("Cluster provisioning completed successfully!")
+			if len(packs) > 0 {
+				printPackResults(packs)
+			}
+			healthy := printHealthChecklist(health)
+			if resources != nil {
+				printResourceSnapshot(resources)
+			}
+			if healthy {
+				fmt.Println(style.Render("\nCluster provisioned and healthy."))
+			} else {
+				fmt.Println("The provisioning script completed, but the cluster isn't healthy yet.")
+			}
 		}
 	} else {
 		log.Info("User cancelled cluster provisioning")
@@ -146,7 +282,51 @@ func provisionCluster() {
 	}
 }
 
-func runProvisioningScript(scriptPath, cloud, region, prefix string) error {
+// provisioningResult is the machine-readable outcome of a provisioning run
+type provisioningResult struct {
+	Config             string    `json:"config" yaml:"config"`
+	Status             string    `json:"status" yaml:"status"`
+	StartedAt          time.Time `json:"started_at" yaml:"started_at"`
+	FinishedAt         time.Time `json:"finished_at" yaml:"finished_at"`
+	ExitCode           int       `json:"exit_code" yaml:"exit_code"`
+	Error              string    `json:"error,omitempty" yaml:"error,omitempty"`
+	ScriptLog          string    `json:"script_log,omitempty" yaml:"script_log,omitempty"`
+	LatestKubefirstLog string    `json:"latest_kubefirst_log,omitempty" yaml:"latest_kubefirst_log,omitempty"`
+	// Health is the post-provision checklist, only run when the script succeeds
+	Health []healthCheck `json:"health,omitempty" yaml:"health,omitempty"`
+	// Resources is the node usage snapshot taken after the health checks
+	Resources *resourceSnapshot `json:"resources,omitempty" yaml:"resources,omitempty"`
+	// BootstrapPacks are the packs applied before the health checks
+	BootstrapPacks []packResult `json:"bootstrap_packs,omitempty" yaml:"bootstrap_packs,omitempty"`
+}
+
+func newProvisioningResult(configName string, startedAt time.Time, err error) provisioningResult {
+	result := provisioningResult{
+		Config:     configName,
+		Status:     "succeeded",
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+	}
+	if err == nil {
+		return result
+	}
+
+	result.Status = "failed"
+	result.ExitCode = -1
+	result.Error = err.Error()
+	var failure *provisioningFailure
+	if errors.As(err, &failure) {
+		if failure.TimedOut != "" {
+			result.Status = stateTimedOut
+		}
+		result.ExitCode = failure.ExitCode
+		result.ScriptLog = failure.ScriptLog
+		result.LatestKubefirstLog = failure.LatestKubefirstLog
+	}
+	return result
+}
+
+func runProvisioningScript(scriptPath, cloud, region, prefix string, secretEnv []string) error {
 	// Create log directory
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -157,6 +337,7 @@ func runProvisioningScript(scriptPath, cloud, region, prefix string) error {
 	if err != nil {
 		return fmt.Errorf("error creating log directory: %w", err)
 	}
+	applyLogRetention()
 
 	// Create log file
 	timestamp := time.Now().Format("20060102-150405")
@@ -171,6 +352,10 @@ func runProvisioningScript(scriptPath, cloud, region, prefix string) error {
 	// Prepare command
 	cmd := exec.Command("bash", scriptPath)
 	cmd.Dir = filepath.Dir(scriptPath)
+	cmd.Env = append(append(os.Environ(), airgapEnv()...), secretEnv...)
+	// Its own process group lets a cancel stop kubefirst and terraform along with the script
+	detachProcessGroup(cmd)
+	detach := detachOnHangupEnabled()
 
 	// Set up pipes for stdout and stderr
 	stdout, err := cmd.StdoutPipe()
@@ -182,22 +367,42 @@ func runProvisioningScript(scriptPath, cloud, region, prefix string) error {
 		return fmt.Errorf("error creating stderr pipe: %w", err)
 	}
 
+	startedAt := time.Now()
+
 	// Start the command
 	err = cmd.Start()
 	if err != nil {
 		return fmt.Errorf("error starting script: %w", err)
 	}
+	configName := fmt.Sprintf("%s_%s_%s", cloud, region, prefix)
+
+	cancel := watchCancel(cmd.Process.Pid, detach)
+	defer cancel.stop()
+
+	// Keep going if the SSH session drops, so the run can be reattached from a new one
+	if detach {
+		stopWatching := watchHangup(detachedRun{Config: configName, ScriptLog: logFilePath, StartedAt: startedAt.UTC()})
+		defer stopWatching()
+	}
 
 	// Create a channel to signal when we're done reading output
 	done := make(chan bool)
 
-	// Function to read from a pipe and write to both console and log file
+	// Mask tokens echoed by kubefirst or terraform before they reach the console or log file
+	redactor := newRedactor()
+	scriptLogs := newScrollingLog("provisioning-script")
+	kubefirstLogs := newScrollingLog("kubefirst-internal")
+	// Pick terraform, argocd and helm progress out of both streams for the dashboard widgets
+	parsers := newLogParsers()
+
+	// Function to read from a pipe and write to both the dashboard and log file
 	readAndLog := func(pipe io.Reader, prefix string) {
 		scanner := bufio.NewScanner(pipe)
 		for scanner.Scan() {
-			line := scanner.Text()
-			fmt.Println(prefix, line)
+			line := redactor.redact(scanner.Text())
 			logFile.WriteString(prefix + line + "\n")
+			scriptLogs.add(prefix + line)
+			parsers.parse(line)
 		}
 		done <- true
 	}
@@ -206,14 +411,61 @@ func runProvisioningScript(scriptPath, cloud, region, prefix string) error {
 	go readAndLog(stdout, "")
 	go readAndLog(stderr, "ERROR: ")
 
+	// Follow kubefirst's own logs so terraform and argocd progress is visible too
+	stop := make(chan struct{})
+	tailer := newKubefirstLogTailer(startedAt, kubefirstLogs, redactor, parsers)
+	stopWatchdog := watchProvisioning(cancel, startedAt, scriptLogs, kubefirstLogs)
+	defer stopWatchdog()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		tailer.run(stop)
+	}()
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+		for {
+			// There's no terminal left to draw on after a hangup
+			if !hungUp.Load() {
+				display := renderProvisioningDashboard(configName, logFilePath, tailer.currentFile(), startedAt, cancel.status(), scriptLogs, kubefirstLogs, parsers)
+				fmt.Print("\033[2J") // Clear the screen
+				fmt.Print("\033[H")  // Move cursor to top-left corner
+				fmt.Print(display)
+			}
+			select {
+			case <-stop:
+				fmt.Println()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
 	// Wait for both stdout and stderr to be fully read
 	<-done
 	<-done
 
 	// Wait for the command to finish
 	err = cmd.Wait()
+	close(stop)
+	wg.Wait()
+	if hungUp.Load() {
+		finishDetachedRun(logFilePath, err)
+	}
+
+	if err != nil && cancel.cancelled() {
+		return fmt.Errorf("%w after %s, the output so far is in %s", errProvisioningCancelled, time.Since(startedAt).Round(time.Second), logFilePath)
+	}
 	if err != nil {
-		return fmt.Errorf("error running script: %w", err)
+		failure := newProvisioningFailure(err, scriptLogs, kubefirstLogs, logFilePath)
+		failure.TimedOut = cancel.timeoutReason()
+		if _, recordErr := recordProvisioningFailure(failure, logDir, timestamp); recordErr != nil {
+			log.Error("Error recording provisioning failure", "error", recordErr)
+		}
+		fmt.Println(renderProvisioningFailure(failure))
+		return failure
 	}
 
 	return nil
@@ -236,25 +488,26 @@ func deprovisionCluster() {
 
 	var selectedConfig string
 	configOptions := make([]huh.Option[string], 0, len(indexFile.Configs))
-	for config := range indexFile.Configs {
-		configOptions = append(configOptions, huh.NewOption(config, config))
+	for config, details := range indexFile.Configs {
+		configOptions = append(configOptions, huh.NewOption(configOptionLabel(config, details), config))
 	}
 
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Title("Select a cluster to deprovision").
-				Options(configOptions...).
-				Value(&selectedConfig),
+	form := newForm(
+		newGroup(
+			newSelect("Select a cluster to deprovision", &selectedConfig, configOptions...),
 		),
 	)
 
-	err = form.Run()
+	err = runForm(form)
 	if err != nil {
 		log.Error("Error in config selection", "error", err)
 		return
 	}
+	deprovisionConfig(selectedConfig, indexFile)
+}
 
+// deprovisionConfig generates (or reuses) a config's deprovision script and offers to run it
+func deprovisionConfig(selectedConfig string, indexFile IndexFile) {
 	parts := strings.Split(selectedConfig, "_")
 	if len(parts) != 3 {
 		log.Error("Invalid config name format", "config", selectedConfig)
@@ -267,15 +520,13 @@ func deprovisionCluster() {
 
 	regenerate := false
 	if _, err := os.Stat(scriptPath); err == nil {
-		regenerateForm := huh.NewForm(
-			huh.NewGroup(
-				huh.NewConfirm().
-					Title("A deprovision script already exists. Do you want to regenerate it?").
-					Value(&regenerate),
+		regenerateForm := newForm(
+			newGroup(
+				newConfirm("A deprovision script already exists. Do you want to regenerate it?", &regenerate),
 			),
 		)
 
-		err = regenerateForm.Run()
+		err = runForm(regenerateForm)
 		if err != nil {
 			log.Error("Error in regenerate confirmation", "error", err)
 			return
@@ -307,30 +558,41 @@ func deprovisionCluster() {
 	fmt.Println("Please review the script before running it to deprovision the cluster.")
 
 	var runScript bool
-	confirmForm := huh.NewForm(
-		huh.NewGroup(
-			huh.NewConfirm().
-				Title("Do you want to run the deprovisioning script now?").
-				Value(&runScript),
+	confirmForm := newForm(
+		newGroup(
+			newConfirm("Do you want to run the deprovisioning script now?", &runScript),
 		),
 	)
 
-	err = confirmForm.Run()
+	err := runForm(confirmForm)
 	if err != nil {
 		log.Error("Error in run script confirmation", "error", err)
 		return
 	}
 
 	if runScript {
+		// The cluster is looked up while it still exists, for the orphaned resource scan afterwards
+		config := recordClusterID(selectedConfig, indexFile.Configs[selectedConfig])
 		cmd := exec.Command("bash", scriptPath)
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
+		startedAt := time.Now()
 		err = cmd.Run()
+		emitDeprovisionFinished(selectedConfig, indexFile.Configs[selectedConfig], startedAt, err)
 		if err != nil {
 			log.Error("Error running deprovision script", "error", err)
+			recordConfigState(selectedConfig, stateFailed)
 			fmt.Println("Deprovisioning script encountered an error. Please check the output and try running it manually if necessary.")
 		} else {
+			recordConfigState(selectedConfig, stateDeprovisioned)
 			fmt.Println("Deprovisioning script completed successfully.")
+			if cloud == "k3d" {
+				err = removeLocalDNS()
+				if err != nil {
+					log.Warn("Error removing local DNS entries", "error", err)
+				}
+			}
+			scanForOrphanedResources(selectedConfig, config)
 		}
 	} else {
 		fmt.Println("Deprovisioning script not run. You can run it manually later.")
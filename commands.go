@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// runCommandLine handles non-interactive subcommands, returning the process exit code
+func runCommandLine(args []string) int {
+	switch args[0] {
+	case "list-configs":
+		fs := flag.NewFlagSet("list-configs", flag.ContinueOnError)
+		format := fs.String("output", outputJSON, "output format (json or yaml)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if *format != outputJSON && *format != outputYAML {
+			fmt.Fprintf(os.Stderr, "unsupported output format %q, expected json or yaml\n", *format)
+			return 2
+		}
+
+		indexFile, err := loadIndexFile()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error loading index file:", err)
+			return 1
+		}
+		if err := writeStructured(os.Stdout, *format, configSummaries(indexFile)); err != nil {
+			fmt.Fprintln(os.Stderr, "error writing output:", err)
+			return 1
+		}
+		return 0
+	case "daemon":
+		fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+		once := fs.Bool("once", false, "check the tracked clusters once and exit, with status 1 if any alert is firing")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		return runAlertDaemon(*once)
+	case "doctor":
+		fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		return runDoctorCommand()
+	case "catalog":
+		if len(args) < 2 || args[1] != "export" {
+			fmt.Fprintln(os.Stderr, "Usage: k1space catalog export [--format json|yaml] [--provider Civo,DigitalOcean]")
+			return 2
+		}
+		fs := flag.NewFlagSet("catalog export", flag.ContinueOnError)
+		format := fs.String("format", outputJSON, "output format (json or yaml)")
+		providers := fs.String("provider", "", "comma-separated providers to export, all cached providers by default")
+		if err := fs.Parse(args[2:]); err != nil {
+			return 2
+		}
+		if *format != outputJSON && *format != outputYAML {
+			fmt.Fprintf(os.Stderr, "unsupported output format %q, expected json or yaml\n", *format)
+			return 2
+		}
+
+		cloudsFile, err := loadCloudsFile()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error loading clouds.hcl:", err)
+			return 1
+		}
+		var selected []string
+		if *providers != "" {
+			selected = strings.Split(*providers, ",")
+		}
+		export, err := buildCatalogExport(cloudsFile, selected)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if err := writeStructured(os.Stdout, *format, export); err != nil {
+			fmt.Fprintln(os.Stderr, "error writing output:", err)
+			return 1
+		}
+		return 0
+	case "scan-push":
+		// Run by the workspace's pre-push hook, which passes the refs being pushed on stdin
+		return runPrePushScan(os.Stdin)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+		fmt.Fprintln(os.Stderr, "Usage: k1space [--answers answers.yaml] [list-configs --output json|yaml | catalog export --format json|yaml | daemon [--once] | doctor | scan-push]")
+		return 2
+	}
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+// Stored flags whose names suggest they control encryption, listed in the report's encryption section
+var encryptionFlagMarkers = []string{"encrypt", "kms", "tls", "cert"}
+
+// exportComplianceReport writes a markdown report of a config's cluster for security reviews, converted to PDF
+// with pandoc when it's installed
+func exportComplianceReport() {
+	log.Info("Starting exportComplianceReport function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations. Please ensure that the config.hcl file exists and is correctly formatted.")
+		return
+	}
+
+	selectedConfig, err := promptConfigSelection(indexFile, "Select a configuration to report on")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations found.")
+		return
+	}
+	if len(strings.Split(selectedConfig, "_")) != 3 {
+		log.Error("Invalid config name format", "config", selectedConfig)
+		fmt.Println("Invalid configuration name format. Export cancelled.")
+		return
+	}
+
+	format := "md"
+	if _, err := exec.LookPath("pandoc"); err == nil {
+		err = runField(newSelect("Select the report format", &format,
+			huh.NewOption("Markdown", "md"),
+			huh.NewOption("PDF (via pandoc)", "pdf"),
+		))
+		if err != nil {
+			log.Error("Error in format selection", "error", err)
+			return
+		}
+	}
+
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		fmt.Println("Failed to load settings:", err)
+		return
+	}
+
+	exportDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".exports")
+	err = os.MkdirAll(exportDir, 0755)
+	if err != nil {
+		log.Error("Error creating export directory", "error", err)
+		return
+	}
+
+	s := startSpinner("Collecting compliance details...")
+	report := renderComplianceReport(selectedConfig, indexFile, settings)
+	stopSpinner(s, true)
+
+	reportPath := filepath.Join(exportDir, fmt.Sprintf("%s-compliance-%s.md", selectedConfig, time.Now().Format("20060102-150405")))
+	err = os.WriteFile(reportPath, []byte(report), 0600)
+	if err != nil {
+		log.Error("Error writing compliance report", "error", err)
+		fmt.Println("Failed to write the compliance report:", err)
+		return
+	}
+
+	if format == "pdf" {
+		pdfPath := strings.TrimSuffix(reportPath, ".md") + ".pdf"
+		output, err := exec.Command("pandoc", reportPath, "-o", pdfPath).CombinedOutput()
+		if err != nil {
+			log.Error("Error converting compliance report to PDF", "error", err, "output", string(output))
+			fmt.Printf("Failed to convert the report to PDF: %v\nThe markdown report is at %s\n", err, reportPath)
+			return
+		}
+		reportPath = pdfPath
+	}
+
+	fmt.Println(style.Render("📋 Compliance report exported"))
+	fmt.Printf("Report: %s\n", reportPath)
+	log.Info("exportComplianceReport function completed successfully", "report", reportPath)
+}
+
+// markdownCell keeps a value from breaking out of its markdown table cell
+func markdownCell(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(value)
+}
+
+func writeMarkdownTable(sb *strings.Builder, header []string, rows [][]string) {
+	sb.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = markdownCell(cell)
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	sb.WriteString("\n")
+}
+
+// renderComplianceReport describes a config's cluster: its versions, where its data lives, how secrets and
+// traffic are protected, who can reach it, how it fares against the policies and what has happened to it
+func renderComplianceReport(configName string, indexFile IndexFile, settings Settings) string {
+	config := indexFile.Configs[configName]
+	flags := storedConfigFlags(configName, config)
+	parts := strings.Split(configName, "_")
+	cloud, region, prefix := parts[0], parts[1], parts[2]
+	host, _ := os.Hostname()
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Compliance report: %s\n\n", configName))
+	sb.WriteString(fmt.Sprintf("Generated %s on %s by k1space %s.\n\n", time.Now().UTC().Format(time.RFC3339), host, getVersion()))
+
+	sb.WriteString("## Cluster\n\n")
+	topology := config.Topology
+	if topology == nil {
+		topology = topologyFromFlags(config.Flags)
+	}
+	nodeCount := flags["node-count"]
+	if topology != nil {
+		nodeCount = fmt.Sprint(topology.NodeCount)
+	}
+	profile := configCredentialProfile(config)
+	if profile == "" {
+		profile = "default"
+	}
+	writeMarkdownTable(&sb, []string{"Setting", "Value"}, [][]string{
+		{"Cloud", cloudProviderName(cloud)},
+		{"Region", region},
+		{"Zone", flags["cloud-zone"]},
+		{"Prefix", prefix},
+		{"Cluster name", flags["cluster-name"]},
+		{"Domain", flags["domain-name"]},
+		{"Node type", flags["node-type"]},
+		{"Node count", nodeCount},
+		{"HA control plane", complianceHAControlPlane(topology, flags)},
+		{"Lifecycle state", config.State.describe()},
+		{"Credential profile", profile},
+	})
+
+	sb.WriteString("## Versions\n\n")
+	sb.WriteString(complianceVersions(config, flags))
+
+	sb.WriteString("## Data residency\n\n")
+	sb.WriteString(fmt.Sprintf("- Cluster nodes and volumes run in %s region `%s`", cloudProviderName(cloud), region))
+	if zone := flags["cloud-zone"]; zone != "" {
+		sb.WriteString(fmt.Sprintf(", zone `%s`", zone))
+	}
+	sb.WriteString(".\n")
+	if config.Failover != nil {
+		if peerParts := strings.Split(config.Failover.Peer, "_"); len(peerParts) == 3 {
+			sb.WriteString(fmt.Sprintf("- This is the %s of a failover pair; its %s, `%s`, runs in %s region `%s`, where data is restored during a failover.\n",
+				config.Failover.Role, failoverCounterpart(config.Failover.Role), config.Failover.Peer, cloudProviderName(peerParts[0]), peerParts[1]))
+		}
+	}
+	if gitProvider := flags["git-provider"]; gitProvider != "" {
+		owner := flags["github-org"]
+		if owner == "" {
+			owner = flags["gitlab-group"]
+		}
+		sb.WriteString(fmt.Sprintf("- GitOps repositories, which hold the cluster's manifests, are hosted on %s", gitProvider))
+		if owner != "" {
+			sb.WriteString(fmt.Sprintf(" under `%s`", owner))
+		}
+		sb.WriteString(".\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("## Encryption and secrets\n\n")
+	sb.WriteString(complianceEncryption(config, flags, settings))
+
+	sb.WriteString("## Access\n\n")
+	sb.WriteString(complianceAccess(configName, config, flags))
+
+	sb.WriteString("## Policies\n\n")
+	results, err := evaluatePolicies(configName, config)
+	switch {
+	case err != nil:
+		sb.WriteString(fmt.Sprintf("The policies in %s couldn't be evaluated: %v\n\n", policyFilePath(), err))
+	case len(results) == 0:
+		sb.WriteString(fmt.Sprintf("No policies in %s apply to this config.\n\n", policyFilePath()))
+	default:
+		rows := make([][]string, len(results))
+		for i, result := range results {
+			outcome := "passed"
+			if !result.Passed {
+				outcome = "failed"
+			}
+			rows[i] = []string{result.Policy, result.Enforcement, outcome, result.Message}
+		}
+		writeMarkdownTable(&sb, []string{"Policy", "Enforcement", "Result", "Message"}, rows)
+	}
+
+	sb.WriteString("## Change history\n\n")
+	sb.WriteString(complianceHistory(configName, config, indexFile))
+	return sb.String()
+}
+
+// complianceHAControlPlane only reports an HA control plane kubefirst actually applies: K3s servers running
+// embedded etcd, or a kubefirst create flag. One requested through a terraform override that kubefirst's
+// templates don't read is reported as such.
+func complianceHAControlPlane(topology *NodeTopology, flags map[string]string) string {
+	if topology != nil && topology.ControlPlaneNodes > 0 {
+		if topology.HAControlPlane {
+			return fmt.Sprintf("yes (%d servers)", topology.ControlPlaneNodes)
+		}
+		return fmt.Sprintf("no (%d server)", topology.ControlPlaneNodes)
+	}
+	if haFlag := findHAFlag(flags); haFlag != "" {
+		if flags[haFlag] == "true" {
+			return fmt.Sprintf("yes (--%s)", haFlag)
+		}
+		return "no"
+	}
+	if topology != nil && topology.HAControlPlane {
+		return "requested, not enforced"
+	}
+	return "no"
+}
+
+// failoverCounterpart names the other side of a failover pair
+func failoverCounterpart(role string) string {
+	if role == failoverPrimary {
+		return failoverSecondary
+	}
+	return failoverPrimary
+}
+
+// complianceVersions lists the kubefirst binary the config provisions with, checked against its recorded
+// provenance, and any version flags the config pins
+func complianceVersions(config Config, flags map[string]string) string {
+	var sb strings.Builder
+	kubefirstPath := config.Flags["KUBEFIRST_PATH"]
+	if kubefirstPath == "" {
+		sb.WriteString("- kubefirst binary: not set\n")
+	} else {
+		version, vendor := "unknown", vendorUnknown
+		if cli, err := detectKubefirstCLI(kubefirstPath); err == nil {
+			vendor = cli.Vendor
+			if cli.Version != "" {
+				version = cli.Version
+			}
+		}
+		sb.WriteString(fmt.Sprintf("- kubefirst binary: `%s`, %s version %s\n", kubefirstPath, vendor, version))
+
+		digest, err := fileSHA256(kubefirstPath)
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("- kubefirst SHA-256: unavailable (%v)\n", err))
+		} else {
+			sb.WriteString(fmt.Sprintf("- kubefirst SHA-256: `%s`", digest))
+			if provenance, err := loadProvenance(); err == nil {
+				for _, entry := range provenance.Binaries {
+					if entry.Path == kubefirstPath {
+						sb.WriteString(fmt.Sprintf(", provenance %s (installed from %s)", verifyBinaryProvenance(entry), entry.Source))
+						break
+					}
+				}
+			}
+			sb.WriteString("\n")
+		}
+	}
+	for _, name := range sortedFlagNames(flags) {
+		if strings.Contains(name, "version") {
+			sb.WriteString(fmt.Sprintf("- %s: %s\n", name, flags[name]))
+		}
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// complianceEncryption reports where the config's secrets are kept and which of them sit in plaintext
+func complianceEncryption(config Config, flags map[string]string, settings Settings) string {
+	var sb strings.Builder
+	backend := "unknown"
+	if provider, err := getSecretProvider(settings); err == nil {
+		backend = provider.Name()
+	}
+	sb.WriteString(fmt.Sprintf("- Secret backend: %s\n", backend))
+
+	var rows [][]string
+	for _, name := range sortedFlagNames(config.Flags) {
+		if !isSecretEnvVar(name) {
+			continue
+		}
+		if provider, ok := secretProviderForRef(config.Flags[name], settings); ok {
+			rows = append(rows, []string{name, "reference to " + provider.Name()})
+		} else {
+			rows = append(rows, []string{name, "⚠️ plaintext in .local.cloud.env"})
+		}
+	}
+	if len(rows) > 0 {
+		sb.WriteString("\n")
+		writeMarkdownTable(&sb, []string{"Secret", "Stored as"}, rows)
+	}
+
+	if domain := flags["domain-name"]; domain != "" {
+		sb.WriteString(fmt.Sprintf("- Ingress TLS: cert-manager issues Let's Encrypt certificates for `%s`\n", domain))
+	}
+	for _, name := range sortedFlagNames(flags) {
+		for _, marker := range encryptionFlagMarkers {
+			if strings.Contains(name, marker) {
+				sb.WriteString(fmt.Sprintf("- %s: %s\n", name, flags[name]))
+				break
+			}
+		}
+	}
+	signing := "not required"
+	if settings.RequireCommitSigning {
+		signing = "required"
+	}
+	sb.WriteString(fmt.Sprintf("- Signed commits to the kubefirst repositories: %s\n\n", signing))
+	return sb.String()
+}
+
+// complianceAccess reports how the cluster API is exposed and where credentials to reach it are kept
+func complianceAccess(configName string, config Config, flags map[string]string) string {
+	var sb strings.Builder
+	parts := strings.Split(configName, "_")
+
+	// Only kubefirst create flags restrict the endpoint; terraform overrides from older k1space versions were
+	// never read by kubefirst's templates
+	apiAccess := "public (no restriction)"
+	cloud := cloudProviderName(parts[0])
+	if allowlistFlag := findAPIAllowlistFlag(cloud, flags); allowlistFlag != "" && flags[allowlistFlag] != "" {
+		apiAccess = fmt.Sprintf("restricted to %s (--%s)", flags[allowlistFlag], allowlistFlag)
+	}
+	if privateFlag := findAPIPrivateFlag(cloud, flags); privateFlag != "" && flags[privateFlag] == "true" {
+		apiAccess = fmt.Sprintf("private networking only, VPN or bastion required (--%s)", privateFlag)
+	}
+	sb.WriteString(fmt.Sprintf("- Cluster API endpoint: %s\n", apiAccess))
+
+	if kubeconfigPath, err := configKubeconfigPath(configName); err == nil {
+		if info, err := os.Stat(kubeconfigPath); err == nil {
+			sb.WriteString(fmt.Sprintf("- Kubeconfig: `%s` (mode %s)\n", kubeconfigPath, info.Mode().Perm()))
+		} else {
+			sb.WriteString("- Kubeconfig: not fetched by k1space\n")
+		}
+	}
+	profile := configCredentialProfile(config)
+	if profile == "" {
+		profile = "default"
+	}
+	sb.WriteString(fmt.Sprintf("- Cloud credentials: %s token, credential profile %s\n", cloudProviderName(parts[0]), profile))
+	if email := flags["alerts-email"]; email != "" {
+		sb.WriteString(fmt.Sprintf("- Alerts contact: %s\n", email))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// complianceHistory lists the config's lifecycle states, provisioning runs, applied bootstrap packs and when its
+// generated files last changed
+func complianceHistory(configName string, config Config, indexFile IndexFile) string {
+	var sb strings.Builder
+	var rows [][]string
+	for _, entry := range config.State.stateTimeline() {
+		state, at, _ := strings.Cut(entry, " ")
+		rows = append(rows, []string{at, "state", state})
+	}
+	if config.Bootstrap != nil {
+		for pack, applied := range config.Bootstrap.Applied {
+			if at, err := time.Parse(time.RFC3339, applied); err == nil {
+				applied = at.Local().Format("2006-01-02 15:04")
+			}
+			rows = append(rows, []string{applied, "bootstrap pack", "applied " + pack})
+		}
+	}
+
+	parts := strings.Split(configName, "_")
+	logDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".logs", parts[0], parts[1], parts[2])
+	if runs, err := listOperationRuns(logDir); err == nil {
+		for timestamp, files := range runs {
+			if at, err := time.ParseInLocation("20060102-150405", timestamp, time.Local); err == nil {
+				rows = append(rows, []string{at.Format("2006-01-02 15:04"), "operation", fmt.Sprintf("run with %d log files", len(files))})
+			}
+		}
+	}
+	for _, file := range config.Files {
+		if info, err := os.Stat(filepath.FromSlash(file)); err == nil {
+			rows = append(rows, []string{info.ModTime().Format("2006-01-02 15:04"), "file", "last modified " + filepath.Base(file)})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+
+	if len(rows) == 0 {
+		sb.WriteString("No recorded changes.\n\n")
+	} else {
+		writeMarkdownTable(&sb, []string{"When", "Kind", "Change"}, rows)
+	}
+	sb.WriteString(fmt.Sprintf("config.hcl was last updated %s.\n", indexFile.LastUpdated))
+	return sb.String()
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -31,6 +34,11 @@ func createConfig(config *CloudConfig) {
 		config.Flags = &sync.Map{}
 	}
 
+	// The trace covers the whole flow; err is whatever made it return early
+	var err error
+	ctx, rootSpan := startSpan(context.Background(), "create config")
+	defer func() { rootSpan.end(err) }()
+
 	indexFile, err := loadIndexFile()
 	if err != nil {
 		log.Error("Error loading index file", "error", err)
@@ -44,6 +52,12 @@ func createConfig(config *CloudConfig) {
 	}
 	log.Info("Clouds file loaded", "cloudsFile", fmt.Sprintf("%+v", cloudsFile))
 
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		return
+	}
+
 	kubefirstPath, err := promptKubefirstBinary("")
 	if err != nil {
 		log.Error("Error selecting kubefirst binary", "error", err)
@@ -53,14 +67,21 @@ func createConfig(config *CloudConfig) {
 	// Set the KUBEFIRST_PATH flag
 	config.Flags.Store("KUBEFIRST_PATH", kubefirstPath)
 
+	selectedTemplate, err := promptTemplateSelection(indexFile)
+	if err != nil {
+		log.Error("Error in template selection", "error", err)
+		return
+	}
+	template, useTemplate := indexFile.Templates[selectedTemplate]
+	if useTemplate && config.CloudPrefix == "" {
+		config.CloudPrefix = template.Cloud
+	}
+
 	// Prompt user if they want to use values from a previous config
 	var usePreviousConfig bool
 	var selectedConfig string
-	if len(indexFile.Configs) > 0 {
-		err = huh.NewConfirm().
-			Title("Do you want to use values from a previous config?").
-			Value(&usePreviousConfig).
-			Run()
+	if len(indexFile.Configs) > 0 && !useTemplate {
+		err = runField(newConfirm("Do you want to use values from a previous config?", &usePreviousConfig))
 
 		if err != nil {
 			log.Error("Error in previous config prompt", "error", err)
@@ -73,11 +94,7 @@ func createConfig(config *CloudConfig) {
 				configOptions = append(configOptions, huh.NewOption(configName, configName))
 			}
 
-			err = huh.NewSelect[string]().
-				Title("Select a previous config to use as a template").
-				Options(configOptions...).
-				Value(&selectedConfig).
-				Run()
+			err = runField(newSelect("Select a previous config to use as a template", &selectedConfig, configOptions...))
 
 			if err != nil {
 				log.Error("Error in config selection", "error", err)
@@ -86,20 +103,16 @@ func createConfig(config *CloudConfig) {
 		}
 	}
 
-	err = huh.NewForm(
-		huh.NewGroup(
-			huh.NewInput().
-				Title("Enter static prefix").
+	err = runForm(newForm(
+		newGroup(
+			newInput("Enter static prefix", &config.StaticPrefix).
 				Description("Default is 'K1'").
 				Placeholder("K1").
-				Value(&config.StaticPrefix),
+				Validate(settings.NamingPolicy.validateStaticPrefix),
 
-			huh.NewSelect[string]().
-				Title("Select cloud provider").
-				Options(getCloudProviderOptions()...).
-				Value(&config.CloudPrefix),
+			newSelect("Select cloud provider", &config.CloudPrefix, getCloudProviderOptions()...),
 		),
-	).Run()
+	))
 
 	if err != nil {
 		log.Error("Error in initial config form", "error", err)
@@ -113,8 +126,24 @@ func createConfig(config *CloudConfig) {
 
 	log.Info("Initial form completed", "StaticPrefix", config.StaticPrefix, "CloudPrefix", config.CloudPrefix)
 
+	// Pick the account to create the cluster in when the cloud has named credential profiles
+	var previousProfile string
+	if usePreviousConfig {
+		previousProfile = configCredentialProfile(indexFile.Configs[selectedConfig])
+	}
+	config.CredentialProfile, err = promptCredentialProfile(config.CloudPrefix, settings, previousProfile)
+	if err != nil {
+		log.Error("Error in credential profile selection", "error", err)
+		return
+	}
+	useCredentialProfile(config.CloudPrefix, config.CredentialProfile)
+
+	rootSpan.setAttribute("cloud", config.CloudPrefix)
+
 	// Check for required tokens
+	_, tokenSpan := startSpan(ctx, "check cloud token", "cloud", config.CloudPrefix)
 	tokenExists, message := checkRequiredTokens(config.CloudPrefix)
+	tokenSpan.end(nil)
 	if !tokenExists {
 		log.Error("Missing required token", "cloud", config.CloudPrefix)
 		fmt.Println(message)
@@ -122,32 +151,19 @@ func createConfig(config *CloudConfig) {
 	}
 
 	// Update cloud regions and node types
-	if config.CloudPrefix == "DigitalOcean" {
-		err = updateDigitalOceanRegions(&cloudsFile)
-		if err != nil {
-			log.Error("Error updating DigitalOcean regions", "error", err)
-			return
-		}
-		err = updateDigitalOceanNodeTypes(&cloudsFile)
-		if err != nil {
-			log.Error("Error updating DigitalOcean node types", "error", err)
-			return
-		}
-	} else if config.CloudPrefix == "Civo" {
-		err = updateCivoRegions(&cloudsFile)
-		if err != nil {
-			log.Error("Error updating Civo regions", "error", err)
-			return
-		}
-		err = updateCivoNodeTypes(&cloudsFile)
-		if err != nil {
-			log.Error("Error updating Civo node types", "error", err)
-			return
-		}
+	_, cloudDataSpan := startSpan(ctx, "fetch cloud data", "cloud", config.CloudPrefix)
+	err = ensureCloudData(config.CloudPrefix, &cloudsFile)
+	cloudDataSpan.end(err)
+	if err != nil {
+		log.Error("Error updating cloud data", "cloud", config.CloudPrefix, "error", err)
+		fmt.Println(err)
+		return
 	}
 	log.Info("Cloud provider specific updates completed")
 
+	_, flagsSpan := startSpan(ctx, "fetch kubefirst flags", "kubefirst", kubefirstPath)
 	flags, err := fetchKubefirstFlags(kubefirstPath, config.CloudPrefix)
+	flagsSpan.end(err)
 	if err != nil {
 		log.Error("Error fetching kubefirst flags", "error", err)
 		return
@@ -160,11 +176,111 @@ func createConfig(config *CloudConfig) {
 		return
 	}
 
+	// Warn about deprecated flags the binary still offers, or that the reused config or template stored
+	deprecationCandidates := make([]string, 0, len(flags))
+	for flag := range flags {
+		deprecationCandidates = append(deprecationCandidates, flag)
+	}
+	if usePreviousConfig {
+		for flag := range storedConfigFlags(selectedConfig, indexFile.Configs[selectedConfig]) {
+			deprecationCandidates = append(deprecationCandidates, flag)
+		}
+	}
+	if useTemplate {
+		for flag := range template.Flags {
+			deprecationCandidates = append(deprecationCandidates, flag)
+		}
+	}
+	warnDeprecatedFlags(kubefirstPath, deprecationCandidates)
+
+	// Optionally measure latency to each region so the closest one is easy to spot
+	var regionLatencies map[string]time.Duration
+	if _, hasRegionFlag := flags["cloud-region"]; hasRegionFlag && supportsRegionProbe(config.CloudPrefix) {
+		var probeLatency bool
+		err = runField(newConfirm("Do you want to measure latency to each region?", &probeLatency))
+		if err != nil {
+			log.Error("Error in latency probe prompt", "error", err)
+			return
+		}
+
+		if probeLatency {
+			s := startSpinner("Probing region latency...")
+			regionLatencies = probeRegionLatencies(config.CloudPrefix, cloudsFile.CloudRegions[config.CloudPrefix])
+			stopSpinner(s, len(regionLatencies) > 0)
+		}
+	}
+
+	spotFlag, err := promptSpotCapacity(config, flags)
+	if err != nil {
+		log.Error("Error in spot capacity prompt", "error", err)
+		return
+	}
+
+	apiAccessFlags, err := promptAPIAccess(config, flags)
+	if err != nil {
+		log.Error("Error in API access prompt", "error", err)
+		return
+	}
+
+	var k3sFlags, topologyFlags []string
+	if config.CloudPrefix == "K3s" {
+		k3sFlags, err = promptK3sInventory(config, flags)
+		if err != nil {
+			log.Error("Error in K3s inventory prompt", "error", err)
+			return
+		}
+		config.Topology = k3sTopology(config.K3s)
+	} else {
+		var previousTopology *NodeTopology
+		if usePreviousConfig {
+			previousTopology = indexFile.Configs[selectedConfig].Topology
+		} else if useTemplate {
+			if count, ok := template.templateDefault(config.CloudPrefix, "node-count"); ok {
+				previousTopology = &NodeTopology{}
+				previousTopology.NodeCount, _ = strconv.Atoi(count)
+			}
+		}
+		topologyFlags, err = promptNodeTopology(config, flags, previousTopology)
+		if err != nil {
+			log.Error("Error in node topology prompt", "error", err)
+			return
+		}
+	}
+
+	fieldCtx := flagFieldContext{
+		CloudProvider:   config.CloudPrefix,
+		CloudsFile:      cloudsFile,
+		RegionLatencies: regionLatencies,
+		ClusterNameValidator: func(name string) error {
+			return settings.NamingPolicy.validateClusterName(config.CloudPrefix, name)
+		},
+		FlagDocs: loadFlagDocs(config.CloudPrefix),
+	}
+
+	// Let users narrow the node type list down to GPU instances for AI workloads
+	if _, hasNodeTypeFlag := flags["node-type"]; hasNodeTypeFlag && hasGPUNodeTypes(config.CloudPrefix, cloudsFile) {
+		var gpuOnly bool
+		err = runField(newConfirm("Only show GPU node types?", &gpuOnly))
+		if err != nil {
+			log.Error("Error in GPU filter prompt", "error", err)
+			return
+		}
+		if gpuOnly {
+			fieldCtx.NodeTypeFilter = InstanceSizeInfo.HasGPU
+		}
+	}
+
 	flagInputs := make([]struct{ Name, Value string }, 0, len(flags))
 	flagGroups := make([]huh.Field, 0, len(flags))
 
 	for flag, description := range flags {
+		if flag == spotFlag || contains(apiAccessFlags, flag) || contains(k3sFlags, flag) || contains(topologyFlags, flag) {
+			continue
+		}
 		var defaultValue string
+		if useTemplate {
+			defaultValue, _ = template.templateDefault(config.CloudPrefix, flag)
+		}
 		if usePreviousConfig {
 			if prevConfig, ok := indexFile.Configs[selectedConfig]; ok {
 				// Create a normalized version of the flag name
@@ -191,40 +307,25 @@ func createConfig(config *CloudConfig) {
 				}
 			}
 		}
+		// A node type picked in 'Compare Node Types' starts out selected
+		if flag == "node-type" && defaultValue == "" && config.SelectedNodeType != "" {
+			if nodeType, ok := findInstanceSize(config.CloudPrefix, config.SelectedNodeType, cloudsFile); ok {
+				defaultValue = nodeTypeOptionValue(nodeType)
+			}
+		}
 		flagInput := struct{ Name, Value string }{Name: flag, Value: defaultValue}
 		flagInputs = append(flagInputs, flagInput)
 
-		var field huh.Field
-		switch flag {
-		case "cloud-region":
-			field = huh.NewSelect[string]().
-				Title("Select cloud region").
-				Description(description).
-				Options(getRegionOptions(config.CloudPrefix, cloudsFile)...).
-				Value(&flagInputs[len(flagInputs)-1].Value)
-		case "node-type":
-			field = huh.NewSelect[string]().
-				Title("Select node type").
-				Description(description).
-				Options(getNodeTypeOptions(config.CloudPrefix, cloudsFile)...).
-				Value(&flagInputs[len(flagInputs)-1].Value)
-		default:
-			field = huh.NewInput().
-				Title(fmt.Sprintf("Enter value for %s", flag)).
-				Description(description).
-				Placeholder(defaultValue).
-				Value(&flagInputs[len(flagInputs)-1].Value)
-		}
-
+		field := newFlagField(flag, description, fieldCtx, &flagInputs[len(flagInputs)-1].Value, defaultValue)
 		flagGroups = append(flagGroups, field)
 	}
 
-	flagForm := huh.NewForm(
-		huh.NewGroup(flagGroups...),
+	flagForm := newForm(
+		newGroup(flagGroups...),
 	)
 	log.Info("Config state before flag input form", "config", fmt.Sprintf("%+v", config))
 
-	err = flagForm.Run()
+	err = runForm(flagForm)
 	if err != nil {
 		log.Error("Error in flag input form", "error", err)
 		return
@@ -240,6 +341,10 @@ func createConfig(config *CloudConfig) {
 			nodeParts := strings.Fields(fi.Value)
 			if len(nodeParts) > 0 {
 				config.Flags.Store(fi.Name, nodeParts[0])
+				config.SelectedNodeType = nodeParts[0]
+				if nodeType, ok := findInstanceSize(config.CloudPrefix, nodeParts[0], cloudsFile); ok {
+					config.Architecture = nodeType.Architecture
+				}
 				log.Info("Debug: After updating node-type flag", "config", fmt.Sprintf("%+v", config))
 			}
 		}
@@ -251,31 +356,39 @@ func createConfig(config *CloudConfig) {
 
 	log.Info("After updating flags", "config", fmt.Sprintf("%+v", config))
 
-	err = generateFiles(config, kubefirstPath)
+	warnIfArchitectureUnsupported(config.Architecture)
+
+	_, filesSpan := startSpan(ctx, "write config files")
+	baseDir, err := writeConfigFiles(config, kubefirstPath)
+	filesSpan.end(err)
 	if err != nil {
-		log.Error("Error generating files", "error", err)
+		log.Error("Error writing config files", "error", err)
 		return
 	}
 	log.Info("Files generated successfully")
+	maintainWorkspaceGit()
 
-	// Update the .local.cloud.env file to ensure KUBEFIRST_PATH is set correctly
-	baseDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", strings.ToLower(config.CloudPrefix), strings.ToLower(config.Region), config.StaticPrefix)
-	envFilePath := filepath.Join(baseDir, ".local.cloud.env")
-	err = updateEnvFile(envFilePath, fmt.Sprintf("%s_%s_%s", config.StaticPrefix, config.CloudPrefix, config.Region), kubefirstPath)
+	err = promptSaveTemplate(config, indexFile)
 	if err != nil {
-		log.Error("Error updating .local.cloud.env file", "error", err)
+		log.Error("Error saving config template", "error", err)
 		return
 	}
-	log.Info("Updated .local.cloud.env file with KUBEFIRST_PATH")
 
-	err = updateIndexFile(config, indexFile)
+	_, existed := indexFile.Configs[cloudConfigKey(config)]
+	rootSpan.setAttribute("config", cloudConfigKey(config))
+	_, indexSpan := startSpan(ctx, "update index")
+	err = updateIndexFile(config)
+	indexSpan.end(err)
 	if err != nil {
 		log.Error("Error updating index file", "error", err)
 		return
 	}
 	log.Info("Index file updated successfully")
+	if !existed {
+		emitConfigCreated(config)
+	}
 
-	err = updateCloudsFile(config, cloudsFile)
+	err = updateCloudsFile(config)
 	if err != nil {
 		log.Error("Error updating clouds file", "error", err)
 		return
@@ -287,8 +400,37 @@ func createConfig(config *CloudConfig) {
 	fmt.Println()
 
 	fmt.Printf("☁️ Cloud Provider: %s\n", config.CloudPrefix)
+	if config.CredentialProfile != "" {
+		fmt.Printf("🔑 Credential Profile: %s\n", profileLabel(config.CloudPrefix, config.CredentialProfile))
+	}
 	fmt.Printf("🌎 Region: %s\n", config.Region)
 	fmt.Printf("💻 Node Type: %s\n", config.SelectedNodeType)
+	if config.K3s == nil {
+		if topology := config.Topology.describe(); topology != "" {
+			fmt.Printf("🖥️ Nodes: %s\n", topology)
+		}
+	}
+	if nodeType, ok := findInstanceSize(config.CloudPrefix, config.SelectedNodeType, cloudsFile); ok {
+		if estimate := estimateMonthlyCost(nodeType, config.Topology); estimate != "" {
+			fmt.Printf("💰 Estimated Cost: %s\n", estimate)
+		} else if note, ok := unpricedClouds[config.CloudPrefix]; ok {
+			fmt.Printf("💰 Estimated Cost: unavailable (%s)\n", note)
+		}
+	}
+	if config.Architecture != "" {
+		fmt.Printf("🧬 Architecture: %s\n", config.Architecture)
+	}
+	if config.K3s != nil {
+		fmt.Printf("🖥️ Nodes: %d server(s), %d agent(s)\n", len(config.K3s.Servers), len(config.K3s.Agents))
+	}
+	if config.Spot {
+		fmt.Println("⚡ Capacity: spot/preemptible")
+	}
+	if len(config.APIAllowlist) > 0 {
+		fmt.Printf("🔒 API Allowlist: %s\n", strings.Join(config.APIAllowlist, ", "))
+	} else if config.PrivateAPI {
+		fmt.Println("🔒 API Access: private networking only")
+	}
 
 	// Print relevant file paths
 	fmt.Println(style.Render("\n📁 Generated Files:"))
@@ -296,6 +438,10 @@ func createConfig(config *CloudConfig) {
 	fmt.Printf("%sInit Script: %s\n", filePrefix, filepath.Join(baseDir, "00-init.sh"))
 	fmt.Printf("%sKubefirst Script: %s\n", filePrefix, filepath.Join(baseDir, "01-kubefirst-cloud.sh"))
 	fmt.Printf("%sEnvironment File: %s\n", filePrefix, filepath.Join(baseDir, ".local.cloud.env"))
+	if config.K3s != nil {
+		fmt.Printf("%sK3s Inventory: %s\n", filePrefix, filepath.Join(baseDir, k3sInventoryFile))
+		fmt.Printf("%sK3s Install Script: %s\n", filePrefix, filepath.Join(baseDir, k3sInstallScriptFile))
+	}
 
 	// Print command to run the generated init script
 	fmt.Println(style.Render("\n🚀 To run the initialization script, use the following command:"))
@@ -304,8 +450,122 @@ func createConfig(config *CloudConfig) {
 	log.Info("createConfig function completed successfully")
 }
 
+// cloudDataFetcher fetches a provider's regions and node types into a CloudsFile
+type cloudDataFetcher struct {
+	// Used in error messages, e.g. "error updating Google Cloud machine types"
+	Label     string
+	NodeTypes string
+	Regions   func(context.Context, *CloudsFile) error
+	Sizes     func(context.Context, *CloudsFile) error
+}
+
+var cloudDataFetchers = map[string]cloudDataFetcher{
+	"Akamai":       {"Akamai", "node types", updateAkamaiRegions, updateAkamaiNodeTypes},
+	"Civo":         {"Civo", "node types", updateCivoRegions, updateCivoNodeTypes},
+	"DigitalOcean": {"DigitalOcean", "node types", updateDigitalOceanRegions, updateDigitalOceanNodeTypes},
+	"Google":       {"Google Cloud", "machine types", updateGoogleRegions, updateGoogleNodeTypes},
+	"Vultr":        {"Vultr", "node types", updateVultrRegions, updateVultrNodeTypes},
+}
+
+// refreshCloudData fetches a provider's regions and node types at the same time. The two write to different
+// maps of cloudsFile (regions and zones, node types), so they don't race; the first failure cancels the other.
+func refreshCloudData(ctx context.Context, cloudProvider string, cloudsFile *CloudsFile) error {
+	fetcher, ok := cloudDataFetchers[cloudProvider]
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, 2)
+	fetch := func(what string, fn func(context.Context, *CloudsFile) error) {
+		err := fn(ctx, cloudsFile)
+		if err != nil {
+			cancel()
+			err = fmt.Errorf("error updating %s %s: %w", fetcher.Label, what, err)
+		}
+		errs <- err
+	}
+	go fetch("regions", fetcher.Regions)
+	go fetch(fetcher.NodeTypes, fetcher.Sizes)
+
+	// Errors arrive in the order the fetches finish, so the first one is the cause rather than the cancellation
+	var firstErr error
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		recordTokenError(cloudProvider, firstErr)
+		return firstErr
+	}
+	clearTokenError(cloudProvider)
+	cloudsFile.CloudLastUpdated[cloudProvider] = time.Now().UTC().Format(time.RFC3339)
+	return nil
+}
+
+// flagFieldContext carries the provider data used to build select fields in the flag form
+type flagFieldContext struct {
+	CloudProvider   string
+	CloudsFile      CloudsFile
+	RegionLatencies map[string]time.Duration
+	NodeTypeFilter  func(InstanceSizeInfo) bool
+	// Applied to the cluster-name input so naming policy violations show up immediately
+	ClusterNameValidator func(string) error
+	FlagDocs             flagDocsCache
+}
+
+func newFlagField(flag, description string, ctx flagFieldContext, value *string, placeholder string) huh.Field {
+	var field huh.Field
+	switch flag {
+	case "cloud-region":
+		field = newSelect("Select cloud region", value, getRegionOptions(ctx.CloudProvider, ctx.CloudsFile, ctx.RegionLatencies)...).
+			Description(description)
+	case "node-type":
+		if note, ok := unpricedClouds[ctx.CloudProvider]; ok {
+			description = fmt.Sprintf("%s\n%s.", description, note)
+		}
+		field = newSelect("Select node type", value, getNodeTypeOptions(ctx.CloudProvider, ctx.CloudsFile, ctx.NodeTypeFilter)...).
+			Description(description)
+	default:
+		input := newInput(fmt.Sprintf("Enter value for %s", flag), value).
+			Description(description).
+			Placeholder(placeholder)
+		if flag == "cluster-name" && ctx.ClusterNameValidator != nil {
+			input = input.Validate(ctx.ClusterNameValidator)
+		}
+		field = input
+	}
+	return withFlagDocs(field, flag, ctx.FlagDocs)
+}
+
+// writeConfigFiles generates the config's scripts and env file and returns the directory they live in
+func writeConfigFiles(config *CloudConfig, kubefirstPath string) (string, error) {
+	err := generateFiles(config, kubefirstPath)
+	if err != nil {
+		return "", fmt.Errorf("error generating files: %w", err)
+	}
+
+	// Update the .local.cloud.env file to ensure KUBEFIRST_PATH is set correctly
+	baseDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", strings.ToLower(config.CloudPrefix), strings.ToLower(config.Region), config.StaticPrefix)
+	envFilePath := filepath.Join(baseDir, ".local.cloud.env")
+	err = updateEnvFile(envFilePath, fmt.Sprintf("%s_%s_%s", config.StaticPrefix, config.CloudPrefix, config.Region), kubefirstPath)
+	if err != nil {
+		return "", fmt.Errorf("error updating .local.cloud.env file: %w", err)
+	}
+	log.Info("Updated .local.cloud.env file with KUBEFIRST_PATH")
+
+	return baseDir, nil
+}
+
+func getCloudsPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "clouds.hcl")
+}
+
 func loadCloudsFile() (CloudsFile, error) {
-	cloudsPath := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "clouds.hcl")
+	cloudsPath := getCloudsPath()
 	var cloudsFile CloudsFile
 
 	data, err := os.ReadFile(cloudsPath)
@@ -323,7 +583,9 @@ func loadCloudsFile() (CloudsFile, error) {
 			},
 			Blocks: []hcl.BlockHeaderSchema{
 				{Type: "cloud_regions"},
+				{Type: "cloud_zones"},
 				{Type: "cloud_node_types"},
+				{Type: "cloud_last_updated"},
 			},
 		})
 		if diags.HasErrors() {
@@ -338,18 +600,42 @@ func loadCloudsFile() (CloudsFile, error) {
 		}
 
 		cloudsFile.CloudRegions = make(map[string][]string)
+		cloudsFile.CloudZones = make(map[string][]string)
 		cloudsFile.CloudNodeTypes = make(map[string][]InstanceSizeInfo)
+		cloudsFile.CloudLastUpdated = make(map[string]string)
 
 		for _, block := range content.Blocks {
 			switch block.Type {
+			case "cloud_last_updated":
+				attrs, diags := block.Body.JustAttributes()
+				if !diags.HasErrors() {
+					for name, attr := range attrs {
+						value, diags := attr.Expr.Value(nil)
+						if !diags.HasErrors() && value.Type() == cty.String {
+							cloudsFile.CloudLastUpdated[name] = value.AsString()
+						}
+					}
+				}
+			case "cloud_zones":
+				attrs, diags := block.Body.JustAttributes()
+				if !diags.HasErrors() {
+					for name, attr := range attrs {
+						values, diags := attr.Expr.Value(nil)
+						if !diags.HasErrors() && values.CanIterateElements() {
+							var zones []string
+							it := values.ElementIterator()
+							for it.Next() {
+								_, value := it.Element()
+								zones = append(zones, value.AsString())
+							}
+							cloudsFile.CloudZones[name] = zones
+						}
+					}
+				}
 			case "cloud_regions":
-				content, _, diags := block.Body.PartialContent(&hcl.BodySchema{
-					Attributes: []hcl.AttributeSchema{
-						{Name: "*"},
-					},
-				})
+				attrs, diags := block.Body.JustAttributes()
 				if !diags.HasErrors() {
-					for name, attr := range content.Attributes {
+					for name, attr := range attrs {
 						values, diags := attr.Expr.Value(nil)
 						if !diags.HasErrors() && values.CanIterateElements() {
 							var regions []string
@@ -363,13 +649,9 @@ func loadCloudsFile() (CloudsFile, error) {
 					}
 				}
 			case "cloud_node_types":
-				content, _, diags := block.Body.PartialContent(&hcl.BodySchema{
-					Attributes: []hcl.AttributeSchema{
-						{Name: "*"},
-					},
-				})
+				attrs, diags := block.Body.JustAttributes()
 				if !diags.HasErrors() {
-					for name, attr := range content.Attributes {
+					for name, attr := range attrs {
 						values, diags := attr.Expr.Value(nil)
 						if !diags.HasErrors() && values.CanIterateElements() {
 							var nodeTypes []InstanceSizeInfo
@@ -385,6 +667,24 @@ func loadCloudsFile() (CloudsFile, error) {
 									nodeType.RAMMegabytes = int(ramMB)
 									diskGB, _ := value.GetAttr("disk_gigabytes").AsBigFloat().Int64()
 									nodeType.DiskGigabytes = int(diskGB)
+									// GPU attributes are absent from clouds.hcl files written by older versions
+									if value.Type().HasAttribute("gpu_count") {
+										gpuCount, _ := value.GetAttr("gpu_count").AsBigFloat().Int64()
+										nodeType.GPUCount = int(gpuCount)
+										nodeType.GPUModel = value.GetAttr("gpu_model").AsString()
+										gpuVRAM, _ := value.GetAttr("gpu_vram_gigabytes").AsBigFloat().Int64()
+										nodeType.GPUVRAMGigabytes = int(gpuVRAM)
+									}
+									if value.Type().HasAttribute("architecture") {
+										nodeType.Architecture = value.GetAttr("architecture").AsString()
+									} else {
+										nodeType.Architecture = detectArchitecture(nodeType.Name)
+									}
+									// Prices are absent from clouds.hcl files written by older versions
+									if value.Type().HasAttribute("price_monthly") {
+										nodeType.PriceHourly, _ = value.GetAttr("price_hourly").AsBigFloat().Float64()
+										nodeType.PriceMonthly, _ = value.GetAttr("price_monthly").AsBigFloat().Float64()
+									}
 									nodeTypes = append(nodeTypes, nodeType)
 								}
 							}
@@ -401,26 +701,53 @@ func loadCloudsFile() (CloudsFile, error) {
 	if cloudsFile.CloudRegions == nil {
 		cloudsFile.CloudRegions = make(map[string][]string)
 	}
+	if cloudsFile.CloudZones == nil {
+		cloudsFile.CloudZones = make(map[string][]string)
+	}
 	if cloudsFile.CloudNodeTypes == nil {
 		cloudsFile.CloudNodeTypes = make(map[string][]InstanceSizeInfo)
 	}
+	if cloudsFile.CloudLastUpdated == nil {
+		cloudsFile.CloudLastUpdated = make(map[string]string)
+	}
 
 	return cloudsFile, nil
 }
 
-func updateCloudsFile(config *CloudConfig, cloudsFile CloudsFile) error {
-	cloudsPath := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "clouds.hcl")
+// updateClouds re-reads clouds.hcl under its lock, applies update and writes the result, so provider data
+// another k1space process saved in the meantime is kept
+func updateClouds(update func(cloudsFile *CloudsFile) error) error {
+	return withFileLock(getCloudsPath(), func() error {
+		cloudsFile, err := loadCloudsFile()
+		if err != nil {
+			// clouds.hcl only caches provider data, so an unreadable one is replaced
+			log.Warn("Replacing unreadable clouds.hcl", "error", err)
+			cloudsFile = newCloudsFile()
+		}
+		err = update(&cloudsFile)
+		if err != nil {
+			return err
+		}
+		return saveCloudsFile(cloudsFile)
+	})
+}
+
+// updateCloudsFile adds a config's region to the provider's regions in clouds.hcl
+func updateCloudsFile(config *CloudConfig) error {
+	return updateClouds(func(cloudsFile *CloudsFile) error {
+		if !contains(cloudsFile.CloudRegions[config.CloudPrefix], config.Region) {
+			cloudsFile.CloudRegions[config.CloudPrefix] = append(
+				cloudsFile.CloudRegions[config.CloudPrefix],
+				config.Region,
+			)
+		}
+		return nil
+	})
+}
 
-	// Update cloud regions
-	if _, exists := cloudsFile.CloudRegions[config.CloudPrefix]; !exists {
-		cloudsFile.CloudRegions[config.CloudPrefix] = []string{}
-	}
-	if !contains(cloudsFile.CloudRegions[config.CloudPrefix], config.Region) {
-		cloudsFile.CloudRegions[config.CloudPrefix] = append(
-			cloudsFile.CloudRegions[config.CloudPrefix],
-			config.Region,
-		)
-	}
+// saveCloudsFile writes the cached provider data to clouds.hcl. Callers hold its lock; see updateClouds.
+func saveCloudsFile(cloudsFile CloudsFile) error {
+	cloudsPath := getCloudsPath()
 
 	// Create HCL file
 	f := hclwrite.NewEmptyFile()
@@ -436,6 +763,16 @@ func updateCloudsFile(config *CloudConfig, cloudsFile CloudsFile) error {
 		cloudRegionsBody.SetAttributeValue(k, cty.ListVal(convertStringSliceToCtyValueSlice(v)))
 	}
 
+	// Write cloud_zones
+	cloudZonesBlock := rootBody.AppendNewBlock("cloud_zones", nil)
+	cloudZonesBody := cloudZonesBlock.Body()
+	for k, v := range cloudsFile.CloudZones {
+		if len(v) == 0 {
+			continue
+		}
+		cloudZonesBody.SetAttributeValue(k, cty.ListVal(convertStringSliceToCtyValueSlice(v)))
+	}
+
 	// Write cloud_node_types
 	cloudNodeTypesBlock := rootBody.AppendNewBlock("cloud_node_types", nil)
 	cloudNodeTypesBody := cloudNodeTypesBlock.Body()
@@ -443,17 +780,30 @@ func updateCloudsFile(config *CloudConfig, cloudsFile CloudsFile) error {
 		nodeTypeValues := make([]cty.Value, len(v))
 		for i, nodeType := range v {
 			nodeTypeValues[i] = cty.ObjectVal(map[string]cty.Value{
-				"name":           cty.StringVal(nodeType.Name),
-				"cpu_cores":      cty.NumberIntVal(int64(nodeType.CPUCores)),
-				"ram_megabytes":  cty.NumberIntVal(int64(nodeType.RAMMegabytes)),
-				"disk_gigabytes": cty.NumberIntVal(int64(nodeType.DiskGigabytes)),
+				"name":               cty.StringVal(nodeType.Name),
+				"cpu_cores":          cty.NumberIntVal(int64(nodeType.CPUCores)),
+				"ram_megabytes":      cty.NumberIntVal(int64(nodeType.RAMMegabytes)),
+				"disk_gigabytes":     cty.NumberIntVal(int64(nodeType.DiskGigabytes)),
+				"gpu_count":          cty.NumberIntVal(int64(nodeType.GPUCount)),
+				"gpu_model":          cty.StringVal(nodeType.GPUModel),
+				"gpu_vram_gigabytes": cty.NumberIntVal(int64(nodeType.GPUVRAMGigabytes)),
+				"architecture":       cty.StringVal(nodeType.Architecture),
+				"price_hourly":       cty.NumberFloatVal(nodeType.PriceHourly),
+				"price_monthly":      cty.NumberFloatVal(nodeType.PriceMonthly),
 			})
 		}
 		cloudNodeTypesBody.SetAttributeValue(k, cty.ListVal(nodeTypeValues))
 	}
 
+	// Write cloud_last_updated
+	cloudLastUpdatedBlock := rootBody.AppendNewBlock("cloud_last_updated", nil)
+	cloudLastUpdatedBody := cloudLastUpdatedBlock.Body()
+	for k, v := range cloudsFile.CloudLastUpdated {
+		cloudLastUpdatedBody.SetAttributeValue(k, cty.StringVal(v))
+	}
+
 	// Write the updated clouds file
-	err := os.WriteFile(cloudsPath, f.Bytes(), 0644)
+	err := writeFileAtomic(cloudsPath, f.Bytes(), 0644)
 	if err != nil {
 		return err
 	}
@@ -471,6 +821,17 @@ func generateFiles(config *CloudConfig, kubefirstPath string) error {
 		return err
 	}
 
+	settings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+	provider, err := getSecretProvider(settings)
+	if err != nil {
+		return err
+	}
+	addSecretReferences(config, settings, provider)
+	addCredentialProfile(config)
+
 	// Generate .local.cloud.env
 	envContent := generateEnvContent(config)
 	log.Info("Generated env content", "content", envContent)
@@ -483,7 +844,7 @@ func generateFiles(config *CloudConfig, kubefirstPath string) error {
 	log.Info("Generated .local.cloud.env", "path", envFilePath)
 
 	// Generate 00-init.sh
-	initContent := generateInitContent()
+	initContent := generateInitContent(config, provider)
 	err = os.WriteFile(filepath.Join(baseDir, "00-init.sh"), []byte(initContent), 0755)
 	if err != nil {
 		return err
@@ -496,15 +857,29 @@ func generateFiles(config *CloudConfig, kubefirstPath string) error {
 		return err
 	}
 
+	if config.K3s != nil {
+		err = writeK3sFiles(config.K3s, baseDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	warnGeneratedSecrets(baseDir, settings)
 	return nil
 }
 
+// envVarPrefix returns the prefix of a config's env vars, e.g. K1_AKAMAI_US_EAST. Dashes become underscores, as
+// the generated scripts couldn't expand a name like $K1_AKAMAI_US-EAST_CLUSTER_NAME.
+func envVarPrefix(staticPrefix, cloud, region string) string {
+	return fmt.Sprintf("%s_%s_%s",
+		strings.ReplaceAll(staticPrefix, "-", "_"),
+		strings.ToUpper(strings.ReplaceAll(cloud, "-", "_")),
+		strings.ToUpper(strings.ReplaceAll(region, "-", "_")))
+}
+
 func generateEnvContent(config *CloudConfig) string {
 	var content strings.Builder
-	prefix := fmt.Sprintf("%s_%s_%s",
-		strings.ReplaceAll(config.StaticPrefix, "-", "_"),
-		strings.ToUpper(strings.ReplaceAll(config.CloudPrefix, "-", "_")),
-		strings.ToUpper(strings.ReplaceAll(config.Region, "-", "_")))
+	prefix := envVarPrefix(config.StaticPrefix, config.CloudPrefix, config.Region)
 
 	config.Flags.Range(func(k, v interface{}) bool {
 		flag := k.(string)
@@ -513,13 +888,31 @@ func generateEnvContent(config *CloudConfig) string {
 		content.WriteString(fmt.Sprintf("export %s=\"%s\"\n", envVarName, value))
 		return true
 	})
+
+	overrideNames := make([]string, 0, len(config.EnvOverrides))
+	for name := range config.EnvOverrides {
+		overrideNames = append(overrideNames, name)
+	}
+	sort.Strings(overrideNames)
+	for _, name := range overrideNames {
+		content.WriteString(fmt.Sprintf("export %s=\"%s\"\n", name, config.EnvOverrides[name]))
+	}
 	return content.String()
 }
 
-func generateInitContent() string {
-	return `#!/bin/bash
-op run --env-file="./.local.cloud.env" -- sh ./01-kubefirst-cloud.sh
-`
+func generateInitContent(config *CloudConfig, provider SecretProvider) string {
+	var content strings.Builder
+	content.WriteString("#!/bin/bash\n")
+	// Share downloaded terraform providers between runs, see cacheTerraformProviders
+	content.WriteString(fmt.Sprintf(`export TF_PLUGIN_CACHE_DIR=%q
+mkdir -p "$TF_PLUGIN_CACHE_DIR"
+`, getTerraformPluginCacheDir()))
+	// K3s nodes need the cluster installed before kubefirst can bootstrap onto them
+	if config.K3s != nil {
+		content.WriteString(fmt.Sprintf("bash ./%s || exit 1\n", k3sInstallScriptFile))
+	}
+	content.WriteString(provider.InitCommand() + "\n")
+	return content.String()
 }
 
 func generateKubefirstContent(config *CloudConfig, kubefirstPath string) string {
@@ -546,9 +939,10 @@ fi
 
 `)
 
-	prefix := fmt.Sprintf("%s_%s_%s", config.StaticPrefix, strings.ToUpper(config.CloudPrefix), strings.ToUpper(config.Region))
+	prefix := envVarPrefix(config.StaticPrefix, config.CloudPrefix, config.Region)
 
-	content.WriteString("\"${KUBEFIRST_PATH}\" civo create \\\n")
+	compat := newKubefirstCompat(kubefirstPath, config.CloudPrefix)
+	content.WriteString(fmt.Sprintf("\"${KUBEFIRST_PATH}\" %s create \\\n", compat.command))
 
 	flags := make([]string, 0)
 	config.Flags.Range(func(k, v interface{}) bool {
@@ -556,7 +950,7 @@ fi
 		value := v.(string)
 		if value != "" && flag != "KUBEFIRST_PATH" { // Exclude KUBEFIRST_PATH from flags
 			envVarName := fmt.Sprintf("%s_%s", prefix, strings.ToUpper(strings.ReplaceAll(flag, "-", "_")))
-			flags = append(flags, fmt.Sprintf("  --%s \"$%s\"", flag, envVarName))
+			flags = append(flags, fmt.Sprintf("  --%s \"$%s\"", compat.flag(flag), envVarName))
 		}
 		return true
 	})
@@ -603,14 +997,17 @@ func promptKubefirstBinary(currentPath string) (string, error) {
 		options = append(options, huh.NewOption("Use ~/.ssot/k1space/.repositories/konstructio/kubefirst", localPath))
 	}
 
+	if bundleDir := activeAirgapBundle(); bundleDir != "" {
+		bundlePath := filepath.Join(bundleDir, airgapKubefirstPath)
+		if currentPath != bundlePath {
+			options = append(options, huh.NewOption("Use kubefirst from the air-gapped bundle", bundlePath))
+		}
+	}
+
 	options = append(options, huh.NewOption("Specify a custom path", "custom"))
 
 	var selectedOption string
-	err = huh.NewSelect[string]().
-		Title("Choose the kubefirst binary option:").
-		Options(options...).
-		Value(&selectedOption).
-		Run()
+	err = runField(newSelect("Choose the kubefirst binary option:", &selectedOption, options...))
 
 	if err != nil {
 		return "", err
@@ -618,10 +1015,7 @@ func promptKubefirstBinary(currentPath string) (string, error) {
 
 	if selectedOption == "custom" {
 		var customPath string
-		err = huh.NewInput().
-			Title("Enter the path to the local kubefirst binary").
-			Value(&customPath).
-			Run()
+		err = runField(newInput("Enter the path to the local kubefirst binary", &customPath))
 
 		if err != nil {
 			return "", err
@@ -639,7 +1033,11 @@ func promptKubefirstBinary(currentPath string) (string, error) {
 }
 
 func fetchKubefirstFlags(kubefirstPath, cloudProvider string) (map[string]string, error) {
-	cmd := exec.Command(kubefirstPath, strings.ToLower(cloudProvider), "create", "--help")
+	command := kubefirstCloudCommand(cloudProvider)
+	if cli, err := detectKubefirstCLI(kubefirstPath); err == nil {
+		command = cli.command(cloudProvider)
+	}
+	cmd := exec.Command(kubefirstPath, command, "create", "--help")
 	log.Info("Executing kubefirst command", "path", kubefirstPath, "args", cmd.Args)
 
 	output, err := cmd.CombinedOutput()
@@ -689,31 +1087,26 @@ func deleteConfig() {
 		configOptions = append(configOptions, huh.NewOption(config, config))
 	}
 
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Title("Select a configuration to delete").
-				Options(configOptions...).
-				Value(&selectedConfig),
+	form := newForm(
+		newGroup(
+			newSelect("Select a configuration to delete", &selectedConfig, configOptions...),
 		),
 	)
 
-	err = form.Run()
+	err = runForm(form)
 	if err != nil {
 		log.Error("Error in config selection", "error", err)
 		return
 	}
 
 	var confirmDelete bool
-	confirmForm := huh.NewForm(
-		huh.NewGroup(
-			huh.NewConfirm().
-				Title(fmt.Sprintf("Are you sure you want to delete the configuration '%s'?", selectedConfig)).
-				Value(&confirmDelete),
+	confirmForm := newForm(
+		newGroup(
+			newConfirm(fmt.Sprintf("Are you sure you want to delete the configuration '%s'?", selectedConfig), &confirmDelete),
 		),
 	)
 
-	err = confirmForm.Run()
+	err = runForm(confirmForm)
 	if err != nil {
 		log.Error("Error in delete confirmation", "error", err)
 		return
@@ -754,8 +1147,11 @@ func deleteConfig() {
 	}
 
 	// Delete the config from config.hcl
-	delete(indexFile.Configs, selectedConfig)
-	err = updateIndexFile(&CloudConfig{Flags: &sync.Map{}}, indexFile)
+	err = updateIndex(func(indexFile *IndexFile) error {
+		unlinkFailoverPeer(indexFile, selectedConfig)
+		delete(indexFile.Configs, selectedConfig)
+		return nil
+	})
 	if err != nil {
 		log.Error("Error updating index file", "error", err)
 		fmt.Printf("Failed to update index file. The configuration '%s' has been backed up but not removed from the index.\n", selectedConfig)
@@ -808,6 +1204,11 @@ func listConfigs() {
 		return
 	}
 
+	if isStructuredOutput() {
+		printStructured(configSummaries(indexFile))
+		return
+	}
+
 	fmt.Println(style.Render("Existing Configurations:"))
 	for configName, config := range indexFile.Configs {
 		parts := strings.Split(configName, "_")
@@ -817,6 +1218,26 @@ func listConfigs() {
 			fmt.Printf("  Cloud Provider: %s\n", cloud)
 			fmt.Printf("  Region: %s\n", region)
 			fmt.Printf("  Prefix: %s\n", prefix)
+			fmt.Printf("  State: %s\n", config.State.describe())
+			if topology := config.Topology.describe(); topology != "" {
+				fmt.Printf("  Nodes: %s\n", topology)
+			}
+			if timeline := config.State.stateTimeline(); len(timeline) > 1 {
+				fmt.Printf("  History: %s\n", strings.Join(timeline, ", "))
+			}
+			if len(config.Maintenance) > 0 {
+				status := ""
+				if inMaintenance(config, time.Now()) {
+					status = " (open now)"
+				}
+				fmt.Printf("  Maintenance: %s%s\n", strings.Join(describeMaintenanceWindows(config.Maintenance), "; "), status)
+			}
+			if config.Failover != nil {
+				fmt.Printf("  Failover: %s\n", config.Failover.describe())
+			}
+			if packs := config.Bootstrap.describe(); packs != "" {
+				fmt.Printf("  Bootstrap packs: %s\n", packs)
+			}
 			fmt.Printf("  Files:\n")
 			for _, file := range config.Files {
 				fmt.Printf("    - %s\n", file)
@@ -831,20 +1252,73 @@ func listConfigs() {
 	fmt.Scanln()
 }
 
+// configSummary is the machine-readable form of a config entry in listConfigs
+type configSummary struct {
+	Name          string   `json:"name" yaml:"name"`
+	CloudProvider string   `json:"cloud_provider" yaml:"cloud_provider"`
+	Region        string   `json:"region" yaml:"region"`
+	Prefix        string   `json:"prefix" yaml:"prefix"`
+	Files         []string `json:"files" yaml:"files"`
+	// State is the lifecycle state, and StateSince when each state was last entered
+	State      string            `json:"state,omitempty" yaml:"state,omitempty"`
+	StateSince map[string]string `json:"state_since,omitempty" yaml:"state_since,omitempty"`
+	// NodeCount and HAControlPlane come from the config's topology block
+	NodeCount      int  `json:"node_count,omitempty" yaml:"node_count,omitempty"`
+	HAControlPlane bool `json:"ha_control_plane,omitempty" yaml:"ha_control_plane,omitempty"`
+	// MaintenanceWindows describe when the daemon leaves the cluster alone
+	MaintenanceWindows []string `json:"maintenance_windows,omitempty" yaml:"maintenance_windows,omitempty"`
+	// Failover is the config's DR pair, e.g. "secondary of civo_nyc1_k1"
+	Failover string `json:"failover,omitempty" yaml:"failover,omitempty"`
+	// BootstrapPacks are applied to the cluster after kubefirst finishes
+	BootstrapPacks []string `json:"bootstrap_packs,omitempty" yaml:"bootstrap_packs,omitempty"`
+}
+
+// configSummaries returns the index entries sorted by name, skipping keys that aren't cloud_region_prefix
+func configSummaries(indexFile IndexFile) []configSummary {
+	var summaries []configSummary
+	for configName, config := range indexFile.Configs {
+		parts := strings.Split(configName, "_")
+		if len(parts) != 3 {
+			continue
+		}
+		summary := configSummary{
+			Name:          configName,
+			CloudProvider: parts[0],
+			Region:        parts[1],
+			Prefix:        parts[2],
+			Files:         config.Files,
+		}
+		if config.State != nil {
+			summary.State = config.State.Current
+			summary.StateSince = config.State.Since
+		}
+		if config.Topology != nil {
+			summary.NodeCount = config.Topology.NodeCount
+			summary.HAControlPlane = config.Topology.HAControlPlane
+		}
+		summary.MaintenanceWindows = describeMaintenanceWindows(config.Maintenance)
+		summary.Failover = config.Failover.describe()
+		summary.BootstrapPacks = config.Bootstrap.selected()
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries
+}
+
 func deleteAllConfigs() {
 	log.Info("Starting deleteAllConfigs function")
 
 	// Confirm with the user
 	var confirmDelete bool
-	confirmForm := huh.NewForm(
-		huh.NewGroup(
-			huh.NewConfirm().
-				Title("Are you sure you want to delete all configurations? This action cannot be undone.").
-				Value(&confirmDelete),
+	confirmForm := newForm(
+		newGroup(
+			newConfirm("Are you sure you want to delete all configurations? This action cannot be undone.", &confirmDelete),
 		),
 	)
 
-	err := confirmForm.Run()
+	err := runForm(confirmForm)
 	if err != nil {
 		log.Error("Error in delete confirmation", "error", err)
 		return
@@ -889,3 +1363,25 @@ func deleteAllConfigs() {
 	fmt.Println("All configurations have been deleted.")
 	log.Info("deleteAllConfigs function completed successfully")
 }
+
+func promptConfigSelection(indexFile IndexFile, title string) (string, error) {
+	if len(indexFile.Configs) == 0 {
+		return "", nil
+	}
+
+	configNames := make([]string, 0, len(indexFile.Configs))
+	for configName := range indexFile.Configs {
+		configNames = append(configNames, configName)
+	}
+	sort.Strings(configNames)
+
+	configOptions := make([]huh.Option[string], 0, len(configNames))
+	for _, configName := range configNames {
+		configOptions = append(configOptions, huh.NewOption(configOptionLabel(configName, indexFile.Configs[configName]), configName))
+	}
+
+	var selectedConfig string
+	err := runField(newSelect(title, &selectedConfig, configOptions...))
+
+	return selectedConfig, err
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// Width of each side of the side-by-side diff
+const diffColumnWidth = 60
+
+// Flags that most often explain why two clusters behave differently
+var importantDiffFlags = []string{"CLOUD_REGION", "NODE_TYPE", "DOMAIN_NAME", "SUBDOMAIN", "GIT_PROVIDER", "GITHUB_ORG", "GITLAB_GROUP", "GIT_PROTOCOL"}
+
+var (
+	diffChangedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500"))
+	diffImportantStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87")).Bold(true)
+	diffRemovedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F5F"))
+	diffAddedStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#5FFF87"))
+	diffSameStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+)
+
+// flagDiff is one flag compared across two configs
+type flagDiff struct {
+	Flag      string `json:"flag" yaml:"flag"`
+	Left      string `json:"left" yaml:"left"`
+	Right     string `json:"right" yaml:"right"`
+	Changed   bool   `json:"changed" yaml:"changed"`
+	Important bool   `json:"important" yaml:"important"`
+}
+
+// diffLine is one row of a line-based diff; Left or Right is empty for added or removed lines
+type diffLine struct {
+	Left, Right string
+	Op          byte // ' ' unchanged, '-' only in left, '+' only in right
+}
+
+func diffConfigs() {
+	log.Info("Starting diffConfigs function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations. Please ensure that the config.hcl file exists and is correctly formatted.")
+		return
+	}
+
+	if len(indexFile.Configs) < 2 {
+		fmt.Println("At least two configurations are needed to compare.")
+		return
+	}
+
+	left, err := promptConfigSelection(indexFile, "Select the first configuration")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+
+	others := IndexFile{Configs: make(map[string]Config)}
+	for name, config := range indexFile.Configs {
+		if name != left {
+			others.Configs[name] = config
+		}
+	}
+	right, err := promptConfigSelection(others, "Select the configuration to compare it with")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+
+	flags := compareConfigFlags(left, indexFile.Configs[left], right, indexFile.Configs[right])
+	if isStructuredOutput() {
+		printStructured(flags)
+		return
+	}
+
+	fmt.Println(style.Render(fmt.Sprintf("Comparing %s with %s", left, right)))
+	printFlagDiff(left, right, flags)
+	printFileDiffs(left, indexFile.Configs[left], right, indexFile.Configs[right])
+
+	fmt.Print("\nPress Enter to continue...")
+	fmt.Scanln()
+}
+
+// configEnvPrefix returns the prefix of the env vars generated for a config, e.g. K1_CIVO_NYC1_
+func configEnvPrefix(configName string) string {
+	parts := strings.Split(configName, "_")
+	if len(parts) != 3 {
+		return ""
+	}
+	cloud, region, prefix := parts[0], parts[1], parts[2]
+	return envVarPrefix(prefix, cloud, region) + "_"
+}
+
+// compareConfigFlags lines up flags by name with each config's env prefix stripped, secrets masked
+func compareConfigFlags(leftName string, left Config, rightName string, right Config) []flagDiff {
+	normalize := func(configName string, flags map[string]string) map[string]string {
+		envPrefix := configEnvPrefix(configName)
+		normalized := make(map[string]string, len(flags))
+		for name, value := range flags {
+			// Skip the per-config copy of KUBEFIRST_PATH, the bare one is kept
+			if strings.HasSuffix(name, "_KUBEFIRST_PATH") {
+				continue
+			}
+			normalized[strings.TrimPrefix(name, envPrefix)] = value
+		}
+		return normalized
+	}
+	leftFlags := normalize(leftName, left.Flags)
+	rightFlags := normalize(rightName, right.Flags)
+
+	names := make([]string, 0, len(leftFlags)+len(rightFlags))
+	for name := range leftFlags {
+		names = append(names, name)
+	}
+	for name := range rightFlags {
+		if _, ok := leftFlags[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	redactor := newRedactor()
+	diffs := make([]flagDiff, 0, len(names))
+	for _, name := range names {
+		leftValue, rightValue := leftFlags[name], rightFlags[name]
+		important := false
+		for _, flag := range importantDiffFlags {
+			if strings.HasSuffix(name, flag) {
+				important = true
+				break
+			}
+		}
+		diffs = append(diffs, flagDiff{
+			Flag:      name,
+			Left:      redactor.redact(leftValue),
+			Right:     redactor.redact(rightValue),
+			Changed:   leftValue != rightValue,
+			Important: important,
+		})
+	}
+	return diffs
+}
+
+func printFlagDiff(leftName, rightName string, flags []flagDiff) {
+	flagWidth := len("Flag")
+	for _, flag := range flags {
+		if len(flag.Flag) > flagWidth {
+			flagWidth = len(flag.Flag)
+		}
+	}
+
+	fmt.Println(titleStyle.Render("\nFlags"))
+	fmt.Printf("  %-*s  %-*s  %s\n", flagWidth, "Flag", diffColumnWidth, truncateDiffCell(leftName), truncateDiffCell(rightName))
+
+	changed := 0
+	for _, flag := range flags {
+		row := fmt.Sprintf("%-*s  %-*s  %s", flagWidth, flag.Flag, diffColumnWidth, truncateDiffCell(flag.Left), truncateDiffCell(flag.Right))
+		switch {
+		case flag.Changed && flag.Important:
+			fmt.Println(diffImportantStyle.Render("! " + row))
+			changed++
+		case flag.Changed:
+			fmt.Println(diffChangedStyle.Render("~ " + row))
+			changed++
+		default:
+			fmt.Println(diffSameStyle.Render("  " + row))
+		}
+	}
+	fmt.Printf("\n%d of %d flags differ ('!' marks region, node type, domain and git settings)\n", changed, len(flags))
+}
+
+// printFileDiffs compares the generated files both configs have in common, matched by file name
+func printFileDiffs(leftName string, left Config, rightName string, right Config) {
+	rightFiles := make(map[string]string, len(right.Files))
+	for _, file := range right.Files {
+		rightFiles[filepath.Base(file)] = file
+	}
+
+	for _, leftFile := range left.Files {
+		name := filepath.Base(leftFile)
+		rightFile, ok := rightFiles[name]
+		if !ok {
+			fmt.Println(titleStyle.Render("\n" + name))
+			fmt.Printf("  Only in %s\n", leftName)
+			continue
+		}
+		delete(rightFiles, name)
+
+		leftLines, err := readDiffLines(leftFile, leftName)
+		if err != nil {
+			log.Error("Error reading file", "file", leftFile, "error", err)
+			continue
+		}
+		rightLines, err := readDiffLines(rightFile, rightName)
+		if err != nil {
+			log.Error("Error reading file", "file", rightFile, "error", err)
+			continue
+		}
+
+		fmt.Println(titleStyle.Render("\n" + name))
+		lines := diffLines(leftLines, rightLines)
+		identical := true
+		for _, line := range lines {
+			if line.Op != ' ' {
+				identical = false
+				break
+			}
+		}
+		if identical {
+			fmt.Println(diffSameStyle.Render("  Identical"))
+			continue
+		}
+		for _, line := range lines {
+			row := fmt.Sprintf("%-*s  %s", diffColumnWidth, truncateDiffCell(line.Left), truncateDiffCell(line.Right))
+			switch line.Op {
+			case '-':
+				fmt.Println(diffRemovedStyle.Render("- " + row))
+			case '+':
+				fmt.Println(diffAddedStyle.Render("+ " + row))
+			default:
+				fmt.Println(diffSameStyle.Render("  " + row))
+			}
+		}
+	}
+
+	remaining := make([]string, 0, len(rightFiles))
+	for name := range rightFiles {
+		remaining = append(remaining, name)
+	}
+	sort.Strings(remaining)
+	for _, name := range remaining {
+		fmt.Println(titleStyle.Render("\n" + name))
+		fmt.Printf("  Only in %s\n", rightName)
+	}
+}
+
+// readDiffLines reads a generated file with secrets masked and the config's env prefix replaced,
+// so two configs' env files line up on the values rather than on the variable names
+func readDiffLines(path, configName string) ([]string, error) {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	text := redactEnvContent(string(content))
+	if envPrefix := configEnvPrefix(configName); envPrefix != "" {
+		text = strings.ReplaceAll(text, envPrefix, "<PREFIX>_")
+	}
+	return strings.Split(strings.TrimRight(text, "\n"), "\n"), nil
+}
+
+// diffLines aligns two files using their longest common subsequence of lines
+func diffLines(left, right []string) []diffLine {
+	lcs := make([][]int, len(left)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(right)+1)
+	}
+	for i := len(left) - 1; i >= 0; i-- {
+		for j := len(right) - 1; j >= 0; j-- {
+			if left[i] == right[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(left) && j < len(right) {
+		switch {
+		case left[i] == right[j]:
+			lines = append(lines, diffLine{Left: left[i], Right: right[j], Op: ' '})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{Left: left[i], Op: '-'})
+			i++
+		default:
+			lines = append(lines, diffLine{Right: right[j], Op: '+'})
+			j++
+		}
+	}
+	for ; i < len(left); i++ {
+		lines = append(lines, diffLine{Left: left[i], Op: '-'})
+	}
+	for ; j < len(right); j++ {
+		lines = append(lines, diffLine{Right: right[j], Op: '+'})
+	}
+	return lines
+}
+
+func truncateDiffCell(s string) string {
+	if len(s) <= diffColumnWidth {
+		return s
+	}
+	return s[:diffColumnWidth-3] + "..."
+}
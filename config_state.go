@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Lifecycle states of a config's cluster, as recorded in config.hcl
+const (
+	stateCreated       = "created"
+	stateProvisioning  = "provisioning"
+	stateProvisioned   = "provisioned"
+	stateFailed        = "failed"
+	stateCancelled     = "cancelled"
+	stateTimedOut      = "timed-out"
+	stateDeprovisioned = "deprovisioned"
+)
+
+// ConfigState is a config's current lifecycle state and when it last entered each state, as RFC 3339 times
+type ConfigState struct {
+	Current string
+	Since   map[string]string
+}
+
+// newConfigState starts a config's lifecycle in state at time at
+func newConfigState(state string, at time.Time) *ConfigState {
+	return &ConfigState{Current: state, Since: map[string]string{state: at.UTC().Format(time.RFC3339)}}
+}
+
+// changedAt returns when the config entered its current state
+func (s *ConfigState) changedAt() (time.Time, bool) {
+	if s == nil {
+		return time.Time{}, false
+	}
+	changed, err := time.Parse(time.RFC3339, s.Since[s.Current])
+	return changed, err == nil
+}
+
+// describe formats the state for menus and listings, e.g. "provisioned 3h ago"
+func (s *ConfigState) describe() string {
+	if s == nil || s.Current == "" {
+		return "unknown"
+	}
+	changed, ok := s.changedAt()
+	if !ok {
+		return s.Current
+	}
+	return fmt.Sprintf("%s %s", s.Current, formatCloudDataAge(time.Since(changed)))
+}
+
+// setConfigState records that a config entered state now. It goes through updateIndex, as provisioning can run
+// for a long time while other changes are saved.
+func setConfigState(configName, state string) error {
+	return updateIndex(func(indexFile *IndexFile) error {
+		config, ok := indexFile.Configs[configName]
+		if !ok {
+			return fmt.Errorf("config %s not found", configName)
+		}
+
+		now := time.Now().UTC()
+		if config.State == nil {
+			config.State = newConfigState(state, now)
+		} else {
+			config.State.Current = state
+			config.State.Since[state] = now.Format(time.RFC3339)
+		}
+		indexFile.Configs[configName] = config
+		return nil
+	})
+}
+
+// recordConfigState is setConfigState for callers that carry on if the index can't be written
+func recordConfigState(configName, state string) {
+	err := setConfigState(configName, state)
+	if err != nil {
+		log.Warn("Could not record config state", "config", configName, "state", state, "error", err)
+	}
+}
+
+// configOptionLabel shows a config's state next to its name in selection menus
+func configOptionLabel(configName string, config Config) string {
+	return fmt.Sprintf("%s (%s)", configName, config.State.describe())
+}
+
+// stateTimeline lists the states a config has been in, oldest first, e.g. "created 2024-05-01 10:00"
+func (s *ConfigState) stateTimeline() []string {
+	if s == nil {
+		return nil
+	}
+	type entry struct {
+		state string
+		at    time.Time
+	}
+	var entries []entry
+	for state, since := range s.Since {
+		at, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry{state, at})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].at.Before(entries[j].at) })
+
+	timeline := make([]string, len(entries))
+	for i, e := range entries {
+		timeline[i] = fmt.Sprintf("%s %s", e.state, e.at.Local().Format("2006-01-02 15:04"))
+	}
+	return timeline
+}
+
+// markExistingConfigsCreated gives configs from before state tracking a starting state. Whether they were ever
+// provisioned isn't known, so they start as created, dated to the index's last update.
+func markExistingConfigsCreated(indexFile *IndexFile) error {
+	at, err := time.Parse(time.RFC3339, indexFile.LastUpdated)
+	if err != nil {
+		at = time.Now()
+	}
+	for name, config := range indexFile.Configs {
+		if config.State == nil {
+			config.State = newConfigState(stateCreated, at)
+			indexFile.Configs[name] = config
+		}
+	}
+	return nil
+}
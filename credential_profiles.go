@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Written to a config's .local.cloud.env (and so recorded in config.hcl) when it uses a named profile
+const credentialProfileVar = "K1_CREDENTIAL_PROFILE"
+
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// activeProfiles maps a cloud token variable to the profile lookupToken should read it from instead
+var activeProfiles = map[string]string{}
+
+// profileTokenVar is the variable a profile's token is kept in: CIVO_TOKEN for the default profile,
+// CIVO_TOKEN_WORK for civo:work. It's looked up in the environment and keychain like any other token.
+func profileTokenVar(tokenVar, profile string) string {
+	if profile == "" {
+		return tokenVar
+	}
+	return tokenVar + "_" + nonEnvVarChars.ReplaceAllString(strings.ToUpper(profile), "_")
+}
+
+// profileLabel formats a profile the way users refer to it, e.g. civo:work
+func profileLabel(cloud, profile string) string {
+	if profile == "" {
+		profile = "default"
+	}
+	return strings.ToLower(cloud) + ":" + profile
+}
+
+// cloudProfiles returns the named profiles credential_profiles in settings.hcl defines for a cloud
+func cloudProfiles(settings Settings, cloud string) []string {
+	return settings.CredentialProfiles[strings.ToLower(cloud)]
+}
+
+// activeTokenVar returns the variable lookupToken actually reads for tokenVar
+func activeTokenVar(tokenVar string) string {
+	return profileTokenVar(tokenVar, activeProfiles[tokenVar])
+}
+
+// useCredentialProfile points lookupToken at a profile's token for the rest of the session, so region and
+// node type fetches go to that account. An empty profile switches back to the default token.
+func useCredentialProfile(cloud, profile string) {
+	tokenVar := cloudTokenVar(cloud)
+	if tokenVar == "" {
+		return
+	}
+	if profile == "" {
+		delete(activeProfiles, tokenVar)
+		return
+	}
+	activeProfiles[tokenVar] = profile
+}
+
+// promptCredentialProfile lets users pick which account a config is created in. It returns "" without
+// prompting when the cloud has no named profiles.
+func promptCredentialProfile(cloud string, settings Settings, defaultProfile string) (string, error) {
+	tokenVar := cloudTokenVar(cloud)
+	profiles := cloudProfiles(settings, cloud)
+	if tokenVar == "" || len(profiles) == 0 {
+		return "", nil
+	}
+
+	options := []huh.Option[string]{huh.NewOption(fmt.Sprintf("%s (%s)", profileLabel(cloud, ""), tokenVar), "")}
+	for _, profile := range profiles {
+		options = append(options, huh.NewOption(fmt.Sprintf("%s (%s)", profileLabel(cloud, profile), profileTokenVar(tokenVar, profile)), profile))
+	}
+
+	profile := defaultProfile
+	err := runField(newSelect("Select a credential profile", &profile, options...))
+	return profile, err
+}
+
+// addCredentialProfile records the config's profile in its env file and, unless a secret backend reference
+// already names the profile's secret, points the cloud's token variable at the profile's
+func addCredentialProfile(config *CloudConfig) {
+	tokenVar := cloudTokenVar(config.CloudPrefix)
+	if config.CredentialProfile == "" || tokenVar == "" {
+		return
+	}
+	setEnvOverride(config, credentialProfileVar, profileLabel(config.CloudPrefix, config.CredentialProfile))
+	if _, ok := config.EnvOverrides[tokenVar]; !ok {
+		setEnvOverride(config, tokenVar, fmt.Sprintf("${%s}", profileTokenVar(tokenVar, config.CredentialProfile)))
+	}
+}
+
+// configCredentialProfile reads a config's profile back from config.hcl, "" for the default one
+func configCredentialProfile(config Config) string {
+	_, profile, _ := strings.Cut(config.Flags[credentialProfileVar], ":")
+	if profile == "default" {
+		return ""
+	}
+	return profile
+}
+
+func validateProfileName(name string) error {
+	if name == "default" {
+		return fmt.Errorf("'default' is the profile using the plain token variable")
+	}
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("use lowercase letters, digits and dashes")
+	}
+	return nil
+}
+
+// saveCredentialProfile adds a profile to credential_profiles in settings.hcl
+func saveCredentialProfile(settings Settings, cloud, profile string) error {
+	cloud = strings.ToLower(cloud)
+	if contains(settings.CredentialProfiles[cloud], profile) {
+		return nil
+	}
+
+	profiles := map[string][]string{}
+	for name, names := range settings.CredentialProfiles {
+		profiles[name] = names
+	}
+	profiles[cloud] = append(append([]string{}, profiles[cloud]...), profile)
+	sort.Strings(profiles[cloud])
+
+	values := make(map[string]cty.Value, len(profiles))
+	for name, names := range profiles {
+		if len(names) == 0 {
+			continue
+		}
+		list := make([]cty.Value, len(names))
+		for i, n := range names {
+			list[i] = cty.StringVal(n)
+		}
+		values[name] = cty.ListVal(list)
+	}
+	return saveSetting("credential_profiles", cty.ObjectVal(values))
+}
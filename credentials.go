@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+// Service name k1space's tokens are filed under in the OS keychain
+const keyringService = "k1space"
+
+// Option value for adding a credential profile in the token list
+const newCredentialProfile = "new-profile"
+
+var errKeyringNotFound = errors.New("not found in keychain")
+
+// credentialNames lists the tokens the credentials manager offers: every cloud token and credential profile
+// plus the git providers'
+func credentialNames(settings Settings) []string {
+	names := []string{"GITHUB_TOKEN", "GITLAB_TOKEN"}
+	for cloud, tokenVar := range cloudTokenVars {
+		names = append(names, tokenVar)
+		for _, profile := range cloudProfiles(settings, cloud) {
+			names = append(names, profileTokenVar(tokenVar, profile))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupToken returns a token from the environment, falling back to the OS keychain. A token found in the
+// keychain is exported too, so scripts and kubefirst started afterwards see it. Cloud tokens are read from
+// the active credential profile's variable, see useCredentialProfile.
+func lookupToken(name string) string {
+	name = activeTokenVar(name)
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	value, err := keyringGet(name)
+	if err != nil {
+		if !errors.Is(err, errKeyringNotFound) {
+			log.Debug("Keychain unavailable", "token", name, "error", err)
+		}
+		return ""
+	}
+	os.Setenv(name, value)
+	return value
+}
+
+func manageCredentials() {
+	log.Info("Starting manageCredentials function")
+
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		fmt.Println("Failed to load settings:", err)
+		return
+	}
+
+	names := credentialNames(settings)
+	invalid := currentInvalidTokens()
+	summary := [][]string{{"Token", "Source", "Status"}}
+	options := make([]huh.Option[string], 0, len(names)+1)
+	for _, name := range names {
+		source := "not set"
+		if _, err := keyringGet(name); err == nil {
+			source = "keychain"
+		}
+		if os.Getenv(name) != "" {
+			source = "environment"
+			if _, err := keyringGet(name); err == nil {
+				source = "environment (also in keychain)"
+			}
+		}
+		status, label := "", fmt.Sprintf("%s (%s)", name, source)
+		if token, ok := invalid[name]; ok {
+			status = "invalid: " + token.describe()
+			label += " ⚠️ invalid"
+		}
+		summary = append(summary, []string{name, source, status})
+		options = append(options, huh.NewOption(label, name))
+	}
+	options = append(options, huh.NewOption("Add a credential profile (e.g. civo:work)", newCredentialProfile))
+	printSummaryTable("Credentials", summary)
+
+	var name string
+	err = runField(newSelect("Select a token", &name, options...))
+	if err != nil {
+		log.Error("Error in credentials form", "error", err)
+		return
+	}
+
+	action := "save"
+	if name == newCredentialProfile {
+		name, err = addCredentialProfileToken(settings)
+		if err != nil {
+			log.Error("Error adding credential profile", "error", err)
+			fmt.Println("Failed to add the credential profile:", err)
+			return
+		}
+	} else {
+		actions := []huh.Option[string]{
+			huh.NewOption("Save to keychain", "save"),
+			huh.NewOption("Remove from keychain", "remove"),
+		}
+		if _, ok := invalid[name]; ok {
+			action = "replace"
+			actions = append([]huh.Option[string]{huh.NewOption("Replace with a new token, checked with the provider", "replace")}, actions...)
+		}
+		err = runField(newSelect("Action", &action, actions...))
+		if err != nil {
+			log.Error("Error in credentials form", "error", err)
+			return
+		}
+	}
+
+	if action == "replace" {
+		token := invalid[name]
+		previous := activeProfiles[cloudCredentialVar(token.Provider)]
+		useCredentialProfile(token.Provider, token.Profile)
+		promptReplaceCloudToken(token.Provider)
+		useCredentialProfile(token.Provider, previous)
+		return
+	}
+
+	if action == "remove" {
+		err = keyringDelete(name)
+		if err != nil {
+			log.Error("Error removing token from keychain", "token", name, "error", err)
+			fmt.Printf("Failed to remove %s from the keychain: %v\n", name, err)
+			return
+		}
+		fmt.Printf("%s removed from the keychain.\n", name)
+		return
+	}
+
+	value := os.Getenv(name)
+	useEnv := value != ""
+	if useEnv {
+		err = runField(newConfirm(fmt.Sprintf("Save the %s currently set in your environment?", name), &useEnv))
+		if err != nil {
+			log.Error("Error in confirmation prompt", "error", err)
+			return
+		}
+	}
+	if !useEnv {
+		value = ""
+		err = runField(newPasswordInput(fmt.Sprintf("Enter %s", name), &value))
+		if err != nil {
+			log.Error("Error in token input", "error", err)
+			return
+		}
+	}
+	if value == "" {
+		fmt.Println("No value entered; nothing saved.")
+		return
+	}
+
+	err = keyringSet(name, value)
+	if err != nil {
+		log.Error("Error saving token to keychain", "token", name, "error", err)
+		fmt.Printf("Failed to save %s to the keychain: %v\n", name, err)
+		return
+	}
+	fmt.Printf("%s saved to the keychain. k1space will use it whenever %s is not set in your environment.\n", name, name)
+}
+
+// addCredentialProfileToken asks for a cloud and profile name, records the profile in settings.hcl and returns
+// the variable its token is kept in
+func addCredentialProfileToken(settings Settings) (string, error) {
+	clouds := make([]string, 0, len(cloudTokenVars))
+	for cloud := range cloudTokenVars {
+		clouds = append(clouds, cloud)
+	}
+	sort.Strings(clouds)
+	cloudOptions := make([]huh.Option[string], 0, len(clouds))
+	for _, cloud := range clouds {
+		cloudOptions = append(cloudOptions, huh.NewOption(cloud, cloud))
+	}
+
+	var cloud, profile string
+	err := runForm(newForm(
+		newGroup(
+			newSelect("Cloud provider", &cloud, cloudOptions...),
+			newInput("Profile name", &profile).
+				Description("e.g. 'work' for civo:work").
+				Validate(validateProfileName),
+		),
+	))
+	if err != nil {
+		return "", err
+	}
+
+	err = saveCredentialProfile(settings, cloud, profile)
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("Added credential profile %s.\n", profileLabel(cloud, profile))
+	return profileTokenVar(cloudTokenVar(cloud), profile), nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -46,6 +47,14 @@ var (
 				BorderForeground(lipgloss.Color("#FF00FF")).
 				Width(180)
 
+	localURLStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(special)
+
+	swaggerStyle = boxStyle.Copy().
+			BorderForeground(lipgloss.Color("#FFA500")).
+			Width(180)
+
 	// New styles from clusters.go
 	clusterTitleStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("#7D56F4")).
@@ -69,11 +78,19 @@ var (
 			Width(100)
 )
 
-func renderDashboard(kubefirstAPILogs, consoleLogs, kubefirstLogs *scrollingLog) string {
+func renderDashboard(kubefirstAPILogs, consoleLogs, kubefirstLogs *scrollingLog, swaggerDiff *swaggerDiffCache, urls []localURL, usage []serviceUsage, controls *logPaneControls) string {
 	doc := strings.Builder{}
 
-	// Render summary
-	summary := fmt.Sprintf("Kubefirst repositories running\nStatus: All systems operational\nLast updated: %s", time.Now().Format("15:04:05"))
+	// Render summary, with the URLs as checked rather than assumed
+	urlLines, status := renderLocalURLs(urls)
+	summary := fmt.Sprintf("Kubefirst repositories running\nStatus: %s\n\n%s\n\n", status, localURLStyle.Render(urlLines))
+	if len(usage) > 0 {
+		summary += renderServiceUsage(usage) + "\n\n"
+	}
+	summary += fmt.Sprintf("Last updated: %s\n%s", time.Now().Format("15:04:05"), controls.help())
+	if status := controls.lastStatus(); status != "" {
+		summary += "\n" + status
+	}
 	doc.WriteString(summaryStyle.Render(summary))
 	doc.WriteString("\n\n")
 
@@ -81,7 +98,7 @@ func renderDashboard(kubefirstAPILogs, consoleLogs, kubefirstLogs *scrollingLog)
 	kubefirstLogPath := getLogPath("kubefirst")
 	kubefirstLogsContent := formatLogs(kubefirstLogs, 178, 3)
 	kubefirstLogsSection := kubefirstStyle.Render(
-		titleStyle.Render("Kubefirst Logs") + "\n" +
+		titleStyle.Render(paneTitle("Kubefirst Logs", kubefirstLogs)) + "\n" +
 			pathStyle.Render(kubefirstLogPath) + "\n" +
 			kubefirstLogsContent,
 	)
@@ -92,7 +109,7 @@ func renderDashboard(kubefirstAPILogs, consoleLogs, kubefirstLogs *scrollingLog)
 	consoleLogPath := getLogPath("console")
 	consoleLogsContent := formatLogs(consoleLogs, 178, 10)
 	consoleLogsSection := consoleStyle.Render(
-		titleStyle.Render("Console Logs") + "\n" +
+		titleStyle.Render(paneTitle("Console Logs", consoleLogs)) + "\n" +
 			pathStyle.Render(consoleLogPath) + "\n" +
 			consoleLogsContent,
 	)
@@ -103,18 +120,23 @@ func renderDashboard(kubefirstAPILogs, consoleLogs, kubefirstLogs *scrollingLog)
 	apiLogPath := getLogPath("kubefirst-api")
 	apiLogsContent := formatLogs(kubefirstAPILogs, 178, 20)
 	apiLogsSection := kubefirstAPIStyle.Render(
-		titleStyle.Render("Kubefirst-API Logs") + "\n" +
+		titleStyle.Render(paneTitle("Kubefirst-API Logs", kubefirstAPILogs)) + "\n" +
 			pathStyle.Render(apiLogPath) + "\n" +
 			apiLogsContent,
 	)
 	doc.WriteString(apiLogsSection)
+	doc.WriteString("\n\n")
+
+	// Render API surface changes from the latest make updateswagger
+	swaggerDiff.refresh()
+	doc.WriteString(renderSwaggerSection(swaggerDiff, 8))
 
 	return doc.String()
 }
 
 func formatLogs(logs *scrollingLog, width, height int) string {
 	var result strings.Builder
-	lines := logs.getLastN(height)
+	lines := logs.view(height)
 	for _, line := range lines {
 		result.WriteString(truncateOrWrap(removeDateFromLog(line), width) + "\n")
 	}
@@ -137,37 +159,40 @@ func removeDateFromLog(log string) string {
 }
 
 func getLogPath(serviceName string) string {
-	homeDir, _ := os.UserHomeDir()
-	logDir := filepath.Join(homeDir, ".ssot", "k1space", ".logs")
-
-	files, err := os.ReadDir(logDir)
+	files, err := serviceLogFiles(serviceName)
 	if err != nil {
 		return "Error reading log directory"
 	}
-
-	var latestFile string
-	var latestTime time.Time
-
-	prefix := serviceName + "-"
-	for _, file := range files {
-		if strings.HasPrefix(file.Name(), prefix) {
-			filePath := filepath.Join(logDir, file.Name())
-			fileInfo, err := os.Stat(filePath)
-			if err != nil {
-				continue
-			}
-			if fileInfo.ModTime().After(latestTime) {
-				latestFile = filePath
-				latestTime = fileInfo.ModTime()
-			}
-		}
+	if len(files) == 0 {
+		return "No log file found for " + serviceName
 	}
+	return files[0].path
+}
 
-	if latestFile == "" {
-		return "No log file found for " + serviceName
+// serviceLogFiles returns a service's timestamped logs in ~/.ssot/k1space/.logs, newest first
+func serviceLogFiles(serviceName string) ([]logFile, error) {
+	logDir := getLogsDir()
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		return nil, err
 	}
 
-	return latestFile
+	var files []logFile
+	for _, entry := range entries {
+		match := logTimestampPattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil || match[1] != serviceName {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{path: filepath.Join(logDir, entry.Name()), size: info.Size(), modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+	return files, nil
 }
 
 func renderClusterProvisioningTUI(selectedConfig string, configContent string, fileContents []string, filePaths []string) string {
@@ -190,3 +215,37 @@ func renderClusterProvisioningTUI(selectedConfig string, configContent string, f
 
 	return sb.String()
 }
+
+func renderProvisioningDashboard(configName, scriptLogPath, kubefirstLogPath string, startedAt time.Time, status string, scriptLogs, kubefirstLogs *scrollingLog, parsers *logParserSet) string {
+	doc := strings.Builder{}
+
+	summary := fmt.Sprintf("Provisioning %s\nElapsed: %s | Current step: %s\nLast updated: %s\n%s",
+		configName, time.Since(startedAt).Round(time.Second), parsers.currentStep(), time.Now().Format("15:04:05"), status)
+	doc.WriteString(summaryStyle.Render(summary))
+	doc.WriteString("\n\n")
+
+	scriptLogsSection := consoleStyle.Render(
+		titleStyle.Render("Provisioning Script Output") + "\n" +
+			pathStyle.Render(scriptLogPath) + "\n" +
+			formatLogs(scriptLogs, 178, 12),
+	)
+	doc.WriteString(scriptLogsSection)
+	doc.WriteString("\n\n")
+
+	if widgets := parsers.render(); widgets != "" {
+		doc.WriteString(widgets)
+		doc.WriteString("\n\n")
+	}
+
+	if kubefirstLogPath == "" {
+		kubefirstLogPath = "Waiting for kubefirst to write to " + kubefirstLogDir()
+	}
+	kubefirstLogsSection := kubefirstStyle.Render(
+		titleStyle.Render("Kubefirst Internal Logs (terraform, argocd, ...)") + "\n" +
+			pathStyle.Render(kubefirstLogPath) + "\n" +
+			formatLogs(kubefirstLogs, 178, 20),
+	)
+	doc.WriteString(kubefirstLogsSection)
+
+	return doc.String()
+}
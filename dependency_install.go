@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+// packageManager installs tools with a command k1space runs on the user's behalf
+type packageManager struct {
+	Name      string
+	available func() bool
+	// command installs pkg, the name from a dependency's packages, or upgrades it if it's already installed
+	command func(pkg string, upgrade bool) []string
+}
+
+// packageManagers in the order they're preferred. go install is first, as the go tools are only packaged for it;
+// apt is last, as its versions lag furthest behind.
+var packageManagers = []packageManager{
+	{
+		Name:      "go",
+		available: func() bool { return onPath("go") },
+		command: func(pkg string, upgrade bool) []string {
+			return []string{"go", "install", pkg}
+		},
+	},
+	{
+		Name:      "brew",
+		available: func() bool { return onPath("brew") },
+		command: func(pkg string, upgrade bool) []string {
+			action := "install"
+			if upgrade {
+				action = "upgrade"
+			}
+			return append([]string{"brew", action}, strings.Fields(pkg)...)
+		},
+	},
+	{
+		Name:      "asdf",
+		available: func() bool { return onPath("asdf") },
+		command: func(pkg string, upgrade bool) []string {
+			// The plugin may already be added. asdf 0.16 replaced `asdf global` with `asdf set -u`.
+			return []string{"sh", "-c", fmt.Sprintf("asdf plugin add %[1]s; asdf install %[1]s latest && (asdf set -u %[1]s latest 2>/dev/null || asdf global %[1]s latest)", pkg)}
+		},
+	},
+	{
+		Name:      "apt",
+		available: func() bool { return runtime.GOOS == "linux" && onPath("apt-get") },
+		command: func(pkg string, upgrade bool) []string {
+			args := []string{"apt-get", "install", "-y", pkg}
+			if os.Geteuid() != 0 {
+				args = append([]string{"sudo"}, args...)
+			}
+			return args
+		},
+	},
+}
+
+func onPath(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+func findDependency(name string) (dependency, bool) {
+	for _, dep := range dependencies {
+		if dep.Name == name {
+			return dep, true
+		}
+	}
+	return dependency{}, false
+}
+
+// installCommand returns the command that installs dep with the first available package manager that has it
+func installCommand(dep dependency, upgrade bool) ([]string, bool) {
+	for _, manager := range packageManagers {
+		pkg, ok := dep.packages[manager.Name]
+		if ok && manager.available() {
+			return manager.command(pkg, upgrade), true
+		}
+	}
+	return nil, false
+}
+
+// installDependency runs the install command in the foreground, so sudo and the package managers can prompt,
+// then checks the tool again
+func installDependency(dep dependency, upgrade bool) (doctorResult, error) {
+	args, ok := installCommand(dep, upgrade)
+	if !ok {
+		return checkDependency(dep), fmt.Errorf("no package manager found for %s. To install it, %s", dep.Name, dep.install[runtime.GOOS])
+	}
+
+	fmt.Printf("\n$ %s\n", strings.Join(args, " "))
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		return checkDependency(dep), fmt.Errorf("error installing %s: %w", dep.Name, err)
+	}
+
+	result := checkDependency(dep)
+	if result.Status == doctorMissing && args[0] == "go" {
+		// go install succeeded, so the binary is in GOBIN, which isn't on PATH
+		gobin, _ := exec.Command("go", "env", "GOPATH").Output()
+		return result, fmt.Errorf("%s was installed to %s, add it to your PATH", dep.Name, filepath.Join(strings.TrimSpace(string(gobin)), "bin"))
+	}
+	log.Info("Installed dependency", "name", dep.Name, "version", result.Version, "path", result.Path)
+	return result, nil
+}
+
+// installDependencies runs the installs, asking before each one when confirm is set, and returns whether every
+// tool ended up installed and up to date
+func installDependencies(results []doctorResult, confirm bool) bool {
+	ok := true
+	for _, result := range results {
+		upgrade := result.Status == doctorOutdated
+		args, found := installCommand(result.Dependency, upgrade)
+		if !found {
+			fmt.Printf("%s can't be installed automatically here. To install it, %s\n", result.Dependency.Name, result.Dependency.install[runtime.GOOS])
+			ok = false
+			continue
+		}
+
+		if confirm {
+			action := "Install"
+			if upgrade {
+				action = "Upgrade"
+			}
+			var install bool
+			err := runField(newConfirm(fmt.Sprintf("%s %s?", action, result.Dependency.Name), &install).
+				Description(fmt.Sprintf("It's used for %s. k1space will run:\n%s", result.Dependency.UsedFor, strings.Join(args, " "))))
+			if err != nil {
+				log.Error("Error in install confirmation", "error", err)
+				return false
+			}
+			if !install {
+				ok = false
+				continue
+			}
+		}
+
+		installed, err := installDependency(result.Dependency, upgrade)
+		if err != nil {
+			fmt.Println(err)
+			ok = false
+			continue
+		}
+		if installed.Status == doctorMissing || installed.Status == doctorOutdated {
+			fmt.Printf("%s is still %s after installing it: %s\n", installed.Dependency.Name, installed.Status, doctorHint(installed))
+			ok = false
+			continue
+		}
+		fmt.Printf("✅ %s %s installed at %s\n", installed.Dependency.Name, installed.Version, installed.Path)
+	}
+	return ok
+}
+
+// ensureDependencies checks for the tools a step is about to run and offers to install the missing ones, so the
+// step doesn't fail half-way through a script. It returns false when a tool is still missing.
+func ensureDependencies(names ...string) bool {
+	var missing []doctorResult
+	var unknown []string
+	for _, name := range names {
+		if onPath(name) {
+			continue
+		}
+		dep, ok := findDependency(name)
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		missing = append(missing, checkDependency(dep))
+	}
+	if len(unknown) > 0 {
+		fmt.Printf("Missing %s. Please install it and try again.\n", strings.Join(unknown, ", "))
+		return false
+	}
+	if len(missing) == 0 {
+		return true
+	}
+
+	missingNames := make([]string, len(missing))
+	for i, result := range missing {
+		missingNames[i] = result.Dependency.Name
+	}
+	fmt.Printf("This step needs %s, which isn't installed.\n", strings.Join(missingNames, ", "))
+	return installDependencies(missing, true)
+}
+
+// offerDoctorInstalls lets the user pick which of doctor's missing or outdated tools to install
+func offerDoctorInstalls(results []doctorResult) {
+	var options []huh.Option[string]
+	var selected []string
+	byName := make(map[string]doctorResult)
+	for _, result := range results {
+		if result.Status != doctorMissing && result.Status != doctorOutdated {
+			continue
+		}
+		args, ok := installCommand(result.Dependency, result.Status == doctorOutdated)
+		if !ok {
+			continue
+		}
+		byName[result.Dependency.Name] = result
+		option := huh.NewOption(fmt.Sprintf("%s (%s): %s", result.Dependency.Name, result.Status, strings.Join(args, " ")), result.Dependency.Name)
+		// Preselect what doctor counts as a problem
+		options = append(options, option.Selected(result.problem()))
+	}
+	if len(options) == 0 {
+		return
+	}
+
+	err := runField(newMultiSelect("Install or upgrade these tools?", &selected, options...))
+	if err != nil {
+		log.Error("Error selecting tools to install", "error", err)
+		return
+	}
+	var chosen []doctorResult
+	for _, result := range results {
+		if contains(selected, result.Dependency.Name) {
+			chosen = append(chosen, byName[result.Dependency.Name])
+		}
+	}
+	installDependencies(chosen, false)
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// kubefirst names the repository it creates in the git org or group "gitops"
+const gitopsRepoName = "gitops"
+
+var nonEnvVarChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// deployKeyClient registers read-only SSH keys on the gitops repository of a git provider
+type deployKeyClient interface {
+	Add(title, publicKey string) (string, error)
+	Remove(id string) error
+	// RepoURL is shown so users can find the key in the provider's UI
+	RepoURL() string
+}
+
+type githubDeployKeys struct {
+	Owner string
+	Token string
+}
+
+func (c githubDeployKeys) RepoURL() string {
+	return fmt.Sprintf("https://github.com/%s/%s", c.Owner, gitopsRepoName)
+}
+
+func (c githubDeployKeys) do(method, path string, payload interface{}) ([]byte, error) {
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("https://api.github.com/repos/%s/%s%s", url.PathEscape(c.Owner), gitopsRepoName, path), &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := newHTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return readResponseBody(resp)
+}
+
+func (c githubDeployKeys) Add(title, publicKey string) (string, error) {
+	body, err := c.do(http.MethodPost, "/keys", map[string]interface{}{"title": title, "key": publicKey, "read_only": true})
+	if err != nil {
+		return "", err
+	}
+	var key struct {
+		ID int64 `json:"id"`
+	}
+	err = json.Unmarshal(body, &key)
+	return fmt.Sprint(key.ID), err
+}
+
+func (c githubDeployKeys) Remove(id string) error {
+	_, err := c.do(http.MethodDelete, "/keys/"+id, nil)
+	return err
+}
+
+type gitlabDeployKeys struct {
+	BaseURL string
+	Group   string
+	Token   string
+}
+
+func (c gitlabDeployKeys) RepoURL() string {
+	return fmt.Sprintf("%s/%s/%s", c.BaseURL, c.Group, gitopsRepoName)
+}
+
+func (c gitlabDeployKeys) do(method, path string, payload interface{}) ([]byte, error) {
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			return nil, err
+		}
+	}
+	project := url.PathEscape(c.Group + "/" + gitopsRepoName)
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/api/v4/projects/%s%s", c.BaseURL, project, path), &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.Token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := newHTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return readResponseBody(resp)
+}
+
+func (c gitlabDeployKeys) Add(title, publicKey string) (string, error) {
+	body, err := c.do(http.MethodPost, "/deploy_keys", map[string]interface{}{"title": title, "key": publicKey, "can_push": false})
+	if err != nil {
+		return "", err
+	}
+	var key struct {
+		ID int64 `json:"id"`
+	}
+	err = json.Unmarshal(body, &key)
+	return fmt.Sprint(key.ID), err
+}
+
+func (c gitlabDeployKeys) Remove(id string) error {
+	_, err := c.do(http.MethodDelete, "/deploy_keys/"+id, nil)
+	return err
+}
+
+// gitopsRepo identifies a config's gitops repository and the token to reach it with
+type gitopsRepo struct {
+	Provider string
+	// GitHub org or GitLab group
+	Owner string
+	// GitLab instance; empty for GitHub
+	BaseURL string
+	Token   string
+}
+
+// resolveGitopsRepo reads the config's git provider and org/group flags and looks up the provider token
+func resolveGitopsRepo(config Config) (gitopsRepo, error) {
+	switch gitProvider := strings.ToLower(findConfigFlag(config.Flags, "git-provider")); gitProvider {
+	case "github":
+		owner := findConfigFlag(config.Flags, "github-org")
+		if owner == "" {
+			return gitopsRepo{}, fmt.Errorf("github-org is not set for this config")
+		}
+		token := lookupToken("GITHUB_TOKEN")
+		if token == "" {
+			return gitopsRepo{}, fmt.Errorf("GITHUB_TOKEN not found in environment or keychain. Please set it and try again")
+		}
+		return gitopsRepo{Provider: gitProvider, Owner: owner, Token: token}, nil
+	case "gitlab":
+		group := findConfigFlag(config.Flags, "gitlab-group")
+		if group == "" {
+			return gitopsRepo{}, fmt.Errorf("gitlab-group is not set for this config")
+		}
+		token := lookupToken("GITLAB_TOKEN")
+		if token == "" {
+			return gitopsRepo{}, fmt.Errorf("GITLAB_TOKEN not found in environment or keychain. Please set it and try again")
+		}
+		baseURL := os.Getenv("GITLAB_URL")
+		if baseURL == "" {
+			baseURL = defaultGitLabURL
+		}
+		return gitopsRepo{Provider: gitProvider, Owner: group, BaseURL: strings.TrimSuffix(baseURL, "/"), Token: token}, nil
+	default:
+		return gitopsRepo{}, fmt.Errorf("unsupported git provider %q", gitProvider)
+	}
+}
+
+// newDeployKeyClient builds a client for the config's git provider from its org/group flag and the provider token
+func newDeployKeyClient(config Config) (deployKeyClient, error) {
+	repo, err := resolveGitopsRepo(config)
+	if err != nil {
+		return nil, err
+	}
+	if repo.Provider == "gitlab" {
+		return gitlabDeployKeys{BaseURL: repo.BaseURL, Group: repo.Owner, Token: repo.Token}, nil
+	}
+	return githubDeployKeys{Owner: repo.Owner, Token: repo.Token}, nil
+}
+
+// generateSSHKeyPair creates an ed25519 key pair with ssh-keygen in a throwaway directory
+func generateSSHKeyPair(comment string) (string, string, error) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		return "", "", fmt.Errorf("ssh-keygen could not be found")
+	}
+	dir, err := os.MkdirTemp("", "k1space-deploy-key-*")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.RemoveAll(dir)
+
+	keyPath := filepath.Join(dir, "id_ed25519")
+	output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", comment, "-f", keyPath).CombinedOutput()
+	if err != nil {
+		return "", "", fmt.Errorf("error generating key: %w\nOutput: %s", err, string(output))
+	}
+	privateKey, err := os.ReadFile(keyPath)
+	if err != nil {
+		return "", "", err
+	}
+	publicKey, err := os.ReadFile(keyPath + ".pub")
+	if err != nil {
+		return "", "", err
+	}
+	return string(privateKey), strings.TrimSpace(string(publicKey)), nil
+}
+
+// deployKeySecretName is the secret the private key is stored under, e.g. GITOPS_DEPLOY_KEY_MY_CLUSTER
+func deployKeySecretName(clusterName string) string {
+	return "GITOPS_DEPLOY_KEY_" + nonEnvVarChars.ReplaceAllString(strings.ToUpper(clusterName), "_")
+}
+
+func createGitopsDeployKey() {
+	log.Info("Starting createGitopsDeployKey function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations:", err)
+		return
+	}
+	selectedConfig, err := promptConfigSelection(indexFile, "Select the cluster to create a gitops deploy key for")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations available. Please create a configuration first.")
+		return
+	}
+	config := indexFile.Configs[selectedConfig]
+
+	clusterName := findConfigFlag(config.Flags, "cluster-name")
+	if clusterName == "" {
+		clusterName = selectedConfig
+	}
+
+	client, err := newDeployKeyClient(config)
+	if err != nil {
+		log.Error("Error preparing git provider client", "error", err)
+		fmt.Println("Cannot create a deploy key:", err)
+		return
+	}
+
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		fmt.Println("Failed to load settings:", err)
+		return
+	}
+	provider, err := getSecretProvider(settings)
+	if err == nil {
+		err = provider.Check()
+	}
+	if err != nil {
+		log.Error("Secret backend unavailable", "error", err)
+		fmt.Println("The private key needs a secret backend:", err)
+		return
+	}
+
+	title := "k1space-" + clusterName
+	privateKey, publicKey, err := generateSSHKeyPair(title)
+	if err != nil {
+		log.Error("Error generating SSH key pair", "error", err)
+		fmt.Println("Failed to generate the key pair:", err)
+		return
+	}
+
+	s := startSpinner("Adding the deploy key to " + client.RepoURL() + "...")
+	keyID, err := client.Add(title, publicKey)
+	stopSpinner(s, err == nil)
+	if err != nil {
+		log.Error("Error adding deploy key", "repo", client.RepoURL(), "error", err)
+		fmt.Println("Failed to add the deploy key:", err)
+		return
+	}
+
+	ref, err := provider.Store(deployKeySecretName(clusterName), privateKey)
+	if err != nil {
+		log.Error("Error storing private key", "backend", provider.Name(), "error", err)
+		fmt.Printf("Failed to store the private key in %s: %v\n", provider.Name(), err)
+		// Without the private key the deploy key is useless, so don't leave it registered
+		if removeErr := client.Remove(keyID); removeErr != nil {
+			log.Error("Error removing deploy key", "id", keyID, "error", removeErr)
+			fmt.Printf("Remove deploy key %s from %s manually.\n", title, client.RepoURL())
+		}
+		return
+	}
+
+	printSummaryTable("Gitops Deploy Key", [][]string{
+		{"Setting", "Value"},
+		{"Cluster", clusterName},
+		{"Repository", client.RepoURL()},
+		{"Deploy Key", fmt.Sprintf("%s (id %s, read-only)", title, keyID)},
+		{"Public Key", publicKey},
+		{"Private Key", ref},
+	})
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+// detachedRun is a provisioning run that k1space kept going after its terminal hung up
+type detachedRun struct {
+	Config string `json:"config"`
+	// PID is the k1space process still reading the script's output and writing its log
+	PID        int       `json:"pid"`
+	ScriptLog  string    `json:"script_log"`
+	StartedAt  time.Time `json:"started_at"`
+	DetachedAt time.Time `json:"detached_at"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+func (run detachedRun) finished() bool {
+	return !run.FinishedAt.IsZero()
+}
+
+func (run detachedRun) state() string {
+	switch {
+	case run.finished() && run.Error == "":
+		return "Succeeded"
+	case run.finished():
+		return "Failed"
+	case processAlive(run.PID):
+		return "Running"
+	}
+	return "Lost (k1space exited before the run finished)"
+}
+
+type detachedRunsFile struct {
+	Runs []detachedRun `json:"runs"`
+}
+
+// Set once the terminal hangs up during a provisioning run, after which nothing is left to prompt or draw on
+var hungUp atomic.Bool
+
+func getDetachedRunsPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "detached_runs.json")
+}
+
+// detachOnHangupEnabled reports whether detach_on_hangup is set in settings.hcl
+func detachOnHangupEnabled() bool {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings, not detaching on hangup", "error", err)
+		return false
+	}
+	if settings.DetachOnHangup && !hangupSupported {
+		log.Warn("detach_on_hangup is not supported on this platform")
+		return false
+	}
+	return settings.DetachOnHangup
+}
+
+func loadDetachedRuns() (detachedRunsFile, error) {
+	var runs detachedRunsFile
+	data, err := os.ReadFile(getDetachedRunsPath())
+	if os.IsNotExist(err) {
+		return runs, nil
+	}
+	if err != nil {
+		return runs, fmt.Errorf("error reading detached_runs.json: %w", err)
+	}
+	err = json.Unmarshal(data, &runs)
+	if err != nil {
+		return runs, fmt.Errorf("error parsing detached_runs.json: %w", err)
+	}
+	return runs, nil
+}
+
+// updateDetachedRuns applies change to the saved runs and writes them back, holding the file's lock throughout
+func updateDetachedRuns(change func(runs []detachedRun) []detachedRun) error {
+	return withFileLock(getDetachedRunsPath(), func() error {
+		runs, err := loadDetachedRuns()
+		if err != nil {
+			return err
+		}
+		runs.Runs = change(runs.Runs)
+		data, err := json.MarshalIndent(runs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding detached runs: %w", err)
+		}
+		return writeFileAtomic(getDetachedRunsPath(), data, 0644)
+	})
+}
+
+// saveDetachedRun stores a run, replacing the saved one with the same script log
+func saveDetachedRun(run detachedRun) error {
+	return updateDetachedRuns(func(runs []detachedRun) []detachedRun {
+		for i, existing := range runs {
+			if existing.ScriptLog == run.ScriptLog {
+				runs[i] = run
+				return runs
+			}
+		}
+		return append(runs, run)
+	})
+}
+
+func removeDetachedRun(scriptLog string) error {
+	return updateDetachedRuns(func(runs []detachedRun) []detachedRun {
+		kept := runs[:0]
+		for _, run := range runs {
+			if run.ScriptLog != scriptLog {
+				kept = append(kept, run)
+			}
+		}
+		return kept
+	})
+}
+
+func findDetachedRun(scriptLog string) (detachedRun, bool) {
+	runs, err := loadDetachedRuns()
+	if err != nil {
+		log.Warn("Could not load detached runs", "error", err)
+		return detachedRun{}, false
+	}
+	for _, run := range runs.Runs {
+		if run.ScriptLog == scriptLog {
+			return run, true
+		}
+	}
+	return detachedRun{}, false
+}
+
+// watchHangup keeps k1space and the script running when the terminal hangs up, recording the run so it can be
+// reattached from another session. Ctrl+C is left to watchCancel.
+func watchHangup(run detachedRun) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-signals:
+				if !hungUp.CompareAndSwap(false, true) {
+					continue
+				}
+				run.PID = os.Getpid()
+				run.DetachedAt = time.Now().UTC()
+				if err := saveDetachedRun(run); err != nil {
+					log.Error("Error recording detached run", "error", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// finishDetachedRun records the outcome of a run whose terminal hung up
+func finishDetachedRun(scriptLog string, runErr error) {
+	run, ok := findDetachedRun(scriptLog)
+	if !ok {
+		return
+	}
+	run.FinishedAt = time.Now().UTC()
+	if runErr != nil {
+		run.Error = runErr.Error()
+	}
+	if err := saveDetachedRun(run); err != nil {
+		log.Error("Error recording detached run outcome", "error", err)
+	}
+}
+
+// exitIfHungUp ends a k1space whose terminal went away during a run, once the run is over and recorded
+func exitIfHungUp() {
+	if hungUp.Load() {
+		os.Exit(0)
+	}
+}
+
+func reattachDetachedRun() {
+	log.Info("Starting reattachDetachedRun function")
+
+	runs, err := loadDetachedRuns()
+	if err != nil {
+		log.Error("Error loading detached runs", "error", err)
+		fmt.Println("Failed to load detached runs:", err)
+		return
+	}
+	if len(runs.Runs) == 0 {
+		fmt.Println("No detached runs. Set detach_on_hangup = true in settings.hcl to keep provisioning going when an SSH session drops.")
+		return
+	}
+
+	options := make([]huh.Option[string], 0, len(runs.Runs))
+	for _, run := range runs.Runs {
+		label := fmt.Sprintf("%s (%s, detached %s)", run.Config, run.state(), run.DetachedAt.Local().Format("2006-01-02 15:04"))
+		options = append(options, huh.NewOption(label, run.ScriptLog))
+	}
+	var scriptLog string
+	err = runField(newSelect("Select the run to reattach to", &scriptLog, options...))
+	if err != nil {
+		log.Error("Error in detached run selection", "error", err)
+		return
+	}
+
+	run, ok := findDetachedRun(scriptLog)
+	if !ok {
+		fmt.Println("The run is no longer recorded.")
+		return
+	}
+	if run.state() == "Running" {
+		run = followDetachedRun(run)
+	}
+
+	summary := [][]string{
+		{"Config", "State", "Started", "Detached", "Finished", "Script Log"},
+		{run.Config, run.state(), run.StartedAt.Local().Format("2006-01-02 15:04"), run.DetachedAt.Local().Format("2006-01-02 15:04"), "", run.ScriptLog},
+	}
+	if run.finished() {
+		summary[1][4] = run.FinishedAt.Local().Format("2006-01-02 15:04")
+	}
+	printSummaryTable("Detached Run", summary)
+	if run.Error != "" {
+		fmt.Println("Error provisioning cluster:", run.Error)
+	}
+
+	// Once its outcome has been seen, a finished or lost run has nothing left to reattach to
+	if run.state() != "Running" {
+		if err := removeDetachedRun(run.ScriptLog); err != nil {
+			log.Warn("Could not remove detached run", "error", err)
+		}
+	}
+}
+
+// followDetachedRun shows the provisioning dashboard for a run another k1space process is keeping going, until it
+// finishes, and returns its latest record
+func followDetachedRun(run detachedRun) detachedRun {
+	scriptLogs := newScrollingLog("provisioning-script")
+	kubefirstLogs := newScrollingLog("kubefirst-internal")
+	redactor := newRedactor()
+	parsers := newLogParsers()
+
+	// The script log is only this run's, so the tailer reads it from the start rather than polling a directory
+	scriptTailer := newKubefirstLogTailer(run.StartedAt, scriptLogs, redactor, parsers)
+	tailer := newKubefirstLogTailer(run.StartedAt, kubefirstLogs, redactor, parsers)
+	stop := make(chan struct{})
+	defer close(stop)
+	go tailer.run(stop)
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	for {
+		if err := scriptTailer.readFrom(run.ScriptLog); err != nil {
+			log.Warn("Error reading detached run's script log", "path", run.ScriptLog, "error", err)
+		}
+		display := renderProvisioningDashboard(run.Config+" (reattached)", run.ScriptLog, tailer.currentFile(), run.StartedAt, "Ctrl+C stops watching, the run keeps going", scriptLogs, kubefirstLogs, parsers)
+		fmt.Print("\033[2J") // Clear the screen
+		fmt.Print("\033[H")  // Move cursor to top-left corner
+		fmt.Print(display)
+		fmt.Println()
+
+		latest, ok := findDetachedRun(run.ScriptLog)
+		if !ok {
+			return run
+		}
+		run = latest
+		if run.state() != "Running" {
+			return run
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const hangupSupported = true
+
+// detachProcessGroup starts cmd in its own process group, so the hangup sent to the terminal's foreground group
+// when an SSH session drops doesn't reach it, and the whole group can be signalled at once
+func detachProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalProcessGroup sends a signal to every process in a detached process group, as the terminal no longer does
+func signalProcessGroup(pid int, sig os.Signal) error {
+	return unix.Kill(-pid, sig.(syscall.Signal))
+}
+
+// processGroupAlive reports whether any process in pid's process group is still running
+func processGroupAlive(pid int) bool {
+	err := unix.Kill(-pid, 0)
+	return err == nil || err == unix.EPERM
+}
+
+// processAlive reports whether pid is still running, by sending it the null signal
+func processAlive(pid int) bool {
+	err := unix.Kill(pid, 0)
+	// EPERM means the process exists but belongs to another user
+	return err == nil || err == unix.EPERM
+}
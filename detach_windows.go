@@ -0,0 +1,46 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+
+	"golang.org/x/sys/windows"
+)
+
+// GetExitCodeProcess reports this instead of an exit code while the process runs
+const stillActive = 259
+
+// Windows consoles don't send SIGHUP, so there's nothing to detach from
+const hangupSupported = false
+
+func detachProcessGroup(cmd *exec.Cmd) {}
+
+func signalProcessGroup(pid int, sig os.Signal) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
+
+// processGroupAlive is processAlive, as processes aren't started in groups of their own here
+func processGroupAlive(pid int) bool {
+	return processAlive(pid)
+}
+
+// processAlive reports whether pid is still running. Signal(0) isn't supported on Windows, so this asks for the
+// process's exit code instead.
+func processAlive(pid int) bool {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// Access is denied for processes of other users, which are still running
+		return err == windows.ERROR_ACCESS_DENIED
+	}
+	defer windows.CloseHandle(handle)
+
+	var code uint32
+	err = windows.GetExitCodeProcess(handle, &code)
+	return err == nil && code == stillActive
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// How long a tool's version command may take before doctor gives up on it
+const doctorVersionTimeout = 10 * time.Second
+
+// Matches the first version number in a tool's version output, e.g. "git version 2.39.2" or "Client Version: v1.28.2"
+var toolVersionPattern = regexp.MustCompile(`v?(\d+\.\d+(?:\.\d+)?)`)
+
+// dependency is an external tool k1space shells out to
+type dependency struct {
+	Name        string
+	VersionArgs []string
+	// MinVersion is the oldest version known to work, "" if any will do
+	MinVersion string
+	UsedFor    string
+	// Required tools are needed by the core menus; the others only by the features listed in UsedFor
+	Required bool
+	install  map[string]string
+	// packages names the tool for each package manager that can install it, see packageManagers
+	packages map[string]string
+}
+
+var dependencies = []dependency{
+	{
+		Name: "git", VersionArgs: []string{"--version"}, MinVersion: "2.25.0", Required: true,
+		UsedFor: "cloning and syncing the kubefirst repositories",
+		install: map[string]string{
+			"darwin":  "brew install git",
+			"linux":   "install git from your distribution's packages, e.g. sudo apt install git",
+			"windows": "winget install -e --id Git.Git",
+		},
+		packages: map[string]string{"brew": "git", "apt": "git"},
+	},
+	{
+		Name: "go", VersionArgs: []string{"version"}, MinVersion: "1.22.0", Required: true,
+		UsedFor: "building kubefirst and running kubefirst-api",
+		install: map[string]string{
+			"darwin":  "brew install go",
+			"linux":   "download it from https://go.dev/dl/",
+			"windows": "winget install -e --id GoLang.Go",
+		},
+		packages: map[string]string{"brew": "go", "asdf": "golang", "apt": "golang-go"},
+	},
+	{
+		Name: "kubectl", VersionArgs: []string{"version", "--client"}, MinVersion: "1.25.0", Required: true,
+		UsedFor: "health checks, resource snapshots and observability",
+		install: map[string]string{
+			"darwin":  "brew install kubectl",
+			"linux":   "see https://kubernetes.io/docs/tasks/tools/install-kubectl-linux/",
+			"windows": "winget install -e --id Kubernetes.kubectl",
+		},
+		packages: map[string]string{"brew": "kubectl", "asdf": "kubectl"},
+	},
+	{
+		Name: "kubefirst", MinVersion: "2.4.0",
+		UsedFor: "provisioning clusters, unless a config uses the kubefirst built from the managed repository",
+		install: map[string]string{
+			"darwin":  "brew install konstructio/taps/kubefirst, or build it with 'Kubefirst' -> 'Setup Kubefirst'",
+			"linux":   "download a release from https://github.com/konstructio/kubefirst/releases, or build it with 'Kubefirst' -> 'Setup Kubefirst'",
+			"windows": "download a release from https://github.com/konstructio/kubefirst/releases, or build it with 'Kubefirst' -> 'Setup Kubefirst'",
+		},
+		packages: map[string]string{"brew": "konstructio/taps/kubefirst"},
+	},
+	{
+		Name: "k3d", VersionArgs: []string{"version"}, MinVersion: "5.0.0",
+		UsedFor: "local k3d clusters and the k3d registry",
+		install: map[string]string{
+			"darwin":  "brew install k3d",
+			"linux":   "curl -s https://raw.githubusercontent.com/k3d-io/k3d/main/install.sh | bash",
+			"windows": "choco install k3d",
+		},
+		packages: map[string]string{"brew": "k3d", "asdf": "k3d"},
+	},
+	{
+		Name: "yarn", VersionArgs: []string{"--version"}, MinVersion: "1.22.0",
+		UsedFor: "running the console",
+		install: map[string]string{
+			"darwin":  "npm install -g yarn",
+			"linux":   "npm install -g yarn",
+			"windows": "npm install -g yarn",
+		},
+		packages: map[string]string{"brew": "yarn", "asdf": "yarn"},
+	},
+	{
+		Name: "air", VersionArgs: []string{"-v"}, MinVersion: "1.49.0",
+		UsedFor: "live reloading kubefirst-api",
+		install: map[string]string{
+			"darwin":  "go install github.com/air-verse/air@latest",
+			"linux":   "go install github.com/air-verse/air@latest",
+			"windows": "go install github.com/air-verse/air@latest",
+		},
+		packages: map[string]string{"go": "github.com/air-verse/air@latest"},
+	},
+	{
+		Name: "swag", VersionArgs: []string{"--version"}, MinVersion: "1.16.0",
+		UsedFor: "regenerating kubefirst-api's Swagger docs",
+		install: map[string]string{
+			"darwin":  "go install github.com/swaggo/swag/cmd/swag@latest",
+			"linux":   "go install github.com/swaggo/swag/cmd/swag@latest",
+			"windows": "go install github.com/swaggo/swag/cmd/swag@latest",
+		},
+		packages: map[string]string{"go": "github.com/swaggo/swag/cmd/swag@latest"},
+	},
+	{
+		Name: "op", VersionArgs: []string{"--version"}, MinVersion: "2.0.0",
+		UsedFor: "1Password secret references",
+		install: map[string]string{
+			"darwin":  "brew install 1password-cli",
+			"linux":   "see https://developer.1password.com/docs/cli/get-started/",
+			"windows": "winget install -e --id AgileBits.1Password.CLI",
+		},
+		packages: map[string]string{"brew": "--cask 1password-cli"},
+	},
+	{
+		Name: "doctl", VersionArgs: []string{"version"}, MinVersion: "1.90.0",
+		UsedFor: "working with DigitalOcean clusters by hand",
+		install: map[string]string{
+			"darwin":  "brew install doctl",
+			"linux":   "snap install doctl, or download a release from https://github.com/digitalocean/doctl/releases",
+			"windows": "winget install -e --id DigitalOcean.Doctl",
+		},
+		packages: map[string]string{"brew": "doctl", "asdf": "doctl"},
+	},
+	{
+		Name: "civo", VersionArgs: []string{"version"}, MinVersion: "1.0.0",
+		UsedFor: "working with Civo clusters by hand",
+		install: map[string]string{
+			"darwin":  "brew tap civo/tools && brew install civo",
+			"linux":   "curl -sL https://civo.com/get | sh",
+			"windows": "choco install civo-cli",
+		},
+		packages: map[string]string{"brew": "civo/tools/civo", "asdf": "civo"},
+	},
+	{
+		Name: "make", VersionArgs: []string{"--version"},
+		UsedFor: "kubefirst-api's make targets, like updateswagger",
+		install: map[string]string{
+			"darwin":  "xcode-select --install",
+			"linux":   "install make from your distribution's packages, e.g. sudo apt install make",
+			"windows": "choco install make",
+		},
+		packages: map[string]string{"apt": "make"},
+	},
+	{
+		Name: "terraform", VersionArgs: []string{"version"}, MinVersion: "1.3.0",
+		UsedFor: "deprovisioning, air-gapped bundles and the provider cache",
+		install: map[string]string{
+			"darwin":  "brew install hashicorp/tap/terraform",
+			"linux":   "see https://developer.hashicorp.com/terraform/install",
+			"windows": "winget install -e --id Hashicorp.Terraform",
+		},
+		packages: map[string]string{"brew": "hashicorp/tap/terraform", "asdf": "terraform"},
+	},
+	{
+		Name: "kind", VersionArgs: []string{"version"}, MinVersion: "0.20.0",
+		UsedFor: "local kind clusters",
+		install: map[string]string{
+			"darwin":  "brew install kind",
+			"linux":   "go install sigs.k8s.io/kind@latest",
+			"windows": "winget install -e --id Kubernetes.kind",
+		},
+		packages: map[string]string{"brew": "kind", "go": "sigs.k8s.io/kind@latest", "asdf": "kind"},
+	},
+}
+
+// Outcomes of checking a dependency
+const (
+	doctorOK       = "ok"
+	doctorOutdated = "outdated"
+	doctorMissing  = "missing"
+	doctorUnknown  = "unknown version"
+)
+
+type doctorResult struct {
+	Dependency dependency
+	Path       string
+	Version    string
+	Status     string
+}
+
+// problem reports whether the result needs fixing: a missing or outdated required tool, or an outdated optional one
+func (r doctorResult) problem() bool {
+	return r.Status == doctorOutdated || (r.Status == doctorMissing && r.Dependency.Required)
+}
+
+// toolVersion runs a tool's version command and picks the version out of its output
+func toolVersion(dep dependency, path string) string {
+	if dep.Name == "kubefirst" {
+		cli, err := detectKubefirstCLI(path)
+		if err != nil {
+			return ""
+		}
+		return cli.Version
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), doctorVersionTimeout)
+	defer cancel()
+	output, _ := exec.CommandContext(ctx, path, dep.VersionArgs...).CombinedOutput()
+	match := toolVersionPattern.FindStringSubmatch(string(output))
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+func checkDependency(dep dependency) doctorResult {
+	result := doctorResult{Dependency: dep}
+	path, err := exec.LookPath(dep.Name)
+	if err != nil {
+		result.Status = doctorMissing
+		return result
+	}
+	result.Path = path
+	result.Version = toolVersion(dep, path)
+	switch {
+	case result.Version == "":
+		result.Status = doctorUnknown
+	case dep.MinVersion != "" && compareKubefirstVersions(result.Version, dep.MinVersion) < 0:
+		result.Status = doctorOutdated
+	default:
+		result.Status = doctorOK
+	}
+	return result
+}
+
+// runDoctorChecks checks every dependency
+func runDoctorChecks() []doctorResult {
+	results := make([]doctorResult, len(dependencies))
+	for i, dep := range dependencies {
+		results[i] = checkDependency(dep)
+		log.Debug("Checked dependency", "name", dep.Name, "status", results[i].Status, "version", results[i].Version)
+	}
+	return results
+}
+
+func doctorStatusLabel(result doctorResult) string {
+	switch result.Status {
+	case doctorOK:
+		return "✅ ok"
+	case doctorOutdated:
+		return "⚠️ outdated"
+	case doctorUnknown:
+		return "❔ unknown version"
+	case doctorMissing:
+		if result.Dependency.Required {
+			return "❌ missing"
+		}
+		return "➖ not installed"
+	}
+	return result.Status
+}
+
+// doctorHint says how to fix a result, "" when there's nothing to fix
+func doctorHint(result doctorResult) string {
+	install := result.Dependency.install[runtime.GOOS]
+	switch result.Status {
+	case doctorMissing:
+		return fmt.Sprintf("%s is used for %s. To install it, %s", result.Dependency.Name, result.Dependency.UsedFor, install)
+	case doctorOutdated:
+		return fmt.Sprintf("%s %s is older than %s, which %s needs. To upgrade it, %s", result.Dependency.Name, result.Version, result.Dependency.MinVersion, result.Dependency.UsedFor, install)
+	case doctorUnknown:
+		return fmt.Sprintf("%s at %s didn't report a version; check that it runs", result.Dependency.Name, result.Path)
+	}
+	return ""
+}
+
+// printDoctorReport shows each dependency's status, then how to fix what's missing or outdated
+func printDoctorReport(results []doctorResult) {
+	rows := [][]string{{"Tool", "Status", "Version", "Minimum", "Used For"}}
+	for _, result := range results {
+		rows = append(rows, []string{result.Dependency.Name, doctorStatusLabel(result), result.Version, result.Dependency.MinVersion, result.Dependency.UsedFor})
+	}
+	printSummaryTable("Environment", rows)
+	if isStructuredOutput() {
+		return
+	}
+
+	var hints []string
+	for _, result := range results {
+		if hint := doctorHint(result); hint != "" {
+			hints = append(hints, hint)
+		}
+	}
+	if len(hints) == 0 {
+		fmt.Println("\nEverything k1space uses is installed and up to date.")
+		return
+	}
+	fmt.Println("\nTo fix:")
+	for _, hint := range hints {
+		fmt.Println("- " + hint)
+	}
+}
+
+// runDoctor is the 'Doctor' menu item
+func runDoctor() {
+	s := startSpinner("Checking dependencies...")
+	results := runDoctorChecks()
+	stopSpinner(s, true)
+	printDoctorReport(results)
+	if !isStructuredOutput() {
+		offerDoctorInstalls(results)
+	}
+}
+
+// runDoctorCommand is 'k1space doctor'. It exits with status 1 when a required tool is missing or any is outdated,
+// so it can gate CI jobs and setup scripts.
+func runDoctorCommand() int {
+	results := runDoctorChecks()
+	printDoctorReport(results)
+	for _, result := range results {
+		if result.problem() {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+func duplicateConfig() {
+	log.Info("Starting duplicateConfig function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations. Please ensure that the config.hcl file exists and is correctly formatted.")
+		return
+	}
+
+	sourceConfig, err := promptConfigSelection(indexFile, "Select a configuration to duplicate")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if sourceConfig == "" {
+		fmt.Println("No configurations found.")
+		return
+	}
+
+	parts := strings.Split(sourceConfig, "_")
+	if len(parts) != 3 {
+		log.Error("Invalid config name format", "config", sourceConfig)
+		fmt.Println("Invalid configuration name format. Duplication cancelled.")
+		return
+	}
+	cloud, region, prefix := parts[0], parts[1], parts[2]
+
+	config, err := loadCloudConfig(sourceConfig)
+	if err != nil {
+		log.Error("Error reading source config", "config", sourceConfig, "error", err)
+		fmt.Println("Failed to read the source configuration:", err)
+		return
+	}
+	if config.CloudPrefix == "K3s" {
+		fmt.Println("K3s configs target specific hosts rather than regions. Use 'Create Config' instead.")
+		return
+	}
+
+	cloudsFile, err := loadCloudsFile()
+	if err != nil {
+		log.Error("Error loading clouds file", "error", err)
+		return
+	}
+
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		return
+	}
+
+	newRegion := region
+	if value, ok := config.Flags.Load("cloud-region"); ok {
+		newRegion = value.(string)
+	}
+	newPrefix := prefix
+	var clusterName string
+	if value, ok := config.Flags.Load("cluster-name"); ok {
+		clusterName = value.(string)
+	}
+
+	var regionField huh.Field
+	if regionOptions := getRegionOptions(config.CloudPrefix, cloudsFile, nil); len(regionOptions) > 0 {
+		regionField = newSelect("Select the region for the new config", &newRegion, regionOptions...)
+	} else {
+		regionField = newInput("Enter the region for the new config", &newRegion)
+	}
+
+	fields := []huh.Field{
+		regionField,
+		newInput("Enter static prefix", &newPrefix).
+			Description(fmt.Sprintf("Copying %s", sourceConfig)).
+			Validate(settings.NamingPolicy.validateStaticPrefix),
+	}
+	if clusterName != "" {
+		fields = append(fields, newInput("Enter cluster name", &clusterName).
+			Validate(func(name string) error {
+				return settings.NamingPolicy.validateClusterName(config.CloudPrefix, name)
+			}))
+	}
+
+	err = runForm(newForm(newGroup(fields...)))
+	if err != nil {
+		log.Error("Error in duplicate config form", "error", err)
+		return
+	}
+
+	newConfig := fmt.Sprintf("%s_%s_%s", cloud, strings.ToLower(newRegion), newPrefix)
+	if _, exists := indexFile.Configs[newConfig]; exists {
+		fmt.Printf("Configuration '%s' already exists. Choose a different region or prefix.\n", newConfig)
+		return
+	}
+
+	config.Region = newRegion
+	config.StaticPrefix = newPrefix
+	if _, ok := config.Flags.Load("cloud-region"); ok {
+		config.Flags.Store("cloud-region", newRegion)
+	}
+	if clusterName != "" {
+		config.Flags.Store("cluster-name", clusterName)
+	}
+
+	kubefirstPath := ""
+	if value, ok := config.Flags.Load("KUBEFIRST_PATH"); ok {
+		kubefirstPath = value.(string)
+	}
+
+	baseDir, err := writeConfigFiles(config, kubefirstPath)
+	if err != nil {
+		log.Error("Error writing config files", "error", err)
+		return
+	}
+	maintainWorkspaceGit()
+
+	err = updateIndexFile(config)
+	if err != nil {
+		log.Error("Error updating index file", "error", err)
+		return
+	}
+	emitConfigCreated(config)
+
+	err = updateCloudsFile(config)
+	if err != nil {
+		log.Error("Error updating clouds file", "error", err)
+	}
+
+	fmt.Println(style.Render(fmt.Sprintf("✅ Duplicated %s as %s", sourceConfig, newConfig)))
+	fmt.Printf("cd %s && ./00-init.sh\n", baseDir)
+	log.Info("duplicateConfig function completed successfully", "source", sourceConfig, "config", newConfig)
+}
+
+// loadCloudConfig rebuilds a CloudConfig from a config's .local.cloud.env, turning prefixed variables
+// back into kubefirst flags and keeping everything else (e.g. terraform overrides) as env overrides
+func loadCloudConfig(configName string) (*CloudConfig, error) {
+	parts := strings.Split(configName, "_")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid config name %q", configName)
+	}
+	cloud, region, prefix := parts[0], parts[1], parts[2]
+
+	envFilePath := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", cloud, region, prefix, ".local.cloud.env")
+	envContent, err := os.ReadFile(envFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading .local.cloud.env: %w", err)
+	}
+
+	config := NewCloudConfig()
+	config.StaticPrefix = prefix
+	config.Region = region
+	config.CloudPrefix = cloud
+	for _, provider := range cloudProviders {
+		if strings.EqualFold(provider, cloud) {
+			config.CloudPrefix = provider
+			break
+		}
+	}
+
+	envPrefix := configEnvPrefix(configName)
+	for _, line := range strings.Split(string(envContent), "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		name := strings.TrimPrefix(kv[0], "export ")
+		value := strings.Trim(kv[1], "\"")
+
+		switch {
+		case name == "KUBEFIRST_PATH":
+			config.Flags.Store(name, value)
+		case strings.HasPrefix(name, envPrefix):
+			flag := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(name, envPrefix), "_", "-"))
+			if flag == "kubefirst-path" {
+				continue
+			}
+			config.Flags.Store(flag, value)
+			if flag == "node-type" {
+				config.SelectedNodeType = value
+				config.Architecture = detectArchitecture(value)
+			}
+		default:
+			setEnvOverride(config, name, value)
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+// editorFallbacks are tried in order when neither $VISUAL nor $EDITOR names an installed editor
+func editorFallbacks() []string {
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"nano", "vim", "vi"}
+}
+
+// editorCommand returns the editor to run and its arguments, preferring $VISUAL over $EDITOR. Both may carry
+// arguments, e.g. EDITOR="code --wait".
+func editorCommand() ([]string, error) {
+	for _, variable := range []string{"VISUAL", "EDITOR"} {
+		args := strings.Fields(os.Getenv(variable))
+		if len(args) == 0 {
+			continue
+		}
+		if _, err := exec.LookPath(args[0]); err == nil {
+			return args, nil
+		}
+		log.Warn("Editor not found, trying the next one", "variable", variable, "editor", args[0])
+	}
+	for _, editor := range editorFallbacks() {
+		if _, err := exec.LookPath(editor); err == nil {
+			return []string{editor}, nil
+		}
+	}
+	return nil, fmt.Errorf("no editor found; set $EDITOR, e.g. export EDITOR=vim")
+}
+
+// openInEditor opens path in the user's editor and waits for it to exit
+func openInEditor(path string) error {
+	args, err := editorCommand()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("error running %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// fileDigest returns a hash of path's content, or "" if it can't be read
+func fileDigest(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+func openConfigInEditor() {
+	log.Info("Starting openConfigInEditor function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations. Please ensure that the config.hcl file exists and is correctly formatted.")
+		return
+	}
+	selectedConfig, err := promptConfigSelection(indexFile, "Select a configuration to edit")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations available. Please create a configuration first.")
+		return
+	}
+
+	var fileOptions []huh.Option[string]
+	for _, file := range indexFile.Configs[selectedConfig].Files {
+		path := filepath.FromSlash(file)
+		if _, err := os.Stat(path); err == nil {
+			fileOptions = append(fileOptions, huh.NewOption(filepath.Base(path), path))
+		}
+	}
+	if len(fileOptions) == 0 {
+		fmt.Println("None of this configuration's files exist. Recreate the configuration first.")
+		return
+	}
+
+	var path string
+	err = runField(newSelect("Select the file to edit", &path, fileOptions...))
+	if err != nil {
+		log.Error("Error in file selection", "error", err)
+		return
+	}
+
+	before := fileDigest(path)
+	err = openInEditor(path)
+	if err != nil {
+		log.Error("Error opening editor", "path", path, "error", err)
+		fmt.Println(err)
+		fmt.Println("Edit the file yourself:", path)
+		return
+	}
+	if fileDigest(path) == before {
+		fmt.Printf("No changes to %s.\n", filepath.Base(path))
+		return
+	}
+
+	switch {
+	case filepath.Base(path) == ".local.cloud.env":
+		reindexConfigFlags(selectedConfig, path)
+	case strings.HasSuffix(path, ".sh"):
+		checkScriptSyntax(path)
+	default:
+		fmt.Printf("Saved changes to %s.\n", filepath.Base(path))
+	}
+}
+
+// reindexConfigFlags reloads a config's flags in config.hcl from its edited env file, then validates them
+func reindexConfigFlags(configName, envFilePath string) {
+	flags, err := readEnvFileFlags(envFilePath)
+	if err != nil {
+		log.Error("Error reading edited env file", "path", envFilePath, "error", err)
+		fmt.Println("Failed to read the edited env file:", err)
+		return
+	}
+
+	// Updated under the lock in case anything else saved config.hcl while the editor was open
+	var config Config
+	err = updateIndex(func(indexFile *IndexFile) error {
+		config = indexFile.Configs[configName]
+		config.Flags = flags
+		indexFile.Configs[configName] = config
+		return nil
+	})
+	if err != nil {
+		log.Error("Error updating index file", "error", err)
+		fmt.Println("Failed to update config.hcl:", err)
+		return
+	}
+	fmt.Printf("Updated %d flags for %s in config.hcl.\n", len(flags), configName)
+
+	reportConfigValidation(configName, config)
+}
+
+// checkScriptSyntax has bash parse an edited script without running it
+func checkScriptSyntax(path string) {
+	output, err := exec.Command("bash", "-n", path).CombinedOutput()
+	if err != nil {
+		log.Error("Edited script has syntax errors", "path", path, "error", err)
+		fmt.Printf("%s has syntax errors:\n%s", filepath.Base(path), output)
+		return
+	}
+	fmt.Printf("Saved changes to %s. Syntax check passed.\n", filepath.Base(path))
+}
+
+func openRepositoryInEditor() {
+	log.Info("Starting openRepositoryInEditor function")
+
+	repoDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".repositories")
+	var repoOptions []huh.Option[string]
+	for _, repo := range []string{"kubefirst", "console", "kubefirst-api"} {
+		if _, err := os.Stat(filepath.Join(repoDir, repo)); err == nil {
+			repoOptions = append(repoOptions, huh.NewOption(repo, repo))
+		}
+	}
+	if len(repoOptions) == 0 {
+		fmt.Println("No repositories found. Use 'Clone Repositories' first.")
+		return
+	}
+
+	var repo string
+	err := runField(newSelect("Select the repository to open", &repo, repoOptions...))
+	if err != nil {
+		log.Error("Error in repository selection", "error", err)
+		return
+	}
+	repoPath := filepath.Join(repoDir, repo)
+
+	before := gitStatusLines(repoPath)
+	err = openInEditor(repoPath)
+	if err != nil {
+		log.Error("Error opening editor", "path", repoPath, "error", err)
+		fmt.Println(err)
+		fmt.Println("Open the repository yourself:", repoPath)
+		return
+	}
+
+	var changed []string
+	for _, line := range gitStatusLines(repoPath) {
+		if !contains(before, line) {
+			changed = append(changed, line)
+		}
+	}
+	if len(changed) == 0 {
+		fmt.Printf("No new changes in %s.\n", repo)
+		return
+	}
+	summary := [][]string{{"Status", "File"}}
+	for _, line := range changed {
+		summary = append(summary, []string{strings.TrimSpace(line[:2]), strings.TrimSpace(line[2:])})
+	}
+	printSummaryTable(fmt.Sprintf("Changed in %s", repo), summary)
+	fmt.Println("Run 'Verify Before Push' to lint and test them.")
+}
+
+// gitStatusLines returns a repository's `git status --porcelain` lines, or nil if git can't read it
+func gitStatusLines(repoPath string) []string {
+	output, err := exec.Command("git", "-C", repoPath, "status", "--porcelain").Output()
+	if err != nil {
+		log.Warn("Could not read git status", "repo", repoPath, "error", err)
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if len(line) > 3 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// Hostnames a kubefirst install creates records for under its domain
+var kubefirstHostnames = []string{"argocd", "vault", "kubefirst", "atlantis", "chartmuseum", "metaphor-development"}
+
+// findExistingKubefirstRecords returns the kubefirst hostnames that already resolve under the domain
+func findExistingKubefirstRecords(domain, subdomain string) []string {
+	base := domain
+	if subdomain != "" {
+		base = fmt.Sprintf("%s.%s", subdomain, domain)
+	}
+
+	var existing []string
+	for _, hostname := range kubefirstHostnames {
+		fqdn := fmt.Sprintf("%s.%s", hostname, base)
+		if addrs, err := net.LookupHost(fqdn); err == nil && len(addrs) > 0 {
+			existing = append(existing, fmt.Sprintf("%s -> %s", fqdn, strings.Join(addrs, ", ")))
+		}
+	}
+	return existing
+}
+
+// confirmDomainNotInUse warns when the config's domain already serves a kubefirst install and asks whether to continue
+func confirmDomainNotInUse(config Config) bool {
+	domain := findConfigFlag(config.Flags, "domain-name")
+	if domain == "" {
+		return true
+	}
+	subdomain := findConfigFlag(config.Flags, "subdomain")
+
+	s := startSpinner(fmt.Sprintf("Checking whether %s is already in use...", domain))
+	existing := findExistingKubefirstRecords(domain, subdomain)
+	stopSpinner(s, len(existing) == 0)
+
+	if len(existing) == 0 {
+		return true
+	}
+
+	log.Warn("Domain already serving a kubefirst install", "domain", domain, "records", existing)
+	fmt.Println(style.Render("⚠️  This domain looks like it already hosts a kubefirst install"))
+	for _, record := range existing {
+		fmt.Printf("  - %s\n", record)
+	}
+	fmt.Println("Provisioning will overwrite these records, which may belong to a teammate's environment.")
+	fmt.Println("Consider a different domain or the subdomain flag.")
+
+	var proceed bool
+	err := runField(newConfirm("Continue provisioning and overwrite the existing records?", &proceed))
+	if err != nil {
+		log.Error("Error in confirmation prompt", "error", err)
+		return false
+	}
+	return proceed
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+const (
+	failoverPrimary   = "primary"
+	failoverSecondary = "secondary"
+	failoverRunbook   = "FAILOVER.md"
+)
+
+// FailoverPair links a config to its disaster recovery counterpart, kept in a failover block per config in
+// config.hcl. Both configs of a pair carry one, pointing at each other.
+type FailoverPair struct {
+	Role string `hcl:"role"`
+	Peer string `hcl:"peer"`
+}
+
+// describe formats the pair for listings, e.g. "secondary of civo_nyc1_k1"
+func (p *FailoverPair) describe() string {
+	if p == nil {
+		return ""
+	}
+	if p.Role == failoverSecondary {
+		return "secondary of " + p.Peer
+	}
+	return "primary, recovers to " + p.Peer
+}
+
+// Flags that depend on where the cluster runs, so they're asked again for the DR config instead of copied
+var failoverLocalFlags = []string{"cloud-region", "cloud-zone", "node-type", "cluster-name"}
+
+// unlinkFailoverPeer drops the failover block of a config's peer, for when the config itself goes away
+func unlinkFailoverPeer(indexFile *IndexFile, configName string) {
+	pair := indexFile.Configs[configName].Failover
+	if pair == nil {
+		return
+	}
+	if peer, ok := indexFile.Configs[pair.Peer]; ok && peer.Failover != nil && peer.Failover.Peer == configName {
+		peer.Failover = nil
+		indexFile.Configs[pair.Peer] = peer
+	}
+}
+
+// createFailoverPair creates a disaster recovery config for an existing one in another region or cloud, links
+// the two in config.hcl and writes a failover runbook next to the DR config's scripts
+func createFailoverPair() {
+	log.Info("Starting createFailoverPair function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations. Please ensure that the config.hcl file exists and is correctly formatted.")
+		return
+	}
+
+	sourceConfig, err := promptConfigSelection(indexFile, "Select the primary configuration to pair a DR cluster with")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if sourceConfig == "" {
+		fmt.Println("No configurations found.")
+		return
+	}
+	if pair := indexFile.Configs[sourceConfig].Failover; pair != nil {
+		fmt.Printf("%s is already the %s. Delete %s first to pair it again.\n", sourceConfig, pair.describe(), pair.Peer)
+		return
+	}
+
+	source, err := loadCloudConfig(sourceConfig)
+	if err != nil {
+		log.Error("Error reading source config", "config", sourceConfig, "error", err)
+		fmt.Println("Failed to read the source configuration:", err)
+		return
+	}
+	if source.CloudPrefix == "K3s" {
+		fmt.Println("K3s configs target specific hosts rather than regions, so they can't be paired with a DR cluster.")
+		return
+	}
+
+	cloudsFile, err := loadCloudsFile()
+	if err != nil {
+		log.Error("Error loading clouds file", "error", err)
+		return
+	}
+
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		return
+	}
+
+	cloudProvider := source.CloudPrefix
+	var providerOptions []huh.Option[string]
+	for _, option := range getCloudProviderOptions() {
+		if option.Value != "K3s" {
+			providerOptions = append(providerOptions, option)
+		}
+	}
+	err = runField(newSelect("Select the cloud provider for the DR cluster", &cloudProvider, providerOptions...).
+		Description(fmt.Sprintf("%s runs on %s in %s", sourceConfig, source.CloudPrefix, source.Region)))
+	if err != nil {
+		log.Error("Error in cloud provider selection", "error", err)
+		return
+	}
+	sameCloud := cloudProvider == source.CloudPrefix
+
+	previousProfile := ""
+	if sameCloud {
+		previousProfile = configCredentialProfile(indexFile.Configs[sourceConfig])
+	}
+	profile, err := promptCredentialProfile(cloudProvider, settings, previousProfile)
+	if err != nil {
+		log.Error("Error in credential profile selection", "error", err)
+		return
+	}
+	useCredentialProfile(cloudProvider, profile)
+
+	tokenExists, message := checkRequiredTokens(cloudProvider)
+	if !tokenExists {
+		log.Error("Missing required token", "cloud", cloudProvider)
+		fmt.Println(message)
+		return
+	}
+
+	err = ensureCloudData(cloudProvider, &cloudsFile)
+	if err != nil {
+		log.Error("Error updating cloud data", "cloud", cloudProvider, "error", err)
+		fmt.Println(err)
+		return
+	}
+
+	kubefirstPath := ""
+	if value, ok := source.Flags.Load("KUBEFIRST_PATH"); ok {
+		kubefirstPath = value.(string)
+	}
+	flags, err := fetchKubefirstFlags(kubefirstPath, cloudProvider)
+	if err != nil {
+		log.Error("Error fetching kubefirst flags", "error", err)
+		return
+	}
+
+	config := NewCloudConfig()
+	config.CloudPrefix = cloudProvider
+	config.CredentialProfile = profile
+	config.Flags.Store("KUBEFIRST_PATH", kubefirstPath)
+	// Terraform overrides and the like are specific to the provider they were written for
+	if sameCloud {
+		for name, value := range source.EnvOverrides {
+			setEnvOverride(config, name, value)
+		}
+	}
+
+	// Copy what the DR cloud's kubefirst command accepts, and note what it doesn't
+	var dropped []string
+	source.Flags.Range(func(key, value any) bool {
+		flag := key.(string)
+		if flag == "KUBEFIRST_PATH" || contains(failoverLocalFlags, flag) {
+			return true
+		}
+		if _, ok := flags[flag]; !ok {
+			dropped = append(dropped, flag)
+			return true
+		}
+		config.Flags.Store(flag, value.(string))
+		return true
+	})
+	sort.Strings(dropped)
+
+	var sourceTopology *NodeTopology
+	if topology := indexFile.Configs[sourceConfig].Topology; topology != nil {
+		// Managed control planes don't carry over between providers
+		sourceTopology = &NodeTopology{NodeCount: topology.NodeCount, HAControlPlane: sameCloud && topology.HAControlPlane}
+	}
+	topologyFlags, err := promptNodeTopology(config, flags, sourceTopology)
+	if err != nil {
+		log.Error("Error in node topology prompt", "error", err)
+		return
+	}
+
+	staticPrefix := source.StaticPrefix
+	clusterName := ""
+	if value, ok := source.Flags.Load("cluster-name"); ok && value.(string) != "" {
+		clusterName = value.(string) + "-dr"
+	}
+	nodeType := ""
+	if sameCloud {
+		nodeType = source.SelectedNodeType
+	}
+
+	fieldCtx := flagFieldContext{
+		CloudProvider: cloudProvider,
+		CloudsFile:    cloudsFile,
+		ClusterNameValidator: func(name string) error {
+			return settings.NamingPolicy.validateClusterName(cloudProvider, name)
+		},
+		FlagDocs: loadFlagDocs(cloudProvider),
+	}
+	flagInputs := make([]struct{ Name, Value string }, 0, len(flags))
+	fields := []huh.Field{
+		newInput("Enter static prefix", &staticPrefix).
+			Description(fmt.Sprintf("Pairing with %s", sourceConfig)).
+			Validate(settings.NamingPolicy.validateStaticPrefix),
+	}
+	for _, flag := range sortedFlagNames(flags) {
+		_, copied := config.Flags.Load(flag)
+		if copied || contains(topologyFlags, flag) {
+			continue
+		}
+		// Flags only the DR cloud has are asked for too, e.g. google-project when moving to Google
+		defaultValue := ""
+		switch flag {
+		case "cluster-name":
+			defaultValue = clusterName
+		case "node-type":
+			defaultValue = nodeType
+		case "cloud-region":
+			if sameCloud {
+				defaultValue = source.Region
+			}
+		}
+		flagInputs = append(flagInputs, struct{ Name, Value string }{Name: flag, Value: defaultValue})
+		fields = append(fields, newFlagField(flag, flags[flag], fieldCtx, &flagInputs[len(flagInputs)-1].Value, defaultValue))
+	}
+
+	err = runForm(newForm(newGroup(fields...)))
+	if err != nil {
+		log.Error("Error in failover config form", "error", err)
+		return
+	}
+
+	config.StaticPrefix = staticPrefix
+	for _, fi := range flagInputs {
+		config.Flags.Store(fi.Name, fi.Value)
+		switch fi.Name {
+		case "node-type":
+			if nodeParts := strings.Fields(fi.Value); len(nodeParts) > 0 {
+				config.Flags.Store(fi.Name, nodeParts[0])
+				config.SelectedNodeType = nodeParts[0]
+				if instance, ok := findInstanceSize(cloudProvider, nodeParts[0], cloudsFile); ok {
+					config.Architecture = instance.Architecture
+				}
+			}
+		case "cloud-region":
+			config.Region = fi.Value
+		}
+	}
+	if config.Region == "" {
+		fmt.Println("The DR cluster needs a region. Failover pair creation cancelled.")
+		return
+	}
+	if sameCloud && strings.EqualFold(config.Region, source.Region) {
+		fmt.Printf("%s already runs in %s; choose another region so an outage there doesn't take out both clusters.\n", sourceConfig, source.Region)
+		return
+	}
+
+	drConfig := fmt.Sprintf("%s_%s_%s", strings.ToLower(cloudProvider), strings.ToLower(config.Region), staticPrefix)
+	if _, exists := indexFile.Configs[drConfig]; exists {
+		fmt.Printf("Configuration '%s' already exists. Choose a different region or prefix.\n", drConfig)
+		return
+	}
+	warnIfArchitectureUnsupported(config.Architecture)
+
+	baseDir, err := writeConfigFiles(config, kubefirstPath)
+	if err != nil {
+		log.Error("Error writing config files", "error", err)
+		return
+	}
+	maintainWorkspaceGit()
+
+	var primary, secondary Config
+	err = updateIndex(func(indexFile *IndexFile) error {
+		err := addConfigToIndex(config, indexFile)
+		if err != nil {
+			return fmt.Errorf("error adding config to index: %w", err)
+		}
+		primary = indexFile.Configs[sourceConfig]
+		primary.Failover = &FailoverPair{Role: failoverPrimary, Peer: drConfig}
+		indexFile.Configs[sourceConfig] = primary
+		secondary = indexFile.Configs[drConfig]
+		secondary.Failover = &FailoverPair{Role: failoverSecondary, Peer: sourceConfig}
+		indexFile.Configs[drConfig] = secondary
+		return nil
+	})
+	if err != nil {
+		log.Error("Error updating index file", "error", err)
+		return
+	}
+	emitConfigCreated(config)
+
+	err = updateCloudsFile(config)
+	if err != nil {
+		log.Error("Error updating clouds file", "error", err)
+	}
+
+	runbookPath := filepath.Join(baseDir, failoverRunbook)
+	err = os.WriteFile(runbookPath, []byte(renderFailoverRunbook(sourceConfig, primary, drConfig, secondary, baseDir)), 0644)
+	if err != nil {
+		log.Error("Error writing failover runbook", "error", err)
+		fmt.Println("Failed to write the failover runbook:", err)
+	}
+
+	fmt.Println(style.Render(fmt.Sprintf("✅ Paired %s with DR config %s", sourceConfig, drConfig)))
+	if len(dropped) > 0 {
+		fmt.Printf("Not copied, %s's kubefirst command has no such flags: %s\n", cloudProvider, strings.Join(dropped, ", "))
+	}
+	if err == nil {
+		fmt.Printf("Failover runbook: %s\n", runbookPath)
+	}
+	log.Info("createFailoverPair function completed successfully", "primary", sourceConfig, "secondary", drConfig)
+}
+
+func sortedFlagNames(flags map[string]string) []string {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// renderFailoverRunbook documents how to move traffic from the primary to the DR cluster and back
+func renderFailoverRunbook(primaryName string, primary Config, secondaryName string, secondary Config, secondaryDir string) string {
+	flag := func(config Config, name string) string {
+		if value := findConfigFlag(config.Flags, name); value != "" {
+			return value
+		}
+		return "-"
+	}
+	row := func(role, name string, config Config) string {
+		parts := strings.Split(name, "_")
+		return fmt.Sprintf("| %s | `%s` | %s | %s | %s | %s | %s |\n", role, name, parts[0], parts[1],
+			flag(config, "cluster-name"), flag(config, "node-type"), flag(config, "domain-name"))
+	}
+	domain := findConfigFlag(secondary.Flags, "domain-name")
+	if domain == "" {
+		domain = "<domain>"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Failover runbook: %s → %s\n\n", primaryName, secondaryName))
+	sb.WriteString(fmt.Sprintf("Generated by k1space on %s. It describes both configs as they were that day.\n\n", time.Now().Format("2006-01-02")))
+	sb.WriteString("| Role | Config | Cloud | Region | Cluster | Node type | Domain |\n")
+	sb.WriteString("|------|--------|-------|--------|---------|-----------|--------|\n")
+	sb.WriteString(row("Primary", primaryName, primary))
+	sb.WriteString(row("DR", secondaryName, secondary))
+
+	sb.WriteString("\n## Before an incident\n\n")
+	sb.WriteString(fmt.Sprintf("- The DR cluster is not provisioned until you fail over. Keep `%s` in step with `%s` when the primary's flags change ('Open Config in Editor').\n", secondaryName, primaryName))
+	sb.WriteString(fmt.Sprintf("- Check the DR cloud token with 'Check Token Permissions' on `%s`, and again whenever tokens are rotated.\n", secondaryName))
+	sb.WriteString("- Back up what the gitops repository doesn't hold: Vault secrets and persistent volumes (e.g. with Velero), to storage that survives losing the primary's cloud or region.\n")
+	sb.WriteString("- Rehearse this runbook against a non-production pair and time it.\n")
+
+	sb.WriteString("\n## Failing over\n\n")
+	sb.WriteString(fmt.Sprintf("1. Confirm the primary is down: run 'Verify Cluster Health' on `%s` and check the provider's status page.\n", primaryName))
+	sb.WriteString("2. Announce the failover and freeze merges to the gitops repository.\n")
+	sb.WriteString("3. kubefirst won't create git repositories that already exist. If the primary's `gitops` and `metaphor` repositories are still there, rename or archive them, or give the DR config its own git owner.\n")
+	sb.WriteString(fmt.Sprintf("4. Provision the DR cluster with 'Provision Cluster' on `%s`, or run:\n\n", secondaryName))
+	sb.WriteString(fmt.Sprintf("   ```sh\n   cd %s && ./00-init.sh && ./01-kubefirst-cloud.sh\n   ```\n\n", secondaryDir))
+	sb.WriteString("5. Restore Vault secrets and persistent volumes from the latest backups.\n")
+	sb.WriteString(fmt.Sprintf("6. Switch DNS for `%s` to the DR cluster. Find its load balancer with\n", domain))
+	sb.WriteString("   `kubectl -n ingress-nginx get svc ingress-nginx-controller`. If the DR cluster manages its own DNS zone, delegate the domain to it at the registrar; external-dns then creates the records.\n")
+	sb.WriteString(fmt.Sprintf("7. Verify with 'Verify Cluster Health' on `%s`, then open `https://kubefirst.%s` and `https://argocd.%s`.\n", secondaryName, domain, domain))
+	sb.WriteString("8. Unfreeze the gitops repository and announce that traffic is served from the DR cluster.\n")
+
+	sb.WriteString("\n## Failing back\n\n")
+	sb.WriteString(fmt.Sprintf("1. Once the primary's cloud has recovered, run 'Deprovision Cluster' on `%s` to clean up what remains of it, then provision it again.\n", primaryName))
+	sb.WriteString("2. Back up the DR cluster's Vault secrets and volumes and restore them on the primary.\n")
+	sb.WriteString("3. Switch DNS back to the primary and verify it as above.\n")
+	sb.WriteString(fmt.Sprintf("4. Run 'Deprovision Cluster' on `%s` so the DR cluster stops costing money.\n", secondaryName))
+	return sb.String()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileLocks serialises goroutines of this process on a path, as the advisory lock only keeps other processes out
+var fileLocks sync.Map
+
+// withFileLock holds an advisory lock on path+".lock" while fn runs, so concurrent k1space processes take turns
+// updating the same state file. Load-modify-save callers re-read the file inside fn, so nothing saved by another
+// process since they last loaded it is lost. The lock isn't reentrant: fn writes with writeFileAtomic, not
+// writeFileLocked.
+func withFileLock(path string, fn func() error) error {
+	mu, _ := fileLocks.LoadOrStore(path, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("error creating directory for %s: %w", filepath.Base(path), err)
+	}
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening lock for %s: %w", filepath.Base(path), err)
+	}
+	defer lock.Close()
+
+	err = lockFileHandle(lock)
+	if err != nil {
+		return fmt.Errorf("error locking %s: %w", filepath.Base(path), err)
+	}
+	defer unlockFileHandle(lock)
+
+	return fn()
+}
+
+// writeFileLocked replaces path under its lock, for files written whole without reading them first
+func writeFileLocked(path string, data []byte, perm os.FileMode) error {
+	return withFileLock(path, func() error {
+		return writeFileAtomic(path, data, perm)
+	})
+}
+
+// writeFileAtomic replaces path by writing to a temp file first, so readers never see a partial file. Callers
+// hold path's lock.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(tmp.Name(), perm)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// kubefirstFlagDeprecation records when a create flag was deprecated, and removed if it has been
+type kubefirstFlagDeprecation struct {
+	Flag        string
+	Replacement string
+	Since       string
+	Removed     string
+}
+
+// kubefirstFlagDeprecations follows the kubefirst changelogs; add an entry when a release deprecates a create
+// flag. An entry with a Replacement is a rename, so configs storing the old flag pass it under the new name.
+var kubefirstFlagDeprecations = []kubefirstFlagDeprecation{
+	{Flag: "admin-email", Replacement: "alerts-email", Since: "2.0.0"},
+	{Flag: "hosted-zone-name", Replacement: "domain-name", Since: "2.0.0"},
+	{Flag: "github-owner", Replacement: "github-org", Since: "2.0.0"},
+}
+
+// flagDeprecationWarning is a stored flag that's deprecated for the selected kubefirst binary
+type flagDeprecationWarning struct {
+	Flag    string `json:"flag" yaml:"flag"`
+	Message string `json:"message" yaml:"message"`
+}
+
+// compareKubefirstVersions compares the numeric major.minor.patch of two versions, ignoring pre-release suffixes
+func compareKubefirstVersions(a, b string) int {
+	pa, pb := versionNumbers(a), versionNumbers(b)
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionNumbers(version string) [3]int {
+	var numbers [3]int
+	version = strings.TrimPrefix(version, "v")
+	version, _, _ = strings.Cut(version, "-")
+	version, _, _ = strings.Cut(version, "+")
+	for i, part := range strings.SplitN(version, ".", 3) {
+		numbers[i], _ = strconv.Atoi(part)
+	}
+	return numbers
+}
+
+// checkFlagDeprecations reports which of flags are deprecated or removed in the given kubefirst version
+func checkFlagDeprecations(flags []string, version string) []flagDeprecationWarning {
+	if version == "" {
+		return nil
+	}
+	var warnings []flagDeprecationWarning
+	for _, deprecation := range kubefirstFlagDeprecations {
+		if !contains(flags, deprecation.Flag) || compareKubefirstVersions(version, deprecation.Since) < 0 {
+			continue
+		}
+		message := fmt.Sprintf("deprecated since %s", deprecation.Since)
+		if deprecation.Removed != "" && compareKubefirstVersions(version, deprecation.Removed) >= 0 {
+			message = fmt.Sprintf("removed in %s", deprecation.Removed)
+		}
+		if deprecation.Replacement != "" {
+			message += fmt.Sprintf(", use --%s instead", deprecation.Replacement)
+		}
+		warnings = append(warnings, flagDeprecationWarning{Flag: deprecation.Flag, Message: message})
+	}
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i].Flag < warnings[j].Flag
+	})
+	return warnings
+}
+
+// warnDeprecatedFlags prints the flags that are deprecated for the kubefirst binary at kubefirstPath
+func warnDeprecatedFlags(kubefirstPath string, flags []string) []flagDeprecationWarning {
+	cli, err := detectKubefirstCLI(kubefirstPath)
+	if err != nil {
+		log.Warn("Could not inspect kubefirst binary, skipping flag deprecation checks", "path", kubefirstPath, "error", err)
+		return nil
+	}
+	if cli.Version == "" {
+		log.Info("kubefirst binary has no version, skipping flag deprecation checks", "path", kubefirstPath)
+		return nil
+	}
+
+	warnings := checkFlagDeprecations(flags, cli.Version)
+	if len(warnings) == 0 || isStructuredOutput() {
+		return warnings
+	}
+	summary := [][]string{{"Flag", "Warning"}}
+	for _, warning := range warnings {
+		log.Warn("Deprecated kubefirst flag", "flag", warning.Flag, "version", cli.Version, "warning", warning.Message)
+		summary = append(summary, []string{"--" + warning.Flag, warning.Message})
+	}
+	printSummaryTable(fmt.Sprintf("Deprecated Flags for kubefirst %s", cli.Version), summary)
+	return warnings
+}
+
+// warnDeprecatedConfigFlags checks the flags stored for a config against its kubefirst binary
+func warnDeprecatedConfigFlags(configName string, config Config) {
+	kubefirstPath := config.Flags["KUBEFIRST_PATH"]
+	if kubefirstPath == "" {
+		return
+	}
+	stored := storedConfigFlags(configName, config)
+	flags := make([]string, 0, len(stored))
+	for flag := range stored {
+		flags = append(flags, flag)
+	}
+	if len(warnDeprecatedFlags(kubefirstPath, flags)) > 0 {
+		fmt.Println("Recreate the config to store the replacement flags. Renamed flags are still passed under their new names.")
+	}
+}
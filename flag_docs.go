@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"golang.org/x/net/html"
+)
+
+const flagDocsMaxAge = 7 * 24 * time.Hour
+
+var (
+	flagDocsKey   = key.NewBinding(key.WithKeys("ctrl+o"), key.WithHelp("ctrl+o", "flag docs"))
+	flagDocsStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#7D56F4")).
+			Padding(0, 1).
+			Width(80)
+)
+
+type flagDocsCache struct {
+	FetchedAt time.Time         `json:"fetched_at"`
+	URL       string            `json:"url"`
+	Flags     map[string]string `json:"flags"`
+}
+
+func flagDocsURL(cloudProvider string) string {
+	return fmt.Sprintf("https://docs.kubefirst.io/%s/quick-start/install/cli", kubefirstCloudCommand(cloudProvider))
+}
+
+// loadFlagDocs returns extended documentation for each kubefirst flag of a provider, using the local cache when it's fresh
+func loadFlagDocs(cloudProvider string) flagDocsCache {
+	cachePath := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".cache", "flag-docs", strings.ToLower(cloudProvider)+".json")
+
+	var cache flagDocsCache
+	if data, err := os.ReadFile(cachePath); err == nil {
+		if err := json.Unmarshal(data, &cache); err != nil {
+			log.Warn("Ignoring unreadable flag docs cache", "path", cachePath, "error", err)
+		}
+	}
+	if cache.Flags != nil && time.Since(cache.FetchedAt) < flagDocsMaxAge {
+		return cache
+	}
+
+	url := flagDocsURL(cloudProvider)
+	flags, err := fetchFlagDocs(url)
+	if err != nil {
+		// A stale cache is still better than the one-line help string
+		log.Warn("Could not fetch kubefirst flag docs", "url", url, "error", err)
+		cache.URL = url
+		return cache
+	}
+
+	cache = flagDocsCache{FetchedAt: time.Now(), URL: url, Flags: flags}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(cachePath), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(cachePath, data, 0644)
+	}
+	if err != nil {
+		log.Warn("Could not cache kubefirst flag docs", "path", cachePath, "error", err)
+	}
+	return cache
+}
+
+// fetchFlagDocs scrapes the flag tables from a kubefirst docs page, keyed by flag name without dashes
+func fetchFlagDocs(url string) (map[string]string, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("request to %s failed with status %d", url, resp.StatusCode)
+	}
+
+	doc, err := html.Parse(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing docs page: %w", err)
+	}
+
+	flags := make(map[string]string)
+	var headers []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "tr" {
+			var cells []string
+			isHeader := false
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && (c.Data == "td" || c.Data == "th") {
+					isHeader = isHeader || c.Data == "th"
+					cells = append(cells, strings.Join(strings.Fields(nodeText(c)), " "))
+				}
+			}
+			if isHeader {
+				headers = cells
+			} else if len(cells) > 1 && strings.HasPrefix(cells[0], "--") {
+				name := strings.TrimPrefix(strings.Fields(cells[0])[0], "--")
+				var doc strings.Builder
+				doc.WriteString(cells[1])
+				for i := 2; i < len(cells) && i < len(headers); i++ {
+					if cells[i] != "" {
+						doc.WriteString(fmt.Sprintf("\n%s: %s", headers[i], cells[i]))
+					}
+				}
+				flags[name] = doc.String()
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	if len(flags) == 0 {
+		return nil, fmt.Errorf("no flag tables found at %s", url)
+	}
+	return flags, nil
+}
+
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var text strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		text.WriteString(nodeText(c))
+	}
+	return text.String()
+}
+
+// flagDocsField wraps a form field so ctrl+o toggles the flag's extended documentation below it
+type flagDocsField struct {
+	huh.Field
+	docs     string
+	showDocs bool
+}
+
+func withFlagDocs(field huh.Field, flag string, docs flagDocsCache) huh.Field {
+	text, ok := docs.Flags[flag]
+	if !ok {
+		text = "No extended documentation found for this flag."
+	}
+	if docs.URL != "" {
+		text = fmt.Sprintf("%s\n\nMore: %s", text, docs.URL)
+	}
+	return &flagDocsField{Field: field, docs: fmt.Sprintf("--%s\n%s", flag, text)}
+}
+
+func (f *flagDocsField) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && key.Matches(keyMsg, flagDocsKey) {
+		f.showDocs = !f.showDocs
+		return f, nil
+	}
+	m, cmd := f.Field.Update(msg)
+	f.Field = m.(huh.Field)
+	return f, cmd
+}
+
+func (f *flagDocsField) View() string {
+	if !f.showDocs {
+		return f.Field.View()
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, f.Field.View(), flagDocsStyle.Render(f.docs))
+}
+
+func (f *flagDocsField) Blur() tea.Cmd {
+	f.showDocs = false
+	return f.Field.Blur()
+}
+
+func (f *flagDocsField) KeyBinds() []key.Binding {
+	return append(f.Field.KeyBinds(), flagDocsKey)
+}
+
+// The With* setters return the inner field, so re-wrap it to keep the docs binding
+
+func (f *flagDocsField) WithTheme(theme *huh.Theme) huh.Field {
+	f.Field = f.Field.WithTheme(theme)
+	return f
+}
+
+func (f *flagDocsField) WithAccessible(accessible bool) huh.Field {
+	f.Field = f.Field.WithAccessible(accessible)
+	return f
+}
+
+func (f *flagDocsField) WithKeyMap(k *huh.KeyMap) huh.Field {
+	f.Field = f.Field.WithKeyMap(k)
+	return f
+}
+
+func (f *flagDocsField) WithWidth(width int) huh.Field {
+	f.Field = f.Field.WithWidth(width)
+	return f
+}
+
+func (f *flagDocsField) WithHeight(height int) huh.Field {
+	f.Field = f.Field.WithHeight(height)
+	return f
+}
+
+func (f *flagDocsField) WithPosition(p huh.FieldPosition) huh.Field {
+	f.Field = f.Field.WithPosition(p)
+	return f
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+// gitIdentity is the commit identity and signing setup git resolves for a repository
+type gitIdentity struct {
+	Name       string
+	Email      string
+	Sign       bool
+	Format     string
+	SigningKey string
+	Program    string
+}
+
+func gitConfigValue(repoPath, key string) string {
+	output, err := exec.Command("git", "-C", repoPath, "config", "--get", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func readGitIdentity(repoPath string) gitIdentity {
+	identity := gitIdentity{
+		Name:       gitConfigValue(repoPath, "user.name"),
+		Email:      gitConfigValue(repoPath, "user.email"),
+		Sign:       gitConfigValue(repoPath, "commit.gpgsign") == "true",
+		Format:     gitConfigValue(repoPath, "gpg.format"),
+		SigningKey: gitConfigValue(repoPath, "user.signingkey"),
+	}
+	if identity.Format == "" {
+		identity.Format = "openpgp"
+	}
+	identity.Program = gitConfigValue(repoPath, "gpg."+identity.Format+".program")
+	if identity.Program == "" {
+		identity.Program = gitConfigValue(repoPath, "gpg.program")
+	}
+	return identity
+}
+
+// signingProblem reports why git would fail to sign a commit with this identity, or "" if it can
+func (identity gitIdentity) signingProblem() string {
+	switch identity.Format {
+	case "ssh":
+		program := identity.Program
+		if program == "" {
+			program = "ssh-keygen"
+		}
+		if _, err := exec.LookPath(program); err != nil {
+			return program + " is needed for SSH commit signing but could not be found"
+		}
+		if identity.SigningKey == "" {
+			return "commit signing uses SSH but user.signingkey is not set"
+		}
+		// Literal keys ("key::..." or "ssh-ed25519 ...") are handed to the agent; paths must exist
+		if strings.HasPrefix(identity.SigningKey, "key::") || strings.HasPrefix(identity.SigningKey, "ssh-") {
+			return ""
+		}
+		keyPath := identity.SigningKey
+		if strings.HasPrefix(keyPath, "~/") {
+			keyPath = filepath.Join(os.Getenv("HOME"), keyPath[2:])
+		}
+		if _, err := os.Stat(keyPath); err != nil {
+			return fmt.Sprintf("SSH signing key %s could not be read", identity.SigningKey)
+		}
+	case "x509":
+		program := identity.Program
+		if program == "" {
+			program = "gpgsm"
+		}
+		if _, err := exec.LookPath(program); err != nil {
+			return program + " is needed for X.509 commit signing but could not be found"
+		}
+	default:
+		program := identity.Program
+		if program == "" {
+			program = "gpg"
+		}
+		if _, err := exec.LookPath(program); err != nil {
+			return program + " is needed for GPG commit signing but could not be found"
+		}
+		// Without user.signingkey, git signs with the key matching the committer email
+		key := identity.SigningKey
+		if key == "" {
+			key = identity.Email
+		}
+		if key == "" || exec.Command(program, "--list-secret-keys", key).Run() != nil {
+			return fmt.Sprintf("no GPG secret key found for %q", key)
+		}
+	}
+	return ""
+}
+
+// checkGitIdentity lists what would stop git from creating a commit in repoPath; signing is only checked
+// when it's enabled or require_commit_signing is set in settings.hcl
+func checkGitIdentity(repoPath string, requireSigning bool) []string {
+	identity := readGitIdentity(repoPath)
+
+	var problems []string
+	if identity.Name == "" {
+		problems = append(problems, "user.name is not set")
+	}
+	if identity.Email == "" {
+		problems = append(problems, "user.email is not set")
+	}
+	switch {
+	case identity.Sign:
+		if problem := identity.signingProblem(); problem != "" {
+			problems = append(problems, problem)
+		}
+	case requireSigning:
+		problems = append(problems, "commit signing is required but commit.gpgsign is not enabled")
+	}
+	return problems
+}
+
+// ensureGitIdentity runs before k1space does anything in repoPath that may create commits (stashes, merge
+// pulls), offering to fix the global git config when the identity or signing setup is incomplete
+func ensureGitIdentity(repoPath string) bool {
+	requireSigning := false
+	if settings, err := loadSettings(); err == nil {
+		requireSigning = settings.RequireCommitSigning
+	}
+
+	problems := checkGitIdentity(repoPath, requireSigning)
+	if len(problems) == 0 {
+		return true
+	}
+
+	fmt.Println(style.Render("⚠️  Git can't create commits in " + filepath.Base(repoPath)))
+	for _, problem := range problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+
+	var fix bool
+	err := runField(newConfirm("Set up your git identity now? This updates your global git config.", &fix))
+	if err != nil || !fix {
+		return false
+	}
+
+	err = setupGitIdentity(repoPath, requireSigning)
+	if err != nil {
+		log.Error("Error setting up git identity", "error", err)
+		fmt.Println("Failed to set up git identity:", err)
+		return false
+	}
+
+	problems = checkGitIdentity(repoPath, requireSigning)
+	if len(problems) > 0 {
+		fmt.Println("Git is still not ready to commit:")
+		for _, problem := range problems {
+			fmt.Printf("  - %s\n", problem)
+		}
+		return false
+	}
+	return true
+}
+
+func setupGitIdentity(repoPath string, requireSigning bool) error {
+	identity := readGitIdentity(repoPath)
+
+	err := runForm(newForm(
+		newGroup(
+			newInput("Git user.name", &identity.Name),
+			newInput("Git user.email", &identity.Email),
+		),
+	))
+	if err != nil {
+		return err
+	}
+	if identity.Name == "" || identity.Email == "" {
+		return fmt.Errorf("user.name and user.email are both required")
+	}
+	err = setGlobalGitConfig(map[string]string{"user.name": identity.Name, "user.email": identity.Email})
+	if err != nil {
+		return err
+	}
+	identity = readGitIdentity(repoPath)
+
+	if !requireSigning && (!identity.Sign || identity.signingProblem() == "") {
+		return nil
+	}
+
+	signing := "ssh"
+	err = runField(newSelect("How should commits be signed?", &signing,
+		huh.NewOption("SSH key", "ssh"),
+		huh.NewOption("GPG key", "openpgp"),
+		huh.NewOption("Don't sign commits", "none"),
+	))
+	if err != nil {
+		return err
+	}
+
+	switch signing {
+	case "ssh":
+		keyPath := filepath.Join(os.Getenv("HOME"), ".ssh", "id_ed25519.pub")
+		err = runField(newInput("Public key to sign commits with", &keyPath))
+		if err != nil {
+			return err
+		}
+		return setGlobalGitConfig(map[string]string{"gpg.format": "ssh", "user.signingkey": keyPath, "commit.gpgsign": "true"})
+	case "openpgp":
+		output, err := exec.Command("gpg", "--list-secret-keys", "--with-colons", identity.Email).Output()
+		if err != nil {
+			return fmt.Errorf("no GPG secret key found for %s; create one with 'gpg --full-generate-key'", identity.Email)
+		}
+		var keyID string
+		for _, line := range strings.Split(string(output), "\n") {
+			fields := strings.Split(line, ":")
+			if fields[0] == "sec" && len(fields) > 4 {
+				keyID = fields[4]
+				break
+			}
+		}
+		if keyID == "" {
+			return fmt.Errorf("no GPG secret key found for %s", identity.Email)
+		}
+		return setGlobalGitConfig(map[string]string{"gpg.format": "openpgp", "user.signingkey": keyID, "commit.gpgsign": "true"})
+	default:
+		return setGlobalGitConfig(map[string]string{"commit.gpgsign": "false"})
+	}
+}
+
+func setGlobalGitConfig(values map[string]string) error {
+	for key, value := range values {
+		output, err := exec.Command("git", "config", "--global", key, value).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("error setting %s: %w\nOutput: %s", key, err, string(output))
+		}
+	}
+	return nil
+}
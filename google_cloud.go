@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+)
+
+type googleComputeClient struct {
+	service   *compute.Service
+	projectID string
+}
+
+// loadGoogleCredentials finds Application Default Credentials: the key file GOOGLE_APPLICATION_CREDENTIALS points
+// at, then gcloud's own, then the metadata server when running on Google Cloud.
+func loadGoogleCredentials(scopes ...string) (*google.Credentials, error) {
+	// Token requests go through a client with the HTTP timeout too
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, newHTTPClient())
+	creds, err := google.FindDefaultCredentials(ctx, scopes...)
+	if err != nil {
+		return nil, fmt.Errorf("error loading Google credentials: %w", err)
+	}
+
+	// GOOGLE_CLOUD_PROJECT takes precedence, and is required for user credentials that carry no project
+	if project := os.Getenv("GOOGLE_CLOUD_PROJECT"); project != "" {
+		creds.ProjectID = project
+	}
+	return creds, nil
+}
+
+// getGoogleClient returns an HTTP client authorized for scopes, and the project it works in
+func getGoogleClient(scopes ...string) (*http.Client, string, error) {
+	creds, err := loadGoogleCredentials(scopes...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if creds.ProjectID == "" {
+		return nil, "", fmt.Errorf("no Google Cloud project found in credentials. Please set GOOGLE_CLOUD_PROJECT and try again")
+	}
+
+	httpClient := oauth2.NewClient(context.Background(), creds.TokenSource)
+	httpClient.Timeout = getHTTPTimeout()
+	return httpClient, creds.ProjectID, nil
+}
+
+func getGoogleComputeClient() (*googleComputeClient, error) {
+	httpClient, projectID, err := getGoogleClient(compute.ComputeReadonlyScope)
+	if err != nil {
+		return nil, err
+	}
+	service, err := compute.NewService(context.Background(), option.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, err
+	}
+	return &googleComputeClient{service: service, projectID: projectID}, nil
+}
+
+// getProject fetches the project itself, which checks both the credentials and that the project exists
+func (c *googleComputeClient) getProject(ctx context.Context) error {
+	_, err := c.service.Projects.Get(c.projectID).Context(ctx).Do()
+	return err
+}
+
+func (c *googleComputeClient) listRegions(ctx context.Context) ([]string, []string, error) {
+	var regions, zones []string
+	err := c.service.Regions.List(c.projectID).Pages(ctx, func(page *compute.RegionList) error {
+		for _, region := range page.Items {
+			if region.Status != "UP" {
+				continue
+			}
+			regions = append(regions, region.Name)
+			for _, zoneURL := range region.Zones {
+				zones = append(zones, zoneURL[strings.LastIndex(zoneURL, "/")+1:])
+			}
+		}
+		return nil
+	})
+	return regions, zones, err
+}
+
+func (c *googleComputeClient) listMachineTypes(ctx context.Context) ([]InstanceSizeInfo, error) {
+	seen := make(map[string]bool)
+	var sizeInfos []InstanceSizeInfo
+
+	err := c.service.MachineTypes.AggregatedList(c.projectID).MaxResults(500).Pages(ctx, func(page *compute.MachineTypeAggregatedList) error {
+		// The aggregated list repeats every machine type once per zone
+		for _, scope := range page.Items {
+			for _, machineType := range scope.MachineTypes {
+				if seen[machineType.Name] {
+					continue
+				}
+				seen[machineType.Name] = true
+
+				info := InstanceSizeInfo{
+					Name:         machineType.Name,
+					CPUCores:     int(machineType.GuestCpus),
+					RAMMegabytes: int(machineType.MemoryMb),
+					Architecture: detectArchitecture(machineType.Name),
+				}
+				if len(machineType.Accelerators) > 0 {
+					model := strings.ToUpper(strings.TrimPrefix(machineType.Accelerators[0].GuestAcceleratorType, "nvidia-"))
+					info.GPUCount = int(machineType.Accelerators[0].GuestAcceleratorCount)
+					info.GPUModel = model
+					info.GPUVRAMGigabytes = gpuVRAMGigabytes(model)
+				}
+				sizeInfos = append(sizeInfos, info)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(sizeInfos, func(i, j int) bool {
+		return sizeInfos[i].Name < sizeInfos[j].Name
+	})
+	return sizeInfos, nil
+}
+
+func updateGoogleRegions(ctx context.Context, cloudsFile *CloudsFile) error {
+	client, err := getGoogleComputeClient()
+	if err != nil {
+		return err
+	}
+
+	regions, zones, err := client.listRegions(ctx)
+	if err != nil {
+		return err
+	}
+
+	cloudsFile.CloudRegions["Google"] = regions
+	cloudsFile.CloudZones["Google"] = zones
+	return nil
+}
+
+func updateGoogleNodeTypes(ctx context.Context, cloudsFile *CloudsFile) error {
+	client, err := getGoogleComputeClient()
+	if err != nil {
+		return err
+	}
+
+	sizeInfos, err := client.listMachineTypes(ctx)
+	if err != nil {
+		return err
+	}
+
+	cloudsFile.CloudNodeTypes["Google"] = sizeInfos
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VRAM per GPU in gigabytes for models whose name doesn't include it
+var knownGPUVRAM = map[string]int{
+	"H100":    80,
+	"H200":    141,
+	"A100":    40,
+	"L40S":    48,
+	"L40":     48,
+	"L4":      24,
+	"A10":     24,
+	"A40":     48,
+	"RTX4000": 20,
+	"RTX6000": 48,
+	"MI300X":  192,
+}
+
+var (
+	// Matches a memory suffix in GPU model names such as A100-80 or A100-80GB
+	gpuVRAMSuffixPattern = regexp.MustCompile(`(?i)[-_ ](\d+)\s*(gb)?$`)
+	// Matches DigitalOcean GPU slugs such as gpu-h100x1-80gb or gpu-h100x8-640gb
+	digitalOceanGPUSlugPattern = regexp.MustCompile(`^gpu-([a-z0-9]+)x(\d+)(?:-(\d+)gb)?`)
+)
+
+func gpuVRAMGigabytes(model string) int {
+	if model == "" {
+		return 0
+	}
+	if match := gpuVRAMSuffixPattern.FindStringSubmatch(model); match != nil {
+		vram, err := strconv.Atoi(match[1])
+		if err == nil {
+			return vram
+		}
+	}
+	normalized := strings.ToUpper(strings.ReplaceAll(model, " ", ""))
+	for name, vram := range knownGPUVRAM {
+		if normalized == name || strings.HasPrefix(normalized, name+"-") {
+			return vram
+		}
+	}
+	return 0
+}
+
+func parseDigitalOceanGPUSize(slug string) (count int, model string, ok bool) {
+	match := digitalOceanGPUSlugPattern.FindStringSubmatch(slug)
+	if match == nil {
+		return 0, "", false
+	}
+	count, err := strconv.Atoi(match[2])
+	if err != nil {
+		return 0, "", false
+	}
+	model = strings.ToUpper(match[1])
+	// The slug carries the total VRAM across all GPUs; keep it on the model so the per-GPU value can be derived
+	if match[3] != "" {
+		if total, err := strconv.Atoi(match[3]); err == nil && count > 0 {
+			model = model + "-" + strconv.Itoa(total/count)
+		}
+	}
+	return count, model, true
+}
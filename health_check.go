@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+const (
+	defaultHealthCheckTimeout = 15 * time.Minute
+	healthCheckInterval       = 15 * time.Second
+)
+
+// healthCheck is one line of the post-provision checklist
+type healthCheck struct {
+	Name   string `json:"name" yaml:"name"`
+	Passed bool   `json:"passed" yaml:"passed"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+type kubernetesNodeList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Status struct {
+			Conditions []struct {
+				Type   string `json:"type"`
+				Status string `json:"status"`
+			} `json:"conditions"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+type argoApplicationList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Status struct {
+			Sync struct {
+				Status string `json:"status"`
+			} `json:"sync"`
+			Health struct {
+				Status string `json:"status"`
+			} `json:"health"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+type kubernetesPodList struct {
+	Items []struct {
+		Metadata struct {
+			Name   string            `json:"name"`
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	} `json:"items"`
+}
+
+// getHealthCheckTimeout returns health_check_timeout from settings.hcl (e.g. "30m"), how long to wait for a new
+// cluster to settle
+func getHealthCheckTimeout() time.Duration {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings, using the default health check timeout", "error", err)
+		return defaultHealthCheckTimeout
+	}
+	if settings.HealthCheckTimeout == "" {
+		return defaultHealthCheckTimeout
+	}
+	timeout, err := time.ParseDuration(settings.HealthCheckTimeout)
+	if err != nil || timeout <= 0 {
+		log.Warn("Invalid health_check_timeout in settings.hcl, using the default", "health_check_timeout", settings.HealthCheckTimeout)
+		return defaultHealthCheckTimeout
+	}
+	return timeout
+}
+
+// summarizeWaiting lists the first few names still being waited on
+func summarizeWaiting(names []string) string {
+	if len(names) > 3 {
+		return fmt.Sprintf("%s and %d more", strings.Join(names[:3], ", "), len(names)-3)
+	}
+	return strings.Join(names, ", ")
+}
+
+func checkNodesReady(ctx context.Context, kubeconfig string) healthCheck {
+	check := healthCheck{Name: "Nodes Ready"}
+	var nodes kubernetesNodeList
+	err := kubectlJSON(ctx, kubeconfig, "", &nodes, "get", "nodes")
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	var notReady []string
+	for _, node := range nodes.Items {
+		ready := false
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == "Ready" && condition.Status == "True" {
+				ready = true
+			}
+		}
+		if !ready {
+			notReady = append(notReady, node.Metadata.Name)
+		}
+	}
+	total := len(nodes.Items)
+	check.Passed = total > 0 && len(notReady) == 0
+	check.Detail = fmt.Sprintf("%d/%d nodes Ready", total-len(notReady), total)
+	if len(notReady) > 0 {
+		check.Detail += "; waiting on " + summarizeWaiting(notReady)
+	}
+	return check
+}
+
+func checkArgoCDSynced(ctx context.Context, kubeconfig string) healthCheck {
+	check := healthCheck{Name: "Argo CD Apps Synced"}
+	var apps argoApplicationList
+	err := kubectlJSON(ctx, kubeconfig, "", &apps, "get", "applications.argoproj.io", "-n", "argocd")
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	var pending []string
+	for _, app := range apps.Items {
+		if app.Status.Sync.Status != "Synced" || app.Status.Health.Status != "Healthy" {
+			pending = append(pending, fmt.Sprintf("%s (%s/%s)", app.Metadata.Name, app.Status.Sync.Status, app.Status.Health.Status))
+		}
+	}
+	total := len(apps.Items)
+	if total == 0 {
+		check.Detail = "no applications in the argocd namespace yet"
+		return check
+	}
+	check.Passed = len(pending) == 0
+	check.Detail = fmt.Sprintf("%d/%d synced and healthy", total-len(pending), total)
+	if len(pending) > 0 {
+		check.Detail += "; waiting on " + summarizeWaiting(pending)
+	}
+	return check
+}
+
+// checkVaultUnsealed reads the vault-sealed label Vault's Kubernetes service registration keeps on its pods
+func checkVaultUnsealed(ctx context.Context, kubeconfig string) healthCheck {
+	check := healthCheck{Name: "Vault Unsealed"}
+	var pods kubernetesPodList
+	err := kubectlJSON(ctx, kubeconfig, "", &pods, "get", "pods", "-n", "vault", "-l", "app.kubernetes.io/name=vault")
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	if len(pods.Items) == 0 {
+		check.Detail = "no Vault pods in the vault namespace yet"
+		return check
+	}
+	var sealed []string
+	for _, pod := range pods.Items {
+		switch pod.Metadata.Labels["vault-sealed"] {
+		case "false":
+		case "true":
+			sealed = append(sealed, pod.Metadata.Name)
+		default:
+			sealed = append(sealed, pod.Metadata.Name+" (status unknown)")
+		}
+	}
+	check.Passed = len(sealed) == 0
+	check.Detail = fmt.Sprintf("%d/%d pods unsealed", len(pods.Items)-len(sealed), len(pods.Items))
+	if len(sealed) > 0 {
+		check.Detail += "; sealed: " + summarizeWaiting(sealed)
+	}
+	return check
+}
+
+func runHealthChecks(ctx context.Context, kubeconfig string) []healthCheck {
+	return []healthCheck{
+		checkNodesReady(ctx, kubeconfig),
+		checkArgoCDSynced(ctx, kubeconfig),
+		checkVaultUnsealed(ctx, kubeconfig),
+	}
+}
+
+func allHealthChecksPassed(checks []healthCheck) bool {
+	for _, check := range checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return len(checks) > 0
+}
+
+// healthCheckKubeconfig prefers the kubeconfig k1space fetched for the config over the one kubefirst wrote
+func healthCheckKubeconfig(configName, clusterName string) string {
+	if path, err := configKubeconfigPath(configName); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	path := clusterKubeconfigPath(clusterName)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	return ""
+}
+
+// verifyClusterHealth waits for a new cluster's nodes, Argo CD applications and Vault until they're all healthy
+// or health_check_timeout passes, and returns the last result of each check
+func verifyClusterHealth(configName string, config Config) []healthCheck {
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return []healthCheck{{Name: "kubectl", Detail: "kubectl is not installed, so the cluster can't be checked"}}
+	}
+	clusterName := findConfigFlag(config.Flags, "cluster-name")
+	kubeconfig := healthCheckKubeconfig(configName, clusterName)
+	if kubeconfig == "" {
+		return []healthCheck{{Name: "Kubeconfig", Detail: fmt.Sprintf("no kubeconfig found for %s", configName)}}
+	}
+
+	timeout := getHealthCheckTimeout()
+	var checks []healthCheck
+	err := runCancellable(fmt.Sprintf("Waiting for nodes, Argo CD and Vault (up to %s, Esc to stop)...", timeout), func(ctx context.Context) error {
+		deadline := time.Now().Add(timeout)
+		for {
+			checks = runHealthChecks(ctx, kubeconfig)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if allHealthChecksPassed(checks) || time.Now().After(deadline) {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(healthCheckInterval):
+			}
+		}
+	})
+	if err != nil {
+		log.Warn("Health verification stopped", "config", configName, "error", err)
+	}
+	for i := range checks {
+		if !checks[i].Passed && err == nil {
+			checks[i].Detail += fmt.Sprintf(" (after waiting %s)", timeout)
+		}
+	}
+	log.Info("Cluster health verified", "config", configName, "passed", allHealthChecksPassed(checks))
+	return checks
+}
+
+// printHealthChecklist shows each check as a green or red line
+func printHealthChecklist(checks []healthCheck) bool {
+	summary := [][]string{{"Check", "Result", "Details"}}
+	failed := 0
+	for _, check := range checks {
+		status := "✅ Pass"
+		if !check.Passed {
+			status = "❌ Fail"
+			failed++
+		}
+		summary = append(summary, []string{check.Name, status, check.Detail})
+	}
+	printSummaryTable("Cluster Health", summary)
+	if failed > 0 {
+		fmt.Printf("\n%d of %d health checks failed. Use 'Verify Cluster Health' to check again.\n", failed, len(checks))
+	}
+	return failed == 0
+}
+
+func verifyClusterHealthForConfig() {
+	log.Info("Starting verifyClusterHealthForConfig function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations:", err)
+		return
+	}
+	selectedConfig, err := promptConfigSelection(indexFile, "Select the cluster to verify")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations available. Please create a configuration first.")
+		return
+	}
+
+	checks := verifyClusterHealth(selectedConfig, indexFile.Configs[selectedConfig])
+	if isStructuredOutput() {
+		printStructured(checks)
+		return
+	}
+	if printHealthChecklist(checks) {
+		fmt.Println(style.Render("\nAll health checks passed."))
+	}
+}
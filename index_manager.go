@@ -8,12 +8,49 @@ import (
 	"time"
 
 	"github.com/charmbracelet/log"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/zclconf/go-cty/cty"
 )
 
+func getIndexPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "config.hcl")
+}
+
 func loadIndexFile() (IndexFile, error) {
-	indexPath := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "config.hcl")
+	indexPath := getIndexPath()
+	var indexFile IndexFile
+	err := withFileLock(indexPath, func() error {
+		var err error
+		indexFile, err = readIndexFile(indexPath)
+		return err
+	})
+	return indexFile, err
+}
+
+// updateIndex re-reads config.hcl under its lock, applies update and writes the result, so changes another
+// k1space process saved since this one loaded the index aren't overwritten. Prompts belong before the call, as
+// other processes wait on the lock while update runs.
+func updateIndex(update func(indexFile *IndexFile) error) error {
+	indexPath := getIndexPath()
+	return withFileLock(indexPath, func() error {
+		indexFile, err := readIndexFile(indexPath)
+		if err != nil {
+			return err
+		}
+		err = update(&indexFile)
+		if err != nil {
+			return err
+		}
+		indexFile.LastUpdated = time.Now().UTC().Format(time.RFC3339)
+		return createOrUpdateIndexFile(indexPath, indexFile)
+	})
+}
+
+// readIndexFile loads config.hcl, creating or migrating it as needed. Callers hold its lock.
+func readIndexFile(indexPath string) (IndexFile, error) {
 	var indexFile IndexFile
 
 	log.Info("Attempting to read config.hcl", "path", indexPath)
@@ -21,7 +58,7 @@ func loadIndexFile() (IndexFile, error) {
 	if _, err := os.Stat(indexPath); os.IsNotExist(err) {
 		log.Info("config.hcl does not exist, creating a new one")
 		err := createOrUpdateIndexFile(indexPath, IndexFile{
-			Version:     1,
+			Version:     currentIndexVersion,
 			LastUpdated: time.Now().UTC().Format(time.RFC3339),
 			Configs:     make(map[string]Config),
 		})
@@ -37,25 +74,46 @@ func loadIndexFile() (IndexFile, error) {
 	}
 	log.Info("Successfully read config.hcl", "bytes", len(data))
 
-	content := string(data)
-	configs := simpleHCLParser(content)
-
-	indexFile.Configs = configs
-	for configName, config := range configs {
+	indexFile, err = decodeIndexFile(data, indexPath)
+	if err != nil {
+		log.Error("Failed to parse config.hcl", "error", err)
+		return indexFile, err
+	}
+	for configName, config := range indexFile.Configs {
 		log.Info("Parsed config", "name", configName, "fileCount", len(config.Files))
 	}
 
 	cleanupIndexFile(&indexFile)
 
+	fromVersion := indexFile.Version
+	migrated, err := migrateIndexFile(&indexFile)
+	if err != nil {
+		log.Error("Failed to migrate config.hcl", "error", err)
+		return indexFile, err
+	}
+	if migrated {
+		err = backupIndexFile(indexPath, data, fromVersion)
+		if err != nil {
+			return indexFile, fmt.Errorf("error backing up config.hcl before migration: %w", err)
+		}
+		err = createOrUpdateIndexFile(indexPath, indexFile)
+		if err != nil {
+			return indexFile, fmt.Errorf("error writing migrated config.hcl: %w", err)
+		}
+		log.Info("Migrated config.hcl", "from", fromVersion, "to", indexFile.Version)
+	}
+
 	log.Info("Finished parsing config.hcl", "configCount", len(indexFile.Configs))
 	return indexFile, nil
 }
 
+// createOrUpdateIndexFile writes config.hcl. Callers hold its lock; see updateIndex.
 func createOrUpdateIndexFile(path string, indexFile IndexFile) error {
 	f := hclwrite.NewEmptyFile()
 	rootBody := f.Body()
 
-	rootBody.SetAttributeValue("version", cty.NumberIntVal(int64(indexFile.Version)))
+	// Everything in memory has been migrated on load, so it's always written as the current schema
+	rootBody.SetAttributeValue("version", cty.NumberIntVal(int64(currentIndexVersion)))
 	rootBody.SetAttributeValue("last_updated", cty.StringVal(indexFile.LastUpdated))
 
 	configsBlock := rootBody.AppendNewBlock("configs", nil)
@@ -68,21 +126,84 @@ func createOrUpdateIndexFile(path string, indexFile IndexFile) error {
 		for i, file := range v.Files {
 			fileValues[i] = cty.StringVal(file)
 		}
-		configBody.SetAttributeValue("files", cty.ListVal(fileValues))
+		if len(fileValues) == 0 {
+			configBody.SetAttributeValue("files", cty.ListValEmpty(cty.String))
+		} else {
+			configBody.SetAttributeValue("files", cty.ListVal(fileValues))
+		}
+		if v.ClusterID != "" {
+			configBody.SetAttributeValue("cluster_id", cty.StringVal(v.ClusterID))
+		}
 
 		flagsBlock := configBody.AppendNewBlock("flags", nil)
 		flagsBody := flagsBlock.Body()
 		for flagK, flagV := range v.Flags {
 			flagsBody.SetAttributeValue(flagK, cty.StringVal(flagV))
 		}
-	}
 
-	err := os.MkdirAll(filepath.Dir(path), 0755)
-	if err != nil {
-		return fmt.Errorf("error creating directory for config.hcl: %w", err)
+		if v.State != nil {
+			stateBody := configBody.AppendNewBlock("state", nil).Body()
+			stateBody.SetAttributeValue("current", cty.StringVal(v.State.Current))
+			for state, since := range v.State.Since {
+				stateBody.SetAttributeValue(state, cty.StringVal(since))
+			}
+		}
+
+		if v.Topology != nil {
+			topologyBody := configBody.AppendNewBlock("topology", nil).Body()
+			topologyBody.SetAttributeValue("node_count", cty.NumberIntVal(int64(v.Topology.NodeCount)))
+			topologyBody.SetAttributeValue("ha_control_plane", cty.BoolVal(v.Topology.HAControlPlane))
+			if v.Topology.ControlPlaneNodes > 0 {
+				topologyBody.SetAttributeValue("control_plane_nodes", cty.NumberIntVal(int64(v.Topology.ControlPlaneNodes)))
+			}
+		}
+
+		if v.Alerts != nil {
+			configBody.AppendNewBlock("alerts", nil).Body().SetAttributeValue("enabled", cty.BoolVal(v.Alerts.Enabled))
+		}
+
+		for _, window := range v.Maintenance {
+			windowBody := configBody.AppendNewBlock("maintenance", nil).Body()
+			if len(window.Days) > 0 {
+				days := make([]cty.Value, len(window.Days))
+				for i, day := range window.Days {
+					days[i] = cty.StringVal(day)
+				}
+				windowBody.SetAttributeValue("days", cty.ListVal(days))
+			}
+			windowBody.SetAttributeValue("start", cty.StringVal(window.Start))
+			windowBody.SetAttributeValue("duration", cty.StringVal(window.Duration))
+			if window.Timezone != "" {
+				windowBody.SetAttributeValue("timezone", cty.StringVal(window.Timezone))
+			}
+		}
+
+		if v.Failover != nil {
+			failoverBody := configBody.AppendNewBlock("failover", nil).Body()
+			failoverBody.SetAttributeValue("role", cty.StringVal(v.Failover.Role))
+			failoverBody.SetAttributeValue("peer", cty.StringVal(v.Failover.Peer))
+		}
+
+		if v.Bootstrap != nil && len(v.Bootstrap.Packs) > 0 {
+			bootstrapBody := configBody.AppendNewBlock("bootstrap", nil).Body()
+			packs := make([]cty.Value, len(v.Bootstrap.Packs))
+			for i, pack := range v.Bootstrap.Packs {
+				packs[i] = cty.StringVal(pack)
+			}
+			bootstrapBody.SetAttributeValue("packs", cty.ListVal(packs))
+			if len(v.Bootstrap.Applied) > 0 {
+				applied := make(map[string]cty.Value, len(v.Bootstrap.Applied))
+				for pack, at := range v.Bootstrap.Applied {
+					applied[pack] = cty.StringVal(at)
+				}
+				bootstrapBody.SetAttributeValue("applied", cty.MapVal(applied))
+			}
+		}
 	}
 
-	err = os.WriteFile(path, f.Bytes(), 0644)
+	writeTemplatesBlock(rootBody, indexFile.Templates)
+
+	err := writeFileAtomic(path, f.Bytes(), 0644)
 	if err != nil {
 		return fmt.Errorf("error writing config.hcl: %w", err)
 	}
@@ -90,123 +211,209 @@ func createOrUpdateIndexFile(path string, indexFile IndexFile) error {
 	return nil
 }
 
-func updateIndexFile(config *CloudConfig, indexFile IndexFile) error {
-	indexPath := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "config.hcl")
-
-	// Update LastUpdated
-	indexFile.LastUpdated = time.Now().UTC().Format(time.RFC3339)
-
-	// Add or update the new configuration
-	if config.CloudPrefix != "" && config.Region != "" && config.StaticPrefix != "" {
-		key := fmt.Sprintf("%s_%s_%s", strings.ToLower(config.CloudPrefix), strings.ToLower(config.Region), config.StaticPrefix)
-
-		newConfig := Config{
-			Files: []string{
-				filepath.ToSlash(filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", strings.ToLower(config.CloudPrefix), strings.ToLower(config.Region), config.StaticPrefix, "00-init.sh")),
-				filepath.ToSlash(filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", strings.ToLower(config.CloudPrefix), strings.ToLower(config.Region), config.StaticPrefix, "01-kubefirst-cloud.sh")),
-				filepath.ToSlash(filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", strings.ToLower(config.CloudPrefix), strings.ToLower(config.Region), config.StaticPrefix, ".local.cloud.env")),
-			},
-			Flags: make(map[string]string),
+// updateIndexFile adds or updates a config in config.hcl and drops entries whose names aren't cloud_region_prefix
+func updateIndexFile(config *CloudConfig) error {
+	return updateIndex(func(indexFile *IndexFile) error {
+		if config.CloudPrefix != "" && config.Region != "" && config.StaticPrefix != "" {
+			err := addConfigToIndex(config, indexFile)
+			if err != nil {
+				return err
+			}
 		}
 
-		// Read the .local.cloud.env file
-		envFilePath := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", strings.ToLower(config.CloudPrefix), strings.ToLower(config.Region), config.StaticPrefix, ".local.cloud.env")
-		envContent, err := os.ReadFile(envFilePath)
-		if err != nil {
-			return fmt.Errorf("error reading .local.cloud.env: %w", err)
+		for key := range indexFile.Configs {
+			parts := strings.Split(key, "_")
+			if len(parts) != 3 {
+				// Remove invalid configs
+				delete(indexFile.Configs, key)
+			}
 		}
+		return nil
+	})
+}
 
-		// Parse the environment variables
-		envVars := strings.Split(string(envContent), "\n")
-		for _, envVar := range envVars {
-			if strings.TrimSpace(envVar) == "" {
-				continue
-			}
-			parts := strings.SplitN(envVar, "=", 2)
-			if len(parts) != 2 {
-				continue
-			}
-			flagName := strings.TrimPrefix(parts[0], "export ")
-			flagValue := strings.Trim(parts[1], "\"")
+// cloudConfigKey returns the name a config is indexed under, cloud_region_prefix
+func cloudConfigKey(config *CloudConfig) string {
+	return fmt.Sprintf("%s_%s_%s", strings.ToLower(config.CloudPrefix), strings.ToLower(config.Region), config.StaticPrefix)
+}
+
+func addConfigToIndex(config *CloudConfig, indexFile *IndexFile) error {
+	key := cloudConfigKey(config)
+
+	newConfig := Config{
+		Files: []string{
+			filepath.ToSlash(filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", strings.ToLower(config.CloudPrefix), strings.ToLower(config.Region), config.StaticPrefix, "00-init.sh")),
+			filepath.ToSlash(filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", strings.ToLower(config.CloudPrefix), strings.ToLower(config.Region), config.StaticPrefix, "01-kubefirst-cloud.sh")),
+			filepath.ToSlash(filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", strings.ToLower(config.CloudPrefix), strings.ToLower(config.Region), config.StaticPrefix, ".local.cloud.env")),
+		},
+		Flags:    make(map[string]string),
+		State:    newConfigState(stateCreated, time.Now()),
+		Topology: config.Topology,
+	}
+	// Regenerating a config's files doesn't change what happened to its cluster
+	if existing, ok := indexFile.Configs[key]; ok && existing.State != nil {
+		newConfig.State = existing.State
+	}
+	if existing, ok := indexFile.Configs[key]; ok {
+		newConfig.Alerts = existing.Alerts
+		newConfig.Maintenance = existing.Maintenance
+		newConfig.Failover = existing.Failover
+		newConfig.Bootstrap = existing.Bootstrap
+		newConfig.ClusterID = existing.ClusterID
+	}
+	if config.K3s != nil {
+		baseDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", strings.ToLower(config.CloudPrefix), strings.ToLower(config.Region), config.StaticPrefix)
+		newConfig.Files = append(newConfig.Files,
+			filepath.ToSlash(filepath.Join(baseDir, k3sInventoryFile)),
+			filepath.ToSlash(filepath.Join(baseDir, k3sInstallScriptFile)),
+		)
+	}
+
+	// Read the .local.cloud.env file
+	envFilePath := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", strings.ToLower(config.CloudPrefix), strings.ToLower(config.Region), config.StaticPrefix, ".local.cloud.env")
+	flags, err := readEnvFileFlags(envFilePath)
+	if err != nil {
+		return err
+	}
+	newConfig.Flags = flags
+	if newConfig.Topology == nil {
+		newConfig.Topology = topologyFromFlags(flags)
+	}
 
-			// Ensure the flag name is in uppercase and uses underscores
-			flagName = strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+	// Update or add the new configuration
+	indexFile.Configs[key] = newConfig
 
-			newConfig.Flags[flagName] = flagValue
-		}
+	return nil
+}
 
-		// Update or add the new configuration
-		indexFile.Configs[key] = newConfig
+// readEnvFileFlags parses a config's .local.cloud.env into the flags stored for it in config.hcl
+func readEnvFileFlags(envFilePath string) (map[string]string, error) {
+	envContent, err := os.ReadFile(envFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading .local.cloud.env: %w", err)
 	}
 
-	// Add this new section here
-	for key := range indexFile.Configs {
-		parts := strings.Split(key, "_")
-		if len(parts) != 3 {
-			// Remove invalid configs
-			delete(indexFile.Configs, key)
+	// Parse the environment variables
+	flags := make(map[string]string)
+	envVars := strings.Split(string(envContent), "\n")
+	for _, envVar := range envVars {
+		if strings.TrimSpace(envVar) == "" {
+			continue
 		}
+		parts := strings.SplitN(envVar, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		flagName := strings.TrimPrefix(parts[0], "export ")
+		flagValue := strings.Trim(parts[1], "\"")
+
+		// Ensure the flag name is in uppercase and uses underscores
+		flagName = strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+
+		flags[flagName] = flagValue
 	}
+	return flags, nil
+}
 
-	return createOrUpdateIndexFile(indexPath, indexFile)
+// indexHeader holds config.hcl's top-level attributes; its blocks are keyed by config name and walked separately
+type indexHeader struct {
+	Version     int      `hcl:"version,optional"`
+	LastUpdated string   `hcl:"last_updated,optional"`
+	Remain      hcl.Body `hcl:",remain"`
 }
 
-func simpleHCLParser(content string) map[string]Config {
-	configs := make(map[string]Config)
-	lines := strings.Split(content, "\n")
-	inConfigsBlock := false
-	currentConfig := ""
-	inFlagsBlock := false
-	nestedLevel := 0
-
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-		if trimmedLine == "configs {" {
-			inConfigsBlock = true
-			nestedLevel++
-			continue
-		}
-		if inConfigsBlock {
-			if strings.HasSuffix(trimmedLine, "{") {
-				nestedLevel++
-				if nestedLevel == 2 {
-					currentConfig = strings.TrimSuffix(trimmedLine, " {")
-					configs[currentConfig] = Config{Files: []string{}, Flags: make(map[string]string)}
-				} else if nestedLevel == 3 && trimmedLine == "flags {" {
-					inFlagsBlock = true
+type configBlock struct {
+	Files       []string            `hcl:"files,optional"`
+	Flags       *flagsBlock         `hcl:"flags,block"`
+	State       *flagsBlock         `hcl:"state,block"`
+	Topology    *topologyBlock      `hcl:"topology,block"`
+	Alerts      *ClusterAlerts      `hcl:"alerts,block"`
+	Maintenance []MaintenanceWindow `hcl:"maintenance,block"`
+	Failover    *FailoverPair       `hcl:"failover,block"`
+	Bootstrap   *BootstrapPacks     `hcl:"bootstrap,block"`
+	ClusterID   string              `hcl:"cluster_id,optional"`
+}
+
+type topologyBlock struct {
+	NodeCount         int  `hcl:"node_count,optional"`
+	HAControlPlane    bool `hcl:"ha_control_plane,optional"`
+	ControlPlaneNodes int  `hcl:"control_plane_nodes,optional"`
+}
+
+type flagsBlock struct {
+	Values map[string]string `hcl:",remain"`
+}
+
+// decodeIndexFile parses config.hcl, returning an error rather than dropping entries it can't read
+func decodeIndexFile(data []byte, path string) (IndexFile, error) {
+	indexFile := IndexFile{
+		Configs:   make(map[string]Config),
+		Templates: make(map[string]ConfigTemplate),
+	}
+
+	file, diags := hclsyntax.ParseConfig(data, path, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return indexFile, fmt.Errorf("error parsing config.hcl: %s", diags)
+	}
+
+	var header indexHeader
+	diags = gohcl.DecodeBody(file.Body, nil, &header)
+	if diags.HasErrors() {
+		return indexFile, fmt.Errorf("error decoding config.hcl: %s", diags)
+	}
+	indexFile.Version = header.Version
+	indexFile.LastUpdated = header.LastUpdated
+
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		switch block.Type {
+		case "configs":
+			for _, configDef := range block.Body.Blocks {
+				var decoded configBlock
+				diags = gohcl.DecodeBody(configDef.Body, nil, &decoded)
+				if diags.HasErrors() {
+					return indexFile, fmt.Errorf("error decoding config %s: %s", configDef.Type, diags)
 				}
-			} else if trimmedLine == "}" {
-				nestedLevel--
-				if nestedLevel == 1 {
-					currentConfig = ""
-					inFlagsBlock = false
-				} else if nestedLevel == 0 {
-					inConfigsBlock = false
+				config := Config{Files: decoded.Files, Flags: make(map[string]string)}
+				if config.Files == nil {
+					config.Files = []string{}
 				}
-			} else if strings.HasPrefix(trimmedLine, "files = [") {
-				files := strings.Trim(strings.TrimPrefix(trimmedLine, "files = ["), "]")
-				if files != "" && currentConfig != "" {
-					filesList := strings.Split(files, ", ")
-					for i := range filesList {
-						filesList[i] = strings.Trim(filesList[i], "\"")
+				if decoded.Flags != nil {
+					for name, value := range decoded.Flags.Values {
+						config.Flags[name] = value
 					}
-					currentConfigStruct := configs[currentConfig]
-					currentConfigStruct.Files = append(currentConfigStruct.Files, filesList...)
-					configs[currentConfig] = currentConfigStruct
 				}
-			} else if inFlagsBlock && strings.Contains(trimmedLine, "=") {
-				parts := strings.SplitN(trimmedLine, "=", 2)
-				if len(parts) == 2 && currentConfig != "" {
-					key := strings.TrimSpace(parts[0])
-					value := strings.Trim(strings.TrimSpace(parts[1]), "\"")
-					currentConfigStruct := configs[currentConfig]
-					currentConfigStruct.Flags[key] = value
-					configs[currentConfig] = currentConfigStruct
+				// The state block holds the current state and a timestamp attribute per state entered
+				if decoded.State != nil {
+					config.State = &ConfigState{Current: decoded.State.Values["current"], Since: make(map[string]string)}
+					for name, value := range decoded.State.Values {
+						if name != "current" {
+							config.State.Since[name] = value
+						}
+					}
+				}
+				if decoded.Topology != nil {
+					config.Topology = &NodeTopology{
+						NodeCount:         decoded.Topology.NodeCount,
+						HAControlPlane:    decoded.Topology.HAControlPlane,
+						ControlPlaneNodes: decoded.Topology.ControlPlaneNodes,
+					}
 				}
+				config.Alerts = decoded.Alerts
+				config.Maintenance = decoded.Maintenance
+				config.Failover = decoded.Failover
+				config.Bootstrap = decoded.Bootstrap
+				config.ClusterID = decoded.ClusterID
+				indexFile.Configs[configDef.Type] = config
 			}
+		case "templates":
+			templates, err := decodeTemplateBlocks(block.Body)
+			if err != nil {
+				return indexFile, err
+			}
+			indexFile.Templates = templates
 		}
 	}
-	return configs
+	return indexFile, nil
 }
 
 func cleanupIndexFile(indexFile *IndexFile) {
@@ -219,6 +426,7 @@ func cleanupIndexFile(indexFile *IndexFile) {
 			cleaned = filepath.ToSlash(cleaned)
 			cleanedFiles[i] = cleaned
 		}
-		indexFile.Configs[configName] = Config{Files: cleanedFiles, Flags: config.Flags}
+		config.Files = cleanedFiles
+		indexFile.Configs[configName] = config
 	}
 }
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// indexMigration upgrades config.hcl from schema version From to From+1
+type indexMigration struct {
+	From        int
+	Description string
+	Apply       func(*IndexFile) error
+}
+
+// indexMigrations run in order; append a step and currentIndexVersion follows
+var indexMigrations = []indexMigration{
+	{
+		// Older loaders never read version back, so any rewrite saved it as 0; the layout is the same as 1
+		From:        0,
+		Description: "mark unversioned index as version 1",
+		Apply:       func(*IndexFile) error { return nil },
+	},
+	{
+		From:        1,
+		Description: "drop per-config copies of KUBEFIRST_PATH",
+		Apply:       dropConfigKubefirstPathCopies,
+	},
+	{
+		From:        2,
+		Description: "start lifecycle state tracking",
+		Apply:       markExistingConfigsCreated,
+	},
+	{
+		From:        3,
+		Description: "record node topology from stored flags",
+		Apply:       recordNodeTopology,
+	},
+}
+
+var currentIndexVersion = len(indexMigrations)
+
+// migrateIndexFile applies every migration newer than the file's version, reporting whether anything ran
+func migrateIndexFile(indexFile *IndexFile) (bool, error) {
+	if indexFile.Version > currentIndexVersion {
+		return false, fmt.Errorf("config.hcl uses schema version %d but this k1space only understands up to %d; upgrade k1space", indexFile.Version, currentIndexVersion)
+	}
+
+	migrated := false
+	for _, migration := range indexMigrations {
+		if migration.From < indexFile.Version {
+			continue
+		}
+		log.Info("Migrating config.hcl", "from", migration.From, "to", migration.From+1, "step", migration.Description)
+		err := migration.Apply(indexFile)
+		if err != nil {
+			return migrated, fmt.Errorf("error migrating config.hcl to version %d (%s): %w", migration.From+1, migration.Description, err)
+		}
+		indexFile.Version = migration.From + 1
+		migrated = true
+	}
+	return migrated, nil
+}
+
+// backupIndexFile keeps the pre-migration file next to config.hcl as config.hcl.v<version>.bak
+func backupIndexFile(indexPath string, data []byte, version int) error {
+	backupPath := fmt.Sprintf("%s.v%d.bak", indexPath, version)
+	if _, err := os.Stat(backupPath); err == nil {
+		return nil
+	}
+	return os.WriteFile(backupPath, data, 0644)
+}
+
+// editKubefirstBinaryForConfig used to store <config>_KUBEFIRST_PATH next to KUBEFIRST_PATH; keep only the bare key
+func dropConfigKubefirstPathCopies(indexFile *IndexFile) error {
+	for name, config := range indexFile.Configs {
+		for flag, value := range config.Flags {
+			if flag == "KUBEFIRST_PATH" || !strings.HasSuffix(flag, "_KUBEFIRST_PATH") {
+				continue
+			}
+			if _, ok := config.Flags["KUBEFIRST_PATH"]; !ok {
+				config.Flags["KUBEFIRST_PATH"] = value
+			}
+			delete(config.Flags, flag)
+		}
+		indexFile.Configs[name] = config
+	}
+	return nil
+}
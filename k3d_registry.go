@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+const (
+	// k3d prefixes registry names with "k3d-", so this becomes k3d-k1space-registry
+	k3dRegistryName = "k1space-registry"
+	k3dRegistryPort = "5050"
+	localImageTag   = "dev"
+)
+
+// Kubefirst components whose container images can be built locally and pushed to the k3d registry
+var localImageRepos = []string{"kubefirst-api", "console"}
+
+// k3dRegistry is a registry container managed by k3d
+type k3dRegistry struct {
+	Name     string
+	HostPort string
+}
+
+// pushAddress is where images are pushed from the host
+func (r k3dRegistry) pushAddress() string {
+	return "localhost:" + r.HostPort
+}
+
+// clusterAddress is how pods inside clusters created with --registry-use refer to the registry
+func (r k3dRegistry) clusterAddress() string {
+	return r.Name + ":" + r.HostPort
+}
+
+func listK3dRegistries() ([]k3dRegistry, error) {
+	output, err := exec.Command("k3d", "registry", "list", "-o", "json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error listing k3d registries: %w", err)
+	}
+
+	var nodes []struct {
+		Name         string `json:"name"`
+		PortMappings map[string][]struct {
+			HostPort string `json:"HostPort"`
+		} `json:"portMappings"`
+	}
+	err = json.Unmarshal(output, &nodes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing k3d registry list: %w", err)
+	}
+
+	registries := make([]k3dRegistry, 0, len(nodes))
+	for _, node := range nodes {
+		registry := k3dRegistry{Name: node.Name}
+		for _, bindings := range node.PortMappings {
+			if len(bindings) > 0 {
+				registry.HostPort = bindings[0].HostPort
+				break
+			}
+		}
+		if registry.HostPort != "" {
+			registries = append(registries, registry)
+		}
+	}
+	return registries, nil
+}
+
+// findK3dRegistry returns k1space's registry if it exists, otherwise any other k3d registry on the machine
+func findK3dRegistry() (k3dRegistry, bool, error) {
+	registries, err := listK3dRegistries()
+	if err != nil {
+		return k3dRegistry{}, false, err
+	}
+	for _, registry := range registries {
+		if registry.Name == "k3d-"+k3dRegistryName {
+			return registry, true, nil
+		}
+	}
+	if len(registries) > 0 {
+		return registries[0], true, nil
+	}
+	return k3dRegistry{}, false, nil
+}
+
+// ensureK3dRegistry reuses an existing k3d registry or creates k1space's own
+func ensureK3dRegistry() (k3dRegistry, error) {
+	registry, found, err := findK3dRegistry()
+	if err != nil {
+		return registry, err
+	}
+	if found {
+		fmt.Printf("Reusing k3d registry '%s' on port %s.\n", registry.Name, registry.HostPort)
+		return registry, nil
+	}
+
+	fmt.Printf("Creating k3d registry '%s' on port %s...\n", k3dRegistryName, k3dRegistryPort)
+	createCmd := exec.Command("k3d", "registry", "create", k3dRegistryName, "--port", k3dRegistryPort)
+	createCmd.Stdout = os.Stdout
+	createCmd.Stderr = os.Stderr
+	err = createCmd.Run()
+	if err != nil {
+		return registry, fmt.Errorf("failed to create k3d registry: %w", err)
+	}
+	return k3dRegistry{Name: "k3d-" + k3dRegistryName, HostPort: k3dRegistryPort}, nil
+}
+
+// localRegistryEnv exposes the k3d registry to the dev setup scripts and builds, when the k3d backend has one
+func localRegistryEnv() []string {
+	if getLocalClusterBackend().Name != "k3d" {
+		return nil
+	}
+	registry, found, err := findK3dRegistry()
+	if err != nil || !found {
+		return nil
+	}
+	return []string{
+		"K3D_REGISTRY_USE=" + registry.clusterAddress(),
+		"K1_LOCAL_REGISTRY=" + registry.pushAddress(),
+		"K1_LOCAL_REGISTRY_CLUSTER=" + registry.clusterAddress(),
+	}
+}
+
+// pushLocalImages builds the kubefirst-api and console images and pushes them to the k3d registry
+func pushLocalImages() {
+	if getLocalClusterBackend().Name != "k3d" {
+		fmt.Println("The local registry is only available with the k3d backend. Switch backends in 'Setup Kubefirst'.")
+		return
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		fmt.Println("docker could not be found. Please install it and try again.")
+		return
+	}
+
+	registry, err := ensureK3dRegistry()
+	if err != nil {
+		log.Error("Error setting up k3d registry", "error", err)
+		fmt.Println("Failed to set up the k3d registry:", err)
+		return
+	}
+
+	repoDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".repositories")
+	summary := [][]string{{"Component", "Push Image", "In-Cluster Image", "Status"}}
+	for _, repo := range localImageRepos {
+		pushImage := fmt.Sprintf("%s/%s:%s", registry.pushAddress(), repo, localImageTag)
+		clusterImage := fmt.Sprintf("%s/%s:%s", registry.clusterAddress(), repo, localImageTag)
+		dir := filepath.Join(repoDir, repo)
+
+		if _, err := os.Stat(filepath.Join(dir, "Dockerfile")); err != nil {
+			summary = append(summary, []string{repo, pushImage, clusterImage, "No Dockerfile, clone repositories first"})
+			continue
+		}
+
+		s := startSpinner(fmt.Sprintf("Building %s...", pushImage))
+		output, err := exec.Command("docker", "build", "-t", pushImage, dir).CombinedOutput()
+		stopSpinner(s, err == nil)
+		if err != nil {
+			log.Error("Error building image", "repo", repo, "error", err, "output", string(output))
+			summary = append(summary, []string{repo, pushImage, clusterImage, "Failed to build"})
+			continue
+		}
+
+		s = startSpinner(fmt.Sprintf("Pushing %s...", pushImage))
+		output, err = exec.Command("docker", "push", pushImage).CombinedOutput()
+		stopSpinner(s, err == nil)
+		if err != nil {
+			log.Error("Error pushing image", "repo", repo, "error", err, "output", string(output))
+			summary = append(summary, []string{repo, pushImage, clusterImage, "Failed to push"})
+			continue
+		}
+		summary = append(summary, []string{repo, pushImage, clusterImage, "Pushed"})
+	}
+
+	printSummaryTable("Local Registry Images", summary)
+	fmt.Println(strings.TrimSpace(fmt.Sprintf(`
+Reference the in-cluster images in your manifests or Helm values to run local builds.
+Clusters need to be created with --registry-use %s to pull from the registry; 'Setup Kubefirst' does this for k3d.`, registry.clusterAddress())))
+}
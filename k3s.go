@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	k3sInventoryFile     = "inventory.ini"
+	k3sInstallScriptFile = "00-install-k3s.sh"
+)
+
+func splitHosts(value string) []string {
+	var hosts []string
+	for _, host := range strings.Split(value, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+func validateHosts(value string) error {
+	for _, host := range splitHosts(value) {
+		if strings.ContainsAny(host, " @/") {
+			return fmt.Errorf("%q is not a valid host; enter hostnames or IP addresses only", host)
+		}
+	}
+	return nil
+}
+
+// promptK3sInventory asks for the nodes to install K3s on and records them on the config.
+// It returns the kubefirst flags it filled in, so the caller can leave them out of the flag form.
+func promptK3sInventory(config *CloudConfig, flags map[string]string) ([]string, error) {
+	var servers, agents, location string
+	sshUser := "root"
+	sshKeyPath := filepath.Join(os.Getenv("HOME"), ".ssh", "id_ed25519")
+
+	err := runForm(newForm(
+		newGroup(
+			newInput("Enter the K3s server hosts (comma separated)", &servers).
+				Description("The first server initializes the cluster; the rest join as additional control plane nodes.").
+				Validate(func(value string) error {
+					if len(splitHosts(value)) == 0 {
+						return fmt.Errorf("at least one server host is required")
+					}
+					return validateHosts(value)
+				}),
+			newInput("Enter the K3s agent hosts (comma separated, optional)", &agents).
+				Validate(validateHosts),
+			newInput("Enter the SSH user", &sshUser),
+			newInput("Enter the path to the SSH private key", &sshKeyPath).
+				Validate(func(value string) error {
+					if _, err := os.Stat(value); err != nil {
+						return fmt.Errorf("cannot read SSH key: %w", err)
+					}
+					return nil
+				}),
+			newInput("Enter a location name for these nodes", &location).
+				Description("Used in place of a cloud region to organize configs. Default is 'onprem'").
+				Placeholder("onprem"),
+		),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if location == "" {
+		location = "onprem"
+	}
+	config.Region = location
+	config.K3s = &K3sInventory{
+		Servers:    splitHosts(servers),
+		Agents:     splitHosts(agents),
+		SSHUser:    sshUser,
+		SSHKeyPath: sshKeyPath,
+	}
+
+	// Pre-fill the kubefirst flags that describe the same nodes
+	values := map[string]string{
+		"servers-public-ips": strings.Join(config.K3s.Servers, ","),
+		"ssh-user":           sshUser,
+		"ssh-privatekey":     sshKeyPath,
+	}
+	var filled []string
+	for flag, value := range values {
+		if _, ok := flags[flag]; ok {
+			config.Flags.Store(flag, value)
+			filled = append(filled, flag)
+		}
+	}
+
+	return filled, nil
+}
+
+// generateK3sInventory renders the nodes as an Ansible-style inventory
+func generateK3sInventory(inventory *K3sInventory) string {
+	var content strings.Builder
+	content.WriteString("[server]\n")
+	for _, host := range inventory.Servers {
+		content.WriteString(host + "\n")
+	}
+	content.WriteString("\n[agent]\n")
+	for _, host := range inventory.Agents {
+		content.WriteString(host + "\n")
+	}
+	content.WriteString("\n[k3s_cluster:children]\nserver\nagent\n")
+	content.WriteString(fmt.Sprintf("\n[all:vars]\nansible_user=%s\nansible_ssh_private_key_file=%s\n", inventory.SSHUser, inventory.SSHKeyPath))
+	return content.String()
+}
+
+// generateK3sInstallContent renders a script that installs K3s on every node that doesn't already run it
+func generateK3sInstallContent(inventory *K3sInventory) string {
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf(`#!/bin/bash
+set -euo pipefail
+
+SSH_USER=%q
+SSH_KEY=%q
+FIRST_SERVER=%q
+
+run_remote() {
+    ssh -i "$SSH_KEY" -o StrictHostKeyChecking=accept-new "$SSH_USER@$1" "$2"
+}
+
+install_k3s() {
+    local host=$1
+    local args=$2
+    if run_remote "$host" "command -v k3s >/dev/null 2>&1"; then
+        echo "K3s already installed on $host, skipping"
+        return
+    fi
+    echo "Installing K3s on $host"
+    run_remote "$host" "curl -sfL https://get.k3s.io | sudo sh -s - $args"
+}
+
+install_k3s "$FIRST_SERVER" "server --cluster-init --tls-san $FIRST_SERVER"
+K3S_TOKEN=$(run_remote "$FIRST_SERVER" "sudo cat /var/lib/rancher/k3s/server/node-token")
+K3S_URL="https://$FIRST_SERVER:6443"
+
+`, inventory.SSHUser, inventory.SSHKeyPath, inventory.Servers[0]))
+
+	for _, host := range inventory.Servers[1:] {
+		content.WriteString(fmt.Sprintf("install_k3s %q \"server --server $K3S_URL --token $K3S_TOKEN\"\n", host))
+	}
+	for _, host := range inventory.Agents {
+		content.WriteString(fmt.Sprintf("install_k3s %q \"agent --server $K3S_URL --token $K3S_TOKEN\"\n", host))
+	}
+
+	content.WriteString("\necho \"K3s is installed on all nodes\"\n")
+	return content.String()
+}
+
+// writeK3sFiles writes the inventory and install script next to the generated kubefirst scripts
+func writeK3sFiles(inventory *K3sInventory, baseDir string) error {
+	err := os.WriteFile(filepath.Join(baseDir, k3sInventoryFile), []byte(generateK3sInventory(inventory)), 0644)
+	if err != nil {
+		return fmt.Errorf("error writing %s: %w", k3sInventoryFile, err)
+	}
+
+	err = os.WriteFile(filepath.Join(baseDir, k3sInstallScriptFile), []byte(generateK3sInstallContent(inventory)), 0755)
+	if err != nil {
+		return fmt.Errorf("error writing %s: %w", k3sInstallScriptFile, err)
+	}
+	return nil
+}
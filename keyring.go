@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+// keyringGet reads a token kept under the k1space service with its variable name as the account: in Keychain on
+// macOS, the Secret Service (GNOME Keyring, KWallet) on Linux and the BSDs, and as a generic credential named
+// k1space:<NAME> in Windows Credential Manager
+func keyringGet(name string) (string, error) {
+	value, err := keyring.Get(keyringService, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", errKeyringNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading keychain: %w", err)
+	}
+	return value, nil
+}
+
+func keyringSet(name, value string) error {
+	if value == "" {
+		return fmt.Errorf("refusing to store an empty %s", name)
+	}
+	err := keyring.Set(keyringService, name, value)
+	if err != nil {
+		return fmt.Errorf("error writing keychain: %w", err)
+	}
+	return nil
+}
+
+func keyringDelete(name string) error {
+	err := keyring.Delete(keyringService, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return errKeyringNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error deleting from keychain: %w", err)
+	}
+	return nil
+}
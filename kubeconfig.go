@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/civo/civogo"
+	"gopkg.in/yaml.v2"
+)
+
+// kubeconfigFile is the part of a kubeconfig k1space rewrites when merging; everything else is kept in Extra
+type kubeconfigFile struct {
+	APIVersion     string                 `yaml:"apiVersion,omitempty"`
+	Kind           string                 `yaml:"kind,omitempty"`
+	Clusters       []kubeconfigEntry      `yaml:"clusters"`
+	Contexts       []kubeconfigEntry      `yaml:"contexts"`
+	Users          []kubeconfigEntry      `yaml:"users"`
+	CurrentContext string                 `yaml:"current-context"`
+	Extra          map[string]interface{} `yaml:",inline"`
+}
+
+// kubeconfigEntry is a named cluster, context or user; the body is kept as-is
+type kubeconfigEntry struct {
+	Name   string                 `yaml:"name"`
+	Fields map[string]interface{} `yaml:",inline"`
+}
+
+// configKubeconfigPath is where k1space keeps a config's kubeconfig, next to its scripts
+func configKubeconfigPath(configName string) (string, error) {
+	parts := strings.Split(configName, "_")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("invalid config name format: %s", configName)
+	}
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", parts[0], parts[1], parts[2], "kubeconfig"), nil
+}
+
+func defaultKubeConfigPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".kube", "config")
+}
+
+// retrieveClusterKubeconfig downloads a config's kubeconfig from the Civo or DigitalOcean API, and copies the one
+// kubefirst wrote to ~/.k1 for other clouds
+func retrieveClusterKubeconfig(ctx context.Context, cloud, region, clusterName string) ([]byte, error) {
+	cluster := cloudCluster{Provider: cloudProviderName(cloud), Name: clusterName, Region: region}
+	switch strings.ToLower(cloud) {
+	case "civo":
+		client, err := civoRegionClient(region)
+		if err != nil {
+			return nil, err
+		}
+		var found *civogo.KubernetesCluster
+		err = awaitContext(ctx, func() (err error) {
+			found, err = client.FindKubernetesCluster(clusterName)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error finding %s on Civo: %w", clusterName, err)
+		}
+		cluster.ID = found.ID
+	case "digitalocean":
+		clusters, err := listDigitalOceanClusters(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, found := range clusters {
+			if found.Name == clusterName {
+				cluster.ID = found.ID
+				break
+			}
+		}
+		if cluster.ID == "" {
+			return nil, fmt.Errorf("no DigitalOcean cluster named %s", clusterName)
+		}
+	default:
+		data, err := os.ReadFile(clusterKubeconfigPath(clusterName))
+		if err != nil {
+			return nil, fmt.Errorf("error reading the kubeconfig kubefirst wrote: %w", err)
+		}
+		return data, nil
+	}
+	return fetchCloudKubeconfig(ctx, cluster)
+}
+
+// renameKubeconfigContext names the cluster, user and context of a single-cluster kubeconfig after name, so
+// merging it can't clash with entries already in ~/.kube/config
+func renameKubeconfigContext(data []byte, name string) (kubeconfigFile, error) {
+	var kubeconfig kubeconfigFile
+	err := yaml.Unmarshal(data, &kubeconfig)
+	if err != nil {
+		return kubeconfig, fmt.Errorf("error parsing kubeconfig: %w", err)
+	}
+	if len(kubeconfig.Clusters) != 1 || len(kubeconfig.Users) != 1 || len(kubeconfig.Contexts) != 1 {
+		return kubeconfig, fmt.Errorf("expected one cluster, user and context, found %d, %d and %d", len(kubeconfig.Clusters), len(kubeconfig.Users), len(kubeconfig.Contexts))
+	}
+
+	kubeconfig.Clusters[0].Name = name
+	kubeconfig.Users[0].Name = name
+	kubeconfig.Contexts[0].Name = name
+	body, ok := kubeconfig.Contexts[0].Fields["context"].(map[interface{}]interface{})
+	if !ok {
+		return kubeconfig, fmt.Errorf("context %s has no body", name)
+	}
+	body["cluster"] = name
+	body["user"] = name
+	kubeconfig.CurrentContext = name
+	return kubeconfig, nil
+}
+
+// mergeKubeconfigEntries replaces entries with the same name and appends the rest
+func mergeKubeconfigEntries(existing, added []kubeconfigEntry) []kubeconfigEntry {
+	for _, entry := range added {
+		replaced := false
+		for i := range existing {
+			if existing[i].Name == entry.Name {
+				existing[i] = entry
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			existing = append(existing, entry)
+		}
+	}
+	return existing
+}
+
+// mergeIntoKubeConfig adds a cluster's kubeconfig to ~/.kube/config as contextName, keeping a backup of the
+// previous file. The current context is only switched when makeCurrent is set.
+func mergeIntoKubeConfig(data []byte, contextName string, makeCurrent bool) error {
+	added, err := renameKubeconfigContext(data, contextName)
+	if err != nil {
+		return err
+	}
+
+	path := defaultKubeConfigPath()
+	merged := kubeconfigFile{APIVersion: "v1", Kind: "Config"}
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		err = yaml.Unmarshal(existing, &merged)
+		if err != nil {
+			return fmt.Errorf("error parsing %s: %w", path, err)
+		}
+		err = os.WriteFile(path+".k1space.bak", existing, 0600)
+		if err != nil {
+			return fmt.Errorf("error backing up %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	merged.Clusters = mergeKubeconfigEntries(merged.Clusters, added.Clusters)
+	merged.Users = mergeKubeconfigEntries(merged.Users, added.Users)
+	merged.Contexts = mergeKubeconfigEntries(merged.Contexts, added.Contexts)
+	if makeCurrent || merged.CurrentContext == "" {
+		merged.CurrentContext = contextName
+	}
+
+	out, err := yaml.Marshal(merged)
+	if err != nil {
+		return fmt.Errorf("error encoding kubeconfig: %w", err)
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0600)
+}
+
+// saveConfigKubeconfig downloads a config's kubeconfig to configKubeconfigPath and offers to merge it into
+// ~/.kube/config
+func saveConfigKubeconfig(configName string, config Config) {
+	parts := strings.Split(configName, "_")
+	if len(parts) != 3 {
+		log.Error("Invalid config name format", "config", configName)
+		return
+	}
+	cloud, region := parts[0], parts[1]
+	clusterName := findConfigFlag(config.Flags, "cluster-name")
+	if clusterName == "" {
+		fmt.Printf("%s has no cluster name set, so its kubeconfig can't be fetched.\n", configName)
+		return
+	}
+	useCredentialProfile(cloud, configCredentialProfile(config))
+
+	var kubeconfig []byte
+	err := runCancellable(fmt.Sprintf("Fetching the kubeconfig of %s...", clusterName), func(ctx context.Context) (err error) {
+		kubeconfig, err = retrieveClusterKubeconfig(ctx, cloud, region, clusterName)
+		return err
+	})
+	if err != nil {
+		err = describeNetworkError("Fetching the kubeconfig", err)
+		log.Error("Error fetching kubeconfig", "config", configName, "error", err)
+		fmt.Println(err)
+		return
+	}
+
+	path, err := configKubeconfigPath(configName)
+	if err == nil {
+		err = os.WriteFile(path, kubeconfig, 0600)
+	}
+	if err != nil {
+		log.Error("Error saving kubeconfig", "path", path, "error", err)
+		fmt.Println("Failed to save the kubeconfig:", err)
+		return
+	}
+	log.Info("Saved kubeconfig", "config", configName, "path", path)
+	if isStructuredOutput() {
+		return
+	}
+	fmt.Printf("Saved the kubeconfig to %s\n", path)
+
+	merge := true
+	makeCurrent := false
+	contextName := "k1-" + clusterName
+	err = runForm(newForm(
+		newGroup(
+			newConfirm(fmt.Sprintf("Merge it into %s?", defaultKubeConfigPath()), &merge),
+		),
+		hideGroupWhen(newGroup(
+			newInput("Context name", &contextName).
+				Validate(func(s string) error {
+					if strings.TrimSpace(s) == "" {
+						return fmt.Errorf("context name cannot be empty")
+					}
+					return nil
+				}),
+			newConfirm("Switch to this context?", &makeCurrent),
+		), func() bool { return !merge }),
+	))
+	if err != nil {
+		log.Error("Error in kubeconfig merge prompt", "error", err)
+		return
+	}
+	if !merge {
+		fmt.Printf("Use it with: export KUBECONFIG=%s\n", path)
+		return
+	}
+
+	contextName = strings.TrimSpace(contextName)
+	err = mergeIntoKubeConfig(kubeconfig, contextName, makeCurrent)
+	if err != nil {
+		log.Error("Error merging kubeconfig", "error", err)
+		fmt.Println("Failed to merge the kubeconfig:", err)
+		fmt.Printf("Use it with: export KUBECONFIG=%s\n", path)
+		return
+	}
+	fmt.Printf("Merged into %s as context %s. Use it with: kubectl --context %s get nodes\n", defaultKubeConfigPath(), contextName, contextName)
+}
+
+func fetchKubeconfigForConfig() {
+	log.Info("Starting fetchKubeconfigForConfig function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations:", err)
+		return
+	}
+	selectedConfig, err := promptConfigSelection(indexFile, "Select the cluster to fetch the kubeconfig of")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations available. Please create a configuration first.")
+		return
+	}
+	saveConfigKubeconfig(selectedConfig, indexFile.Configs[selectedConfig])
+}
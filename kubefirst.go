@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -15,6 +16,7 @@ import (
 	"github.com/fatih/color"
 )
 
+// Backend-specific placeholders are filled in by renderClusterScript
 const kubefirstAPISetupScript = `#!/bin/bash
 set -e
 
@@ -31,8 +33,9 @@ echo "API directory: ${API_DIR}"
 
 cd "${API_DIR}"
 
+__CLUSTER_FUNCTIONS__
 # Check for required tools
-for cmd in go k3d kubectl make air swag; do
+for cmd in go __CLUSTER_CLI__ kubectl make air swag; do
     if ! command -v $cmd &> /dev/null; then
         echo "ERROR: $cmd could not be found. Please install it and try again."
         exit 1
@@ -43,26 +46,26 @@ echo "Installing required tools..."
 go install github.com/air-verse/air@latest
 go install github.com/swaggo/swag/cmd/swag@latest
 
-# Check k3d cluster and wait for it to be ready
+# Check __CLUSTER_CLI__ cluster and wait for it to be ready
 max_retries=5
 retries=0
-while ! k3d cluster list | grep -q "dev"; do
+while ! cluster_exists "__CLUSTER_NAME__"; do
     if [ $retries -ge $max_retries ]; then
-        echo "ERROR: k3d cluster 'dev' not found after $max_retries attempts. Please check k3d setup."
+        echo "ERROR: __CLUSTER_CLI__ cluster '__CLUSTER_NAME__' not found after $max_retries attempts. Please check __CLUSTER_CLI__ setup."
         exit 1
     fi
-    echo "Waiting for k3d cluster 'dev' to be ready..."
+    echo "Waiting for __CLUSTER_CLI__ cluster '__CLUSTER_NAME__' to be ready..."
     sleep 10
     retries=$((retries+1))
 done
 
-echo "k3d cluster 'dev' is ready."
+echo "__CLUSTER_CLI__ cluster '__CLUSTER_NAME__' is ready."
 
 # Set environment variables
 export K1_LOCAL_DEBUG=true
-export K1_LOCAL_KUBECONFIG_PATH=$(k3d kubeconfig get dev)
+export K1_LOCAL_KUBECONFIG_PATH=$(cluster_kubeconfig "__CLUSTER_NAME__")
 export CLUSTER_ID="local-dev"
-export CLUSTER_TYPE="k3d"
+export CLUSTER_TYPE="__CLUSTER_CLI__"
 export INSTALL_METHOD="local"
 export K1_ACCESS_TOKEN="local-dev-token"
 export IS_CLUSTER_ZERO=true
@@ -83,6 +86,11 @@ set -a
 source "${ENV_FILE}"
 set +a
 
+# Connection settings for a state store k1space manages take precedence over .env
+for var in ${K1_STATE_STORE_VARS:-}; do
+    export "$var"
+done
+
 echo "Environment variables set:"
 env | grep -E 'K1_|CLUSTER_|KUBECONFIG' | sed 's/^/  /'
 
@@ -109,10 +117,7 @@ func setupKubefirstRepositories() {
 	}
 
 	var branch string
-	err := huh.NewInput().
-		Title("Enter the branch name to checkout (default: main)").
-		Value(&branch).
-		Run()
+	err := runField(newInput("Enter the branch name to checkout (default: main)", &branch))
 
 	if err != nil {
 		log.Error("Error getting branch name", "error", err)
@@ -134,6 +139,9 @@ func setupKubefirstRepositories() {
 	summary := make([][]string, 0, len(repos)+1)
 	summary = append(summary, []string{"Repository", "Clone Path", "Symlink Path", "Branch", "Status"})
 
+	ctx, setupSpan := startSpan(context.Background(), "setup repositories", "branch", branch)
+	defer setupSpan.end(nil)
+
 	for _, repo := range repos {
 		repoName := filepath.Base(repo)
 		repoPath := filepath.Join(repoDir, repoName)
@@ -142,15 +150,17 @@ func setupKubefirstRepositories() {
 		if _, err := os.Stat(repoPath); !os.IsNotExist(err) {
 			// Repository already exists, sync instead
 			fmt.Printf("Repository %s already exists. Syncing...\n", repo)
-			status := syncRepository(repoPath, branch)
+			status := syncRepository(ctx, repoPath, branch)
 			summary = append(summary, []string{repo, repoPath, symlinkPath, branch, status})
 			continue
 		}
 
 		fmt.Printf("Cloning %s...\n", repo)
 
+		_, cloneSpan := startSpan(ctx, "git clone", "repository", repo)
 		cmd := exec.Command("git", "clone", "-b", branch, "https://"+repo+".git", repoPath)
 		output, err := cmd.CombinedOutput()
+		cloneSpan.end(err)
 		if err != nil {
 			log.Error("Error cloning repository", "repo", repo, "error", err, "output", string(output))
 			summary = append(summary, []string{repo, repoPath, symlinkPath, branch, "Failed to clone"})
@@ -171,7 +181,7 @@ func setupKubefirstRepositories() {
 		fmt.Printf("Repository %s setup complete\n", repo)
 	}
 
-	printSummaryTable(summary)
+	printSummaryTable("Repository Setup Summary", summary)
 }
 
 func syncKubefirstRepositories() {
@@ -187,12 +197,22 @@ func syncKubefirstRepositories() {
 	summary := make([][]string, 0, len(repos)+1)
 	summary = append(summary, []string{"Repository", "Path", "Current Branch", "Status"})
 
+	ctx, syncSpan := startSpan(context.Background(), "sync repositories")
+	defer syncSpan.end(nil)
+
 	for _, repo := range repos {
 		if !repo.IsDir() {
 			continue
 		}
 
 		repoPath := filepath.Join(repoDir, repo.Name())
+
+		// Pulling a diverged branch creates a merge commit
+		if !ensureGitIdentity(repoPath) {
+			summary = append(summary, []string{repo.Name(), repoPath, "Unknown", "Git identity not configured"})
+			continue
+		}
+
 		fmt.Printf("Syncing %s...\n", repo.Name())
 
 		// Get current branch
@@ -203,12 +223,12 @@ func syncKubefirstRepositories() {
 			continue
 		}
 
-		status := syncRepository(repoPath, branch)
+		status := syncRepository(ctx, repoPath, branch)
 		summary = append(summary, []string{repo.Name(), repoPath, branch, status})
 		fmt.Printf("Repository %s sync complete\n", repo.Name())
 	}
 
-	printSummaryTable(summary)
+	printSummaryTable("Repository Sync Summary", summary)
 }
 
 func runKubefirst(repoDir, logsDir string) {
@@ -217,6 +237,7 @@ func runKubefirst(repoDir, logsDir string) {
 
 	buildCmd := exec.Command("go", "build", "-o", "kubefirst")
 	buildCmd.Dir = kubefirstDir
+	buildCmd.Env = append(os.Environ(), sharedCacheEnv()...)
 
 	err := runAndLogCommand(buildCmd, logFile, color.FgYellow)
 	if err != nil {
@@ -278,8 +299,9 @@ log_info "Current working directory: $(pwd)"
 log_info "Log file: $LOG_FILE"
 log_info "API directory: $API_DIR"
 
+__CLUSTER_FUNCTIONS__
 # Check for required tools
-for cmd in go k3d kubectl make air swag; do
+for cmd in go __CLUSTER_CLI__ kubectl make air swag; do
     if ! command -v $cmd &> /dev/null; then
         log_error "$cmd could not be found. Please install it and try again."
         exit 1
@@ -290,16 +312,16 @@ log_info "Installing required tools..."
 go install github.com/air-verse/air@latest
 go install github.com/swaggo/swag/cmd/swag@latest
 
-# Check k3d cluster
-if ! k3d cluster list | grep -q "dev"; then
-    log_info "Creating k3d cluster 'dev'..."
-    k3d cluster create dev
+# Check __CLUSTER_CLI__ cluster
+if ! cluster_exists "__CLUSTER_NAME__"; then
+    log_info "Creating __CLUSTER_CLI__ cluster '__CLUSTER_NAME__'..."
+    create_cluster "__CLUSTER_NAME__"
 else
-    log_info "k3d cluster 'dev' already exists."
+    log_info "__CLUSTER_CLI__ cluster '__CLUSTER_NAME__' already exists."
 fi
 
 log_info "Ensuring kubeconfig is accessible..."
-KUBECONFIG_PATH=$(k3d kubeconfig write dev)
+KUBECONFIG_PATH=$(cluster_kubeconfig "__CLUSTER_NAME__")
 export KUBECONFIG="$KUBECONFIG_PATH"
 log_info "Kubeconfig path: $KUBECONFIG_PATH"
 
@@ -311,7 +333,7 @@ kubectl wait --for=condition=Ready nodes --all --timeout=300s
 export K1_LOCAL_DEBUG=true
 export K1_LOCAL_KUBECONFIG_PATH="$KUBECONFIG_PATH"
 export CLUSTER_ID="local-dev"
-export CLUSTER_TYPE="k3d"
+export CLUSTER_TYPE="__CLUSTER_CLI__"
 export INSTALL_METHOD="local"
 export K1_ACCESS_TOKEN="local-dev-token"
 export IS_CLUSTER_ZERO=true
@@ -332,6 +354,11 @@ set -a
 source "$ENV_FILE"
 set +a
 
+# Connection settings for a state store k1space manages take precedence over .env
+for var in ${K1_STATE_STORE_VARS:-}; do
+    export "$var"
+done
+
 log_info "Environment variables set:"
 env | grep -E 'K1_|CLUSTER_|KUBECONFIG' | sed 's/^/  /'
 
@@ -351,7 +378,7 @@ air
 `
 
 	// Create the script file
-	err = os.WriteFile(scriptFile, []byte(setupScript), 0755)
+	err = os.WriteFile(scriptFile, []byte(renderClusterScript(setupScript, getLocalClusterBackend())), 0755)
 	if err != nil {
 		log.Error("Failed to create setup script", "error", err, "path", scriptFile)
 		return
@@ -369,6 +396,8 @@ air
 	log.Info("Running kubefirst-api setup script")
 	cmd := exec.Command("bash", scriptFile)
 	cmd.Dir = apiDir
+	cmd.Env = append(os.Environ(), sharedCacheEnv()...)
+	cmd.Env = append(cmd.Env, localRegistryEnv()...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
@@ -446,10 +475,7 @@ func runConsole(repoDir, logsDir string) {
 func runKubefirstSetup() error {
 	// Prompt for branch name
 	var branch string
-	err := huh.NewInput().
-		Title("Enter the branch name to checkout (default: main)").
-		Value(&branch).
-		Run()
+	err := runField(newInput("Enter the branch name to checkout (default: main)", &branch))
 
 	if err != nil {
 		return fmt.Errorf("error getting branch name: %w", err)
@@ -498,15 +524,13 @@ func setupConsoleEnvironment() error {
 	// Check if .env already exists
 	if _, err := os.Stat(envPath); err == nil {
 		var overwrite bool
-		form := huh.NewForm(
-			huh.NewGroup(
-				huh.NewConfirm().
-					Title("The .env file in `Console` already exists. Do you want to overwrite it?").
-					Value(&overwrite),
+		form := newForm(
+			newGroup(
+				newConfirm("The .env file in `Console` already exists. Do you want to overwrite it?", &overwrite),
 			),
 		)
 
-		err = form.Run()
+		err = runForm(form)
 		if err != nil {
 			return fmt.Errorf("error in user prompt: %w", err)
 		}
@@ -546,43 +570,48 @@ func setupKubefirstAPI(branch string) error {
 	scriptFile := filepath.Join(apiDir, "setup_and_run.sh")
 
 	// Create the script file
-	err = os.WriteFile(scriptFile, []byte(kubefirstAPISetupScript), 0755)
+	backend, err := promptLocalClusterBackend()
+	if err != nil {
+		return fmt.Errorf("error selecting local cluster backend: %w", err)
+	}
+
+	err = os.WriteFile(scriptFile, []byte(renderClusterScript(kubefirstAPISetupScript, backend)), 0755)
 	if err != nil {
 		return fmt.Errorf("failed to create setup script: %w", err)
 	}
-	log.Info("Created setup script", "path", scriptFile)
+	log.Info("Created setup script", "path", scriptFile, "backend", backend.Name)
 
-	// Check if k3d cluster exists
-	clusterExists, err := checkK3dClusterExists("dev")
+	// Check if the local cluster exists
+	clusterExists, err := backend.exists(localClusterName)
 	if err != nil {
-		return fmt.Errorf("error checking k3d cluster: %w", err)
+		return fmt.Errorf("error checking %s cluster: %w", backend.Name, err)
 	}
 
 	if clusterExists {
-		printK3dClusters()
+		backend.list()
 
 		var deleteCluster bool
-		err := huh.NewConfirm().
-			Title("k3d cluster 'dev' already exists. Do you want to delete and recreate it?").
-			Value(&deleteCluster).
-			Run()
+		err := runField(newConfirm(fmt.Sprintf("%s cluster '%s' already exists. Do you want to delete and recreate it?", backend.Name, localClusterName), &deleteCluster))
 
 		if err != nil {
 			return fmt.Errorf("error in user prompt: %w", err)
 		}
 
 		if deleteCluster {
-			err = deleteAndRecreateK3dCluster("dev")
+			err = backend.delete(localClusterName)
+			if err == nil {
+				err = backend.create(localClusterName)
+			}
 			if err != nil {
-				return fmt.Errorf("error deleting and recreating k3d cluster: %w", err)
+				return fmt.Errorf("error deleting and recreating %s cluster: %w", backend.Name, err)
 			}
 		} else {
-			fmt.Println("Using existing k3d cluster 'dev'.")
+			fmt.Printf("Using existing %s cluster '%s'.\n", backend.Name, localClusterName)
 		}
 	} else {
-		err = createK3dCluster("dev")
+		err = backend.create(localClusterName)
 		if err != nil {
-			return fmt.Errorf("error creating k3d cluster: %w", err)
+			return fmt.Errorf("error creating %s cluster: %w", backend.Name, err)
 		}
 	}
 
@@ -594,7 +623,7 @@ func setupKubefirstAPI(branch string) error {
 	}
 
 	fmt.Printf("Checked out %s branch for Kubefirst API\n", branch)
-	fmt.Println("Setup script created and k3d cluster setup completed.")
+	fmt.Printf("Setup script created and %s cluster setup completed.\n", backend.Name)
 	fmt.Println("You can now use the 'Run Kubefirst Repositories' command to start the API.")
 
 	return nil
@@ -654,6 +683,7 @@ func setupKubefirst(branch string) error {
 	// Build the kubefirst binary
 	buildCmd := exec.Command("go", "build", "-o", "kubefirst")
 	buildCmd.Dir = kubefirstDir
+	buildCmd.Env = append(os.Environ(), sharedCacheEnv()...)
 	buildOutput, err := buildCmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("error building kubefirst: %w\nOutput: %s", err, buildOutput)
@@ -661,6 +691,11 @@ func setupKubefirst(branch string) error {
 
 	fmt.Println("Built Kubefirst binary successfully")
 
+	err = recordProvenance("kubefirst", filepath.Join(kubefirstDir, "kubefirst"), branch, gitSource(kubefirstDir))
+	if err != nil {
+		log.Warn("Failed to record kubefirst provenance", "error", err)
+	}
+
 	return nil
 }
 
@@ -672,10 +707,7 @@ func revertKubefirstToMain() {
 	summary := make(map[string]string)
 
 	var stashChanges bool
-	err := huh.NewConfirm().
-		Title("Local changes detected. Do you want to stash changes in the repositories?").
-		Value(&stashChanges).
-		Run()
+	err := runField(newConfirm("Local changes detected. Do you want to stash changes in the repositories?", &stashChanges))
 
 	if err != nil {
 		log.Error("Error in user prompt", "error", err)
@@ -690,6 +722,12 @@ func revertKubefirstToMain() {
 	for _, repo := range repos {
 		repoPath := filepath.Join(baseDir, ".repositories", repo)
 
+		// Stashing and pulling both need a commit identity
+		if !ensureGitIdentity(repoPath) {
+			summary[repo] = "Git identity not configured"
+			continue
+		}
+
 		// Check for local changes
 		cmd := exec.Command("git", "-C", repoPath, "status", "--porcelain")
 		output, err := cmd.Output()
@@ -777,6 +815,7 @@ func runKubefirstRepositories() {
 		log.Error("Error creating logs directory", "error", err)
 		return
 	}
+	applyLogRetention()
 
 	// Check if the script file exists
 	if _, err := os.Stat(scriptFile); os.IsNotExist(err) {
@@ -784,50 +823,100 @@ func runKubefirstRepositories() {
 		return
 	}
 
-	timestamp := time.Now().Format("2006-01-02-150405")
+	// The same tools setup_and_run.sh checks for, plus yarn for the console, offered for install up front rather
+	// than failing half-way through the script
+	if !ensureDependencies("go", getLocalClusterBackend().Name, "kubectl", "make", "air", "swag", "yarn") {
+		return
+	}
 
-	kubefirstAPILogs := &scrollingLog{}
-	consoleLogs := &scrollingLog{}
-	kubefirstLogs := &scrollingLog{}
+	var apiEnv []string
+	if store, ok := getLocalStateStore(); ok {
+		s := startSpinner(fmt.Sprintf("Starting %s state store...", store.Name))
+		err = startStateStore(store)
+		stopSpinner(s, err == nil)
+		if err != nil {
+			log.Error("Error starting state store", "store", store.Name, "error", err)
+			fmt.Println("Failed to start the state store:", err)
+			return
+		}
+		defer stopStateStore(store)
+		apiEnv = append(store.apiEnv, "K1_STATE_STORE_VARS="+strings.Join(store.apiEnv, " "))
+	}
+
+	timestamp := time.Now().Format("2006-01-02-150405")
+	startMetricsServer()
+	ctx, runSpan := startSpan(context.Background(), "run kubefirst repositories")
+	defer runSpan.end(nil)
+
+	kubefirstAPILogs := newScrollingLog("kubefirst-api")
+	consoleLogs := newScrollingLog("console")
+	kubefirstLogs := newScrollingLog("kubefirst")
+	controls := newLogPaneControls(logsDir, kubefirstLogs, consoleLogs, kubefirstAPILogs)
+	kubefirstAPIService := newLocalService("kubefirst-api")
+	consoleService := newLocalService("console")
+	kubefirstService := newLocalService("kubefirst")
+	services := []*localService{kubefirstAPIService, consoleService, kubefirstService}
+	controls.addServices(services...)
+	stopWatching := stopServicesOnInterrupt(services)
 
 	var wg sync.WaitGroup
 	wg.Add(3)
 
 	go func() {
 		defer wg.Done()
-		runServiceWithColoredLogs("kubefirst-api", filepath.Join(repoDir, "kubefirst-api"), logsDir, timestamp, color.New(color.FgMagenta), func(dir string) *exec.Cmd {
-			return exec.Command("bash", scriptFile)
+		runServiceWithColoredLogs(ctx, kubefirstAPIService, filepath.Join(repoDir, "kubefirst-api"), logsDir, timestamp, color.New(color.FgMagenta), func(dir string) *exec.Cmd {
+			cmd := exec.Command("bash", scriptFile)
+			cmd.Env = apiEnv
+			return cmd
 		}, kubefirstAPILogs)
 	}()
 
 	go func() {
 		defer wg.Done()
-		runServiceWithColoredLogs("console", filepath.Join(repoDir, "console"), logsDir, timestamp, color.New(color.FgCyan), func(dir string) *exec.Cmd {
-			return exec.Command("yarn", "dev")
+		runServiceWithColoredLogs(ctx, consoleService, filepath.Join(repoDir, "console"), logsDir, timestamp, color.New(color.FgCyan), func(dir string) *exec.Cmd {
+			cmd := consoleDevCommand()
+			cmd.Env = consoleSecretEnv(dir)
+			return cmd
 		}, consoleLogs)
 	}()
 
 	go func() {
 		defer wg.Done()
-		runServiceWithColoredLogs("kubefirst", filepath.Join(repoDir, "kubefirst"), logsDir, timestamp, color.New(color.FgYellow), func(dir string) *exec.Cmd {
+		runServiceWithColoredLogs(ctx, kubefirstService, filepath.Join(repoDir, "kubefirst"), logsDir, timestamp, color.New(color.FgYellow), func(dir string) *exec.Cmd {
 			return exec.Command("go", "run", "main.go")
 		}, kubefirstLogs)
 	}()
 
-	go updateDisplayWithLogs(kubefirstAPILogs, consoleLogs, kubefirstLogs)
+	urlChecker := newLocalURLChecker()
+	stopChecks := make(chan struct{})
+	defer close(stopChecks)
+	go urlChecker.run(stopChecks)
+
+	usageSampler := newServiceUsageSampler(services)
+	go usageSampler.run(stopChecks)
+
+	go updateDisplayWithLogs(kubefirstAPILogs, consoleLogs, kubefirstLogs, urlChecker, usageSampler, controls)
 
-	fmt.Println("Press 'q' to quit and return to the main menu.")
-	waitForQuit()
+	fmt.Println("Type 'q' and press Enter to quit and return to the main menu.")
+	controls.run()
+
+	// The services run in their own process groups, so they'd outlive k1space if they weren't stopped here
+	stopWatching()
+	for _, service := range services {
+		service.stop()
+	}
 }
 
-func updateDisplayWithLogs(kubefirstAPILogs, consoleLogs, kubefirstLogs *scrollingLog) {
+func updateDisplayWithLogs(kubefirstAPILogs, consoleLogs, kubefirstLogs *scrollingLog, urlChecker *localURLChecker, usageSampler *serviceUsageSampler, controls *logPaneControls) {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
+	swaggerDiff := &swaggerDiffCache{apiDir: filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".repositories", "kubefirst-api")}
+
 	for {
 		select {
 		case <-ticker.C:
-			display := renderDashboard(kubefirstAPILogs, consoleLogs, kubefirstLogs)
+			display := renderDashboard(kubefirstAPILogs, consoleLogs, kubefirstLogs, swaggerDiff, urlChecker.snapshot(), usageSampler.snapshot(), controls)
 			fmt.Print("\033[2J") // Clear the screen
 			fmt.Print("\033[H")  // Move cursor to top-left corner
 			fmt.Print(display)
@@ -835,7 +924,11 @@ func updateDisplayWithLogs(kubefirstAPILogs, consoleLogs, kubefirstLogs *scrolli
 	}
 }
 
-func runServiceWithColoredLogs(serviceName, serviceDir, logsDir, timestamp string, printer *color.Color, cmdCreator func(string) *exec.Cmd, logs *scrollingLog) {
+// runServiceWithColoredLogs runs a service until it exits, starting it again when the dashboard restarts it with
+// another log level. Each run is traced as a child of ctx's span, covering builds the service runs on start,
+// e.g. `go run`.
+func runServiceWithColoredLogs(ctx context.Context, service *localService, serviceDir, logsDir, timestamp string, printer *color.Color, cmdCreator func(string) *exec.Cmd, logs *scrollingLog) {
+	serviceName := service.name
 	logFileName := fmt.Sprintf("%s-%s.log", serviceName, timestamp)
 	logFile := filepath.Join(logsDir, logFileName)
 	f, err := os.Create(logFile)
@@ -845,48 +938,81 @@ func runServiceWithColoredLogs(serviceName, serviceDir, logsDir, timestamp strin
 	}
 	defer f.Close()
 
+	for {
+		err = runServiceOnce(ctx, service, serviceDir, logFile, f, printer, cmdCreator, logs)
+		if !service.takeRestart() {
+			break
+		}
+		logs.add(fmt.Sprintf("Restarting %s with log level %s", serviceName, service.describeLevel()))
+	}
+	if err != nil && !service.isStopped() {
+		log.Error("Service exited with error", "service", serviceName, "error", err)
+	}
+}
+
+func runServiceOnce(ctx context.Context, service *localService, serviceDir, logFile string, f *os.File, printer *color.Color, cmdCreator func(string) *exec.Cmd, logs *scrollingLog) error {
+	serviceName := service.name
 	cmd := cmdCreator(serviceDir)
 	cmd.Dir = serviceDir
+	// Service-specific env from cmdCreator is layered on top of the process environment
+	cmd.Env = append(append(os.Environ(), cmd.Env...), sharedCacheEnv()...)
+	cmd.Env = append(cmd.Env, localRegistryEnv()...)
+	cmd.Env = append(cmd.Env, service.logLevelEnv()...)
+	// A restart has to stop what the service started too, e.g. air or the binary `go run` built
+	detachProcessGroup(cmd)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		log.Error("Error creating stdout pipe", "service", serviceName, "error", err)
-		return
+		return err
 	}
 
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
 		log.Error("Error creating stderr pipe", "service", serviceName, "error", err)
-		return
+		return err
 	}
 
 	err = cmd.Start()
 	if err != nil {
 		log.Error("Error starting service", "service", serviceName, "error", err)
-		return
+		return err
 	}
+	service.setRunning(cmd)
+	defer service.setRunning(nil)
+	metrics.serviceStarted(serviceName)
+	defer metrics.serviceStopped(serviceName)
+	_, serviceSpan := startSpan(ctx, "run service", "service", serviceName, "log", logFile, "log_level", service.describeLevel())
 
 	go logOutput(serviceName, stdout, f, printer, logs)
 	go logOutput(serviceName, stderr, f, printer, logs)
 
 	err = cmd.Wait()
-	if err != nil {
-		log.Error("Service exited with error", "service", serviceName, "error", err)
-	}
+	serviceSpan.end(err)
+	return err
 }
 
-func syncRepository(repoPath, branch string) string {
+// syncRepository fetches and pulls a repository, tracing both under ctx's span
+func syncRepository(ctx context.Context, repoPath, branch string) string {
+	ctx, repoSpan := startSpan(ctx, "sync repository", "repository", filepath.Base(repoPath), "branch", branch)
+	var err error
+	defer func() { repoSpan.end(err) }()
+
 	// Fetch the latest changes
+	_, fetchSpan := startSpan(ctx, "git fetch")
 	cmd := exec.Command("git", "-C", repoPath, "fetch", "origin")
 	output, err := cmd.CombinedOutput()
+	fetchSpan.end(err)
 	if err != nil {
 		log.Error("Error fetching repository", "repo", repoPath, "error", err, "output", string(output))
 		return "Failed to fetch"
 	}
 
 	// Pull the latest changes for the current branch
+	_, pullSpan := startSpan(ctx, "git pull")
 	cmd = exec.Command("git", "-C", repoPath, "pull", "origin", branch)
 	output, err = cmd.CombinedOutput()
+	pullSpan.end(err)
 	if err != nil {
 		log.Error("Error pulling latest changes", "repo", repoPath, "branch", branch, "error", err, "output", string(output))
 		return "Failed to pull latest changes"
@@ -898,8 +1024,13 @@ func syncRepository(repoPath, branch string) string {
 	return "Updated"
 }
 
-func printSummaryTable(summary [][]string) {
-	fmt.Println(style.Render("\nRepository Setup Summary:"))
+func printSummaryTable(title string, summary [][]string) {
+	if isStructuredOutput() {
+		printStructured(tableToRecords(summary))
+		return
+	}
+
+	fmt.Println(style.Render("\n" + title + ":"))
 
 	colWidths := make([]int, len(summary[0]))
 	for _, row := range summary {
@@ -942,11 +1073,7 @@ func editKubefirstBinaryForConfig() {
 	}
 
 	var selectedConfig string
-	err = huh.NewSelect[string]().
-		Title("Select a configuration to edit").
-		Options(configOptions...).
-		Value(&selectedConfig).
-		Run()
+	err = runField(newSelect("Select a configuration to edit", &selectedConfig, configOptions...))
 
 	if err != nil {
 		log.Error("Error in config selection", "error", err)
@@ -973,12 +1100,19 @@ func editKubefirstBinaryForConfig() {
 	log.Info("New Kubefirst binary path", "path", kubefirstPath)
 
 	// Update the configuration
-	config.Flags["KUBEFIRST_PATH"] = kubefirstPath
-	config.Flags[selectedConfig+"_KUBEFIRST_PATH"] = kubefirstPath
-	indexFile.Configs[selectedConfig] = config
-
 	// Update the index file
-	err = createOrUpdateIndexFile(filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "config.hcl"), indexFile)
+	err = updateIndex(func(indexFile *IndexFile) error {
+		config, ok := indexFile.Configs[selectedConfig]
+		if !ok {
+			return fmt.Errorf("config %s not found", selectedConfig)
+		}
+		if config.Flags == nil {
+			config.Flags = make(map[string]string)
+		}
+		config.Flags["KUBEFIRST_PATH"] = kubefirstPath
+		indexFile.Configs[selectedConfig] = config
+		return nil
+	})
 	if err != nil {
 		log.Error("Error updating index file", "error", err)
 		return
@@ -995,7 +1129,7 @@ func editKubefirstBinaryForConfig() {
 
 	log.Info("Updating Kubefirst script", "scriptPath", scriptPath, "kubefirstPath", kubefirstPath)
 
-	err = updateKubefirstScript(scriptPath, kubefirstPath)
+	err = updateKubefirstScript(scriptPath, kubefirstPath, cloudProvider)
 	if err != nil {
 		log.Error("Error updating Kubefirst script", "error", err)
 		fmt.Printf("Failed to update the Kubefirst script. You may need to manually edit %s\n", scriptPath)
@@ -1014,7 +1148,7 @@ func editKubefirstBinaryForConfig() {
 	}
 
 	// Update the 01-kubefirst-cloud.sh file
-	err = updateKubefirstScript(scriptPath, kubefirstPath) // Changed := to =
+	err = updateKubefirstScript(scriptPath, kubefirstPath, cloudProvider) // Changed := to =
 	if err != nil {
 		log.Error("Error updating Kubefirst script", "error", err)
 		fmt.Printf("Failed to update the Kubefirst script. You may need to manually edit %s\n", scriptPath)
@@ -1026,7 +1160,7 @@ func editKubefirstBinaryForConfig() {
 	fmt.Printf("KUBEFIRST_PATH set to: %s\n", kubefirstPath)
 }
 
-func updateKubefirstScript(scriptPath, kubefirstPath string) error {
+func updateKubefirstScript(scriptPath, kubefirstPath, cloudProvider string) error {
 	content, err := os.ReadFile(scriptPath)
 	if err != nil {
 		return fmt.Errorf("error reading script file: %w", err)
@@ -1039,6 +1173,18 @@ func updateKubefirstScript(scriptPath, kubefirstPath string) error {
 		return fmt.Errorf("script file is empty")
 	}
 
+	// Rename flags the new binary knows by a different name, e.g. --admin-email to --alerts-email
+	compat := newKubefirstCompat(kubefirstPath, cloudProvider)
+	for i, line := range lines {
+		match := scriptFlagPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if mapped := compat.flag(match[2]); mapped != match[2] {
+			lines[i] = match[1] + mapped + match[3]
+		}
+	}
+
 	// Find the line that contains the kubefirst command
 	kubefirstLineIndex := -1
 	for i, line := range lines {
@@ -1050,12 +1196,12 @@ func updateKubefirstScript(scriptPath, kubefirstPath string) error {
 
 	if kubefirstLineIndex == -1 {
 		// If kubefirst command is not found, add it to the end of the script
-		kubefirstLine := "${KUBEFIRST_PATH} civo create \\"
+		kubefirstLine := fmt.Sprintf("${KUBEFIRST_PATH} %s create \\", compat.command)
 		lines = append(lines, "", "# Added by k1space", kubefirstLine)
 		log.Info("Added kubefirst command to script", "line", kubefirstLine)
 	} else {
 		// Update the existing kubefirst command line
-		lines[kubefirstLineIndex] = "${KUBEFIRST_PATH} civo create \\"
+		lines[kubefirstLineIndex] = fmt.Sprintf("${KUBEFIRST_PATH} %s create \\", compat.command)
 		log.Info("Updated existing kubefirst command in script", "line", lines[kubefirstLineIndex])
 	}
 
@@ -1104,7 +1250,7 @@ func checkK3dClusterExists(name string) (bool, error) {
 	return false, nil
 }
 
-func deleteAndRecreateK3dCluster(name string) error {
+func deleteK3dCluster(name string) error {
 	fmt.Printf("Deleting k3d cluster '%s'...\n", name)
 	deleteCmd := exec.Command("k3d", "cluster", "delete", name)
 	deleteCmd.Stdout = os.Stdout
@@ -1114,16 +1260,24 @@ func deleteAndRecreateK3dCluster(name string) error {
 		return fmt.Errorf("failed to delete k3d cluster: %w", err)
 	}
 	fmt.Printf("k3d cluster '%s' deleted successfully.\n", name)
-
-	return createK3dCluster(name)
+	return nil
 }
 
 func createK3dCluster(name string) error {
+	args := []string{"cluster", "create", name}
+	// Let the dev cluster pull locally built kubefirst-api and console images
+	registry, err := ensureK3dRegistry()
+	if err != nil {
+		log.Warn("Creating k3d cluster without a local registry", "error", err)
+	} else {
+		args = append(args, "--registry-use", registry.clusterAddress())
+	}
+
 	fmt.Printf("Creating k3d cluster '%s'...\n", name)
-	createCmd := exec.Command("k3d", "cluster", "create", name)
+	createCmd := exec.Command("k3d", args...)
 	createCmd.Stdout = os.Stdout
 	createCmd.Stderr = os.Stderr
-	err := createCmd.Run()
+	err = createCmd.Run()
 	if err != nil {
 		return fmt.Errorf("failed to create k3d cluster: %w", err)
 	}
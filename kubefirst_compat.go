@@ -0,0 +1,236 @@
+package main
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/log"
+)
+
+// Who published a kubefirst binary. The CLI moved from the kubefirst GitHub org to konstructio.
+const (
+	vendorKubefirst   = "kubefirst"
+	vendorKonstructio = "konstructio"
+	vendorUnknown     = "unknown"
+)
+
+// kubefirstCommandAliases are subcommands kubefirst has renamed, each listed with its older names.
+// k3d clusters were created with `kubefirst local` before 2.0.
+var kubefirstCommandAliases = [][]string{
+	{"k3d", "local"},
+}
+
+// kubefirstFlagAliases returns the create flags kubefirst has renamed, each listed with its older names. They
+// come from the deprecations that name a replacement. Add to them in settings.hcl with
+// kubefirst_flag_aliases = { "old-name" = "new-name" }.
+func kubefirstFlagAliases() [][]string {
+	var aliases [][]string
+	groups := make(map[string]int)
+	for _, deprecation := range kubefirstFlagDeprecations {
+		if deprecation.Replacement == "" {
+			continue
+		}
+		i, ok := groups[deprecation.Replacement]
+		if !ok {
+			i = len(aliases)
+			groups[deprecation.Replacement] = i
+			aliases = append(aliases, []string{deprecation.Replacement})
+		}
+		aliases[i] = append(aliases[i], deprecation.Flag)
+	}
+	return aliases
+}
+
+// findKubefirstFlag returns the first of names, in sorted order, that the binary's create flags include
+func findKubefirstFlag(flags map[string]string, names []string) string {
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		if _, ok := flags[name]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// scriptFlagPattern matches a flag line in 01-kubefirst-cloud.sh, e.g. `  --domain-name "$K1_CIVO_NYC1_DOMAIN_NAME" \`
+var scriptFlagPattern = regexp.MustCompile(`^(\s*--)([a-z0-9-]+)(\s.*)?$`)
+
+var kubefirstVersionPattern = regexp.MustCompile(`v?(\d+\.\d+\.\d+[0-9A-Za-z.+-]*)`)
+
+// kubefirstCLI is what k1space knows about a kubefirst binary's vendor, version and subcommands
+type kubefirstCLI struct {
+	Path     string
+	Vendor   string
+	Version  string
+	Commands map[string]bool
+}
+
+var (
+	kubefirstCLICache   = make(map[string]kubefirstCLI)
+	kubefirstCLICacheMu sync.Mutex
+)
+
+// detectKubefirstCLI inspects a kubefirst binary once per run. The vendor comes from the module path
+// embedded in the binary, falling back to the `version` output for binaries built without it.
+func detectKubefirstCLI(kubefirstPath string) (kubefirstCLI, error) {
+	kubefirstCLICacheMu.Lock()
+	defer kubefirstCLICacheMu.Unlock()
+	if cli, ok := kubefirstCLICache[kubefirstPath]; ok {
+		return cli, nil
+	}
+
+	cli := kubefirstCLI{Path: kubefirstPath, Vendor: vendorUnknown, Commands: make(map[string]bool)}
+	if info, err := buildinfo.ReadFile(kubefirstPath); err == nil {
+		cli.Vendor = kubefirstVendor(info.Main.Path)
+		if info.Main.Version != "" && info.Main.Version != "(devel)" {
+			cli.Version = strings.TrimPrefix(info.Main.Version, "v")
+		}
+	}
+
+	if output, err := exec.Command(kubefirstPath, "version").CombinedOutput(); err == nil {
+		if cli.Vendor == vendorUnknown {
+			cli.Vendor = kubefirstVendor(string(output))
+		}
+		if match := kubefirstVersionPattern.FindStringSubmatch(string(output)); match != nil {
+			cli.Version = match[1]
+		}
+	}
+
+	output, err := exec.Command(kubefirstPath, "--help").CombinedOutput()
+	if err != nil {
+		return cli, fmt.Errorf("error running kubefirst --help: %w\nOutput: %s", err, string(output))
+	}
+	cli.Commands = parseHelpCommands(string(output))
+
+	log.Info("Detected kubefirst CLI", "path", kubefirstPath, "vendor", cli.Vendor, "version", cli.Version, "commands", len(cli.Commands))
+	kubefirstCLICache[kubefirstPath] = cli
+	return cli, nil
+}
+
+func kubefirstVendor(s string) string {
+	switch {
+	case strings.Contains(s, "konstructio"):
+		return vendorKonstructio
+	case strings.Contains(s, "kubefirst/kubefirst"):
+		return vendorKubefirst
+	}
+	return vendorUnknown
+}
+
+// parseHelpCommands reads the subcommand names under "Available Commands:" in cobra help output
+func parseHelpCommands(help string) map[string]bool {
+	commands := make(map[string]bool)
+	inCommands := false
+	for _, line := range strings.Split(help, "\n") {
+		if strings.HasPrefix(line, "Available Commands:") {
+			inCommands = true
+			continue
+		}
+		if !inCommands {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		if fields := strings.Fields(line); len(fields) > 0 {
+			commands[fields[0]] = true
+		}
+	}
+	return commands
+}
+
+// aliasFor returns the name in aliases' group that accepts reports as valid, or name when nothing in
+// its group does
+func aliasFor(name string, aliases [][]string, accepts func(string) bool) string {
+	if accepts(name) {
+		return name
+	}
+	for _, group := range aliases {
+		if !contains(group, name) {
+			continue
+		}
+		for _, alias := range group {
+			if alias != name && accepts(alias) {
+				return alias
+			}
+		}
+	}
+	return name
+}
+
+// command returns the subcommand this binary uses for a provider, e.g. "local" for K3d on kubefirst 1.x
+func (cli kubefirstCLI) command(cloudProvider string) string {
+	command := kubefirstCloudCommand(cloudProvider)
+	if len(cli.Commands) == 0 {
+		return command
+	}
+	return aliasFor(command, kubefirstCommandAliases, func(c string) bool { return cli.Commands[c] })
+}
+
+// flagAliases returns the built-in flag renames plus any from settings.hcl
+func flagAliases() [][]string {
+	aliases := kubefirstFlagAliases()
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings, using built-in flag aliases only", "error", err)
+		return aliases
+	}
+	for old, current := range settings.KubefirstFlagAliases {
+		aliases = append(aliases, []string{current, old})
+	}
+	return aliases
+}
+
+// mapKubefirstFlag returns the name a binary accepts for a config flag, following renames in either
+// direction so an older config works with a newer binary and the other way round
+func mapKubefirstFlag(flag string, known map[string]string, aliases [][]string) string {
+	if len(known) == 0 {
+		return flag
+	}
+	return aliasFor(flag, aliases, func(f string) bool {
+		_, ok := known[f]
+		return ok
+	})
+}
+
+// kubefirstCompat resolves the subcommand and flags to use with a binary for a provider. When the binary
+// can't be inspected it falls back to the names stored in the config.
+type kubefirstCompat struct {
+	cli     kubefirstCLI
+	command string
+	known   map[string]string
+	aliases [][]string
+}
+
+func newKubefirstCompat(kubefirstPath, cloudProvider string) kubefirstCompat {
+	compat := kubefirstCompat{command: kubefirstCloudCommand(cloudProvider), aliases: flagAliases()}
+	cli, err := detectKubefirstCLI(kubefirstPath)
+	if err != nil {
+		log.Warn("Could not inspect kubefirst binary, using flags as stored", "path", kubefirstPath, "error", err)
+		return compat
+	}
+	compat.cli = cli
+	compat.command = cli.command(cloudProvider)
+
+	known, err := fetchKubefirstFlags(kubefirstPath, cloudProvider)
+	if err != nil {
+		log.Warn("Could not read kubefirst flags, using flags as stored", "path", kubefirstPath, "error", err)
+		return compat
+	}
+	compat.known = known
+	return compat
+}
+
+func (c kubefirstCompat) flag(name string) string {
+	mapped := mapKubefirstFlag(name, c.known, c.aliases)
+	if mapped != name {
+		log.Info("Mapped renamed kubefirst flag", "from", name, "to", mapped, "vendor", c.cli.Vendor, "version", c.cli.Version)
+	}
+	return mapped
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// kubefirstLogTailer follows the log files kubefirst writes under ~/.k1/logs during a run
+type kubefirstLogTailer struct {
+	dir      string
+	since    time.Time
+	logs     *scrollingLog
+	redactor *redactor
+	parsers  *logParserSet
+	offsets  map[string]int64
+
+	mu      sync.Mutex
+	current string
+}
+
+func newKubefirstLogTailer(since time.Time, logs *scrollingLog, redactor *redactor, parsers *logParserSet) *kubefirstLogTailer {
+	return &kubefirstLogTailer{
+		dir:      kubefirstLogDir(),
+		since:    since,
+		logs:     logs,
+		redactor: redactor,
+		parsers:  parsers,
+		offsets:  make(map[string]int64),
+	}
+}
+
+// currentFile returns the log file most recently written to, for display in the dashboard
+func (t *kubefirstLogTailer) currentFile() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current
+}
+
+// run polls for new or growing log files until stop is closed
+func (t *kubefirstLogTailer) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		t.poll()
+		select {
+		case <-stop:
+			// Pick up anything written between the last poll and the script exiting
+			t.poll()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *kubefirstLogTailer) poll() {
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		// kubefirst creates the directory on its first run
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().Before(t.since) {
+			continue
+		}
+
+		path := filepath.Join(t.dir, entry.Name())
+		if info.Size() <= t.offsets[path] {
+			continue
+		}
+
+		err = t.readFrom(path)
+		if err != nil {
+			log.Warn("Error tailing kubefirst log", "path", path, "error", err)
+		}
+	}
+}
+
+func (t *kubefirstLogTailer) readFrom(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Seek(t.offsets[path], io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// Leave a partial last line for the next poll
+			break
+		}
+		t.offsets[path] += int64(len(line))
+		redacted := t.redactor.redact(line[:len(line)-1])
+		t.logs.add(redacted)
+		t.parsers.parse(redacted)
+	}
+
+	t.mu.Lock()
+	t.current = path
+	t.mu.Unlock()
+	return nil
+}
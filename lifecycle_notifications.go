@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// latestScriptLog returns the newest provisioning script log in a config's log directory; their timestamped
+// names sort in the order they were written
+func latestScriptLog(logDir string) string {
+	logs, _ := filepath.Glob(filepath.Join(logDir, "00-init-*.log"))
+	if len(logs) == 0 {
+		return ""
+	}
+	sort.Strings(logs)
+	return logs[len(logs)-1]
+}
+
+// provisioningStatus names how a run ended: succeeded, failed, cancelled or timed-out
+func provisioningStatus(err error) string {
+	var failure *provisioningFailure
+	switch {
+	case err == nil:
+		return "succeeded"
+	case errors.Is(err, errProvisioningCancelled):
+		return stateCancelled
+	case errors.As(err, &failure) && failure.TimedOut != "":
+		return stateTimedOut
+	default:
+		return "failed"
+	}
+}
+
+// provisioningScriptLog returns the log of the run that ended with err, falling back to the newest in logDir when
+// the error doesn't carry one
+func provisioningScriptLog(logDir string, err error) string {
+	var failure *provisioningFailure
+	if errors.As(err, &failure) && failure.ScriptLog != "" {
+		return failure.ScriptLog
+	}
+	return latestScriptLog(logDir)
+}
+
+// describeCluster names a config and, when it has one, the cluster it creates
+func describeCluster(configName string, config Config) string {
+	if clusterName := findConfigFlag(config.Flags, "cluster-name"); clusterName != "" {
+		return fmt.Sprintf("%s (cluster %s)", configName, clusterName)
+	}
+	return configName
+}
+
+// notifyLifecycle posts a provisioning event to the channels in settings.hcl. A channel that can't be reached
+// only gets logged, it never fails the run.
+func notifyLifecycle(message string) {
+	err := sendNotification(context.Background(), "[k1space] "+message)
+	if err != nil {
+		log.Error("Error sending provisioning notification", "error", err)
+	}
+}
+
+func notifyProvisioningStarted(configName string, config Config) {
+	notifyLifecycle(fmt.Sprintf("🚀 Provisioning %s started", describeCluster(configName, config)))
+}
+
+// notifyProvisioningFinished reports how a run ended, how long it took and where its log is
+func notifyProvisioningFinished(configName string, config Config, logDir string, startedAt time.Time, err error) {
+	duration := time.Since(startedAt).Round(time.Second)
+	cluster := describeCluster(configName, config)
+
+	var message string
+	switch provisioningStatus(err) {
+	case "succeeded":
+		message = fmt.Sprintf("✅ %s provisioned in %s", cluster, duration)
+	case stateCancelled:
+		message = fmt.Sprintf("⏹ Provisioning %s cancelled after %s", cluster, duration)
+	case stateTimedOut:
+		var failure *provisioningFailure
+		errors.As(err, &failure)
+		message = fmt.Sprintf("⏱ Provisioning %s %s", cluster, failure.TimedOut)
+	default:
+		message = fmt.Sprintf("❌ Provisioning %s failed after %s: %v", cluster, duration, err)
+	}
+	if scriptLog := provisioningScriptLog(logDir, err); scriptLog != "" {
+		message += "\nLog: " + scriptLog
+	}
+	notifyLifecycle(message)
+}
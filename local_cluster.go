@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Name of the local cluster that kubefirst-api runs against
+const localClusterName = "dev"
+
+// localClusterBackend creates and inspects the local cluster used for kubefirst development
+type localClusterBackend struct {
+	Name   string
+	exists func(name string) (bool, error)
+	create func(name string) error
+	delete func(name string) error
+	list   func()
+	// Shell functions cluster_exists, create_cluster and cluster_kubeconfig used by the generated setup scripts
+	shellFunctions string
+}
+
+var localClusterBackends = map[string]localClusterBackend{
+	"k3d": {
+		Name:   "k3d",
+		exists: checkK3dClusterExists,
+		create: createK3dCluster,
+		delete: deleteK3dCluster,
+		list:   printK3dClusters,
+		shellFunctions: `cluster_exists() { k3d cluster list | grep -q "$1"; }
+create_cluster() { k3d cluster create "$1" ${K3D_REGISTRY_USE:+--registry-use "$K3D_REGISTRY_USE"}; }
+cluster_kubeconfig() { k3d kubeconfig write "$1"; }
+`,
+	},
+	"kind": {
+		Name:   "kind",
+		exists: checkKindClusterExists,
+		create: createKindCluster,
+		delete: deleteKindCluster,
+		list:   printKindClusters,
+		shellFunctions: `cluster_exists() { kind get clusters 2>/dev/null | grep -qx "$1"; }
+create_cluster() { kind create cluster --name "$1"; }
+cluster_kubeconfig() {
+    local path="${HOME}/.kube/kind-$1.yaml"
+    mkdir -p "${HOME}/.kube"
+    kind get kubeconfig --name "$1" > "$path"
+    echo "$path"
+}
+`,
+	},
+}
+
+// getLocalClusterBackend returns the backend saved in settings.hcl, defaulting to k3d
+func getLocalClusterBackend() localClusterBackend {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings, using k3d", "error", err)
+		return localClusterBackends["k3d"]
+	}
+	if backend, ok := localClusterBackends[settings.LocalClusterBackend]; ok {
+		return backend
+	}
+	return localClusterBackends["k3d"]
+}
+
+func promptLocalClusterBackend() (localClusterBackend, error) {
+	current := getLocalClusterBackend()
+	selected := current.Name
+
+	err := runField(newSelect("Select the local cluster backend", &selected,
+		huh.NewOption("k3d", "k3d"),
+		huh.NewOption("kind", "kind"),
+	))
+	if err != nil {
+		return current, err
+	}
+
+	backend := localClusterBackends[selected]
+	if !ensureDependencies(backend.Name) {
+		return current, fmt.Errorf("%s could not be found. Please install it and try again", backend.Name)
+	}
+
+	if selected != current.Name {
+		err = saveSetting("local_cluster_backend", cty.StringVal(selected))
+		if err != nil {
+			return backend, err
+		}
+	}
+	return backend, nil
+}
+
+// renderClusterScript fills in the backend-specific parts of a setup script
+func renderClusterScript(script string, backend localClusterBackend) string {
+	return strings.NewReplacer(
+		"__CLUSTER_FUNCTIONS__", backend.shellFunctions,
+		"__CLUSTER_CLI__", backend.Name,
+		"__CLUSTER_NAME__", localClusterName,
+	).Replace(script)
+}
+
+func checkKindClusterExists(name string) (bool, error) {
+	output, err := exec.Command("kind", "get", "clusters").Output()
+	if err != nil {
+		return false, fmt.Errorf("error listing kind clusters: %w", err)
+	}
+
+	for _, cluster := range strings.Fields(string(output)) {
+		if cluster == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func createKindCluster(name string) error {
+	fmt.Printf("Creating kind cluster '%s'...\n", name)
+	createCmd := exec.Command("kind", "create", "cluster", "--name", name, "--wait", "5m")
+	createCmd.Stdout = os.Stdout
+	createCmd.Stderr = os.Stderr
+	err := createCmd.Run()
+	if err != nil {
+		return fmt.Errorf("failed to create kind cluster: %w", err)
+	}
+	fmt.Printf("kind cluster '%s' created successfully.\n", name)
+	return nil
+}
+
+func deleteKindCluster(name string) error {
+	fmt.Printf("Deleting kind cluster '%s'...\n", name)
+	deleteCmd := exec.Command("kind", "delete", "cluster", "--name", name)
+	deleteCmd.Stdout = os.Stdout
+	deleteCmd.Stderr = os.Stderr
+	err := deleteCmd.Run()
+	if err != nil {
+		return fmt.Errorf("failed to delete kind cluster: %w", err)
+	}
+	fmt.Printf("kind cluster '%s' deleted successfully.\n", name)
+	return nil
+}
+
+func printKindClusters() {
+	output, err := exec.Command("kind", "get", "clusters").Output()
+	if err != nil {
+		log.Error("Failed to list kind clusters", "error", err)
+		return
+	}
+
+	fmt.Println(style.Render("\nCurrent kind clusters:"))
+	for _, cluster := range strings.Fields(string(output)) {
+		fmt.Printf("  %s\n", cluster)
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// Domain kubefirst's k3d flow serves its apps on
+	defaultLocalDNSDomain = "kubefirst.dev"
+	hostsFilePath         = "/etc/hosts"
+	hostsBlockStart       = "# BEGIN k1space local dns"
+	hostsBlockEnd         = "# END k1space local dns"
+	dnsmasqConfName       = "k1space.conf"
+)
+
+// Hosts kubefirst's k3d flow exposes through its ingress; "" is the apex domain
+var localDNSSubdomains = []string{
+	"", "kubefirst", "console", "argocd", "vault", "atlantis", "chartmuseum", "minio", "minio-console",
+	"metaphor-development", "metaphor-staging", "metaphor-production",
+}
+
+// getLocalDNSMode returns local_dns from settings.hcl: "hosts" (default), "dnsmasq" or "off"
+func getLocalDNSMode() string {
+	settings, err := loadSettings()
+	if err != nil || settings.LocalDNS == "" {
+		return "hosts"
+	}
+	return settings.LocalDNS
+}
+
+func localDNSHostnames(domain string) []string {
+	hostnames := make([]string, len(localDNSSubdomains))
+	for i, subdomain := range localDNSSubdomains {
+		if subdomain == "" {
+			hostnames[i] = domain
+		} else {
+			hostnames[i] = subdomain + "." + domain
+		}
+	}
+	return hostnames
+}
+
+// localDNSDomain returns the domain a k3d config serves on, falling back to kubefirst.dev
+func localDNSDomain(configName string, config Config) string {
+	if domain := storedConfigFlags(configName, config)["domain-name"]; domain != "" {
+		return domain
+	}
+	return defaultLocalDNSDomain
+}
+
+// localDNSResolves reports whether every host for domain already resolves to loopback,
+// in which case public DNS is doing its job and nothing needs to be written
+func localDNSResolves(domain string) bool {
+	for _, hostname := range localDNSHostnames(domain) {
+		addrs, err := net.LookupHost(hostname)
+		if err != nil || len(addrs) == 0 {
+			return false
+		}
+		for _, addr := range addrs {
+			if ip := net.ParseIP(addr); ip == nil || !ip.IsLoopback() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ensureLocalDNS points the k3d hosts at 127.0.0.1 using the configured mode, for resolvers
+// (e.g. with DNS rebinding protection) that refuse to return loopback addresses for public names
+func ensureLocalDNS(domain string) error {
+	mode := getLocalDNSMode()
+	if mode == "off" || localDNSResolves(domain) {
+		return nil
+	}
+
+	switch mode {
+	case "dnsmasq":
+		return writeDnsmasqConf(domain)
+	default:
+		return writeHostsBlock(domain)
+	}
+}
+
+// removeLocalDNS removes whatever k1space added to /etc/hosts and dnsmasq
+func removeLocalDNS() error {
+	err := writeHostsBlock("")
+	if err != nil {
+		return err
+	}
+	dir, ok := dnsmasqConfDir()
+	if !ok {
+		return nil
+	}
+	confPath := filepath.Join(dir, dnsmasqConfName)
+	if _, err := os.Stat(confPath); os.IsNotExist(err) {
+		return nil
+	}
+	err = runPrivileged("rm", "-f", confPath)
+	if err != nil {
+		return fmt.Errorf("error removing %s: %w", confPath, err)
+	}
+	restartDnsmasq()
+	return nil
+}
+
+// renderHostsFile replaces k1space's block in a hosts file, dropping it entirely when domain is empty
+func renderHostsFile(current, domain string) string {
+	var lines []string
+	inBlock := false
+	for _, line := range strings.Split(strings.TrimRight(current, "\n"), "\n") {
+		switch {
+		case line == hostsBlockStart:
+			inBlock = true
+		case line == hostsBlockEnd:
+			inBlock = false
+		case !inBlock:
+			lines = append(lines, line)
+		}
+	}
+
+	if domain != "" {
+		lines = append(lines, hostsBlockStart)
+		for _, hostname := range localDNSHostnames(domain) {
+			lines = append(lines, "127.0.0.1 "+hostname)
+		}
+		lines = append(lines, hostsBlockEnd)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func writeHostsBlock(domain string) error {
+	current, err := os.ReadFile(hostsFilePath)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", hostsFilePath, err)
+	}
+	updated := renderHostsFile(string(current), domain)
+	if updated == string(current) {
+		return nil
+	}
+
+	if domain != "" {
+		fmt.Printf("Adding %s entries to %s (you may be prompted for your password)...\n", domain, hostsFilePath)
+	} else {
+		fmt.Printf("Removing k1space entries from %s (you may be prompted for your password)...\n", hostsFilePath)
+	}
+	err = writePrivilegedFile(hostsFilePath, updated)
+	if err != nil {
+		return fmt.Errorf("error writing %s: %w", hostsFilePath, err)
+	}
+	return nil
+}
+
+// dnsmasqConfDir finds the dnsmasq.d directory on Linux or a Homebrew install
+func dnsmasqConfDir() (string, bool) {
+	candidates := []string{"/etc/dnsmasq.d"}
+	if output, err := exec.Command("brew", "--prefix").Output(); err == nil {
+		candidates = append([]string{filepath.Join(strings.TrimSpace(string(output)), "etc", "dnsmasq.d")}, candidates...)
+	}
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+func writeDnsmasqConf(domain string) error {
+	dir, ok := dnsmasqConfDir()
+	if !ok {
+		return fmt.Errorf("no dnsmasq.d directory found; install dnsmasq or set local_dns = \"hosts\"")
+	}
+
+	confPath := filepath.Join(dir, dnsmasqConfName)
+	content := fmt.Sprintf("# Managed by k1space\naddress=/%s/127.0.0.1\n", domain)
+	if existing, err := os.ReadFile(confPath); err == nil && string(existing) == content {
+		return nil
+	}
+
+	fmt.Printf("Writing %s (you may be prompted for your password)...\n", confPath)
+	err := writePrivilegedFile(confPath, content)
+	if err != nil {
+		return fmt.Errorf("error writing %s: %w", confPath, err)
+	}
+	restartDnsmasq()
+	return nil
+}
+
+func restartDnsmasq() {
+	var err error
+	if runtime.GOOS == "darwin" {
+		err = runPrivileged("brew", "services", "restart", "dnsmasq")
+	} else {
+		err = runPrivileged("systemctl", "restart", "dnsmasq")
+	}
+	if err != nil {
+		log.Warn("Error restarting dnsmasq, restart it manually to pick up the change", "error", err)
+	}
+}
+
+// writePrivilegedFile writes a system file directly when possible, otherwise through sudo tee
+func writePrivilegedFile(path, content string) error {
+	if f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644); err == nil {
+		_, err = f.WriteString(content)
+		closeErr := f.Close()
+		if err != nil {
+			return err
+		}
+		return closeErr
+	}
+
+	cmd := exec.Command("sudo", "tee", path)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func runPrivileged(name string, args ...string) error {
+	if os.Geteuid() != 0 {
+		args = append([]string{name}, args...)
+		name = "sudo"
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// manageLocalDNS lets users pick how k3d hosts are resolved and apply or remove the entries
+func manageLocalDNS() {
+	mode := getLocalDNSMode()
+	var action string
+	err := runForm(newForm(
+		newGroup(
+			newSelect("Local DNS for k3d clusters", &action,
+				huh.NewOption("Use /etc/hosts entries", "hosts"),
+				huh.NewOption("Use dnsmasq", "dnsmasq"),
+				huh.NewOption("Don't manage local DNS", "off"),
+				huh.NewOption("Remove k1space DNS entries", "remove"),
+			).
+				Description(fmt.Sprintf("Current mode: %s", mode)),
+		),
+	))
+	if err != nil {
+		log.Error("Error in local DNS prompt", "error", err)
+		return
+	}
+
+	if action == "remove" {
+		err = removeLocalDNS()
+		if err != nil {
+			log.Error("Error removing local DNS entries", "error", err)
+			fmt.Println("Failed to remove local DNS entries:", err)
+			return
+		}
+		fmt.Println(style.Render("✅ Removed k1space local DNS entries"))
+		return
+	}
+
+	err = saveSetting("local_dns", cty.StringVal(action))
+	if err != nil {
+		log.Error("Error saving local DNS mode", "error", err)
+		fmt.Println("Failed to save local DNS mode:", err)
+		return
+	}
+
+	// Switching modes shouldn't leave the previous mode's entries behind
+	err = removeLocalDNS()
+	if err != nil {
+		log.Warn("Error removing previous local DNS entries", "error", err)
+	}
+	if action != "off" {
+		err = ensureLocalDNS(defaultLocalDNSDomain)
+		if err != nil {
+			log.Error("Error applying local DNS", "error", err)
+			fmt.Println("Failed to apply local DNS:", err)
+			return
+		}
+	}
+	fmt.Println(style.Render(fmt.Sprintf("✅ Local DNS mode set to %s", action)))
+}
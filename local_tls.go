@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+const (
+	localCertFile = "local.pem"
+	localKeyFile  = "local-key.pem"
+)
+
+// Names the dev servers are reached on, locally and through the k3d ingress
+var localCertHosts = []string{"localhost", "127.0.0.1", "::1", defaultLocalDNSDomain, "*." + defaultLocalDNSDomain}
+
+func getLocalCertDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".certs")
+}
+
+// localCertPaths returns the cert and key generated by setupLocalTLS, if they exist
+func localCertPaths() (string, string, bool) {
+	certPath := filepath.Join(getLocalCertDir(), localCertFile)
+	keyPath := filepath.Join(getLocalCertDir(), localKeyFile)
+	if _, err := os.Stat(certPath); err != nil {
+		return "", "", false
+	}
+	if _, err := os.Stat(keyPath); err != nil {
+		return "", "", false
+	}
+	return certPath, keyPath, true
+}
+
+// consoleDevCommand runs the console's Next.js dev server over HTTPS when local certs are available
+func consoleDevCommand() *exec.Cmd {
+	certPath, keyPath, ok := localCertPaths()
+	if !ok {
+		return exec.Command("yarn", "dev")
+	}
+	return exec.Command("yarn", "dev", "--experimental-https", "--experimental-https-cert", certPath, "--experimental-https-key", keyPath)
+}
+
+func setupLocalTLS() {
+	log.Info("Starting setupLocalTLS function")
+
+	if _, err := exec.LookPath("mkcert"); err != nil {
+		fmt.Println("mkcert could not be found. Install it from https://github.com/FiloSottile/mkcert and try again.")
+		return
+	}
+
+	fmt.Println("Installing the mkcert root CA into the system trust store (you may be prompted for your password)...")
+	installCmd := exec.Command("mkcert", "-install")
+	installCmd.Stdin = os.Stdin
+	installCmd.Stdout = os.Stdout
+	installCmd.Stderr = os.Stderr
+	err := installCmd.Run()
+	if err != nil {
+		log.Error("Error installing mkcert root CA", "error", err)
+		fmt.Println("Failed to install the mkcert root CA:", err)
+		return
+	}
+
+	caRootOutput, err := exec.Command("mkcert", "-CAROOT").Output()
+	if err != nil {
+		log.Error("Error locating mkcert root CA", "error", err)
+		return
+	}
+	caPath := filepath.Join(strings.TrimSpace(string(caRootOutput)), "rootCA.pem")
+
+	certDir := getLocalCertDir()
+	err = os.MkdirAll(certDir, 0700)
+	if err != nil {
+		log.Error("Error creating certificate directory", "error", err)
+		return
+	}
+	certPath := filepath.Join(certDir, localCertFile)
+	keyPath := filepath.Join(certDir, localKeyFile)
+
+	s := startSpinner("Generating local certificates...")
+	args := append([]string{"-cert-file", certPath, "-key-file", keyPath}, localCertHosts...)
+	output, err := exec.Command("mkcert", args...).CombinedOutput()
+	stopSpinner(s, err == nil)
+	if err != nil {
+		log.Error("Error generating certificates", "error", err, "output", string(output))
+		fmt.Println("Failed to generate certificates:", err)
+		return
+	}
+
+	repoDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".repositories")
+	tlsEnv := map[string]string{
+		"K1_TLS_CERT_FILE": certPath,
+		"K1_TLS_KEY_FILE":  keyPath,
+		// Lets Node trust the other dev servers' certificates
+		"NODE_EXTRA_CA_CERTS": caPath,
+	}
+
+	summary := [][]string{{"Component", "Env File", "Status"}}
+	for _, repo := range []string{"kubefirst-api", "console"} {
+		envPath := filepath.Join(repoDir, repo, ".env")
+		err = setDotEnvValues(envPath, filepath.Join(repoDir, repo, ".env.example"), tlsEnv)
+		if err != nil {
+			log.Error("Error updating env file", "path", envPath, "error", err)
+			summary = append(summary, []string{repo, envPath, "Failed to update"})
+			continue
+		}
+		summary = append(summary, []string{repo, envPath, "Updated"})
+	}
+	printSummaryTable("Local TLS", summary)
+
+	fmt.Printf("\nCertificate: %s\nKey: %s\nValid for: %s\n", certPath, keyPath, strings.Join(localCertHosts, ", "))
+	fmt.Println("'Run Kubefirst Repositories' now starts the console on https://localhost:3000.")
+}
+
+// setDotEnvValues sets keys in a .env file, seeding it from .env.example first so the setup scripts
+// don't mistake a file holding only these values for a configured one
+func setDotEnvValues(envPath, examplePath string, values map[string]string) error {
+	content, err := os.ReadFile(envPath)
+	if os.IsNotExist(err) {
+		content, err = os.ReadFile(examplePath)
+		if os.IsNotExist(err) {
+			content, err = nil, nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	remaining := make(map[string]string, len(values))
+	for key, value := range values {
+		remaining[key] = value
+	}
+
+	var lines []string
+	if len(content) > 0 {
+		lines = strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	}
+	for i, line := range lines {
+		key := strings.TrimSpace(strings.SplitN(strings.TrimPrefix(strings.TrimSpace(line), "export "), "=", 2)[0])
+		if value, ok := remaining[key]; ok {
+			lines[i] = fmt.Sprintf("%s=%q", key, value)
+			delete(remaining, key)
+		}
+	}
+	keys := make([]string, 0, len(remaining))
+	for key := range remaining {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("%s=%q", key, remaining[key]))
+	}
+
+	return os.WriteFile(envPath, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
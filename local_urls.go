@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How often the dashboard checks the local URLs, and how long each check may take
+const (
+	localURLCheckInterval = 5 * time.Second
+	localURLCheckTimeout  = 3 * time.Second
+)
+
+// kubefirstAPILocalURL is where kubefirst-api listens, over HTTPS once 'Setup Local TLS' has run
+func kubefirstAPILocalURL() string {
+	if _, _, ok := localCertPaths(); ok {
+		return "https://localhost:8081"
+	}
+	return "http://localhost:8081"
+}
+
+// localURL is a page of the local stack and the outcome of the last request for it
+type localURL struct {
+	Name string
+	URL  string
+	Up   bool
+	// Detail is the response status, or why the request failed
+	Detail string
+}
+
+// localURLChecker keeps requesting the local stack's pages, so the dashboard only shows a URL as ready once it
+// actually answers
+type localURLChecker struct {
+	mu   sync.Mutex
+	urls []localURL
+}
+
+func newLocalURLChecker() *localURLChecker {
+	return &localURLChecker{urls: []localURL{
+		{Name: "Console", URL: consoleLocalURL(), Detail: "not checked yet"},
+		{Name: "API (Swagger)", URL: kubefirstAPILocalURL() + "/swagger/index.html", Detail: "not checked yet"},
+	}}
+}
+
+// checkLocalURL requests url. Anything but a server error counts as up, since the console answers / with a
+// redirect to its login page.
+func checkLocalURL(client *http.Client, url string) (bool, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), localURLCheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err.Error()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, describeLocalURLError(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode < 500, resp.Status
+}
+
+// describeLocalURLError shortens the usual errors while a dev server is still starting
+func describeLocalURLError(err error) string {
+	switch message := err.Error(); {
+	case strings.Contains(message, "connection refused"):
+		return "not listening yet"
+	case strings.Contains(message, "deadline exceeded") || strings.Contains(message, "Client.Timeout"):
+		return "no response yet"
+	case strings.Contains(message, "certificate"):
+		return "certificate not trusted, run 'Setup Local TLS'"
+	default:
+		return message
+	}
+}
+
+// run checks every URL until stop is closed
+func (c *localURLChecker) run(stop <-chan struct{}) {
+	// Don't follow redirects, so a redirect to a page that isn't up yet still counts as an answer
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	ticker := time.NewTicker(localURLCheckInterval)
+	defer ticker.Stop()
+	for {
+		c.mu.Lock()
+		urls := append([]localURL(nil), c.urls...)
+		c.mu.Unlock()
+		for i := range urls {
+			urls[i].Up, urls[i].Detail = checkLocalURL(client, urls[i].URL)
+		}
+		c.mu.Lock()
+		c.urls = urls
+		c.mu.Unlock()
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (c *localURLChecker) snapshot() []localURL {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]localURL(nil), c.urls...)
+}
+
+// renderLocalURLs lists the URLs for the dashboard summary, with the ones that answered first, and a status line
+func renderLocalURLs(urls []localURL) (string, string) {
+	var lines, waiting []string
+	for _, url := range urls {
+		if url.Up {
+			lines = append(lines, fmt.Sprintf("✅ %s: %s", url.Name, url.URL))
+		} else {
+			waiting = append(waiting, url.Name)
+		}
+	}
+	for _, url := range urls {
+		if !url.Up {
+			lines = append(lines, fmt.Sprintf("⏳ %s: %s (%s)", url.Name, url.URL, url.Detail))
+		}
+	}
+	status := "All services responding"
+	if len(waiting) > 0 {
+		status = "Waiting for " + strings.Join(waiting, " and ")
+	}
+	return strings.Join(lines, "\n"), status
+}
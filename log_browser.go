@@ -0,0 +1,392 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// Lines the pager's error jumps stop at: log levels, Go panics and terraform/kubefirst failures
+var logErrorLine = regexp.MustCompile(`(?i)\b(error|erro|fatal|panic|failed|failure)\b`)
+
+var (
+	pagerErrorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F5F"))
+	pagerMatchStyle   = lipgloss.NewStyle().Background(lipgloss.Color("#FFD75F")).Foreground(lipgloss.Color("#000000"))
+	pagerGutterStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	pagerCurrentStyle = lipgloss.NewStyle().Foreground(special).Bold(true)
+)
+
+// logSource is a service or cluster whose past logs can be browsed
+type logSource struct {
+	name  string
+	files []logFile
+}
+
+// listLogSources finds the services with logs in ~/.ssot/k1space/.logs, the way the dashboard finds a pane's
+// latest log, followed by every config with provisioning logs
+func listLogSources() ([]logSource, error) {
+	logsDir := getLogsDir()
+	entries, err := os.ReadDir(logsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[string]bool)
+	appended := make(map[string]logFile)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if match := logTimestampPattern.FindStringSubmatch(entry.Name()); match != nil {
+			services[match[1]] = true
+		} else if name, ok := strings.CutSuffix(entry.Name(), ".log"); ok {
+			// Logs that are appended to across runs, like kubefirst.log, are listed with their service
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			services[name] = true
+			appended[name] = logFile{path: filepath.Join(logsDir, entry.Name()), size: info.Size(), modTime: info.ModTime()}
+		}
+	}
+
+	var sources []logSource
+	for _, name := range sortedKeys(services) {
+		files, err := serviceLogFiles(name)
+		if err != nil {
+			return nil, err
+		}
+		if file, ok := appended[name]; ok {
+			files = append([]logFile{file}, files...)
+		}
+		sources = append(sources, logSource{name: name, files: files})
+	}
+
+	configDirs, err := filepath.Glob(filepath.Join(logsDir, "*", "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(configDirs)
+	for _, dir := range configDirs {
+		files, err := configLogFiles(dir)
+		if err != nil || len(files) == 0 {
+			continue
+		}
+		rel, _ := filepath.Rel(logsDir, dir)
+		sources = append(sources, logSource{name: strings.ReplaceAll(rel, string(filepath.Separator), "_"), files: files})
+	}
+	return sources, nil
+}
+
+// configLogFiles returns the logs in a config's log directory, newest first
+func configLogFiles(dir string) ([]logFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []logFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".log") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, logFile{path: filepath.Join(dir, entry.Name()), size: info.Size(), modTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+	return files, nil
+}
+
+// viewLogs lets the user pick a service or cluster, then one of its log files, and opens it in the pager
+func viewLogs() {
+	sources, err := listLogSources()
+	if err != nil && !os.IsNotExist(err) {
+		log.Error("Error reading logs", "path", getLogsDir(), "error", err)
+		return
+	}
+	if len(sources) == 0 {
+		fmt.Println("No logs found. Logs are written by 'Run Kubefirst Repositories' and by provisioning a cluster.")
+		return
+	}
+
+	for {
+		options := make([]huh.Option[int], 0, len(sources)+1)
+		for i, source := range sources {
+			options = append(options, huh.NewOption(fmt.Sprintf("%s (%d logs)", source.name, len(source.files)), i))
+		}
+		options = append(options, huh.NewOption("Back", -1))
+
+		selected := -1
+		err := runField(newSelect("Select a service or cluster", &selected, options...))
+		if err != nil {
+			log.Error("Error selecting logs", "error", err)
+			return
+		}
+		if selected < 0 {
+			return
+		}
+		browseLogFiles(sources[selected])
+	}
+}
+
+// browseLogFiles lists a source's log files, newest first, until the user goes back
+func browseLogFiles(source logSource) {
+	for {
+		options := make([]huh.Option[string], 0, len(source.files)+1)
+		for _, file := range source.files {
+			label := fmt.Sprintf("%s  %s  %s", filepath.Base(file.path), file.modTime.Format("2006-01-02 15:04"), formatFileSize(file.size))
+			options = append(options, huh.NewOption(label, file.path))
+		}
+		options = append(options, huh.NewOption("Back", ""))
+
+		var selected string
+		err := runField(newSelect(fmt.Sprintf("Logs for %s", source.name), &selected, options...))
+		if err != nil {
+			log.Error("Error selecting log file", "error", err)
+			return
+		}
+		if selected == "" {
+			return
+		}
+		err = runLogPager(selected)
+		if err != nil {
+			log.Error("Error viewing log", "path", selected, "error", err)
+		}
+	}
+}
+
+// runLogPager shows a log file full screen until the user quits
+func runLogPager(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+	pager := newLogPager(path, string(content))
+	_, err = tea.NewProgram(pager, tea.WithAltScreen()).Run()
+	return err
+}
+
+// logPager is a scrollable view of one log file with search and jumps between error lines
+type logPager struct {
+	path     string
+	lines    []string
+	errors   []int
+	viewport viewport.Model
+	ready    bool
+
+	search    textinput.Model
+	searching bool
+	query     *regexp.Regexp
+	matches   []int
+	// current is the line the last jump landed on, -1 before the first
+	current int
+	message string
+}
+
+func newLogPager(path, content string) *logPager {
+	lines := strings.Split(strings.TrimRight(ansiEscape.ReplaceAllString(content, ""), "\n"), "\n")
+	var errors []int
+	for i, line := range lines {
+		if logErrorLine.MatchString(line) {
+			errors = append(errors, i)
+		}
+	}
+	search := textinput.New()
+	search.Prompt = "/"
+	search.CharLimit = 200
+	return &logPager{path: path, lines: lines, errors: errors, search: search, current: -1}
+}
+
+func (p *logPager) Init() tea.Cmd {
+	return nil
+}
+
+// render numbers the lines, colors error lines and highlights search matches
+func (p *logPager) render() string {
+	width := len(strconv.Itoa(len(p.lines)))
+	var sb strings.Builder
+	for i, line := range p.lines {
+		marker := " "
+		if i == p.current {
+			marker = pagerCurrentStyle.Render("▶")
+		}
+		sb.WriteString(pagerGutterStyle.Render(fmt.Sprintf("%*d", width, i+1)) + marker + " ")
+		switch {
+		case p.query != nil && p.query.MatchString(line):
+			sb.WriteString(highlightMatches(p.query, line))
+		case logErrorLine.MatchString(line):
+			sb.WriteString(pagerErrorStyle.Render(line))
+		default:
+			sb.WriteString(line)
+		}
+		if i < len(p.lines)-1 {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+func highlightMatches(query *regexp.Regexp, line string) string {
+	var sb strings.Builder
+	last := 0
+	for _, match := range query.FindAllStringIndex(line, -1) {
+		sb.WriteString(line[last:match[0]])
+		sb.WriteString(pagerMatchStyle.Render(line[match[0]:match[1]]))
+		last = match[1]
+	}
+	sb.WriteString(line[last:])
+	return sb.String()
+}
+
+// jump moves to the next (or previous) line in targets after the current position, wrapping around at the end
+func (p *logPager) jump(targets []int, forward bool, what, none string) {
+	p.message = ""
+	if len(targets) == 0 {
+		p.message = none
+		return
+	}
+	from := p.current
+	if from < 0 {
+		from = p.viewport.YOffset - 1
+		if !forward {
+			from = p.viewport.YOffset
+		}
+	}
+	var next int
+	if forward {
+		index := sort.SearchInts(targets, from+1)
+		if index == len(targets) {
+			index, p.message = 0, "Wrapped to the top"
+		}
+		next = index
+	} else {
+		index := sort.SearchInts(targets, from) - 1
+		if index < 0 {
+			index, p.message = len(targets)-1, "Wrapped to the bottom"
+		}
+		next = index
+	}
+	p.current = targets[next]
+	if p.message == "" {
+		p.message = fmt.Sprintf("%s %d of %d", what, next+1, len(targets))
+	}
+	p.viewport.SetContent(p.render())
+	// Leave some context above the line
+	p.viewport.SetYOffset(p.current - p.viewport.Height/4)
+}
+
+func (p *logPager) applySearch(value string) {
+	p.matches, p.query = nil, nil
+	if value == "" {
+		p.viewport.SetContent(p.render())
+		return
+	}
+	p.query = regexp.MustCompile("(?i)" + regexp.QuoteMeta(value))
+	for i, line := range p.lines {
+		if p.query.MatchString(line) {
+			p.matches = append(p.matches, i)
+		}
+	}
+	p.current = -1
+	p.jump(p.matches, true, "Match", "")
+	if len(p.matches) == 0 {
+		p.message = fmt.Sprintf("No matches for %q", value)
+		p.viewport.SetContent(p.render())
+	}
+}
+
+func (p *logPager) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		height := msg.Height - 2
+		if !p.ready {
+			p.viewport = viewport.New(msg.Width, height)
+			p.viewport.SetContent(p.render())
+			p.ready = true
+		} else {
+			p.viewport.Width, p.viewport.Height = msg.Width, height
+		}
+		p.search.Width = msg.Width - 2
+		return p, nil
+
+	case tea.KeyMsg:
+		if p.searching {
+			switch msg.String() {
+			case "enter":
+				p.searching = false
+				p.search.Blur()
+				p.applySearch(p.search.Value())
+				return p, nil
+			case "esc", "ctrl+c":
+				p.searching = false
+				p.search.Blur()
+				return p, nil
+			}
+			var cmd tea.Cmd
+			p.search, cmd = p.search.Update(msg)
+			return p, cmd
+		}
+
+		p.message = ""
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return p, tea.Quit
+		case "/":
+			p.searching = true
+			p.search.SetValue("")
+			return p, p.search.Focus()
+		case "n":
+			p.jump(p.matches, true, "Match", "No matches, press / to search")
+			return p, nil
+		case "N":
+			p.jump(p.matches, false, "Match", "No matches, press / to search")
+			return p, nil
+		case "e":
+			p.jump(p.errors, true, "Error", "No errors in this log")
+			return p, nil
+		case "E":
+			p.jump(p.errors, false, "Error", "No errors in this log")
+			return p, nil
+		case "g", "home":
+			p.viewport.GotoTop()
+			return p, nil
+		case "G", "end":
+			p.viewport.GotoBottom()
+			return p, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	p.viewport, cmd = p.viewport.Update(msg)
+	return p, cmd
+}
+
+func (p *logPager) View() string {
+	if !p.ready {
+		return "Loading..."
+	}
+	header := titleStyle.Render(filepath.Base(p.path)) + pathStyle.Render(fmt.Sprintf("line %d/%d, %d errors", p.viewport.YOffset+1, len(p.lines), len(p.errors)))
+	if p.searching {
+		return header + "\n" + p.viewport.View() + "\n" + p.search.View()
+	}
+	footer := "/ search  n/N next/prev match  e/E next/prev error  g/G top/bottom  q back"
+	if p.message != "" {
+		footer = p.message + "  |  " + footer
+	}
+	return header + "\n" + p.viewport.View() + "\n" + pathStyle.Render(footer)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// getLogBufferLines returns how many lines a pane keeps in memory, from log_buffer_lines in settings.hcl
+func getLogBufferLines(pane string) int {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings, using the default log buffer size", "error", err)
+		return maxLogLines
+	}
+	lines, ok := settings.LogBufferLines[pane]
+	if !ok {
+		return maxLogLines
+	}
+	if lines <= 0 {
+		log.Warn("Invalid log_buffer_lines in settings.hcl, using the default", "pane", pane, "lines", lines)
+		return maxLogLines
+	}
+	return lines
+}
+
+// paneTitle adds a paused pane's state to its title, so it's clear the pane isn't scrolling
+func paneTitle(title string, logs *scrollingLog) string {
+	following, newLines := logs.following()
+	if following {
+		return title
+	}
+	return fmt.Sprintf("%s (paused, %d new lines)", title, newLines)
+}
+
+// dumpLogBuffer writes a pane's whole in-memory buffer to dir and returns the file's path
+func dumpLogBuffer(logs *scrollingLog, dir string) (string, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", fmt.Errorf("error creating dump directory: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-dump-%s.log", logs.name, time.Now().Format("2006-01-02-150405")))
+	err = os.WriteFile(path, []byte(logs.get()+"\n"), 0644)
+	if err != nil {
+		return "", fmt.Errorf("error writing %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// logPaneControls handles the commands typed under a dashboard: toggling follow mode, dumping buffers and
+// restarting services with another log level. The dashboard redraws every second, so the outcome of the last
+// command is kept for it to show.
+type logPaneControls struct {
+	panes   []*scrollingLog
+	dumpDir string
+	// services are keyed by the name of the pane showing their logs
+	services map[string]*localService
+
+	mu     sync.Mutex
+	status string
+}
+
+func newLogPaneControls(dumpDir string, panes ...*scrollingLog) *logPaneControls {
+	return &logPaneControls{panes: panes, dumpDir: dumpDir, services: make(map[string]*localService)}
+}
+
+// addServices lets the log level command restart services; each one's logs are in the pane with its name
+func (c *logPaneControls) addServices(services ...*localService) {
+	for _, service := range services {
+		c.services[service.name] = service
+	}
+}
+
+// help lists the commands and numbers the panes they apply to
+func (c *logPaneControls) help() string {
+	names := make([]string, len(c.panes))
+	for i, pane := range c.panes {
+		names[i] = fmt.Sprintf("%d=%s", i+1, pane.name)
+	}
+	levelCommand := ""
+	if len(c.services) > 0 {
+		levelCommand = fmt.Sprintf(" l <pane> <%s> restart with log level,", strings.Join(serviceLogLevels, "|"))
+	}
+	return fmt.Sprintf("Type a command and press Enter: f [pane] follow/pause, d [pane] dump buffer,%s q quit (panes: %s)", levelCommand, strings.Join(names, ", "))
+}
+
+func (c *logPaneControls) lastStatus() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status
+}
+
+func (c *logPaneControls) setStatus(format string, args ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status = fmt.Sprintf(format, args...)
+}
+
+// selectPanes returns the pane numbered by arg, or every pane when arg is empty
+func (c *logPaneControls) selectPanes(arg string) ([]*scrollingLog, error) {
+	if arg == "" {
+		return c.panes, nil
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(c.panes) {
+		return nil, fmt.Errorf("no pane %q, use 1 to %d", arg, len(c.panes))
+	}
+	return c.panes[n-1 : n], nil
+}
+
+// restartWithLevel handles "l <pane> <level>" and describes the outcome
+func (c *logPaneControls) restartWithLevel(args []string) string {
+	if len(c.services) == 0 {
+		return "No services to restart"
+	}
+	if len(args) != 2 {
+		return fmt.Sprintf("Usage: l <pane> <%s>", strings.Join(serviceLogLevels, "|"))
+	}
+	panes, err := c.selectPanes(args[0])
+	if err != nil {
+		return err.Error()
+	}
+	service, ok := c.services[panes[0].name]
+	if !ok {
+		return fmt.Sprintf("%s isn't a service k1space can restart", panes[0].name)
+	}
+	err = service.restartWithLevel(args[1])
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("Restarting %s with log level %s", service.name, args[1])
+}
+
+// run reads commands from stdin until the user quits
+func (c *logPaneControls) run() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(strings.ToLower(scanner.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+		command, arg := fields[0], ""
+		if len(fields) > 1 {
+			arg = fields[1]
+		}
+		if command == "l" || command == "level" {
+			c.setStatus("%s", c.restartWithLevel(fields[1:]))
+			continue
+		}
+
+		switch command {
+		case "q", "quit":
+			return
+		case "f", "follow":
+			panes, err := c.selectPanes(arg)
+			if err != nil {
+				c.setStatus("%s", err)
+				continue
+			}
+			var states []string
+			for _, pane := range panes {
+				state := "paused"
+				if pane.toggleFollow() {
+					state = "following"
+				}
+				states = append(states, pane.name+" "+state)
+			}
+			c.setStatus("%s", strings.Join(states, ", "))
+		case "d", "dump":
+			panes, err := c.selectPanes(arg)
+			if err != nil {
+				c.setStatus("%s", err)
+				continue
+			}
+			var paths []string
+			for _, pane := range panes {
+				path, err := dumpLogBuffer(pane, c.dumpDir)
+				if err != nil {
+					log.Error("Error dumping log buffer", "pane", pane.name, "error", err)
+					c.setStatus("Error dumping %s: %s", pane.name, err)
+					break
+				}
+				paths = append(paths, path)
+			}
+			if len(paths) > 0 {
+				c.setStatus("Dumped to %s", strings.Join(paths, ", "))
+			}
+		default:
+			c.setStatus("Unknown command %q", command)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Println("Error reading input:", err)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+// Matches the timestamp suffix shared by all log files of a single run, e.g. 00-init-20240801-153000.log
+var runTimestampPattern = regexp.MustCompile(`-(\d{8}-\d{6})\.log$`)
+
+func exportOperationLogs() {
+	log.Info("Starting exportOperationLogs function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations. Please ensure that the config.hcl file exists and is correctly formatted.")
+		return
+	}
+
+	selectedConfig, err := promptConfigSelection(indexFile, "Select a configuration to export logs for")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations found.")
+		return
+	}
+
+	parts := strings.Split(selectedConfig, "_")
+	if len(parts) != 3 {
+		log.Error("Invalid config name format", "config", selectedConfig)
+		fmt.Println("Invalid configuration name format. Export cancelled.")
+		return
+	}
+	cloud, region, prefix := parts[0], parts[1], parts[2]
+
+	logDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".logs", cloud, region, prefix)
+	runs, err := listOperationRuns(logDir)
+	if err != nil {
+		log.Error("Error reading log directory", "path", logDir, "error", err)
+		fmt.Println("No operation logs found for this configuration.")
+		return
+	}
+	if len(runs) == 0 {
+		fmt.Println("No operation logs found for this configuration.")
+		return
+	}
+
+	timestamps := make([]string, 0, len(runs))
+	for timestamp := range runs {
+		timestamps = append(timestamps, timestamp)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(timestamps)))
+
+	runOptions := make([]huh.Option[string], 0, len(timestamps))
+	for _, timestamp := range timestamps {
+		label := timestamp
+		if t, err := time.ParseInLocation("20060102-150405", timestamp, time.Local); err == nil {
+			label = fmt.Sprintf("%s (%d files)", t.Format("2006-01-02 15:04:05"), len(runs[timestamp]))
+		}
+		runOptions = append(runOptions, huh.NewOption(label, timestamp))
+	}
+
+	var selectedRun string
+	err = runField(newSelect("Select the run to export", &selectedRun, runOptions...))
+	if err != nil {
+		log.Error("Error in run selection", "error", err)
+		return
+	}
+
+	exportDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".exports")
+	err = os.MkdirAll(exportDir, 0755)
+	if err != nil {
+		log.Error("Error creating export directory", "error", err)
+		return
+	}
+
+	bundlePath := filepath.Join(exportDir, fmt.Sprintf("%s-%s.zip", selectedConfig, selectedRun))
+	envFilePath := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", cloud, region, prefix, ".local.cloud.env")
+	err = writeOperationBundle(bundlePath, runs[selectedRun], envFilePath, indexFile.Configs[selectedConfig])
+	if err != nil {
+		log.Error("Error writing operation bundle", "error", err)
+		fmt.Println("Failed to export operation logs:", err)
+		return
+	}
+
+	fmt.Println(style.Render("📦 Operation logs exported"))
+	fmt.Printf("Bundle: %s\n", bundlePath)
+	log.Info("exportOperationLogs function completed successfully", "bundle", bundlePath)
+}
+
+func listOperationRuns(logDir string) (map[string][]string, error) {
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make(map[string][]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := runTimestampPattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		runs[match[1]] = append(runs[match[1]], filepath.Join(logDir, entry.Name()))
+	}
+	return runs, nil
+}
+
+func writeOperationBundle(bundlePath string, logFiles []string, envFilePath string, config Config) error {
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		return fmt.Errorf("error creating bundle file: %w", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+
+	for _, logFile := range logFiles {
+		content, err := os.ReadFile(logFile)
+		if err != nil {
+			return fmt.Errorf("error reading log file %s: %w", logFile, err)
+		}
+		err = addZipEntry(zw, filepath.Join("logs", filepath.Base(logFile)), content)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The env snapshot is optional; the config may have been deleted since the run
+	if envContent, err := os.ReadFile(envFilePath); err == nil {
+		err = addZipEntry(zw, "env/.local.cloud.env", []byte(redactEnvContent(string(envContent))))
+		if err != nil {
+			return err
+		}
+	}
+
+	redactor := newRedactor()
+	redactedFlags := make(map[string]string, len(config.Flags))
+	for k, v := range config.Flags {
+		redactedFlags[k] = redactor.redact(v)
+	}
+	entry, err := json.MarshalIndent(Config{Files: config.Files, Flags: redactedFlags}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding config entry: %w", err)
+	}
+	err = addZipEntry(zw, "state/config.json", entry)
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func addZipEntry(zw *zip.Writer, name string, content []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("error adding %s to bundle: %w", name, err)
+	}
+	_, err = w.Write(content)
+	if err != nil {
+		return fmt.Errorf("error writing %s to bundle: %w", name, err)
+	}
+	return nil
+}
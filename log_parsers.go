@@ -0,0 +1,342 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// How many of a tool's most recent errors its widget shows
+const logParserMaxErrors = 3
+
+var (
+	ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+	parserWidgetStyle = boxStyle.Copy().
+				BorderForeground(lipgloss.Color("#5FAFFF")).
+				Width(58)
+	parserErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F5F"))
+)
+
+// LogParser extracts a tool's progress and errors from provisioning output for its own dashboard widget.
+// Parsers see every line of the provisioning script and kubefirst's logs and ignore the ones that aren't theirs.
+type LogParser interface {
+	Name() string
+	Parse(line string)
+	// Widget returns the widget's lines, or nil until the tool has shown up in the logs
+	Widget() []string
+}
+
+// newLogParsers returns a fresh set of parsers for one provisioning run
+func newLogParsers() *logParserSet {
+	return &logParserSet{step: -1, parsers: []LogParser{
+		&terraformLogParser{},
+		&argocdLogParser{apps: make(map[string]string)},
+		&helmLogParser{releases: make(map[string]string)},
+	}}
+}
+
+// logParserSet feeds lines to its parsers. Lines arrive from the script's pipes and the kubefirst log tailer at
+// once, so the set serializes them and parsers don't need locks of their own.
+type logParserSet struct {
+	mu      sync.Mutex
+	parsers []LogParser
+	// Index into provisioningSteps of the furthest step seen so far, -1 before the first
+	step int
+}
+
+// provisioningStep is a phase of a kubefirst create run, recognized by the first line that mentions it
+type provisioningStep struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// provisioningSteps are in the order kubefirst runs them. kubefirst mentions earlier phases again later on (vault
+// shows up while Argo CD syncs it), so the current step only ever moves forward.
+var provisioningSteps = []provisioningStep{
+	{"Preflight checks", regexp.MustCompile(`(?i)preflight|checking .*(token|credentials|domain|bucket)`)},
+	{"Git repositories", regexp.MustCompile(`(?i)(git(hub|lab)?|gitops).*(repositor|terraform|template)`)},
+	{"Cloud infrastructure", regexp.MustCompile(`(?i)(creating|provisioning|create) .*cluster|cloud terraform`)},
+	{"Argo CD", regexp.MustCompile(`(?i)argo\s?cd`)},
+	{"Vault", regexp.MustCompile(`(?i)vault`)},
+	{"Users", regexp.MustCompile(`(?i)(creating|terraform) users|users terraform`)},
+	{"Console", regexp.MustCompile(`(?i)kubefirst console|cluster .*(is up|created successfully)`)},
+}
+
+func (s *logParserSet) parse(line string) {
+	line = strings.TrimSpace(ansiEscape.ReplaceAllString(line, ""))
+	if line == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, parser := range s.parsers {
+		parser.Parse(line)
+	}
+	for i := len(provisioningSteps) - 1; i > s.step; i-- {
+		if provisioningSteps[i].Pattern.MatchString(line) {
+			s.step = i
+			break
+		}
+	}
+}
+
+// currentStep describes the furthest provisioning step seen, e.g. "Argo CD (4/7)"
+func (s *logParserSet) currentStep() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.step < 0 {
+		return "Starting"
+	}
+	return fmt.Sprintf("%s (%d/%d)", provisioningSteps[s.step].Name, s.step+1, len(provisioningSteps))
+}
+
+// render lays out the widgets of the tools seen so far side by side
+func (s *logParserSet) render() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var widgets []string
+	for _, parser := range s.parsers {
+		lines := parser.Widget()
+		if lines == nil {
+			continue
+		}
+		widgets = append(widgets, parserWidgetStyle.Render(titleStyle.Render(parser.Name())+"\n"+strings.Join(lines, "\n")))
+	}
+	if len(widgets) == 0 {
+		return ""
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, widgets...)
+}
+
+// recentErrors keeps the last few errors a tool reported
+type recentErrors []string
+
+func (e *recentErrors) add(message string) {
+	*e = append(*e, message)
+	if len(*e) > logParserMaxErrors {
+		*e = (*e)[len(*e)-logParserMaxErrors:]
+	}
+}
+
+func (e recentErrors) lines() []string {
+	lines := make([]string, len(e))
+	for i, message := range e {
+		lines[i] = parserErrorStyle.Render("✗ " + truncateOrWrap(message, 52))
+	}
+	return lines
+}
+
+// progressBar draws done out of total as a bar of the given width
+func progressBar(done, total, width int) string {
+	if total <= 0 {
+		return strings.Repeat("░", width)
+	}
+	filled := done * width / total
+	if filled > width {
+		filled = width
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+var (
+	terraformPlan     = regexp.MustCompile(`Plan: (\d+) to add, (\d+) to change, (\d+) to destroy`)
+	terraformApplied  = regexp.MustCompile(`Apply complete! Resources: (\d+) added, (\d+) changed, (\d+) destroyed`)
+	terraformProgress = regexp.MustCompile(`([\w.\-\[\]"]+): (Creating|Modifying|Destroying|Still creating|Still modifying|Still destroying)\.\.\.(?: \[(?:id=[^,\]]*, )?([\dhms]+) elapsed\])?`)
+	terraformDone     = regexp.MustCompile(`([\w.\-\[\]"]+): (Creation|Modifications|Destruction) complete after`)
+	// Terraform draws errors in a box ("│ Error: ..."); older versions print them at the start of the line
+	terraformError = regexp.MustCompile(`(?:^|│\s*)Error: (.+)`)
+)
+
+// terraformLogParser follows plans and applies. kubefirst runs several terraform applies in a row (cloud, git,
+// vault, users), so each new plan restarts the progress count.
+type terraformLogParser struct {
+	seen      bool
+	planned   int
+	done      int
+	plan      string
+	current   string
+	applies   int
+	resources int
+	errors    recentErrors
+}
+
+func (p *terraformLogParser) Name() string { return "Terraform" }
+
+func (p *terraformLogParser) Parse(line string) {
+	if m := terraformPlan.FindStringSubmatch(line); m != nil {
+		add, _ := strconv.Atoi(m[1])
+		change, _ := strconv.Atoi(m[2])
+		destroy, _ := strconv.Atoi(m[3])
+		p.seen = true
+		p.planned = add + change + destroy
+		p.done = 0
+		p.plan = fmt.Sprintf("%d to add, %d to change, %d to destroy", add, change, destroy)
+		return
+	}
+	if m := terraformApplied.FindStringSubmatch(line); m != nil {
+		p.seen = true
+		p.applies++
+		for _, count := range m[1:] {
+			n, _ := strconv.Atoi(count)
+			p.resources += n
+		}
+		p.current = ""
+		return
+	}
+	if m := terraformDone.FindStringSubmatch(line); m != nil {
+		p.seen = true
+		p.done++
+		return
+	}
+	if m := terraformProgress.FindStringSubmatch(line); m != nil {
+		p.seen = true
+		p.current = fmt.Sprintf("%s (%s", m[1], strings.ToLower(strings.TrimPrefix(m[2], "Still ")))
+		if m[3] != "" {
+			p.current += ", " + m[3]
+		}
+		p.current += ")"
+		return
+	}
+	// helm's errors look the same, so leave those to the helm parser
+	if m := terraformError.FindStringSubmatch(line); m != nil && !helmFailed.MatchString(line) {
+		p.seen = true
+		p.errors.add(m[1])
+	}
+}
+
+func (p *terraformLogParser) Widget() []string {
+	if !p.seen {
+		return nil
+	}
+	lines := []string{}
+	if p.plan != "" {
+		lines = append(lines,
+			"Plan: "+p.plan,
+			fmt.Sprintf("%s %d/%d", progressBar(p.done, p.planned, 30), p.done, p.planned),
+		)
+	}
+	if p.current != "" {
+		lines = append(lines, "Now: "+truncateOrWrap(p.current, 50))
+	}
+	lines = append(lines, fmt.Sprintf("Applies completed: %d (%d resources)", p.applies, p.resources))
+	return append(lines, p.errors.lines()...)
+}
+
+var (
+	// e.g. `application "vault" sync status: Synced` or `app=cert-manager health=Progressing`
+	argocdAppStatus = regexp.MustCompile(`(?i)\bapp(?:lication)?[\s=:"']+([a-z0-9][a-z0-9-]*)["']?.*?\b(Synced|OutOfSync|Healthy|Progressing|Degraded|Missing|Suspended)\b`)
+	argocdError     = regexp.MustCompile(`(?i)argo\s?cd.*\b(error|failed)\b[:\s]*(.*)`)
+)
+
+// argocdLogParser tracks the latest sync or health status reported for each Argo CD application
+type argocdLogParser struct {
+	apps   map[string]string
+	errors recentErrors
+}
+
+func (p *argocdLogParser) Name() string { return "Argo CD" }
+
+func (p *argocdLogParser) Parse(line string) {
+	if m := argocdAppStatus.FindStringSubmatch(line); m != nil {
+		p.apps[m[1]] = m[2]
+		return
+	}
+	if m := argocdError.FindStringSubmatch(line); m != nil {
+		message := strings.TrimSpace(m[2])
+		if message == "" {
+			message = line
+		}
+		p.errors.add(message)
+	}
+}
+
+func (p *argocdLogParser) Widget() []string {
+	if len(p.apps) == 0 && len(p.errors) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	var unhealthy []string
+	for app, status := range p.apps {
+		counts[status]++
+		if status == "Degraded" || status == "Missing" || status == "OutOfSync" {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s (%s)", app, status))
+		}
+	}
+	statuses := make([]string, 0, len(counts))
+	for status, count := range counts {
+		statuses = append(statuses, fmt.Sprintf("%s %d", status, count))
+	}
+	sort.Strings(statuses)
+	sort.Strings(unhealthy)
+
+	lines := []string{fmt.Sprintf("Applications: %d", len(p.apps))}
+	if len(statuses) > 0 {
+		lines = append(lines, truncateOrWrap(strings.Join(statuses, ", "), 56))
+	}
+	if len(unhealthy) > 0 {
+		lines = append(lines, "Needs attention: "+truncateOrWrap(strings.Join(unhealthy, ", "), 39))
+	}
+	return append(lines, p.errors.lines()...)
+}
+
+var (
+	helmName     = regexp.MustCompile(`^NAME: (\S+)`)
+	helmStatus   = regexp.MustCompile(`^STATUS: (\S+)`)
+	helmUpgraded = regexp.MustCompile(`Release "([^"]+)" has been upgraded`)
+	helmFailed   = regexp.MustCompile(`Error: (INSTALLATION|UPGRADE) FAILED: (.+)`)
+)
+
+// helmLogParser tracks the releases helm reports installing or upgrading
+type helmLogParser struct {
+	// The NAME: line comes before its STATUS: line
+	lastName string
+	releases map[string]string
+	errors   recentErrors
+}
+
+func (p *helmLogParser) Name() string { return "Helm" }
+
+func (p *helmLogParser) Parse(line string) {
+	switch {
+	case helmName.MatchString(line):
+		p.lastName = helmName.FindStringSubmatch(line)[1]
+	case helmStatus.MatchString(line) && p.lastName != "":
+		p.releases[p.lastName] = helmStatus.FindStringSubmatch(line)[1]
+		p.lastName = ""
+	case helmUpgraded.MatchString(line):
+		p.releases[helmUpgraded.FindStringSubmatch(line)[1]] = "deployed"
+	case helmFailed.MatchString(line):
+		m := helmFailed.FindStringSubmatch(line)
+		p.errors.add(strings.ToLower(m[1]) + " failed: " + m[2])
+	}
+}
+
+func (p *helmLogParser) Widget() []string {
+	if len(p.releases) == 0 && len(p.errors) == 0 {
+		return nil
+	}
+	deployed := 0
+	var other []string
+	for release, status := range p.releases {
+		if status == "deployed" {
+			deployed++
+		} else {
+			other = append(other, fmt.Sprintf("%s (%s)", release, status))
+		}
+	}
+	sort.Strings(other)
+
+	lines := []string{fmt.Sprintf("Releases: %d deployed", deployed)}
+	if len(other) > 0 {
+		lines = append(lines, "Not deployed: "+truncateOrWrap(strings.Join(other, ", "), 42))
+	}
+	return append(lines, p.errors.lines()...)
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Defaults for the log_retention block of settings.hcl. Runs aren't removed by age unless max_age is set.
+const (
+	defaultLogKeepRuns  = 20
+	defaultLogMaxSizeMB = 50
+)
+
+// LogRetentionSettings bound what ~/.ssot/k1space/.logs keeps, from the log_retention block of settings.hcl
+type LogRetentionSettings struct {
+	// KeepRuns is how many runs of each service, and of each config's operations, are kept
+	KeepRuns int `hcl:"keep_runs,optional"`
+	// MaxAge removes runs older than it, except for the newest run of each
+	MaxAge string `hcl:"max_age,optional"`
+	// MaxSizeMB rotates logs that are appended to across runs, like kubefirst.log, once they grow past it
+	MaxSizeMB int `hcl:"max_size_mb,optional"`
+}
+
+// logRetentionPolicy is log_retention with the defaults filled in
+type logRetentionPolicy struct {
+	keepRuns int
+	maxAge   time.Duration
+	maxSize  int64
+}
+
+// Matches the timestamp of a service log, e.g. kubefirst-api-2024-08-01-153000.log, or of a config's operation log,
+// e.g. 00-init-20240801-153000.log
+var logTimestampPattern = regexp.MustCompile(`^(.+)-(\d{4}-\d{2}-\d{2}-\d{6}|\d{8}-\d{6})\.log$`)
+
+func getLogRetentionPolicy() logRetentionPolicy {
+	policy := logRetentionPolicy{keepRuns: defaultLogKeepRuns, maxSize: defaultLogMaxSizeMB << 20}
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings, using the default log retention", "error", err)
+		return policy
+	}
+	if settings.LogRetention == nil {
+		return policy
+	}
+	if settings.LogRetention.KeepRuns > 0 {
+		policy.keepRuns = settings.LogRetention.KeepRuns
+	}
+	if settings.LogRetention.MaxSizeMB > 0 {
+		policy.maxSize = int64(settings.LogRetention.MaxSizeMB) << 20
+	}
+	if settings.LogRetention.MaxAge != "" {
+		maxAge, err := time.ParseDuration(settings.LogRetention.MaxAge)
+		if err != nil || maxAge <= 0 {
+			log.Warn("Invalid log_retention max_age in settings.hcl, not removing logs by age", "max_age", settings.LogRetention.MaxAge)
+		} else {
+			policy.maxAge = maxAge
+		}
+	}
+	return policy
+}
+
+type logFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// logRun is the files written by one run: every log of a config's operation shares a timestamp, while a local
+// service writes one file per run
+type logRun struct {
+	group   string
+	files   []logFile
+	modTime time.Time
+}
+
+func (r logRun) size() int64 {
+	var size int64
+	for _, file := range r.files {
+		size += file.size
+	}
+	return size
+}
+
+// logCleanup is what applying the retention policy would do
+type logCleanup struct {
+	remove []logRun
+	rotate []logFile
+	// total is the size of everything under the logs directory
+	total int64
+}
+
+func (c logCleanup) reclaimed() int64 {
+	var size int64
+	for _, run := range c.remove {
+		size += run.size()
+	}
+	return size
+}
+
+func (c logCleanup) empty() bool {
+	return len(c.remove) == 0 && len(c.rotate) == 0
+}
+
+func getLogsDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".logs")
+}
+
+// isConfigLogDir reports whether dir is a config's log directory, .logs/<cloud>/<region>/<prefix>
+func isConfigLogDir(logsDir, dir string) bool {
+	rel, err := filepath.Rel(logsDir, dir)
+	return err == nil && len(strings.Split(rel, string(filepath.Separator))) == 3
+}
+
+// collectLogRuns groups the timestamped logs under logsDir into runs. Files without a timestamp are appended to
+// across runs and returned on their own.
+func collectLogRuns(logsDir string) (map[string][]logRun, []logFile, int64, error) {
+	runs := make(map[string]map[string]*logRun)
+	var appended []logFile
+	var total int64
+	err := filepath.WalkDir(logsDir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		file := logFile{path: path, size: info.Size(), modTime: info.ModTime()}
+		total += file.size
+
+		match := logTimestampPattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			if strings.HasSuffix(entry.Name(), ".log") {
+				appended = append(appended, file)
+			}
+			return nil
+		}
+		dir := filepath.Dir(path)
+		rel, _ := filepath.Rel(logsDir, dir)
+		group, key := filepath.Join(rel, match[1]), match[2]
+		if isConfigLogDir(logsDir, dir) {
+			group = strings.ReplaceAll(rel, string(filepath.Separator), "_")
+		} else {
+			key = match[1] + "-" + match[2]
+		}
+		if runs[group] == nil {
+			runs[group] = make(map[string]*logRun)
+		}
+		run, ok := runs[group][key]
+		if !ok {
+			run = &logRun{group: group}
+			runs[group][key] = run
+		}
+		run.files = append(run.files, file)
+		if file.modTime.After(run.modTime) {
+			run.modTime = file.modTime
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	grouped := make(map[string][]logRun, len(runs))
+	for group, byKey := range runs {
+		for _, run := range byKey {
+			grouped[group] = append(grouped[group], *run)
+		}
+		// Newest first
+		sort.Slice(grouped[group], func(i, j int) bool {
+			return grouped[group][i].modTime.After(grouped[group][j].modTime)
+		})
+	}
+	return grouped, appended, total, nil
+}
+
+// planLogCleanup works out which runs fall outside policy and which appended logs need rotating
+func planLogCleanup(logsDir string, policy logRetentionPolicy, now time.Time) (logCleanup, error) {
+	runs, appended, total, err := collectLogRuns(logsDir)
+	if err != nil {
+		return logCleanup{}, err
+	}
+	cleanup := logCleanup{total: total}
+	for _, group := range sortedKeys(runs) {
+		for i, run := range runs[group] {
+			expired := policy.maxAge > 0 && now.Sub(run.modTime) > policy.maxAge
+			if i >= policy.keepRuns || (i > 0 && expired) {
+				cleanup.remove = append(cleanup.remove, run)
+			}
+		}
+	}
+	for _, file := range appended {
+		if file.size > policy.maxSize {
+			cleanup.rotate = append(cleanup.rotate, file)
+		}
+	}
+	return cleanup, nil
+}
+
+// rotatedLogPath is where an appended log is moved to, named like a service log so the retention policy covers
+// it from then on
+func rotatedLogPath(file logFile) string {
+	name := strings.TrimSuffix(filepath.Base(file.path), ".log")
+	return filepath.Join(filepath.Dir(file.path), fmt.Sprintf("%s-%s.log", name, file.modTime.Format("2006-01-02-150405")))
+}
+
+// applyLogCleanup rotates and removes what cleanup lists, returning how many files it removed
+func applyLogCleanup(cleanup logCleanup) (int, error) {
+	var errs []string
+	for _, file := range cleanup.rotate {
+		err := os.Rename(file.path, rotatedLogPath(file))
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	removed := 0
+	for _, run := range cleanup.remove {
+		for _, file := range run.files {
+			err := os.Remove(file.path)
+			if err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err.Error())
+				continue
+			}
+			removed++
+		}
+	}
+	if len(errs) > 0 {
+		return removed, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return removed, nil
+}
+
+// applyLogRetention trims the logs directory to the retention policy before a run adds to it
+func applyLogRetention() {
+	cleanup, err := planLogCleanup(getLogsDir(), getLogRetentionPolicy(), time.Now())
+	if err != nil {
+		log.Warn("Error reading logs for retention", "error", err)
+		return
+	}
+	if cleanup.empty() {
+		return
+	}
+	reclaimed := cleanup.reclaimed()
+	removed, err := applyLogCleanup(cleanup)
+	if err != nil {
+		log.Warn("Error applying log retention", "error", err)
+	}
+	log.Info("Applied log retention", "removed", removed, "rotated", len(cleanup.rotate), "reclaimed", formatFileSize(reclaimed))
+}
+
+// formatFileSize shows bytes in the largest unit that keeps the number above one
+func formatFileSize(bytes int64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	size, unit := float64(bytes), 0
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	return strconv.FormatFloat(size, 'f', 1, 64) + " " + units[unit]
+}
+
+// cleanLogs shows what the retention policy would remove and how much space that frees, and applies it once
+// confirmed
+func cleanLogs() {
+	logsDir := getLogsDir()
+	policy := getLogRetentionPolicy()
+	cleanup, err := planLogCleanup(logsDir, policy, time.Now())
+	if err != nil {
+		log.Error("Error reading logs", "path", logsDir, "error", err)
+		return
+	}
+	if cleanup.empty() {
+		fmt.Printf("Logs are within the retention policy (%s in %s).\n", formatFileSize(cleanup.total), logsDir)
+		return
+	}
+
+	type groupSummary struct {
+		runs, files int
+		size        int64
+	}
+	byGroup := make(map[string]*groupSummary)
+	for _, run := range cleanup.remove {
+		summary, ok := byGroup[run.group]
+		if !ok {
+			summary = &groupSummary{}
+			byGroup[run.group] = summary
+		}
+		summary.runs++
+		summary.files += len(run.files)
+		summary.size += run.size()
+	}
+	rows := [][]string{{"Logs", "Runs Removed", "Files", "Size"}}
+	for _, group := range sortedKeys(byGroup) {
+		summary := byGroup[group]
+		rows = append(rows, []string{group, strconv.Itoa(summary.runs), strconv.Itoa(summary.files), formatFileSize(summary.size)})
+	}
+	for _, file := range cleanup.rotate {
+		rel, _ := filepath.Rel(logsDir, file.path)
+		rows = append(rows, []string{rel, "rotated", "1", formatFileSize(file.size)})
+	}
+	printSummaryTable("Log Cleanup", rows)
+
+	retention := fmt.Sprintf("keeping the last %d runs", policy.keepRuns)
+	if policy.maxAge > 0 {
+		retention += fmt.Sprintf(" and runs newer than %s", policy.maxAge)
+	}
+	fmt.Printf("\nCleaning reclaims %s of %s, %s.\n", formatFileSize(cleanup.reclaimed()), formatFileSize(cleanup.total), retention)
+
+	var confirm bool
+	err = runField(newConfirm("Clean the logs?", &confirm))
+	if err != nil {
+		log.Error("Error in confirmation prompt", "error", err)
+		return
+	}
+	if !confirm {
+		fmt.Println("Log cleanup cancelled.")
+		return
+	}
+
+	removed, err := applyLogCleanup(cleanup)
+	if err != nil {
+		log.Error("Error cleaning logs", "error", err)
+	}
+	fmt.Printf("Removed %d log files and rotated %d, reclaiming %s.\n", removed, len(cleanup.rotate), formatFileSize(cleanup.reclaimed()))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
@@ -9,6 +10,26 @@ import (
 
 func main() {
 	log.SetOutput(os.Stderr)
+	fs := flag.NewFlagSet("k1space", flag.ContinueOnError)
+	answersPath := fs.String("answers", "", "answer every prompt from this YAML file, for unattended runs")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+	if *answersPath != "" {
+		answers, err := loadAnswersFile(*answersPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		promptAnswers = answers
+		log.Info("Answering prompts from file", "path", *answersPath)
+	}
+	if fs.NArg() > 0 {
+		code := runCommandLine(fs.Args())
+		shutdownTracing()
+		os.Exit(code)
+	}
+	defer shutdownTracing()
 	printIntro()
 
 	err := initializeAndCleanup()
@@ -20,12 +41,16 @@ func main() {
 	for {
 		action := runMainMenu()
 		switch action {
+		case "Replace Invalid Tokens":
+			replaceInvalidTokens()
 		case "Config":
 			runConfigMenu()
 		case "Kubefirst":
 			runKubefirstMenu()
 		case "Cluster":
 			runClusterMenu()
+		case "View Logs":
+			viewLogs()
 		case "k1space":
 			runK1spaceMenu()
 		case "Exit":
@@ -36,13 +61,12 @@ func main() {
 }
 
 func initializeAndCleanup() error {
-	indexFile, err := loadIndexFile()
+	// Rewrite config.hcl cleaned up, without entries whose names aren't cloud_region_prefix
+	err := updateIndexFile(NewCloudConfig())
 	if err != nil {
 		return err
 	}
-	cleanupIndexFile(&indexFile)
 
-	// Create a new CloudConfig instance and pass its address
-	config := NewCloudConfig()
-	return updateIndexFile(config, indexFile)
+	maintainWorkspaceGit()
+	return nil
 }
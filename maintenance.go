@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+// MaintenanceWindow is a recurring period, kept in a maintenance block per config in config.hcl, during which the
+// daemon leaves a cluster alone
+type MaintenanceWindow struct {
+	// Days are lowercase three-letter weekdays the window opens on; empty means every day
+	Days     []string `hcl:"days,optional"`
+	Start    string   `hcl:"start"`
+	Duration string   `hcl:"duration"`
+	// Timezone is an IANA name such as "Europe/Berlin"; empty means UTC
+	Timezone string `hcl:"timezone,optional"`
+}
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// parse checks the window and returns its start as hour and minute, its duration and its location
+func (w MaintenanceWindow) parse() (int, int, time.Duration, *time.Location, error) {
+	start, err := time.Parse("15:04", w.Start)
+	if err != nil {
+		return 0, 0, 0, nil, fmt.Errorf("start %q is not HH:MM", w.Start)
+	}
+	duration, err := time.ParseDuration(w.Duration)
+	if err != nil || duration <= 0 || duration > 24*time.Hour {
+		return 0, 0, 0, nil, fmt.Errorf("duration %q must be between 1m and 24h", w.Duration)
+	}
+	location := time.UTC
+	if w.Timezone != "" {
+		location, err = time.LoadLocation(w.Timezone)
+		if err != nil {
+			return 0, 0, 0, nil, fmt.Errorf("unknown timezone %q", w.Timezone)
+		}
+	}
+	for _, day := range w.Days {
+		if !contains(weekdayNames, day) {
+			return 0, 0, 0, nil, fmt.Errorf("unknown day %q, use one of %s", day, strings.Join(weekdayNames, ", "))
+		}
+	}
+	return start.Hour(), start.Minute(), duration, location, nil
+}
+
+// openAt reports whether the window is open at t. Windows can run past midnight, so the one that opened the day
+// before is checked too.
+func (w MaintenanceWindow) openAt(t time.Time) bool {
+	hour, minute, duration, location, err := w.parse()
+	if err != nil {
+		return false
+	}
+	t = t.In(location)
+	for _, daysBack := range []int{0, 1} {
+		day := t.AddDate(0, 0, -daysBack)
+		if len(w.Days) > 0 && !contains(w.Days, weekdayNames[day.Weekday()]) {
+			continue
+		}
+		opens := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, location)
+		if !t.Before(opens) && t.Before(opens.Add(duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+// describe formats the window for listings, e.g. "sat,sun 02:00 for 4h (Europe/Berlin)"
+func (w MaintenanceWindow) describe() string {
+	days := "daily"
+	if len(w.Days) > 0 {
+		days = strings.Join(w.Days, ",")
+	}
+	timezone := w.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	return fmt.Sprintf("%s %s for %s (%s)", days, w.Start, w.Duration, timezone)
+}
+
+// inMaintenance reports whether any of a config's maintenance windows is open at t
+func inMaintenance(config Config, t time.Time) bool {
+	for _, window := range config.Maintenance {
+		if window.openAt(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func describeMaintenanceWindows(windows []MaintenanceWindow) []string {
+	described := make([]string, len(windows))
+	for i, window := range windows {
+		described[i] = window.describe()
+	}
+	return described
+}
+
+// promptMaintenanceWindow asks for a new window
+func promptMaintenanceWindow() (MaintenanceWindow, error) {
+	window := MaintenanceWindow{Start: "02:00", Duration: "4h"}
+	dayOptions := make([]huh.Option[string], len(weekdayNames))
+	for i, day := range weekdayNames {
+		dayOptions[i] = huh.NewOption(day, day)
+	}
+	err := runForm(newForm(
+		newGroup(
+			newMultiSelect("Days the window opens on", &window.Days, dayOptions...).
+				Description("Select none for every day"),
+			newInput("Start time (HH:MM)", &window.Start),
+			newInput("Duration", &window.Duration).
+				Description("e.g. 90m or 4h, at most 24h"),
+			newInput("Timezone", &window.Timezone).
+				Description("IANA name such as Europe/Berlin, empty for UTC"),
+		),
+	))
+	if err != nil {
+		return window, err
+	}
+	window.Start = strings.TrimSpace(window.Start)
+	window.Duration = strings.TrimSpace(window.Duration)
+	window.Timezone = strings.TrimSpace(window.Timezone)
+	_, _, _, _, err = window.parse()
+	return window, err
+}
+
+// configureMaintenanceWindows adds or removes a config's maintenance windows
+func configureMaintenanceWindows() {
+	log.Info("Starting configureMaintenanceWindows function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations:", err)
+		return
+	}
+	selectedConfig, err := promptConfigSelection(indexFile, "Select the cluster to set maintenance windows for")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations available. Please create a configuration first.")
+		return
+	}
+	config := indexFile.Configs[selectedConfig]
+
+	options := []huh.Option[int]{huh.NewOption("Add a window", -1)}
+	for i, window := range config.Maintenance {
+		options = append(options, huh.NewOption("Remove "+window.describe(), i))
+	}
+	var selected int
+	err = runField(newSelect(fmt.Sprintf("Maintenance windows of %s", selectedConfig), &selected, options...))
+	if err != nil {
+		log.Error("Error in maintenance window selection", "error", err)
+		return
+	}
+
+	if selected < 0 {
+		window, err := promptMaintenanceWindow()
+		if err != nil {
+			log.Error("Error in maintenance window prompt", "error", err)
+			fmt.Println("Maintenance window not added:", err)
+			return
+		}
+		config.Maintenance = append(config.Maintenance, window)
+	} else {
+		config.Maintenance = append(config.Maintenance[:selected:selected], config.Maintenance[selected+1:]...)
+	}
+
+	err = updateIndex(func(indexFile *IndexFile) error {
+		latest, ok := indexFile.Configs[selectedConfig]
+		if !ok {
+			return fmt.Errorf("config %s not found", selectedConfig)
+		}
+		latest.Maintenance = config.Maintenance
+		indexFile.Configs[selectedConfig] = latest
+		return nil
+	})
+	if err != nil {
+		log.Error("Error saving maintenance windows", "error", err)
+		fmt.Println("Failed to save maintenance windows:", err)
+		return
+	}
+	if len(config.Maintenance) == 0 {
+		fmt.Printf("%s has no maintenance windows.\n", selectedConfig)
+		return
+	}
+	fmt.Printf("Maintenance windows of %s: %s\n", selectedConfig, strings.Join(describeMaintenanceWindows(config.Maintenance), "; "))
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Upper bounds of the provision_duration_seconds buckets; runs usually take 10 to 40 minutes
+var provisionDurationBuckets = []float64{300, 600, 900, 1200, 1800, 2700, 3600, 5400, 7200}
+
+// histogram is a Prometheus histogram: a cumulative count per bucket, plus the sum and count of observations
+type histogram struct {
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+func (h *histogram) observe(value float64) {
+	if h.buckets == nil {
+		h.buckets = make([]uint64, len(provisionDurationBuckets))
+	}
+	for i, bound := range provisionDurationBuckets {
+		if value <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// metricsRegistry holds what k1space reports on /metrics for the lifetime of the process
+type metricsRegistry struct {
+	mu                   sync.Mutex
+	provisionDurations   map[[2]string]*histogram // keyed by config and status
+	provisionsInProgress map[string]int
+	serviceStarts        map[string]int
+	serviceUp            map[string]bool
+}
+
+var metrics = &metricsRegistry{
+	provisionDurations:   map[[2]string]*histogram{},
+	provisionsInProgress: map[string]int{},
+	serviceStarts:        map[string]int{},
+	serviceUp:            map[string]bool{},
+}
+
+var metricsServerOnce sync.Once
+
+// startMetricsServer serves /metrics on metrics_address from settings.hcl, e.g. "127.0.0.1:9464". It's started by
+// the first long-running operation and then kept up until k1space exits, so a scraper sees every later run.
+func startMetricsServer() {
+	metricsServerOnce.Do(func() {
+		settings, err := loadSettings()
+		if err != nil || settings.MetricsAddress == "" {
+			return
+		}
+		listener, err := net.Listen("tcp", settings.MetricsAddress)
+		if err != nil {
+			log.Warn("Error starting the metrics endpoint", "address", settings.MetricsAddress, "error", err)
+			return
+		}
+		mux := http.NewServeMux()
+		// Scrapers authenticate with an access token that has the read-only scope
+		mux.HandleFunc("/metrics", requireAccessToken(scopeReadOnly, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+			fmt.Fprint(w, metrics.render())
+		}))
+		server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		go server.Serve(listener)
+		log.Info("Serving metrics", "url", fmt.Sprintf("http://%s/metrics", listener.Addr()))
+	})
+}
+
+func (m *metricsRegistry) provisionStarted(configName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.provisionsInProgress[configName]++
+}
+
+func (m *metricsRegistry) provisionFinished(configName, status string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.provisionsInProgress[configName]--
+	key := [2]string{configName, status}
+	if m.provisionDurations[key] == nil {
+		m.provisionDurations[key] = &histogram{}
+	}
+	m.provisionDurations[key].observe(duration.Seconds())
+}
+
+func (m *metricsRegistry) serviceStarted(service string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.serviceStarts[service]++
+	m.serviceUp[service] = true
+}
+
+func (m *metricsRegistry) serviceStopped(service string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.serviceUp[service] = false
+}
+
+// metricLabels formats label pairs, escaping values as the exposition format requires
+func metricLabels(pairs ...string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	var labels []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, pairs[i], escaper.Replace(pairs[i+1])))
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// render writes the metrics in the Prometheus text exposition format
+func (m *metricsRegistry) render() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var sb strings.Builder
+
+	sb.WriteString("# HELP k1space_provision_duration_seconds How long provisioning runs took, by config and outcome.\n")
+	sb.WriteString("# TYPE k1space_provision_duration_seconds histogram\n")
+	keys := make([][2]string, 0, len(m.provisionDurations))
+	for key := range m.provisionDurations {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || (keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1])
+	})
+	for _, key := range keys {
+		h := m.provisionDurations[key]
+		for i, bound := range provisionDurationBuckets {
+			sb.WriteString(fmt.Sprintf("k1space_provision_duration_seconds_bucket%s %d\n", metricLabels("config", key[0], "status", key[1], "le", fmt.Sprint(bound)), h.buckets[i]))
+		}
+		sb.WriteString(fmt.Sprintf("k1space_provision_duration_seconds_bucket%s %d\n", metricLabels("config", key[0], "status", key[1], "le", "+Inf"), h.count))
+		sb.WriteString(fmt.Sprintf("k1space_provision_duration_seconds_sum%s %g\n", metricLabels("config", key[0], "status", key[1]), h.sum))
+		sb.WriteString(fmt.Sprintf("k1space_provision_duration_seconds_count%s %d\n", metricLabels("config", key[0], "status", key[1]), h.count))
+	}
+
+	sb.WriteString("# HELP k1space_provisions_in_progress Provisioning runs currently going, by config.\n")
+	sb.WriteString("# TYPE k1space_provisions_in_progress gauge\n")
+	for _, config := range sortedKeys(m.provisionsInProgress) {
+		sb.WriteString(fmt.Sprintf("k1space_provisions_in_progress%s %d\n", metricLabels("config", config), m.provisionsInProgress[config]))
+	}
+
+	sb.WriteString("# HELP k1space_service_up Whether a service started by 'Run Kubefirst Repositories' is running.\n")
+	sb.WriteString("# TYPE k1space_service_up gauge\n")
+	for _, service := range sortedKeys(m.serviceUp) {
+		up := 0
+		if m.serviceUp[service] {
+			up = 1
+		}
+		sb.WriteString(fmt.Sprintf("k1space_service_up%s %d\n", metricLabels("service", service), up))
+	}
+
+	sb.WriteString("# HELP k1space_service_restarts_total Times a service was started again after its first start.\n")
+	sb.WriteString("# TYPE k1space_service_restarts_total counter\n")
+	for _, service := range sortedKeys(m.serviceStarts) {
+		sb.WriteString(fmt.Sprintf("k1space_service_restarts_total%s %d\n", metricLabels("service", service), m.serviceStarts[service]-1))
+	}
+	return sb.String()
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+// Placeholder that is substituted with each target region in flag values
+const regionPlaceholder = "{region}"
+
+func createMultiRegionConfig() {
+	log.Info("Starting createMultiRegionConfig function")
+
+	cloudsFile, err := loadCloudsFile()
+	if err != nil {
+		log.Error("Error loading clouds file", "error", err)
+		return
+	}
+
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		return
+	}
+
+	kubefirstPath, err := promptKubefirstBinary("")
+	if err != nil {
+		log.Error("Error selecting kubefirst binary", "error", err)
+		return
+	}
+
+	var staticPrefix, cloudProvider string
+	err = runForm(newForm(
+		newGroup(
+			newInput("Enter static prefix", &staticPrefix).
+				Description("Default is 'K1'").
+				Placeholder("K1").
+				Validate(settings.NamingPolicy.validateStaticPrefix),
+
+			newSelect("Select cloud provider", &cloudProvider, getCloudProviderOptions()...),
+		),
+	))
+	if err != nil {
+		log.Error("Error in initial config form", "error", err)
+		return
+	}
+
+	if staticPrefix == "" {
+		staticPrefix = "K1"
+	}
+
+	if cloudProvider == "K3s" {
+		fmt.Println("K3s configs target specific hosts rather than regions. Use 'Create Config' instead.")
+		return
+	}
+
+	profile, err := promptCredentialProfile(cloudProvider, settings, "")
+	if err != nil {
+		log.Error("Error in credential profile selection", "error", err)
+		return
+	}
+	useCredentialProfile(cloudProvider, profile)
+
+	tokenExists, message := checkRequiredTokens(cloudProvider)
+	if !tokenExists {
+		log.Error("Missing required token", "cloud", cloudProvider)
+		fmt.Println(message)
+		return
+	}
+
+	err = ensureCloudData(cloudProvider, &cloudsFile)
+	if err != nil {
+		log.Error("Error updating cloud data", "cloud", cloudProvider, "error", err)
+		fmt.Println(err)
+		return
+	}
+
+	var regions []string
+	err = runField(newMultiSelect("Select the regions to create configs for", &regions, getRegionOptions(cloudProvider, cloudsFile, nil)...))
+	if err != nil {
+		log.Error("Error in region selection", "error", err)
+		return
+	}
+
+	if len(regions) == 0 {
+		fmt.Println("No regions selected. Multi-region config creation cancelled.")
+		return
+	}
+
+	flags, err := fetchKubefirstFlags(kubefirstPath, cloudProvider)
+	if err != nil {
+		log.Error("Error fetching kubefirst flags", "error", err)
+		return
+	}
+
+	if len(flags) == 0 {
+		log.Error("No flags found for the selected cloud provider")
+		return
+	}
+
+	fieldCtx := flagFieldContext{
+		CloudProvider: cloudProvider,
+		CloudsFile:    cloudsFile,
+		// Check the name each region will actually get
+		ClusterNameValidator: func(name string) error {
+			for _, region := range regions {
+				err := settings.NamingPolicy.validateClusterName(cloudProvider, regionClusterName(name, region))
+				if err != nil {
+					return fmt.Errorf("%s: %w", region, err)
+				}
+			}
+			return nil
+		},
+		FlagDocs: loadFlagDocs(cloudProvider),
+	}
+	flagInputs := make([]struct{ Name, Value string }, 0, len(flags))
+	flagGroups := make([]huh.Field, 0, len(flags))
+	for flag, description := range flags {
+		// The region is chosen above and applied per config
+		if flag == "cloud-region" {
+			continue
+		}
+		flagInputs = append(flagInputs, struct{ Name, Value string }{Name: flag})
+		description = fmt.Sprintf("%s\nUse %s to insert the target region.", description, regionPlaceholder)
+		flagGroups = append(flagGroups, newFlagField(flag, description, fieldCtx, &flagInputs[len(flagInputs)-1].Value, ""))
+	}
+
+	err = runForm(newForm(newGroup(flagGroups...)))
+	if err != nil {
+		log.Error("Error in flag input form", "error", err)
+		return
+	}
+
+	configs := make([]*CloudConfig, len(regions))
+	for i, region := range regions {
+		configs[i] = newRegionConfig(staticPrefix, cloudProvider, region, kubefirstPath, flagInputs)
+		configs[i].CredentialProfile = profile
+	}
+	warnIfArchitectureUnsupported(configs[0].Architecture)
+
+	// Each region's files go in its own directory, so they can be generated in parallel. The workspace .gitignore
+	// they all affect is updated once afterwards.
+	results := make([]error, len(configs))
+	var wg sync.WaitGroup
+	for i, config := range configs {
+		wg.Add(1)
+		go func(i int, config *CloudConfig) {
+			defer wg.Done()
+			_, results[i] = writeConfigFiles(config, kubefirstPath)
+		}(i, config)
+	}
+	wg.Wait()
+	maintainWorkspaceGit()
+
+	summary := [][]string{{"Config", "Region", "Status"}}
+	var created []*CloudConfig
+	// Write all new index entries in a single pass
+	err = updateIndex(func(indexFile *IndexFile) error {
+		for i, config := range configs {
+			configName := cloudConfigKey(config)
+			if results[i] != nil {
+				log.Error("Error writing config files", "region", config.Region, "error", results[i])
+				summary = append(summary, []string{configName, config.Region, "Failed: " + results[i].Error()})
+				continue
+			}
+
+			err := addConfigToIndex(config, indexFile)
+			if err != nil {
+				log.Error("Error adding config to index", "region", config.Region, "error", err)
+				summary = append(summary, []string{configName, config.Region, "Failed to index"})
+				continue
+			}
+			summary = append(summary, []string{configName, config.Region, "Created"})
+			created = append(created, config)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("Error updating index file", "error", err)
+		return
+	}
+	for _, config := range created {
+		err = updateCloudsFile(config)
+		if err != nil {
+			log.Error("Error updating clouds file", "error", err)
+		}
+	}
+	for _, config := range created {
+		emitConfigCreated(config)
+	}
+
+	printSummaryTable("Multi-Region Config Summary", summary)
+	log.Info("createMultiRegionConfig function completed successfully", "regions", len(regions))
+}
+
+func newRegionConfig(staticPrefix, cloudProvider, region, kubefirstPath string, flagInputs []struct{ Name, Value string }) *CloudConfig {
+	config := NewCloudConfig()
+	config.StaticPrefix = staticPrefix
+	config.CloudPrefix = cloudProvider
+	config.Region = region
+
+	config.Flags.Store("KUBEFIRST_PATH", kubefirstPath)
+	config.Flags.Store("cloud-region", region)
+
+	for _, fi := range flagInputs {
+		value := strings.ReplaceAll(fi.Value, regionPlaceholder, region)
+
+		switch fi.Name {
+		case "node-type":
+			if nodeParts := strings.Fields(value); len(nodeParts) > 0 {
+				value = nodeParts[0]
+				config.SelectedNodeType = value
+				config.Architecture = detectArchitecture(value)
+			}
+		case "cluster-name":
+			value = regionClusterName(fi.Value, region)
+		}
+
+		config.Flags.Store(fi.Name, value)
+	}
+
+	return config
+}
+
+// regionClusterName keeps cluster names unique across regions when no placeholder was used
+func regionClusterName(name, region string) string {
+	if name == "" {
+		return name
+	}
+	if strings.Contains(name, regionPlaceholder) {
+		return strings.ReplaceAll(name, regionPlaceholder, region)
+	}
+	return fmt.Sprintf("%s-%s", name, region)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Cluster names end up in DNS labels and Kubernetes resource names, so they must be valid DNS labels
+var dnsLabelPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// The static prefix is used in environment variable names in the generated scripts
+var staticPrefixPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+const defaultClusterNameMaxLength = 63
+
+// Cluster name length limits imposed by each provider's API
+var clusterNameMaxLengths = map[string]int{
+	"Civo":         63,
+	"DigitalOcean": 63,
+	"Google":       40,
+}
+
+func (p *NamingPolicy) compile() error {
+	for name, pattern := range map[string]string{"cluster_name_pattern": p.ClusterNamePattern, "prefix_pattern": p.PrefixPattern} {
+		if pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (p *NamingPolicy) validateClusterName(cloudProvider, name string) error {
+	// Empty means kubefirst's default cluster name
+	if name == "" {
+		return nil
+	}
+
+	maxLength, ok := clusterNameMaxLengths[cloudProvider]
+	if !ok {
+		maxLength = defaultClusterNameMaxLength
+	}
+	if p.MaxLength > 0 && p.MaxLength < maxLength {
+		maxLength = p.MaxLength
+	}
+	if len(name) > maxLength {
+		return fmt.Errorf("cluster name must be at most %d characters (got %d)", maxLength, len(name))
+	}
+
+	if !dnsLabelPattern.MatchString(name) {
+		return fmt.Errorf("cluster name may only contain lowercase letters, digits and hyphens, and must start and end with a letter or digit")
+	}
+
+	if p.ClusterNamePattern != "" && !regexp.MustCompile(p.ClusterNamePattern).MatchString(name) {
+		return fmt.Errorf("cluster name must match the naming policy pattern %s", p.ClusterNamePattern)
+	}
+
+	if len(p.AllowedPrefixes) > 0 {
+		allowed := false
+		for _, prefix := range p.AllowedPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("cluster name must start with one of: %s", strings.Join(p.AllowedPrefixes, ", "))
+		}
+	}
+
+	return nil
+}
+
+func (p *NamingPolicy) validateStaticPrefix(prefix string) error {
+	// Empty means the default 'K1' prefix
+	if prefix == "" {
+		return nil
+	}
+
+	if !staticPrefixPattern.MatchString(prefix) {
+		return fmt.Errorf("prefix must start with a letter and contain only letters, digits and underscores")
+	}
+
+	if p.PrefixPattern != "" && !regexp.MustCompile(p.PrefixPattern).MatchString(prefix) {
+		return fmt.Errorf("prefix must match the naming policy pattern %s", p.PrefixPattern)
+	}
+
+	return nil
+}
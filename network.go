@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// How long a single API request may take unless http_timeout is set in settings.hcl
+const defaultHTTPTimeout = 30 * time.Second
+
+var errCancelled = errors.New("cancelled")
+
+// getHTTPTimeout returns http_timeout from settings.hcl (e.g. "45s" or "2m")
+func getHTTPTimeout() time.Duration {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings, using the default HTTP timeout", "error", err)
+		return defaultHTTPTimeout
+	}
+	if settings.HTTPTimeout == "" {
+		return defaultHTTPTimeout
+	}
+	timeout, err := time.ParseDuration(settings.HTTPTimeout)
+	if err != nil || timeout <= 0 {
+		log.Warn("Invalid http_timeout in settings.hcl, using the default", "http_timeout", settings.HTTPTimeout)
+		return defaultHTTPTimeout
+	}
+	return timeout
+}
+
+// newHTTPClient returns a client whose requests, including reading the response, give up after the HTTP timeout
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: getHTTPTimeout()}
+}
+
+// newDownloadClient only bounds the wait for the server to respond, since a large binary can take longer than
+// the HTTP timeout to transfer. Stalled transfers are cancelled through the request's context.
+func newDownloadClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = getHTTPTimeout()
+	return &http.Client{Transport: transport}
+}
+
+// abandonedCalls counts calls awaitContext gave up on that are still running
+var abandonedCalls atomic.Int64
+
+// awaitContext runs fn for SDKs whose calls take no context (civogo), giving up when ctx ends or the HTTP
+// timeout passes.
+//
+// Giving up doesn't stop fn. civogo's HTTP client is unexported and has no timeout, and civogo replaces its
+// transport on every request, so neither a context nor a client timeout can reach the request. fn's goroutine
+// and connection leak until the request returns. On a stalled connection that can be as long as k1space runs.
+// Only interactive commands call civogo, so the leak ends when the command exits; nothing long-running, like
+// the daemon or /metrics, does. fn must not touch state the caller reads after awaitContext returns.
+func awaitContext(ctx context.Context, fn func() error) error {
+	ctx, cancel := context.WithTimeout(ctx, getHTTPTimeout())
+	defer cancel()
+
+	// Whichever of fn finishing and awaitContext giving up happens first claims the call
+	const (
+		running = iota
+		finished
+		abandoned
+	)
+	var state atomic.Int32
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+		if !state.CompareAndSwap(running, finished) {
+			abandonedCalls.Add(-1)
+		}
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if !state.CompareAndSwap(running, abandoned) {
+			return <-done
+		}
+		log.Debug("Gave up waiting on an API call that can't be cancelled; it keeps running", "running", abandonedCalls.Add(1))
+		return ctx.Err()
+	}
+}
+
+// describeNetworkError turns timeouts and cancellations into a message naming what was interrupted
+func describeNetworkError(action string, err error) error {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errCancelled) || errors.Is(err, context.Canceled):
+		return fmt.Errorf("%s was %w", action, errCancelled)
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return fmt.Errorf("%s timed out after %s; raise http_timeout in settings.hcl if your connection is slow: %w", action, getHTTPTimeout(), err)
+	}
+	return err
+}
+
+// isConnectionError reports whether err means the request never got an answer, as opposed to an API error
+func isConnectionError(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || errors.Is(err, errCancelled) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// cancellableSpinner shows progress until the work finishes, cancelling its context on Esc or Ctrl+C
+type cancellableSpinner struct {
+	spinner    spinner.Model
+	message    string
+	cancel     context.CancelFunc
+	cancelling bool
+}
+
+type cancellableDoneMsg struct{}
+
+func (m cancellableSpinner) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m cancellableSpinner) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyEsc || msg.Type == tea.KeyCtrlC {
+			m.cancelling = true
+			m.cancel()
+		}
+	case cancellableDoneMsg:
+		return m, tea.Quit
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m cancellableSpinner) View() string {
+	hint := lipgloss.NewStyle().Faint(true).Render("(esc to cancel)")
+	if m.cancelling {
+		hint = lipgloss.NewStyle().Faint(true).Render("(cancelling...)")
+	}
+	return fmt.Sprintf("%s %s %s\n", m.spinner.View(), m.message, hint)
+}
+
+// runCancellable runs fn behind a spinner that Esc cancels. fn should pass ctx to its requests; the HTTP
+// timeout bounds each request, while Esc ends the whole operation.
+func runCancellable(message string, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Without a terminal there is nothing to press Esc in, so just run with the per-request timeouts
+	if isStructuredOutput() || !term.IsTerminal(int(os.Stdin.Fd())) {
+		s := startSpinner(message)
+		err := fn(ctx)
+		stopSpinner(s, err == nil)
+		return err
+	}
+
+	model := cancellableSpinner{spinner: spinner.New(spinner.WithSpinner(spinner.Dot)), message: message, cancel: cancel}
+	p := tea.NewProgram(model)
+	done := make(chan error, 1)
+	go func() {
+		err := fn(ctx)
+		done <- err
+		p.Send(cancellableDoneMsg{})
+	}()
+
+	_, runErr := p.Run()
+	if runErr != nil {
+		log.Warn("Error running spinner", "error", runErr)
+		cancel()
+	}
+	err := <-done
+	if err != nil && ctx.Err() != nil {
+		err = fmt.Errorf("%w: %w", errCancelled, err)
+	}
+
+	if err == nil {
+		color.Green("✓ " + message)
+	} else {
+		color.Red("✗ " + message)
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+)
+
+// NodeTopology is a config's node count and control plane layout, kept in a topology block in config.hcl
+// so summaries and cost estimates don't have to dig it out of the flags
+type NodeTopology struct {
+	NodeCount      int
+	HAControlPlane bool
+	// ControlPlaneNodes counts nodes that run the control plane themselves, e.g. K3s servers; managed
+	// Kubernetes providers leave it at 0
+	ControlPlaneNodes int
+}
+
+// kubefirstHAFlags are the exact create flag names that ask kubefirst for an HA control plane, checked in this
+// order against the selected binary's flags. Current kubefirst releases expose none, so the HA prompt only
+// appears for a binary that adds one; k1space doesn't ask for HA through terraform overrides kubefirst's
+// templates don't read.
+var kubefirstHAFlags = []string{"ha-control-plane", "ha"}
+
+var flagDefaultPattern = regexp.MustCompile(`\(default "?(\d+)"?\)`)
+
+// describe formats the topology for summaries, e.g. "3 nodes, HA control plane"
+func (t *NodeTopology) describe() string {
+	if t == nil || t.NodeCount <= 0 {
+		return ""
+	}
+	parts := []string{fmt.Sprintf("%d nodes", t.NodeCount)}
+	if t.ControlPlaneNodes > 0 {
+		parts = append(parts, fmt.Sprintf("%d control plane", t.ControlPlaneNodes))
+	}
+	if t.HAControlPlane {
+		parts = append(parts, "HA control plane")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// findHAFlag returns the kubefirst flag that asks for an HA control plane, if the binary exposes one
+func findHAFlag(flags map[string]string) string {
+	for _, flag := range kubefirstHAFlags {
+		if _, ok := flags[flag]; ok {
+			return flag
+		}
+	}
+	return ""
+}
+
+// isHAFlagVar reports whether a stored env var holds one of kubefirstHAFlags, e.g. K1_CIVO_NYC1_HA_CONTROL_PLANE
+func isHAFlagVar(name string) bool {
+	for _, flag := range kubefirstHAFlags {
+		if strings.HasSuffix(name, "_"+strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// topologyFromFlags reads node-count and the HA control plane flag back from a config's stored flags, for
+// configs created before the topology block existed
+func topologyFromFlags(flags map[string]string) *NodeTopology {
+	var topology NodeTopology
+	for name, value := range flags {
+		switch {
+		case strings.HasSuffix(name, "_NODE_COUNT"):
+			topology.NodeCount, _ = strconv.Atoi(value)
+		case !strings.HasPrefix(name, "TF_VAR_") && isHAFlagVar(name):
+			topology.HAControlPlane = value == "true"
+		}
+	}
+	if topology.NodeCount <= 0 {
+		return nil
+	}
+	return &topology
+}
+
+// k3sTopology counts the nodes in a K3s inventory; embedded etcd needs three servers to survive losing one
+func k3sTopology(inventory *K3sInventory) *NodeTopology {
+	servers := len(inventory.Servers)
+	return &NodeTopology{
+		NodeCount:         servers + len(inventory.Agents),
+		ControlPlaneNodes: servers,
+		HAControlPlane:    servers >= 3,
+	}
+}
+
+// promptNodeTopology asks for the node count and, where the provider sells one, an HA control plane. It records
+// both on the config and returns the kubefirst flags it set, so the caller can leave them out of the flag form.
+func promptNodeTopology(config *CloudConfig, flags map[string]string, previous *NodeTopology) ([]string, error) {
+	countDescription, hasCount := flags["node-count"]
+	haFlag := findHAFlag(flags)
+	if !hasCount && haFlag == "" {
+		return nil, nil
+	}
+
+	nodeCount := "3"
+	if match := flagDefaultPattern.FindStringSubmatch(countDescription); match != nil {
+		nodeCount = match[1]
+	}
+	useHA := false
+	if previous != nil {
+		if previous.NodeCount > 0 {
+			nodeCount = strconv.Itoa(previous.NodeCount)
+		}
+		useHA = previous.HAControlPlane
+	}
+
+	var fields []huh.Field
+	if hasCount {
+		fields = append(fields, newInput("Number of worker nodes", &nodeCount).
+			Description(countDescription).
+			Validate(func(s string) error {
+				count, err := strconv.Atoi(strings.TrimSpace(s))
+				if err != nil || count < 1 {
+					return fmt.Errorf("enter a whole number of at least 1")
+				}
+				return nil
+			}))
+	}
+	if haFlag != "" {
+		fields = append(fields, newConfirm("Run a highly available control plane?", &useHA).
+			Description(flags[haFlag]))
+	}
+	err := runForm(newForm(newGroup(fields...)))
+	if err != nil {
+		return nil, err
+	}
+
+	var handled []string
+	topology := &NodeTopology{HAControlPlane: useHA && haFlag != ""}
+	if hasCount {
+		topology.NodeCount, _ = strconv.Atoi(strings.TrimSpace(nodeCount))
+		config.Flags.Store("node-count", strconv.Itoa(topology.NodeCount))
+		handled = append(handled, "node-count")
+	}
+	if haFlag != "" {
+		config.Flags.Store(haFlag, strconv.FormatBool(useHA))
+		handled = append(handled, haFlag)
+	}
+	config.Topology = topology
+	return handled, nil
+}
+
+// recordNodeTopology fills in the topology block of configs written before it existed
+func recordNodeTopology(indexFile *IndexFile) error {
+	for name, config := range indexFile.Configs {
+		if config.Topology == nil {
+			config.Topology = topologyFromFlags(config.Flags)
+			indexFile.Configs[name] = config
+		}
+	}
+	return nil
+}
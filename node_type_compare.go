@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+// The cheapest node types shown per provider, so one provider's long catalog doesn't crowd out the others
+const compareRowsPerProvider = 5
+
+// nodeTypeRequirements is the smallest node a comparison looks for
+type nodeTypeRequirements struct {
+	MinCPU    int
+	MinRAMGB  int
+	MinDiskGB int
+	// Architecture is "amd64", "arm64" or "" for either
+	Architecture string
+	GPUOnly      bool
+	NodeCount    int
+}
+
+func (r nodeTypeRequirements) matches(nodeType InstanceSizeInfo) bool {
+	if nodeType.CPUCores < r.MinCPU || nodeType.RAMMegabytes < r.MinRAMGB*1024 || nodeType.DiskGigabytes < r.MinDiskGB {
+		return false
+	}
+	if r.GPUOnly && !nodeType.HasGPU() {
+		return false
+	}
+	switch r.Architecture {
+	case "arm64":
+		return nodeType.IsARM()
+	case "amd64":
+		return !nodeType.IsARM()
+	}
+	return true
+}
+
+func (r nodeTypeRequirements) describe() string {
+	description := fmt.Sprintf("%d+ vCPU, %d+ GB RAM", r.MinCPU, r.MinRAMGB)
+	if r.MinDiskGB > 0 {
+		description += fmt.Sprintf(", %d+ GB disk", r.MinDiskGB)
+	}
+	if r.Architecture != "" {
+		description += ", " + r.Architecture
+	}
+	if r.GPUOnly {
+		description += ", GPU"
+	}
+	return description
+}
+
+// nodeTypeCandidate is one provider's node type in a comparison
+type nodeTypeCandidate struct {
+	Provider string
+	NodeType InstanceSizeInfo
+}
+
+// compareNodeTypes finds the node types of each provider that meet req, cheapest first. Node types without a
+// price sort after the priced ones, smallest first.
+func compareNodeTypes(cloudsFile CloudsFile, providers []string, req nodeTypeRequirements) []nodeTypeCandidate {
+	var candidates []nodeTypeCandidate
+	for _, provider := range providers {
+		var matching []nodeTypeCandidate
+		for _, nodeType := range cloudsFile.CloudNodeTypes[provider] {
+			if req.matches(nodeType) {
+				matching = append(matching, nodeTypeCandidate{Provider: provider, NodeType: nodeType})
+			}
+		}
+		sortNodeTypeCandidates(matching)
+		if len(matching) > compareRowsPerProvider {
+			matching = matching[:compareRowsPerProvider]
+		}
+		candidates = append(candidates, matching...)
+	}
+	sortNodeTypeCandidates(candidates)
+	return candidates
+}
+
+func sortNodeTypeCandidates(candidates []nodeTypeCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i].NodeType, candidates[j].NodeType
+		if (a.PriceMonthly > 0) != (b.PriceMonthly > 0) {
+			return a.PriceMonthly > 0
+		}
+		if a.PriceMonthly != b.PriceMonthly {
+			return a.PriceMonthly < b.PriceMonthly
+		}
+		if a.CPUCores != b.CPUCores {
+			return a.CPUCores < b.CPUCores
+		}
+		return a.RAMMegabytes < b.RAMMegabytes
+	})
+}
+
+func formatRAM(megabytes int) string {
+	if megabytes%1024 == 0 {
+		return fmt.Sprintf("%d GB", megabytes/1024)
+	}
+	return fmt.Sprintf("%.1f GB", float64(megabytes)/1024)
+}
+
+// printNodeTypeComparison shows the candidates side by side, with each one's monthly cost against the cheapest
+func printNodeTypeComparison(candidates []nodeTypeCandidate, cloudsFile CloudsFile, req nodeTypeRequirements) {
+	cheapest := 0.0
+	if len(candidates) > 0 {
+		cheapest = candidates[0].NodeType.PriceMonthly
+	}
+
+	rows := [][]string{{"Provider", "Node Type", "vCPU", "RAM", "Disk", "Arch", "Per Node", "Cluster", "vs Cheapest", "Regions"}}
+	for _, candidate := range candidates {
+		nodeType := candidate.NodeType
+		arch := "amd64"
+		if nodeType.IsARM() {
+			arch = "arm64"
+		}
+		if nodeType.HasGPU() {
+			arch += " " + formatGPU(nodeType)
+		}
+		perNode, cluster, difference := "unknown", "unknown", ""
+		if nodeType.PriceMonthly > 0 {
+			perNode = fmt.Sprintf("$%.2f/mo", nodeType.PriceMonthly)
+			cluster = fmt.Sprintf("$%.2f/mo", nodeType.PriceMonthly*float64(req.NodeCount))
+			if nodeType.PriceMonthly == cheapest {
+				difference = "cheapest"
+			} else {
+				difference = fmt.Sprintf("+$%.2f/mo", (nodeType.PriceMonthly-cheapest)*float64(req.NodeCount))
+			}
+		}
+		rows = append(rows, []string{
+			candidate.Provider,
+			nodeType.Name,
+			strconv.Itoa(nodeType.CPUCores),
+			formatRAM(nodeType.RAMMegabytes),
+			fmt.Sprintf("%d GB", nodeType.DiskGigabytes),
+			arch,
+			perNode,
+			cluster,
+			difference,
+			strconv.Itoa(len(cloudsFile.CloudRegions[candidate.Provider])),
+		})
+	}
+	printSummaryTable(fmt.Sprintf("Node Types with %s, %d nodes", req.describe(), req.NodeCount), rows)
+}
+
+func promptNodeTypeRequirements(req *nodeTypeRequirements) error {
+	minCPU, minRAM, minDisk, nodeCount := strconv.Itoa(req.MinCPU), strconv.Itoa(req.MinRAMGB), strconv.Itoa(req.MinDiskGB), strconv.Itoa(req.NodeCount)
+	validateCount := func(min int) func(string) error {
+		return func(s string) error {
+			n, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil || n < min {
+				return fmt.Errorf("enter a whole number of at least %d", min)
+			}
+			return nil
+		}
+	}
+
+	err := runForm(newForm(
+		newGroup(
+			newInput("Minimum vCPUs", &minCPU).Validate(validateCount(1)),
+			newInput("Minimum RAM (GB)", &minRAM).Validate(validateCount(0)),
+			newInput("Minimum disk (GB)", &minDisk).Description("0 for any").Validate(validateCount(0)),
+			newInput("Number of worker nodes", &nodeCount).Description("Used for the cluster's monthly cost").Validate(validateCount(1)),
+			newSelect("Architecture", &req.Architecture,
+				huh.NewOption("Either", ""),
+				huh.NewOption("amd64", "amd64"),
+				huh.NewOption("arm64", "arm64"),
+			),
+			newConfirm("Only GPU node types?", &req.GPUOnly),
+		),
+	))
+	if err != nil {
+		return err
+	}
+	req.MinCPU, _ = strconv.Atoi(strings.TrimSpace(minCPU))
+	req.MinRAMGB, _ = strconv.Atoi(strings.TrimSpace(minRAM))
+	req.MinDiskGB, _ = strconv.Atoi(strings.TrimSpace(minDisk))
+	req.NodeCount, _ = strconv.Atoi(strings.TrimSpace(nodeCount))
+	return nil
+}
+
+// compareNodeTypesMenu is 'Config' -> 'Compare Node Types'. It compares the node types cached in clouds.hcl
+// across providers for a given size, and starts a config with the one picked.
+func compareNodeTypesMenu() {
+	cloudsFile, err := loadCloudsFile()
+	if err != nil {
+		log.Error("Error loading clouds file", "error", err)
+		fmt.Println("Failed to load clouds.hcl:", err)
+		return
+	}
+
+	var providers, notes []string
+	for _, provider := range cloudDataProviders {
+		age, cached := cloudDataAge(cloudsFile, provider)
+		switch {
+		case !cached:
+			notes = append(notes, fmt.Sprintf("%s was never fetched", provider))
+		case age >= getCloudDataTTL():
+			notes = append(notes, fmt.Sprintf("%s was fetched %s", provider, formatCloudDataAge(age)))
+			providers = append(providers, provider)
+		default:
+			providers = append(providers, provider)
+		}
+	}
+	if len(providers) == 0 {
+		fmt.Println("No node types cached yet. Fetch them with 'Config' -> 'Refresh Cloud Data'.")
+		return
+	}
+
+	req := nodeTypeRequirements{MinCPU: 2, MinRAMGB: 4, NodeCount: 3}
+	for {
+		err = promptNodeTypeRequirements(&req)
+		if err != nil {
+			log.Error("Error in node type requirements", "error", err)
+			return
+		}
+
+		candidates := compareNodeTypes(cloudsFile, providers, req)
+		if len(candidates) == 0 {
+			fmt.Printf("No node types with %s.\n", req.describe())
+		} else {
+			printNodeTypeComparison(candidates, cloudsFile, req)
+		}
+		if len(notes) > 0 {
+			fmt.Printf("\n%s; run 'Config' -> 'Refresh Cloud Data' for current node types and prices.\n", strings.Join(notes, ", "))
+		}
+		if isStructuredOutput() {
+			return
+		}
+
+		options := make([]huh.Option[int], 0, len(candidates)+2)
+		for i, candidate := range candidates {
+			label := fmt.Sprintf("Create a %s config with %s", candidate.Provider, candidate.NodeType.Name)
+			if price := formatNodePrice(candidate.NodeType); price != "" {
+				label += " " + price
+			}
+			options = append(options, huh.NewOption(label, i))
+		}
+		options = append(options, huh.NewOption("Change requirements", -1), huh.NewOption("Back", -2))
+
+		selected := -2
+		err = runField(newSelect("Pick a node type to create a config with", &selected, options...))
+		if err != nil {
+			log.Error("Error selecting node type", "error", err)
+			return
+		}
+		switch {
+		case selected == -2:
+			return
+		case selected >= 0:
+			candidate := candidates[selected]
+			createConfig(&CloudConfig{CloudPrefix: candidate.Provider, SelectedNodeType: candidate.NodeType.Name})
+			return
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// NotificationSettings are the channels k1space posts messages to, from the notifications block of settings.hcl
+type NotificationSettings struct {
+	SlackWebhook   string `hcl:"slack_webhook,optional"`
+	DiscordWebhook string `hcl:"discord_webhook,optional"`
+}
+
+// notificationChannel is a configured destination for messages
+type notificationChannel struct {
+	Name string
+	URL  string
+	// payload wraps the message in the JSON body the service expects
+	payload func(message string) map[string]string
+}
+
+// configuredChannels returns the channels set in settings.hcl; an empty list means notifications are off
+func configuredChannels(settings *NotificationSettings) []notificationChannel {
+	if settings == nil {
+		return nil
+	}
+	var channels []notificationChannel
+	if settings.SlackWebhook != "" {
+		channels = append(channels, notificationChannel{
+			Name:    "Slack",
+			URL:     settings.SlackWebhook,
+			payload: func(message string) map[string]string { return map[string]string{"text": message} },
+		})
+	}
+	if settings.DiscordWebhook != "" {
+		channels = append(channels, notificationChannel{
+			Name:    "Discord",
+			URL:     settings.DiscordWebhook,
+			payload: func(message string) map[string]string { return map[string]string{"content": message} },
+		})
+	}
+	return channels
+}
+
+func (c notificationChannel) send(ctx context.Context, message string) error {
+	body, err := json.Marshal(c.payload(message))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := newHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	_, err = readResponseBody(resp)
+	return err
+}
+
+// sendNotification posts message to every configured channel, returning an error naming the channels it
+// couldn't reach
+func sendNotification(ctx context.Context, message string) error {
+	settings, err := loadSettings()
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, channel := range configuredChannels(settings.Notifications) {
+		if err := channel.send(ctx, message); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", channel.Name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("error sending notification to %v", failed)
+	}
+	return nil
+}
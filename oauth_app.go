@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+const (
+	// How long to wait for the browser side of GitHub's app manifest flow
+	oauthBootstrapTimeout = 5 * time.Minute
+	defaultGitLabURL      = "https://gitlab.com"
+)
+
+// oauthCredentials are what the console needs to log users in through a git provider
+type oauthCredentials struct {
+	ClientID     string
+	ClientSecret string
+	// Where the app can be managed later
+	SettingsURL string
+}
+
+// Auto-submits the app manifest to GitHub, which only accepts it as a form POST from the browser
+var githubManifestPage = template.Must(template.New("manifest").Parse(`<!DOCTYPE html>
+<html><body onload="document.forms[0].submit()">
+<form action="{{.Action}}" method="post">
+<input type="hidden" name="manifest" value="{{.Manifest}}">
+<p>Redirecting to GitHub to create the k1space console app...</p>
+<noscript><button type="submit">Continue to GitHub</button></noscript>
+</form>
+</body></html>`))
+
+// consoleLocalURL is where the console dev server listens, over HTTPS once 'Setup Local TLS' has run
+func consoleLocalURL() string {
+	if _, _, ok := localCertPaths(); ok {
+		return "https://localhost:3000"
+	}
+	return "http://localhost:3000"
+}
+
+func bootstrapOAuthApp() {
+	log.Info("Starting bootstrapOAuthApp function")
+
+	var provider string
+	err := runField(newSelect("Select the git provider to log in to the local console with", &provider,
+		huh.NewOption("GitHub", "github"),
+		huh.NewOption("GitLab", "gitlab"),
+	))
+	if err != nil {
+		log.Error("Error in provider selection", "error", err)
+		return
+	}
+
+	// Check the secret backend first, so an app isn't created with nowhere to keep its secret
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		fmt.Println("Failed to load settings:", err)
+		return
+	}
+	secrets, err := getSecretProvider(settings)
+	if err == nil {
+		err = secrets.Check()
+	}
+	if err != nil {
+		log.Error("Secret backend unavailable", "error", err)
+		fmt.Println("The OAuth client secret needs a secret backend:", err)
+		return
+	}
+
+	callbackURL := fmt.Sprintf("%s/api/auth/callback/%s", consoleLocalURL(), provider)
+
+	var creds oauthCredentials
+	switch provider {
+	case "github":
+		creds, err = createGitHubOAuthApp(callbackURL)
+	case "gitlab":
+		creds, err = createGitLabOAuthApp(callbackURL)
+	}
+	if err != nil {
+		log.Error("Error creating OAuth app", "provider", provider, "error", err)
+		fmt.Println("Failed to create the OAuth app:", err)
+		return
+	}
+
+	prefix := strings.ToUpper(provider)
+	// The .env only holds a reference; 'Run Kubefirst Repositories' resolves it when it starts the console
+	secretRef, err := secrets.Store("CONSOLE_"+prefix+"_CLIENT_SECRET", creds.ClientSecret)
+	if err != nil {
+		log.Error("Error storing OAuth client secret", "backend", secrets.Name(), "error", err)
+		fmt.Printf("The OAuth app was created but its client secret could not be stored in %s: %v\n", secrets.Name(), err)
+		fmt.Printf("Generate a new client secret on %s and store it yourself.\n", creds.SettingsURL)
+		return
+	}
+
+	repoDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".repositories")
+	envPath := filepath.Join(repoDir, "console", ".env")
+	err = setDotEnvValues(envPath, filepath.Join(repoDir, "console", ".env.example"), map[string]string{
+		prefix + "_CLIENT_ID":     creds.ClientID,
+		prefix + "_CLIENT_SECRET": secretRef,
+		"NEXTAUTH_URL":            consoleLocalURL(),
+	})
+	if err != nil {
+		log.Error("Error writing console env file", "path", envPath, "error", err)
+		fmt.Println("The OAuth app was created but the console .env could not be updated:", err)
+		fmt.Printf("Set %s_CLIENT_ID=%s and %s_CLIENT_SECRET=%s manually\n", prefix, creds.ClientID, prefix, secretRef)
+		return
+	}
+
+	printSummaryTable("Console OAuth App", [][]string{
+		{"Setting", "Value"},
+		{"Provider", provider},
+		{"Client ID", creds.ClientID},
+		{"Client Secret", secretRef},
+		{"Callback URL", callbackURL},
+		{"Env File", envPath},
+		{"Manage At", creds.SettingsURL},
+	})
+}
+
+// createGitHubOAuthApp registers a GitHub App through the app manifest flow: the user confirms once in the browser,
+// and GitHub hands back the app's OAuth client credentials
+func createGitHubOAuthApp(callbackURL string) (oauthCredentials, error) {
+	var org string
+	err := runField(newInput("GitHub organization to own the app (leave empty for your personal account)", &org))
+	if err != nil {
+		return oauthCredentials{}, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return oauthCredentials{}, fmt.Errorf("error starting local callback server: %w", err)
+	}
+	baseURL := "http://" + listener.Addr().String()
+	state, err := randomState()
+	if err != nil {
+		listener.Close()
+		return oauthCredentials{}, err
+	}
+
+	manifest, err := json.Marshal(map[string]interface{}{
+		"name":            "k1space-console-" + state[:8],
+		"url":             consoleLocalURL(),
+		"redirect_url":    baseURL + "/callback",
+		"callback_urls":   []string{callbackURL},
+		"public":          false,
+		"hook_attributes": map[string]interface{}{"url": consoleLocalURL(), "active": false},
+		"default_permissions": map[string]string{
+			"email_addresses": "read",
+			"members":         "read",
+		},
+	})
+	if err != nil {
+		listener.Close()
+		return oauthCredentials{}, err
+	}
+
+	action := "https://github.com/settings/apps/new"
+	if org != "" {
+		action = fmt.Sprintf("https://github.com/organizations/%s/settings/apps/new", url.PathEscape(org))
+	}
+	action += "?state=" + state
+
+	codes := make(chan string, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		githubManifestPage.Execute(w, map[string]string{"Action": action, "Manifest": string(manifest)})
+	})
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("state") != state {
+			http.Error(w, "State mismatch, please retry from k1space.", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintln(w, "The app was created. You can close this tab and return to k1space.")
+		select {
+		case codes <- r.URL.Query().Get("code"):
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Shutdown(context.Background())
+
+	fmt.Printf("Opening %s to create the GitHub app. Open it manually if no browser appears.\n", baseURL)
+	openBrowser(baseURL)
+
+	var code string
+	select {
+	case code = <-codes:
+	case <-time.After(oauthBootstrapTimeout):
+		return oauthCredentials{}, fmt.Errorf("timed out waiting for GitHub to redirect back")
+	}
+
+	s := startSpinner("Fetching the app's client credentials...")
+	resp, err := newHTTPClient().Post(fmt.Sprintf("https://api.github.com/app-manifests/%s/conversions", url.PathEscape(code)), "application/json", nil)
+	var body []byte
+	if err == nil {
+		body, err = readResponseBody(resp)
+	}
+	stopSpinner(s, err == nil)
+	if err != nil {
+		return oauthCredentials{}, fmt.Errorf("error converting app manifest: %w", err)
+	}
+
+	var app struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		HTMLURL      string `json:"html_url"`
+	}
+	err = json.Unmarshal(body, &app)
+	if err != nil {
+		return oauthCredentials{}, fmt.Errorf("error parsing GitHub app: %w", err)
+	}
+	return oauthCredentials{ClientID: app.ClientID, ClientSecret: app.ClientSecret, SettingsURL: app.HTMLURL}, nil
+}
+
+// createGitLabOAuthApp registers an instance-wide application, which needs GITLAB_TOKEN to belong to an admin
+func createGitLabOAuthApp(callbackURL string) (oauthCredentials, error) {
+	token := lookupToken("GITLAB_TOKEN")
+	if token == "" {
+		return oauthCredentials{}, fmt.Errorf("GITLAB_TOKEN not found in environment or keychain. Please set it and try again")
+	}
+
+	gitlabURL := os.Getenv("GITLAB_URL")
+	if gitlabURL == "" {
+		gitlabURL = defaultGitLabURL
+	}
+	gitlabURL = strings.TrimSuffix(gitlabURL, "/")
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"name":         "k1space-console",
+		"redirect_uri": callbackURL,
+		"scopes":       "read_user openid profile email",
+		"confidential": true,
+	})
+	if err != nil {
+		return oauthCredentials{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, gitlabURL+"/api/v4/applications", bytes.NewReader(payload))
+	if err != nil {
+		return oauthCredentials{}, err
+	}
+	req.Header.Set("PRIVATE-TOKEN", token)
+	req.Header.Set("Content-Type", "application/json")
+
+	s := startSpinner("Creating the GitLab application...")
+	resp, err := newHTTPClient().Do(req)
+	var body []byte
+	if err == nil {
+		body, err = readResponseBody(resp)
+	}
+	stopSpinner(s, err == nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "403") {
+			return oauthCredentials{}, fmt.Errorf("%w\nCreating applications through the API needs an admin token; on gitlab.com create one under User Settings > Applications with callback %s", err, callbackURL)
+		}
+		return oauthCredentials{}, err
+	}
+
+	var app struct {
+		ApplicationID string `json:"application_id"`
+		Secret        string `json:"secret"`
+	}
+	err = json.Unmarshal(body, &app)
+	if err != nil {
+		return oauthCredentials{}, fmt.Errorf("error parsing GitLab application: %w", err)
+	}
+	return oauthCredentials{ClientID: app.ApplicationID, ClientSecret: app.Secret, SettingsURL: gitlabURL + "/admin/applications"}, nil
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating state: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func openBrowser(target string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	if err := cmd.Start(); err != nil {
+		log.Warn("Could not open browser", "url", target, "error", err)
+	}
+}
+
+// consoleSecretEnv resolves the secret references in the console's .env, e.g. the OAuth client secret, and returns
+// them as environment entries. Next.js prefers the process environment over .env, so the console sees the values
+// while the file keeps only references.
+func consoleSecretEnv(consoleDir string) []string {
+	content, err := os.ReadFile(filepath.Join(consoleDir, ".env"))
+	if err != nil {
+		return nil
+	}
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		return nil
+	}
+
+	var env []string
+	for _, line := range strings.Split(string(content), "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(kv) != 2 || strings.HasPrefix(kv[0], "#") {
+			continue
+		}
+		name, value := strings.TrimPrefix(kv[0], "export "), strings.Trim(kv[1], "\"")
+		provider, ok := secretProviderForRef(value, settings)
+		if !ok {
+			continue
+		}
+		value, err = provider.Resolve(value)
+		if err != nil {
+			log.Error("Error resolving console secret", "name", name, "backend", provider.Name(), "error", err)
+			continue
+		}
+		env = append(env, name+"="+value)
+	}
+	return env
+}
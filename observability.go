@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+// The Grafana chart (alone or through kube-prometheus-stack) labels its admin secret and ingress with this
+const grafanaSelector = "app.kubernetes.io/name=grafana"
+
+const maskedPassword = "********"
+
+// observabilityEndpoints is what k1space could find of a cluster's Grafana and Prometheus
+type observabilityEndpoints struct {
+	GrafanaNamespace string
+	GrafanaService   string
+	GrafanaURL       string
+	Username         string
+	Password         string
+	PrometheusURL    string
+}
+
+type kubernetesSecretList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		// Secret data is base64 in JSON, which encoding/json decodes into []byte
+		Data map[string][]byte `json:"data"`
+	} `json:"items"`
+}
+
+type kubernetesIngressList struct {
+	Items []struct {
+		Spec struct {
+			TLS []struct {
+				Hosts []string `json:"hosts"`
+			} `json:"tls"`
+			Rules []struct {
+				Host string `json:"host"`
+			} `json:"rules"`
+		} `json:"spec"`
+	} `json:"items"`
+}
+
+// kubectlJSON runs a kubectl get against the cluster and decodes its JSON output
+func kubectlJSON(ctx context.Context, kubeconfig, kubeContext string, out interface{}, args ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, getHTTPTimeout())
+	defer cancel()
+
+	args = append([]string{"--kubeconfig", kubeconfig}, args...)
+	if kubeContext != "" {
+		args = append(args, "--context", kubeContext)
+	}
+	args = append(args, "-o", "json")
+	output, err := exec.CommandContext(ctx, "kubectl", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return fmt.Errorf("kubectl %s: %s", strings.Join(args[2:], " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return err
+	}
+	return json.Unmarshal(output, out)
+}
+
+// ingressURL returns the first ingress host starting with prefix, e.g. grafana.example.com for "grafana"
+func ingressURL(ingresses kubernetesIngressList, prefix string) string {
+	for _, ingress := range ingresses.Items {
+		for _, rule := range ingress.Spec.Rules {
+			if strings.HasPrefix(rule.Host, prefix+".") || strings.HasPrefix(rule.Host, prefix+"-") {
+				scheme := "http"
+				for _, tls := range ingress.Spec.TLS {
+					if contains(tls.Hosts, rule.Host) {
+						scheme = "https"
+					}
+				}
+				return scheme + "://" + rule.Host
+			}
+		}
+	}
+	return ""
+}
+
+// findObservabilityEndpoints reads the Grafana admin secret and the Grafana and Prometheus ingresses
+func findObservabilityEndpoints(ctx context.Context, kubeconfig, kubeContext string) (observabilityEndpoints, error) {
+	var endpoints observabilityEndpoints
+
+	var secrets kubernetesSecretList
+	err := kubectlJSON(ctx, kubeconfig, kubeContext, &secrets, "get", "secrets", "--all-namespaces", "-l", grafanaSelector)
+	if err != nil {
+		return endpoints, err
+	}
+	for _, secret := range secrets.Items {
+		if password, ok := secret.Data["admin-password"]; ok {
+			endpoints.GrafanaNamespace = secret.Metadata.Namespace
+			// The chart names the secret after its service
+			endpoints.GrafanaService = secret.Metadata.Name
+			endpoints.Username = string(secret.Data["admin-user"])
+			endpoints.Password = string(password)
+			break
+		}
+	}
+	if endpoints.Password == "" {
+		return endpoints, nil
+	}
+
+	var ingresses kubernetesIngressList
+	err = kubectlJSON(ctx, kubeconfig, kubeContext, &ingresses, "get", "ingresses", "--all-namespaces")
+	if err != nil {
+		// Credentials are still useful through a port-forward
+		log.Warn("Could not list ingresses", "error", err)
+		return endpoints, nil
+	}
+	endpoints.GrafanaURL = ingressURL(ingresses, "grafana")
+	endpoints.PrometheusURL = ingressURL(ingresses, "prometheus")
+	return endpoints, nil
+}
+
+func openGrafana() {
+	log.Info("Starting openGrafana function")
+
+	clusterName, kubeconfig, kubeContext, ok := selectClusterKubeconfig("Select the cluster to open Grafana for")
+	if !ok {
+		return
+	}
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		fmt.Println("kubectl is not installed or not in PATH. It's needed to read Grafana's credentials from the cluster.")
+		return
+	}
+
+	var endpoints observabilityEndpoints
+	err := runCancellable(fmt.Sprintf("Reading Grafana credentials from %s...", clusterName), func(ctx context.Context) (err error) {
+		endpoints, err = findObservabilityEndpoints(ctx, kubeconfig, kubeContext)
+		return err
+	})
+	if err != nil {
+		err = describeNetworkError("Reading Grafana credentials", err)
+		log.Error("Error reading Grafana credentials", "cluster", clusterName, "error", err)
+		fmt.Println(err)
+		printSavedBookmarks(clusterName)
+		return
+	}
+	if endpoints.Password == "" {
+		fmt.Printf("No Grafana admin secret found on %s. Install the observability stack (e.g. kube-prometheus-stack from the kubefirst catalog) and try again.\n", clusterName)
+		return
+	}
+
+	bookmarks := []clusterBookmark{{Cluster: clusterName, Name: "Grafana", URL: endpoints.GrafanaURL, Username: endpoints.Username}}
+	if endpoints.PrometheusURL != "" {
+		bookmarks = append(bookmarks, clusterBookmark{Cluster: clusterName, Name: "Prometheus", URL: endpoints.PrometheusURL})
+	}
+	for _, bookmark := range bookmarks {
+		if bookmark.URL == "" {
+			continue
+		}
+		if err := saveBookmark(bookmark); err != nil {
+			log.Warn("Could not save bookmark", "name", bookmark.Name, "error", err)
+		}
+	}
+
+	grafanaURL := endpoints.GrafanaURL
+	if grafanaURL == "" {
+		grafanaURL = "(no ingress)"
+	}
+	summary := [][]string{
+		{"Service", "URL", "Username", "Password"},
+		{"Grafana", grafanaURL, endpoints.Username, maskedPassword},
+	}
+	if endpoints.PrometheusURL != "" {
+		summary = append(summary, []string{"Prometheus", endpoints.PrometheusURL, "", ""})
+	}
+	printSummaryTable(fmt.Sprintf("Observability for %s", clusterName), summary)
+	if isStructuredOutput() {
+		return
+	}
+	if endpoints.GrafanaURL == "" {
+		fmt.Printf("Grafana has no ingress. Reach it with: kubectl --kubeconfig %s -n %s port-forward svc/%s 3000:80\n", kubeconfig, endpoints.GrafanaNamespace, endpoints.GrafanaService)
+		fmt.Println("Then open http://localhost:3000")
+	}
+
+	for {
+		options := []huh.Option[string]{}
+		if endpoints.GrafanaURL != "" {
+			options = append(options, huh.NewOption("Open Grafana in browser", "Open Grafana"))
+		}
+		if endpoints.PrometheusURL != "" {
+			options = append(options, huh.NewOption("Open Prometheus in browser", "Open Prometheus"))
+		}
+		options = append(options,
+			huh.NewOption("Show Grafana password", "Show Password"),
+			huh.NewOption("Done", "Done"),
+		)
+
+		var action string
+		err = runField(newSelect("Grafana and Prometheus", &action, options...))
+		if err != nil {
+			log.Error("Error in observability action selection", "error", err)
+			return
+		}
+
+		switch action {
+		case "Open Grafana":
+			openBrowser(endpoints.GrafanaURL)
+		case "Open Prometheus":
+			openBrowser(endpoints.PrometheusURL)
+		case "Show Password":
+			fmt.Printf("Grafana admin password for %s: %s\n", endpoints.Username, endpoints.Password)
+		case "Done":
+			return
+		}
+	}
+}
+
+// printSavedBookmarks lists a cluster's bookmarks when the cluster itself can't be reached
+func printSavedBookmarks(clusterName string) {
+	bookmarks, err := loadBookmarks()
+	if err != nil {
+		log.Warn("Could not load bookmarks", "error", err)
+		return
+	}
+	saved := clusterBookmarks(bookmarks, clusterName)
+	if len(saved) == 0 {
+		return
+	}
+	summary := [][]string{{"Service", "URL", "Username", "Saved"}}
+	for _, bookmark := range saved {
+		summary = append(summary, []string{bookmark.Name, bookmark.URL, bookmark.Username, bookmark.SavedAt.Local().Format("2006-01-02 15:04")})
+	}
+	printSummaryTable(fmt.Sprintf("Saved bookmarks for %s", clusterName), summary)
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	defaultOnePasswordVault = "Private"
+	// Field holding the secret on API Credential items
+	onePasswordField = "credential"
+)
+
+// Environment variables holding each cloud's API token; Google uses a key file and isn't stored in 1Password
+var cloudTokenVars = map[string]string{
+	"Akamai":       "LINODE_TOKEN",
+	"Civo":         "CIVO_TOKEN",
+	"DigitalOcean": "DO_TOKEN",
+	"Vultr":        "VULTR_API_KEY",
+}
+
+func cloudTokenVar(cloud string) string {
+	for provider, tokenVar := range cloudTokenVars {
+		if strings.EqualFold(provider, cloud) {
+			return tokenVar
+		}
+	}
+	return ""
+}
+
+// onePasswordItemTitle names the item a token is stored in, shared by every config using that token
+func onePasswordItemTitle(tokenVar string) string {
+	return "k1space-" + strings.ToLower(strings.ReplaceAll(tokenVar, "_", "-"))
+}
+
+func onePasswordRef(vault, tokenVar string) string {
+	return fmt.Sprintf("op://%s/%s/%s", vault, onePasswordItemTitle(tokenVar), onePasswordField)
+}
+
+// getOnePasswordVault returns onepassword_vault from settings.hcl, or "" when 1Password hasn't been set up
+func getOnePasswordVault() string {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings", "error", err)
+		return ""
+	}
+	return settings.OnePasswordVault
+}
+
+// checkOnePasswordCLI makes sure op is installed and can reach an account, which 00-init.sh's `op run` needs
+func checkOnePasswordCLI() error {
+	if _, err := exec.LookPath("op"); err != nil {
+		return fmt.Errorf("the 1Password CLI (op) could not be found; install it from https://developer.1password.com/docs/cli/get-started")
+	}
+	output, err := exec.Command("op", "whoami").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("the 1Password CLI is not signed in (run 'op signin' or enable the desktop app integration): %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func checkOnePasswordVault(vault string) error {
+	output, err := exec.Command("op", "vault", "get", vault).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("vault %q is not accessible: %s", vault, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func readOnePasswordSecret(ref string) (string, error) {
+	output, err := exec.Command("op", "read", "--no-newline", ref).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s does not resolve: %s", ref, commandStderr(err))
+	}
+	return string(output), nil
+}
+
+func onePasswordItemExists(vault, title string) bool {
+	return exec.Command("op", "item", "get", title, "--vault", vault).Run() == nil
+}
+
+// createOnePasswordItem stores a token as an API Credential item; the item JSON goes through stdin so the
+// secret never shows up in the process list
+func createOnePasswordItem(vault, title, secret string) error {
+	item, err := json.Marshal(map[string]interface{}{
+		"title":    title,
+		"category": "API_CREDENTIAL",
+		"fields": []map[string]string{
+			{"id": onePasswordField, "label": onePasswordField, "type": "CONCEALED", "value": secret},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("op", "item", "create", "--vault", vault, "-")
+	cmd.Stdin = bytes.NewReader(item)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error creating item %s: %w\nOutput: %s", title, err, string(output))
+	}
+	return nil
+}
+
+// loadTokenFromOnePassword sets a missing token in k1space's own environment from the configured vault
+func loadTokenFromOnePassword(tokenVar string) error {
+	vault := getOnePasswordVault()
+	if vault == "" {
+		return fmt.Errorf("no 1Password vault configured")
+	}
+	if _, err := exec.LookPath("op"); err != nil {
+		return err
+	}
+	secret, err := readOnePasswordSecret(onePasswordRef(vault, tokenVar))
+	if err != nil {
+		return err
+	}
+	log.Info("Loaded token from 1Password", "token", tokenVar, "vault", vault)
+	return os.Setenv(tokenVar, secret)
+}
+
+// configTokenVars lists the tokens kubefirst needs for a config: the cloud's, from the config's credential
+// profile, and the git provider's
+func configTokenVars(cloud string, config Config) []string {
+	var tokenVars []string
+	if tokenVar := cloudTokenVar(cloud); tokenVar != "" {
+		tokenVars = append(tokenVars, profileTokenVar(tokenVar, configCredentialProfile(config)))
+	}
+	switch strings.ToLower(findConfigFlag(config.Flags, "git-provider")) {
+	case "github":
+		tokenVars = append(tokenVars, "GITHUB_TOKEN")
+	case "gitlab":
+		tokenVars = append(tokenVars, "GITLAB_TOKEN")
+	}
+	return tokenVars
+}
+
+// envFileOnePasswordRefs returns the variables in an env file whose values are op:// references
+func envFileOnePasswordRefs(envPath string) (map[string]string, error) {
+	content, err := os.ReadFile(envPath)
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string]string)
+	for _, line := range strings.Split(string(content), "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value := strings.Trim(kv[1], "\"")
+		if strings.HasPrefix(value, "op://") {
+			refs[strings.TrimPrefix(kv[0], "export ")] = value
+		}
+	}
+	return refs, nil
+}
+
+// setEnvFileExport sets `export name="value"` in a .local.cloud.env, replacing an existing line for name
+func setEnvFileExport(envPath, name, value string) error {
+	content, err := os.ReadFile(envPath)
+	if err != nil {
+		return err
+	}
+
+	line := fmt.Sprintf("export %s=\"%s\"", name, value)
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	replaced := false
+	for i, existing := range lines {
+		if strings.HasPrefix(strings.TrimSpace(existing), "export "+name+"=") {
+			lines[i] = line
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, line)
+	}
+	return os.WriteFile(envPath, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// validateOnePasswordRefs checks that every op:// reference in the env file resolves, grouped by vault
+// so an inaccessible vault is reported once
+func validateOnePasswordRefs(envPath string) []string {
+	refs, err := envFileOnePasswordRefs(envPath)
+	if err != nil {
+		return []string{fmt.Sprintf("error reading %s: %v", envPath, err)}
+	}
+
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	badVaults := make(map[string]bool)
+	checkedVaults := make(map[string]bool)
+	for _, name := range names {
+		ref := refs[name]
+		vault := strings.SplitN(strings.TrimPrefix(ref, "op://"), "/", 2)[0]
+		if !checkedVaults[vault] {
+			checkedVaults[vault] = true
+			if err := checkOnePasswordVault(vault); err != nil {
+				badVaults[vault] = true
+				problems = append(problems, err.Error())
+			}
+		}
+		if badVaults[vault] {
+			continue
+		}
+		if _, err := readOnePasswordSecret(ref); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	return problems
+}
+
+// confirmOnePasswordReady runs before provisioning: a missing or signed-out op stops 00-init.sh outright,
+// while unresolved references only fail once kubefirst needs them, so those ask whether to continue
+func confirmOnePasswordReady(envPath string) bool {
+	s := startSpinner("Checking 1Password CLI and secret references...")
+	err := checkOnePasswordCLI()
+	var problems []string
+	if err == nil {
+		problems = validateOnePasswordRefs(envPath)
+	}
+	stopSpinner(s, err == nil && len(problems) == 0)
+
+	if err != nil {
+		log.Error("1Password CLI unavailable", "error", err)
+		fmt.Println(style.Render("❌ 00-init.sh runs kubefirst through 'op run'"))
+		fmt.Println(err)
+		return false
+	}
+	if len(problems) == 0 {
+		return true
+	}
+
+	fmt.Println(style.Render("⚠️  Some 1Password references in .local.cloud.env don't resolve"))
+	for _, problem := range problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+	fmt.Println("Fix them with 'Config' -> 'Manage 1Password Secrets'.")
+
+	var proceed bool
+	err = runField(newConfirm("Continue provisioning anyway?", &proceed))
+	if err != nil {
+		log.Error("Error in confirmation prompt", "error", err)
+		return false
+	}
+	return proceed
+}
+
+func manageOnePasswordSecrets() {
+	log.Info("Starting manageOnePasswordSecrets function")
+
+	err := checkOnePasswordCLI()
+	if err != nil {
+		log.Error("1Password CLI unavailable", "error", err)
+		fmt.Println(err)
+		return
+	}
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations:", err)
+		return
+	}
+	selectedConfig, err := promptConfigSelection(indexFile, "Select a configuration to store secrets for")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations available. Please create a configuration first.")
+		return
+	}
+	parts := strings.Split(selectedConfig, "_")
+	if len(parts) != 3 {
+		fmt.Println("Error: Invalid configuration name format.")
+		return
+	}
+	cloud, region, prefix := parts[0], parts[1], parts[2]
+	envPath := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", cloud, region, prefix, ".local.cloud.env")
+
+	vault := getOnePasswordVault()
+	if vault == "" {
+		vault = defaultOnePasswordVault
+	}
+	err = runField(newInput("1Password vault to keep k1space tokens in", &vault))
+	if err != nil {
+		log.Error("Error in vault input", "error", err)
+		return
+	}
+	err = checkOnePasswordVault(vault)
+	if err != nil {
+		log.Error("Error checking vault", "vault", vault, "error", err)
+		fmt.Println(err)
+		return
+	}
+	err = saveSetting("onepassword_vault", cty.StringVal(vault))
+	if err != nil {
+		log.Warn("Error saving 1Password vault", "error", err)
+	}
+
+	summary := [][]string{{"Variable", "Reference", "Status"}}
+	for _, tokenVar := range configTokenVars(cloud, indexFile.Configs[selectedConfig]) {
+		title := onePasswordItemTitle(tokenVar)
+		ref := onePasswordRef(vault, tokenVar)
+
+		status := "Existing item"
+		if !onePasswordItemExists(vault, title) {
+			secret := os.Getenv(tokenVar)
+			if secret == "" {
+				err = runField(newPasswordInput(fmt.Sprintf("Enter %s to store in 1Password", tokenVar), &secret))
+				if err != nil {
+					log.Error("Error in token input", "token", tokenVar, "error", err)
+					return
+				}
+			}
+			if secret == "" {
+				summary = append(summary, []string{tokenVar, ref, "Skipped"})
+				continue
+			}
+			err = createOnePasswordItem(vault, title, secret)
+			if err != nil {
+				log.Error("Error creating 1Password item", "item", title, "error", err)
+				summary = append(summary, []string{tokenVar, ref, "Failed to create item"})
+				continue
+			}
+			status = "Created item"
+		}
+
+		err = setEnvFileExport(envPath, tokenVar, ref)
+		if err != nil {
+			log.Error("Error updating env file", "path", envPath, "error", err)
+			summary = append(summary, []string{tokenVar, ref, "Failed to update .local.cloud.env"})
+			continue
+		}
+		summary = append(summary, []string{tokenVar, ref, status})
+	}
+	printSummaryTable("1Password Secrets for "+selectedConfig, summary)
+
+	problems := validateOnePasswordRefs(envPath)
+	if len(problems) == 0 {
+		fmt.Println("\nAll 1Password references in .local.cloud.env resolve.")
+		return
+	}
+	fmt.Println("\nUnresolved 1Password references:")
+	for _, problem := range problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+}
@@ -10,22 +10,51 @@ import (
 	"github.com/fatih/color"
 )
 
+// How many lines a scrollingLog keeps unless log_buffer_lines in settings.hcl says otherwise
 const maxLogLines = 100
 
+// scrollingLog is the in-memory buffer behind a dashboard pane
 type scrollingLog struct {
 	lines []string
 	mu    sync.Mutex
+	// name is the pane's key in log_buffer_lines, and max its buffer size (maxLogLines when 0)
+	name string
+	max  int
+	// total counts every line ever added, so a paused pane can find where it stopped as the buffer trims
+	total    int
+	paused   bool
+	pausedAt int
+	// lastAdded is when the newest line arrived, so a watchdog can tell a silent run from a busy one
+	lastAdded time.Time
+}
+
+// newScrollingLog returns the buffer for a named pane, sized from settings.hcl
+func newScrollingLog(name string) *scrollingLog {
+	return &scrollingLog{name: name, max: getLogBufferLines(name)}
 }
 
 func (sl *scrollingLog) add(line string) {
 	sl.mu.Lock()
 	defer sl.mu.Unlock()
 	sl.lines = append(sl.lines, line)
-	if len(sl.lines) > maxLogLines {
-		sl.lines = sl.lines[len(sl.lines)-maxLogLines:]
+	sl.total++
+	sl.lastAdded = time.Now()
+	max := sl.max
+	if max <= 0 {
+		max = maxLogLines
+	}
+	if len(sl.lines) > max {
+		sl.lines = sl.lines[len(sl.lines)-max:]
 	}
 }
 
+// lastActivity returns when the newest line was added, zero before the first one
+func (sl *scrollingLog) lastActivity() time.Time {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	return sl.lastAdded
+}
+
 func (sl *scrollingLog) getLastN(n int) []string {
 	sl.mu.Lock()
 	defer sl.mu.Unlock()
@@ -35,6 +64,40 @@ func (sl *scrollingLog) getLastN(n int) []string {
 	return sl.lines[len(sl.lines)-n:]
 }
 
+// view returns the last n lines a pane should show: the newest while following, or the ones up to where it was paused
+func (sl *scrollingLog) view(n int) []string {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	end := len(sl.lines)
+	if sl.paused {
+		end -= sl.total - sl.pausedAt
+		if end < 0 {
+			end = 0
+		}
+	}
+	start := end - n
+	if start < 0 {
+		start = 0
+	}
+	return sl.lines[start:end]
+}
+
+// toggleFollow stops or resumes auto-scrolling and reports whether the pane now follows new lines
+func (sl *scrollingLog) toggleFollow() bool {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.paused = !sl.paused
+	sl.pausedAt = sl.total
+	return !sl.paused
+}
+
+// following reports whether the pane is auto-scrolling, and if not how many lines arrived since it was paused
+func (sl *scrollingLog) following() (bool, int) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	return !sl.paused, sl.total - sl.pausedAt
+}
+
 func (sl *scrollingLog) get() string {
 	sl.mu.Lock()
 	defer sl.mu.Unlock()
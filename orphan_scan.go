@@ -0,0 +1,485 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+	"github.com/civo/civogo"
+	"github.com/digitalocean/godo"
+)
+
+// orphanedResource is a cloud resource left behind by a deprovisioned cluster
+type orphanedResource struct {
+	Kind   string
+	ID     string
+	Name   string
+	Detail string
+	// Domain the record belongs to, only set for DNS records
+	DomainID string
+}
+
+// namedAfterCluster matches resources kubefirst's terraform names after the cluster, like its network. Names
+// are compared whole, as another cluster's name can start or end with this one's.
+func namedAfterCluster(name, clusterName string) bool {
+	return strings.EqualFold(name, clusterName)
+}
+
+// taggedWithCluster matches DigitalOcean's k8s:<cluster ID> tag on the load balancers and volumes a cluster
+// creates, or a tag that is exactly the cluster's name
+func taggedWithCluster(tags []string, clusterName, clusterID string) bool {
+	for _, tag := range tags {
+		if (clusterID != "" && tag == "k8s:"+clusterID) || strings.EqualFold(tag, clusterName) {
+			return true
+		}
+	}
+	return false
+}
+
+// dnsRecordLabel prints a record the way it appears in the zone, e.g. argocd.example.com -> 212.2.240.10
+func dnsRecordLabel(name, domain, recordType, value string) string {
+	host := domain
+	if name != "" && name != "@" {
+		host = name + "." + domain
+	}
+	return fmt.Sprintf("%s %s -> %s", recordType, host, value)
+}
+
+// orphanedDNSRecord reports records pointing at a leftover load balancer. Record names say nothing about the
+// cluster: kubefirst names them after its apps, e.g. argocd.
+func orphanedDNSRecord(value string, lbIPs []string) bool {
+	return contains(lbIPs, value)
+}
+
+func scanCivoOrphans(ctx context.Context, region, clusterName, clusterID, domain string) ([]orphanedResource, error) {
+	client, err := civoRegionClient(region)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []orphanedResource
+	var lbIPs []string
+
+	var loadBalancers []civogo.LoadBalancer
+	err = awaitContext(ctx, func() (err error) {
+		loadBalancers, err = client.ListLoadBalancers()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing load balancers: %w", err)
+	}
+	// The cloud controller names load balancers <cluster>-<namespace>-<service>, which another cluster's can start
+	// with too, so they're matched by the cluster ID they carry
+	for _, lb := range loadBalancers {
+		if clusterID == "" || lb.ClusterID != clusterID {
+			continue
+		}
+		orphans = append(orphans, orphanedResource{Kind: "Load balancer", ID: lb.ID, Name: lb.Name, Detail: lb.PublicIP})
+		if lb.PublicIP != "" {
+			lbIPs = append(lbIPs, lb.PublicIP)
+		}
+	}
+
+	// CSI volumes are named pvc-<uid>, so they're only recognisable by their cluster ID
+	var volumes []civogo.Volume
+	err = awaitContext(ctx, func() (err error) {
+		volumes, err = client.ListVolumes()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing volumes: %w", err)
+	}
+	for _, volume := range volumes {
+		if namedAfterCluster(volume.Name, clusterName) || (clusterID != "" && volume.ClusterID == clusterID) {
+			orphans = append(orphans, orphanedResource{Kind: "Volume", ID: volume.ID, Name: volume.Name, Detail: fmt.Sprintf("%d GB, %s", volume.SizeGigabytes, volume.Status)})
+		}
+	}
+
+	var networks []civogo.Network
+	err = awaitContext(ctx, func() (err error) {
+		networks, err = client.ListNetworks()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing networks: %w", err)
+	}
+	for _, network := range networks {
+		if !network.Default && (namedAfterCluster(network.Label, clusterName) || namedAfterCluster(network.Name, clusterName)) {
+			orphans = append(orphans, orphanedResource{Kind: "Network", ID: network.ID, Name: network.Label, Detail: network.CIDR})
+		}
+	}
+
+	if domain == "" {
+		return orphans, nil
+	}
+	var domains []civogo.DNSDomain
+	err = awaitContext(ctx, func() (err error) {
+		domains, err = client.ListDNSDomains()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing DNS domains: %w", err)
+	}
+	for _, dnsDomain := range domains {
+		if !strings.EqualFold(dnsDomain.Name, domain) {
+			continue
+		}
+		var records []civogo.DNSRecord
+		err = awaitContext(ctx, func() (err error) {
+			records, err = client.ListDNSRecords(dnsDomain.ID)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing DNS records of %s: %w", domain, err)
+		}
+		for _, record := range records {
+			if orphanedDNSRecord(record.Value, lbIPs) {
+				orphans = append(orphans, orphanedResource{
+					Kind:     "DNS record",
+					ID:       record.ID,
+					Name:     record.Name,
+					Detail:   dnsRecordLabel(record.Name, domain, string(record.Type), record.Value),
+					DomainID: dnsDomain.ID,
+				})
+			}
+		}
+	}
+	return orphans, nil
+}
+
+func scanDigitalOceanOrphans(ctx context.Context, region, clusterName, clusterID, domain string) ([]orphanedResource, error) {
+	client, err := getDigitalOceanClient()
+	if err != nil {
+		return nil, err
+	}
+	listOptions := &godo.ListOptions{Page: 1, PerPage: 200}
+
+	var orphans []orphanedResource
+	var lbIPs []string
+
+	loadBalancers, _, err := client.LoadBalancers.List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error listing load balancers: %w", err)
+	}
+	for _, lb := range loadBalancers {
+		if lb.Region != nil && lb.Region.Slug != region {
+			continue
+		}
+		// The cloud controller names load balancers after a hash of the service, so only the tag identifies them
+		if taggedWithCluster(lb.Tags, clusterName, clusterID) {
+			orphans = append(orphans, orphanedResource{Kind: "Load balancer", ID: lb.ID, Name: lb.Name, Detail: lb.IP})
+			if lb.IP != "" {
+				lbIPs = append(lbIPs, lb.IP)
+			}
+		}
+	}
+
+	volumes, _, err := client.Storage.ListVolumes(ctx, &godo.ListVolumeParams{Region: region, ListOptions: listOptions})
+	if err != nil {
+		return nil, fmt.Errorf("error listing volumes: %w", err)
+	}
+	for _, volume := range volumes {
+		if namedAfterCluster(volume.Name, clusterName) || taggedWithCluster(volume.Tags, clusterName, clusterID) {
+			detail := fmt.Sprintf("%d GB", volume.SizeGigaBytes)
+			if len(volume.DropletIDs) > 0 {
+				detail += ", attached"
+			}
+			orphans = append(orphans, orphanedResource{Kind: "Volume", ID: volume.ID, Name: volume.Name, Detail: detail})
+		}
+	}
+
+	vpcs, _, err := client.VPCs.List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error listing VPCs: %w", err)
+	}
+	for _, vpc := range vpcs {
+		if !vpc.Default && vpc.RegionSlug == region && namedAfterCluster(vpc.Name, clusterName) {
+			orphans = append(orphans, orphanedResource{Kind: "Network", ID: vpc.ID, Name: vpc.Name, Detail: vpc.IPRange})
+		}
+	}
+
+	if domain == "" {
+		return orphans, nil
+	}
+	records, resp, err := client.Domains.Records(ctx, domain, listOptions)
+	if resp != nil && resp.StatusCode == 404 {
+		// The domain isn't hosted on DigitalOcean, so there are no records to leave behind
+		return orphans, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error listing DNS records of %s: %w", domain, err)
+	}
+	for _, record := range records {
+		if orphanedDNSRecord(record.Data, lbIPs) {
+			orphans = append(orphans, orphanedResource{
+				Kind:     "DNS record",
+				ID:       fmt.Sprint(record.ID),
+				Name:     record.Name,
+				Detail:   dnsRecordLabel(record.Name, domain, record.Type, record.Data),
+				DomainID: domain,
+			})
+		}
+	}
+	return orphans, nil
+}
+
+func scanOrphanedResources(ctx context.Context, cloud, region, clusterName, clusterID, domain string) ([]orphanedResource, error) {
+	switch strings.ToLower(cloud) {
+	case "civo":
+		return scanCivoOrphans(ctx, region, clusterName, clusterID, domain)
+	case "digitalocean":
+		return scanDigitalOceanOrphans(ctx, region, clusterName, clusterID, domain)
+	}
+	return nil, fmt.Errorf("orphaned resource scans are not supported for %s", cloud)
+}
+
+// findClusterID looks up the ID of the cluster named exactly clusterName in a region, or "" if there's none
+func findClusterID(ctx context.Context, cloud, region, clusterName string) (string, error) {
+	switch strings.ToLower(cloud) {
+	case "civo":
+		client, err := civoRegionClient(region)
+		if err != nil {
+			return "", err
+		}
+		var page *civogo.PaginatedKubernetesClusters
+		err = awaitContext(ctx, func() (err error) {
+			page, err = client.ListKubernetesClusters()
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+		for _, cluster := range page.Items {
+			if cluster.Name == clusterName {
+				return cluster.ID, nil
+			}
+		}
+	case "digitalocean":
+		client, err := getDigitalOceanClient()
+		if err != nil {
+			return "", err
+		}
+		clusters, _, err := client.Kubernetes.List(ctx, &godo.ListOptions{Page: 1, PerPage: 200})
+		if err != nil {
+			return "", err
+		}
+		for _, cluster := range clusters {
+			if cluster.Name == clusterName && cluster.RegionSlug == region {
+				return cluster.ID, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// recordClusterID saves the ID of a config's cluster in config.hcl while the cluster exists, as it's what
+// identifies the load balancers and volumes the cluster leaves behind once it's deprovisioned. It returns config
+// with the ID set.
+func recordClusterID(configName string, config Config) Config {
+	parts := strings.Split(configName, "_")
+	if len(parts) != 3 || !contains([]string{"civo", "digitalocean"}, parts[0]) {
+		return config
+	}
+	clusterName := findConfigFlag(config.Flags, "cluster-name")
+	if clusterName == "" {
+		return config
+	}
+	useCredentialProfile(parts[0], configCredentialProfile(config))
+
+	ctx, cancel := context.WithTimeout(context.Background(), getHTTPTimeout())
+	defer cancel()
+	clusterID, err := findClusterID(ctx, parts[0], parts[1], clusterName)
+	if err != nil {
+		log.Warn("Could not look up the cluster's ID", "config", configName, "error", err)
+		return config
+	}
+	if clusterID == "" || clusterID == config.ClusterID {
+		return config
+	}
+	err = updateIndex(func(indexFile *IndexFile) error {
+		latest, ok := indexFile.Configs[configName]
+		if !ok {
+			return fmt.Errorf("config %s not found", configName)
+		}
+		latest.ClusterID = clusterID
+		indexFile.Configs[configName] = latest
+		return nil
+	})
+	if err != nil {
+		log.Warn("Could not record the cluster's ID", "config", configName, "error", err)
+	}
+	config.ClusterID = clusterID
+	return config
+}
+
+func deleteOrphanedResource(ctx context.Context, cloud, region string, orphan orphanedResource) error {
+	switch strings.ToLower(cloud) {
+	case "civo":
+		client, err := civoRegionClient(region)
+		if err != nil {
+			return err
+		}
+		return awaitContext(ctx, func() error {
+			var err error
+			switch orphan.Kind {
+			case "Load balancer":
+				_, err = client.DeleteLoadBalancer(orphan.ID)
+			case "Volume":
+				_, err = client.DeleteVolume(orphan.ID)
+			case "Network":
+				_, err = client.DeleteNetwork(orphan.ID)
+			case "DNS record":
+				_, err = client.DeleteDNSRecord(&civogo.DNSRecord{ID: orphan.ID, DNSDomainID: orphan.DomainID})
+			}
+			return err
+		})
+	case "digitalocean":
+		client, err := getDigitalOceanClient()
+		if err != nil {
+			return err
+		}
+		switch orphan.Kind {
+		case "Load balancer":
+			_, err = client.LoadBalancers.Delete(ctx, orphan.ID)
+		case "Volume":
+			_, err = client.Storage.DeleteVolume(ctx, orphan.ID)
+		case "Network":
+			_, err = client.VPCs.Delete(ctx, orphan.ID)
+		case "DNS record":
+			var recordID int
+			_, err = fmt.Sscan(orphan.ID, &recordID)
+			if err == nil {
+				_, err = client.Domains.DeleteRecord(ctx, orphan.DomainID, recordID)
+			}
+		}
+		return err
+	}
+	return fmt.Errorf("orphaned resource scans are not supported for %s", cloud)
+}
+
+// orphanDeleteOrder removes what depends on a network before the network itself
+var orphanDeleteOrder = map[string]int{"DNS record": 0, "Load balancer": 1, "Volume": 2, "Network": 3}
+
+// scanForOrphanedResources lists what a deprovisioned cluster left behind on Civo or DigitalOcean
+// and offers to delete it
+func scanForOrphanedResources(configName string, config Config) {
+	parts := strings.Split(configName, "_")
+	if len(parts) != 3 {
+		log.Error("Invalid config name format", "config", configName)
+		return
+	}
+	cloud, region := parts[0], parts[1]
+	if !contains([]string{"civo", "digitalocean"}, strings.ToLower(cloud)) {
+		return
+	}
+	clusterName := findConfigFlag(config.Flags, "cluster-name")
+	if clusterName == "" {
+		fmt.Println("The config has no cluster-name, so its leftover cloud resources can't be identified.")
+		return
+	}
+	domain := findConfigFlag(config.Flags, "domain-name")
+	useCredentialProfile(cloud, configCredentialProfile(config))
+
+	var orphans []orphanedResource
+	err := runCancellable(fmt.Sprintf("Looking for resources %s left behind on %s...", clusterName, cloudProviderName(cloud)), func(ctx context.Context) (err error) {
+		orphans, err = scanOrphanedResources(ctx, cloud, region, clusterName, config.ClusterID, domain)
+		return err
+	})
+	if err != nil {
+		err = describeNetworkError("Scanning for orphaned resources", err)
+		log.Error("Error scanning for orphaned resources", "config", configName, "error", err)
+		fmt.Println(err)
+		return
+	}
+	if config.ClusterID == "" {
+		fmt.Printf("%s's cluster ID wasn't recorded, so only resources named exactly %s can be matched; its load balancers and volumes can't.\n", configName, clusterName)
+	}
+	if len(orphans) == 0 {
+		fmt.Printf("No resources left behind by %s were found.\n", clusterName)
+		return
+	}
+	sort.SliceStable(orphans, func(i, j int) bool {
+		return orphanDeleteOrder[orphans[i].Kind] < orphanDeleteOrder[orphans[j].Kind]
+	})
+
+	summary := [][]string{{"Kind", "Name", "ID", "Details"}}
+	options := make([]huh.Option[int], len(orphans))
+	for i, orphan := range orphans {
+		summary = append(summary, []string{orphan.Kind, orphan.Name, orphan.ID, orphan.Detail})
+		options[i] = huh.NewOption(fmt.Sprintf("%s: %s (%s)", orphan.Kind, orphan.Name, orphan.Detail), i)
+	}
+	printSummaryTable(fmt.Sprintf("Resources Left Behind by %s", clusterName), summary)
+	if isStructuredOutput() {
+		return
+	}
+
+	var selected []int
+	err = runField(newMultiSelect("Select resources to delete", &selected, options...).
+		Description("Resources are matched by cluster ID, tag or exact name, so check each one isn't used by something else"))
+	if err != nil {
+		log.Error("Error in orphaned resource selection", "error", err)
+		return
+	}
+	if len(selected) == 0 {
+		fmt.Println("No resources deleted.")
+		return
+	}
+
+	var confirm bool
+	err = runField(newConfirm(fmt.Sprintf("Delete %d resources from %s? This can't be undone.", len(selected), cloudProviderName(cloud)), &confirm))
+	if err != nil || !confirm {
+		fmt.Println("Deletion cancelled.")
+		return
+	}
+
+	sort.Ints(selected)
+	results := [][]string{{"Kind", "Name", "Result"}}
+	err = runCancellable(fmt.Sprintf("Deleting %d resources...", len(selected)), func(ctx context.Context) error {
+		for _, i := range selected {
+			orphan := orphans[i]
+			result := "Deleted"
+			if err := deleteOrphanedResource(ctx, cloud, region, orphan); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				log.Error("Error deleting orphaned resource", "kind", orphan.Kind, "id", orphan.ID, "error", err)
+				result = "Failed: " + err.Error()
+			}
+			results = append(results, []string{orphan.Kind, orphan.Name, result})
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println(describeNetworkError("Deleting orphaned resources", err))
+	}
+	printSummaryTable("Orphaned Resource Cleanup", results)
+}
+
+func scanOrphanedResourcesForConfig() {
+	log.Info("Starting scanOrphanedResourcesForConfig function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations:", err)
+		return
+	}
+	selectedConfig, err := promptConfigSelection(indexFile, "Select a deprovisioned config to scan for leftover resources")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations available. Please create a configuration first.")
+		return
+	}
+	cloud := strings.Split(selectedConfig, "_")[0]
+	if !contains([]string{"civo", "digitalocean"}, strings.ToLower(cloud)) {
+		fmt.Println("Orphaned resource scans are only available for Civo and DigitalOcean configs.")
+		return
+	}
+	scanForOrphanedResources(selectedConfig, indexFile.Configs[selectedConfig])
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// getOutputFormat returns the output format from K1SPACE_OUTPUT, falling back to output_format in settings.hcl
+func getOutputFormat() string {
+	format := os.Getenv("K1SPACE_OUTPUT")
+	if format == "" {
+		settings, err := loadSettings()
+		if err != nil {
+			log.Warn("Error loading settings, using text output", "error", err)
+			return outputText
+		}
+		format = settings.OutputFormat
+	}
+
+	switch format = strings.ToLower(format); format {
+	case outputJSON, outputYAML:
+		return format
+	default:
+		return outputText
+	}
+}
+
+func isStructuredOutput() bool {
+	return getOutputFormat() != outputText
+}
+
+func writeStructured(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case outputYAML:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// printStructured writes v to stdout in the configured machine-readable format
+func printStructured(v interface{}) {
+	err := writeStructured(os.Stdout, getOutputFormat(), v)
+	if err != nil {
+		log.Error("Error writing structured output", "error", err)
+	}
+}
+
+// tableToRecords turns a summary table (header row first) into one record per row keyed by snake_case column name
+func tableToRecords(table [][]string) []map[string]string {
+	if len(table) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(table[0]))
+	for i, header := range table[0] {
+		keys[i] = strings.ReplaceAll(strings.ToLower(header), " ", "_")
+	}
+
+	records := make([]map[string]string, 0, len(table)-1)
+	for _, row := range table[1:] {
+		record := make(map[string]string, len(row))
+		for i, cell := range row {
+			if i < len(keys) {
+				record[keys[i]] = cell
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+const (
+	policyDeny = "deny"
+	policyWarn = "warn"
+)
+
+// Policy is a policy block in policies.hcl: a rule configs must pass before they're provisioned. Applies,
+// Condition and Message are HCL expressions over the config variable.
+type Policy struct {
+	Name        string         `hcl:"name,label"`
+	Description string         `hcl:"description,optional"`
+	Applies     hcl.Expression `hcl:"applies,optional"`
+	Condition   hcl.Expression `hcl:"condition"`
+	Message     hcl.Expression `hcl:"message,optional"`
+	// Enforcement is "deny" (the default), which blocks provisioning, or "warn"
+	Enforcement string `hcl:"enforcement,optional"`
+}
+
+type policyFile struct {
+	Policies []Policy `hcl:"policy,block"`
+}
+
+// policyResult is the outcome of one policy for one config
+type policyResult struct {
+	Policy      string `json:"policy" yaml:"policy"`
+	Passed      bool   `json:"passed" yaml:"passed"`
+	Enforcement string `json:"enforcement" yaml:"enforcement"`
+	Message     string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// Functions available in policy expressions
+var policyFunctions = map[string]function.Function{
+	"can":        tryfunc.CanFunc,
+	"try":        tryfunc.TryFunc,
+	"contains":   stdlib.ContainsFunc,
+	"length":     stdlib.LengthFunc,
+	"lookup":     stdlib.LookupFunc,
+	"lower":      stdlib.LowerFunc,
+	"upper":      stdlib.UpperFunc,
+	"regex":      stdlib.RegexFunc,
+	"regexall":   stdlib.RegexAllFunc,
+	"split":      stdlib.SplitFunc,
+	"startswith": stringPredicate(strings.HasPrefix),
+	"endswith":   stringPredicate(strings.HasSuffix),
+}
+
+func stringPredicate(fn func(s, affix string) bool) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "str", Type: cty.String},
+			{Name: "affix", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.Bool),
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			return cty.BoolVal(fn(args[0].AsString(), args[1].AsString())), nil
+		},
+	})
+}
+
+// policyFilePath returns policy_file from settings.hcl, so an organization can point every workspace at a
+// shared checkout, or policies.hcl in the workspace
+func policyFilePath() string {
+	settings, err := loadSettings()
+	if err == nil && settings.PolicyFile != "" {
+		return settings.PolicyFile
+	}
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "policies.hcl")
+}
+
+// loadPolicies reads the policy file; no file means no policies
+func loadPolicies() ([]Policy, error) {
+	path := policyFilePath()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	file, diags := hclsyntax.ParseConfig(data, path, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error parsing %s: %s", path, diags)
+	}
+	var policies policyFile
+	diags = gohcl.DecodeBody(file.Body, nil, &policies)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error decoding %s: %s", path, diags)
+	}
+	for _, policy := range policies.Policies {
+		if policy.Enforcement != "" && policy.Enforcement != policyDeny && policy.Enforcement != policyWarn {
+			return nil, fmt.Errorf("policy %q: enforcement must be %q or %q", policy.Name, policyDeny, policyWarn)
+		}
+	}
+	return policies.Policies, nil
+}
+
+// policyConfigValue is the config variable policies are evaluated against
+func policyConfigValue(configName string, config Config) cty.Value {
+	flags := storedConfigFlags(configName, config)
+	flagValues := make(map[string]cty.Value, len(flags))
+	for name, value := range flags {
+		flagValues[name] = cty.StringVal(value)
+	}
+	flagsValue := cty.MapValEmpty(cty.String)
+	if len(flagValues) > 0 {
+		flagsValue = cty.MapVal(flagValues)
+	}
+
+	topology := config.Topology
+	if topology == nil {
+		topology = topologyFromFlags(config.Flags)
+	}
+	nodeCount, haControlPlane := 0, false
+	if topology != nil {
+		nodeCount, haControlPlane = topology.NodeCount, topology.HAControlPlane
+	} else if count, err := strconv.Atoi(flags["node-count"]); err == nil {
+		nodeCount = count
+	}
+
+	var cloud, region, prefix string
+	if parts := strings.Split(configName, "_"); len(parts) == 3 {
+		cloud, region, prefix = parts[0], parts[1], parts[2]
+	}
+	return cty.ObjectVal(map[string]cty.Value{
+		"name":               cty.StringVal(configName),
+		"cloud":              cty.StringVal(cloud),
+		"region":             cty.StringVal(region),
+		"prefix":             cty.StringVal(prefix),
+		"cluster_name":       cty.StringVal(flags["cluster-name"]),
+		"domain_name":        cty.StringVal(flags["domain-name"]),
+		"node_type":          cty.StringVal(flags["node-type"]),
+		"node_count":         cty.NumberIntVal(int64(nodeCount)),
+		"ha_control_plane":   cty.BoolVal(haControlPlane),
+		"credential_profile": cty.StringVal(configCredentialProfile(config)),
+		"flags":              flagsValue,
+	})
+}
+
+// evaluateBool evaluates a policy expression that must be true or false. A missing optional expression is null,
+// which counts as fallback.
+func evaluateBool(expr hcl.Expression, ctx *hcl.EvalContext, fallback bool) (bool, error) {
+	value, diags := expr.Value(ctx)
+	if diags.HasErrors() {
+		return false, fmt.Errorf("%s", diags)
+	}
+	if value.IsNull() {
+		return fallback, nil
+	}
+	if value.Type() != cty.Bool || !value.IsKnown() {
+		return false, fmt.Errorf("expression must be true or false, got %s", value.Type().FriendlyName())
+	}
+	return value.True(), nil
+}
+
+// evaluatePolicies checks a config against every policy that applies to it. Policies that can't be evaluated
+// fail, so a broken rule never lets a config through.
+func evaluatePolicies(configName string, config Config) ([]policyResult, error) {
+	policies, err := loadPolicies()
+	if err != nil {
+		return nil, err
+	}
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{"config": policyConfigValue(configName, config)},
+		Functions: policyFunctions,
+	}
+
+	var results []policyResult
+	for _, policy := range policies {
+		result := policyResult{Policy: policy.Name, Enforcement: policy.Enforcement}
+		if result.Enforcement == "" {
+			result.Enforcement = policyDeny
+		}
+
+		applies, err := evaluateBool(policy.Applies, ctx, true)
+		if err != nil {
+			result.Message = "applies: " + err.Error()
+			results = append(results, result)
+			continue
+		}
+		if !applies {
+			continue
+		}
+		result.Passed, err = evaluateBool(policy.Condition, ctx, false)
+		if err != nil {
+			result.Message = "condition: " + err.Error()
+		} else if !result.Passed {
+			result.Message = policyMessage(policy, ctx)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// policyMessage renders a failed policy's message, falling back to its description
+func policyMessage(policy Policy, ctx *hcl.EvalContext) string {
+	value, diags := policy.Message.Value(ctx)
+	if !diags.HasErrors() && !value.IsNull() && value.Type() == cty.String && value.IsKnown() {
+		return value.AsString()
+	}
+	if policy.Description != "" {
+		return policy.Description
+	}
+	return "policy " + policy.Name + " is not met"
+}
+
+// enforceConfigPolicies prints the policies a config breaks and reports whether it may be provisioned: deny
+// policies block it, warn policies only print
+func enforceConfigPolicies(configName string, config Config) bool {
+	results, err := evaluatePolicies(configName, config)
+	if err != nil {
+		log.Error("Error loading policies", "error", err)
+		fmt.Println("Provisioning is blocked because the policies couldn't be read:", err)
+		return false
+	}
+
+	allowed := true
+	for _, result := range results {
+		if result.Passed {
+			continue
+		}
+		if result.Enforcement == policyWarn {
+			log.Warn("Policy warning", "config", configName, "policy", result.Policy, "message", result.Message)
+			fmt.Printf("⚠️  Policy %s: %s\n", result.Policy, result.Message)
+			continue
+		}
+		log.Error("Policy violation", "config", configName, "policy", result.Policy, "message", result.Message)
+		fmt.Printf("❌ Policy %s: %s\n", result.Policy, result.Message)
+		allowed = false
+	}
+	if !allowed {
+		fmt.Printf("%s breaks the policies in %s. Edit the config and try again.\n", configName, policyFilePath())
+	}
+	return allowed
+}
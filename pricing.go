@@ -0,0 +1,33 @@
+package main
+
+import "fmt"
+
+// Providers that bill hourly cap a node at its monthly price over this many hours
+const hoursPerMonth = 730
+
+// unpricedClouds explains, for clouds whose node types never carry a price, why none is shown
+var unpricedClouds = map[string]string{
+	"Civo": "Civo's API doesn't report prices, so none are shown; see https://www.civo.com/pricing",
+}
+
+// formatNodePrice labels a node type with its price, e.g. "~$48.00/mo ($0.0714/hr)", or "" when it's unknown
+func formatNodePrice(nodeType InstanceSizeInfo) string {
+	if nodeType.PriceMonthly <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("~$%.2f/mo ($%.4f/hr)", nodeType.PriceMonthly, nodeType.PriceHourly)
+}
+
+// estimateMonthlyCost describes the monthly cost of a cluster's nodes, or "" when the price is unknown. Control
+// plane fees aren't included, as kubefirst doesn't choose a paid HA tier.
+func estimateMonthlyCost(nodeType InstanceSizeInfo, topology *NodeTopology) string {
+	if nodeType.PriceMonthly <= 0 {
+		return ""
+	}
+	if topology == nil || topology.NodeCount <= 0 {
+		return fmt.Sprintf("~$%.2f/month per node (set node-count for a total)", nodeType.PriceMonthly)
+	}
+	total := float64(topology.NodeCount) * nodeType.PriceMonthly
+	breakdown := fmt.Sprintf("%d × %s at $%.2f", topology.NodeCount, nodeType.Name, nodeType.PriceMonthly)
+	return fmt.Sprintf("~$%.2f/month (%s)", total, breakdown)
+}
@@ -0,0 +1,32 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/shirou/gopsutil/v3/process"
+	"golang.org/x/sys/unix"
+)
+
+// sampleProcessGroups sums the memory and CPU time of every process, grouped by process group
+func sampleProcessGroups() (map[int]processGroupUsage, error) {
+	processes, err := process.Processes()
+	if err != nil {
+		return nil, fmt.Errorf("error listing processes: %w", err)
+	}
+
+	groups := make(map[int]processGroupUsage)
+	for _, p := range processes {
+		usage, ok := sampleProcess(p)
+		if !ok {
+			continue
+		}
+		pgid, err := unix.Getpgid(int(p.Pid))
+		if err != nil {
+			continue
+		}
+		groups[pgid] = groups[pgid].add(usage)
+	}
+	return groups, nil
+}
@@ -0,0 +1,40 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// sampleProcessGroups sums the memory and CPU time of every process tree. Windows has no process groups, so each
+// process counts towards itself and each of its ancestors, and a service's entry covers everything it started.
+func sampleProcessGroups() (map[int]processGroupUsage, error) {
+	processes, err := process.Processes()
+	if err != nil {
+		return nil, fmt.Errorf("error listing processes: %w", err)
+	}
+
+	parents := make(map[int32]int32, len(processes))
+	for _, p := range processes {
+		if ppid, err := p.Ppid(); err == nil {
+			parents[p.Pid] = ppid
+		}
+	}
+
+	groups := make(map[int]processGroupUsage)
+	for _, p := range processes {
+		usage, ok := sampleProcess(p)
+		if !ok {
+			continue
+		}
+		// Windows reuses PIDs, so a stale parent could lead back to the process itself
+		seen := make(map[int32]bool)
+		for pid := p.Pid; pid != 0 && !seen[pid]; pid = parents[pid] {
+			seen[pid] = true
+			groups[int(pid)] = groups[int(pid)].add(usage)
+		}
+	}
+	return groups, nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// binaryProvenance records where a binary k1space installed came from and what it looked like at the time
+type binaryProvenance struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	// Download URL, or repository@commit for binaries built from source
+	Source     string    `json:"source"`
+	SHA256     string    `json:"sha256"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+type provenanceFile struct {
+	Binaries []binaryProvenance `json:"binaries"`
+}
+
+func getProvenancePath() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "provenance.json")
+}
+
+func loadProvenance() (provenanceFile, error) {
+	var provenance provenanceFile
+	data, err := os.ReadFile(getProvenancePath())
+	if os.IsNotExist(err) {
+		return provenance, nil
+	}
+	if err != nil {
+		return provenance, fmt.Errorf("error reading provenance.json: %w", err)
+	}
+	err = json.Unmarshal(data, &provenance)
+	if err != nil {
+		return provenance, fmt.Errorf("error parsing provenance.json: %w", err)
+	}
+	return provenance, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// recordProvenance digests the binary at path and stores its entry, replacing any earlier one for that path
+func recordProvenance(name, path, version, source string) error {
+	digest, err := fileSHA256(path)
+	if err != nil {
+		return fmt.Errorf("error computing digest of %s: %w", path, err)
+	}
+
+	entry := binaryProvenance{
+		Name:       name,
+		Path:       path,
+		Version:    version,
+		Source:     source,
+		SHA256:     digest,
+		RecordedAt: time.Now().UTC(),
+	}
+	err = withFileLock(getProvenancePath(), func() error {
+		provenance, err := loadProvenance()
+		if err != nil {
+			return err
+		}
+		replaced := false
+		for i, existing := range provenance.Binaries {
+			if existing.Path == path {
+				provenance.Binaries[i] = entry
+				replaced = true
+			}
+		}
+		if !replaced {
+			provenance.Binaries = append(provenance.Binaries, entry)
+		}
+
+		data, err := json.MarshalIndent(provenance, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(getProvenancePath(), data, 0644)
+	})
+	if err != nil {
+		return err
+	}
+	log.Info("Recorded binary provenance", "name", name, "path", path, "sha256", digest)
+	return nil
+}
+
+// gitSource describes a checkout as its origin URL and commit, e.g. https://github.com/konstructio/kubefirst@1a2b3c4
+func gitSource(repoPath string) string {
+	origin, err := exec.Command("git", "-C", repoPath, "remote", "get-url", "origin").Output()
+	if err != nil {
+		origin = []byte(repoPath)
+	}
+	commit, err := exec.Command("git", "-C", repoPath, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return strings.TrimSpace(string(origin))
+	}
+	return strings.TrimSpace(string(origin)) + "@" + strings.TrimSpace(string(commit))
+}
+
+func shortDigest(digest string) string {
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
+}
+
+// verifyBinaryProvenance compares a recorded binary with what's on disk now
+func verifyBinaryProvenance(entry binaryProvenance) string {
+	digest, err := fileSHA256(entry.Path)
+	if os.IsNotExist(err) {
+		return "Missing"
+	}
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+	if digest != entry.SHA256 {
+		return "Modified (sha256 " + shortDigest(digest) + ")"
+	}
+	return "OK"
+}
+
+func verifyBinaries() {
+	log.Info("Starting verifyBinaries function")
+
+	provenance, err := loadProvenance()
+	if err != nil {
+		log.Error("Error loading provenance", "error", err)
+		fmt.Println("Failed to load provenance:", err)
+		return
+	}
+	if len(provenance.Binaries) == 0 {
+		fmt.Println("No binaries recorded yet. Provenance is recorded when k1space is upgraded or kubefirst is built from the managed repositories.")
+		return
+	}
+
+	summary := [][]string{{"Binary", "Version", "Source", "SHA256", "Status"}}
+	failed := 0
+	for _, entry := range provenance.Binaries {
+		status := verifyBinaryProvenance(entry)
+		if status != "OK" {
+			failed++
+			log.Warn("Binary does not match its recorded digest", "path", entry.Path, "status", status)
+		}
+		summary = append(summary, []string{entry.Path, entry.Version, entry.Source, shortDigest(entry.SHA256), status})
+	}
+	printSummaryTable("Binary Provenance", summary)
+
+	if failed > 0 {
+		fmt.Printf("%d binary(s) no longer match their recorded digest. Reinstall them or check who replaced them.\n", failed)
+	} else {
+		fmt.Println("All recorded binaries match their digests.")
+	}
+	fmt.Println("Provenance file:", getProvenancePath())
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// How long kubefirst gets to stop after a cancel before its process group is killed
+const provisioningCancelGrace = 30 * time.Second
+
+var errProvisioningCancelled = errors.New("provisioning cancelled")
+
+// provisioningCancel stops a provisioning script's process group on Ctrl+C or SIGTERM, on a hangup unless the run
+// detaches instead, or when the watchdog gives up on it. The group is interrupted first so kubefirst and terraform
+// can stop cleanly; another Ctrl+C, or the grace period running out, kills it.
+type provisioningCancel struct {
+	requested atomic.Bool
+	// timedOut holds the watchdog's reason for stopping the run
+	timedOut atomic.Value
+	signals  chan os.Signal
+	expired  chan string
+	done     chan struct{}
+}
+
+func watchCancel(scriptPID int, detach bool) *provisioningCancel {
+	c := &provisioningCancel{signals: make(chan os.Signal, 1), expired: make(chan string, 1), done: make(chan struct{})}
+	signal.Notify(c.signals, os.Interrupt, syscall.SIGTERM)
+	// The script's process group doesn't get the terminal's hangup, so it's passed on unless watchHangup keeps the
+	// run going
+	if !detach {
+		signal.Notify(c.signals, syscall.SIGHUP)
+	}
+
+	go func() {
+		var grace <-chan time.Time
+		interrupt := func() {
+			if err := signalProcessGroup(scriptPID, os.Interrupt); err != nil {
+				log.Error("Error interrupting provisioning script", "error", err)
+			}
+			grace = time.After(provisioningCancelGrace)
+		}
+		for {
+			select {
+			case sig := <-c.signals:
+				if sig == syscall.SIGHUP {
+					// Nothing is left to prompt or draw on, and k1space exits once the cancel is recorded
+					hungUp.Store(true)
+				}
+				if grace == nil {
+					log.Warn("Cancelling provisioning", "signal", sig)
+					c.requested.Store(true)
+					interrupt()
+					continue
+				}
+				// Only another Ctrl+C cuts the grace period short
+				if sig != syscall.SIGHUP {
+					c.kill(scriptPID)
+				}
+			case reason := <-c.expired:
+				if grace == nil {
+					log.Warn("Stopping provisioning", "reason", reason)
+					c.timedOut.Store(reason)
+					interrupt()
+				}
+			case <-grace:
+				c.kill(scriptPID)
+			case <-c.done:
+				return
+			}
+		}
+	}()
+	return c
+}
+
+// expire stops the run on the watchdog's behalf
+func (c *provisioningCancel) expire(reason string) {
+	select {
+	case c.expired <- reason:
+	default:
+	}
+}
+
+func (c *provisioningCancel) kill(scriptPID int) {
+	log.Warn("Killing provisioning script", "pid", scriptPID)
+	if err := signalProcessGroup(scriptPID, os.Kill); err != nil {
+		log.Error("Error killing provisioning script", "error", err)
+	}
+}
+
+func (c *provisioningCancel) cancelled() bool {
+	return c.requested.Load()
+}
+
+// timeoutReason says why the watchdog stopped the run, or is empty if it didn't
+func (c *provisioningCancel) timeoutReason() string {
+	reason, _ := c.timedOut.Load().(string)
+	return reason
+}
+
+// status is the dashboard's hint on how to cancel, or that a cancel is under way
+func (c *provisioningCancel) status() string {
+	if c.cancelled() {
+		return "Cancelling: waiting for kubefirst to stop (Ctrl+C again to kill it)"
+	}
+	if reason := c.timeoutReason(); reason != "" {
+		return "Stopping: " + reason + " (Ctrl+C to kill it now)"
+	}
+	return "Press Ctrl+C to cancel"
+}
+
+func (c *provisioningCancel) stop() {
+	signal.Stop(c.signals)
+	close(c.done)
+}
+
+// offerCancelledProvisionCleanup offers to deprovision what a cancelled run had already created, such as the
+// cluster, DNS records or the gitops repositories
+func offerCancelledProvisionCleanup(configName string, indexFile IndexFile) {
+	// Nobody is left to answer after a hangup
+	if hungUp.Load() {
+		return
+	}
+	cleanup := false
+	err := runField(newConfirm("Deprovision the resources the cancelled run already created?", &cleanup).
+		Description("kubefirst may have created the cluster, DNS records or gitops repositories before it stopped."))
+	if err != nil {
+		log.Error("Error in cleanup confirmation", "error", err)
+		return
+	}
+	if !cleanup {
+		fmt.Println("Nothing was cleaned up. Deprovision the cluster later from 'Deprovision Cluster', or provision it again to resume.")
+		return
+	}
+	deprovisionConfig(configName, indexFile)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const failureTailLines = 100
+
+var failureStyle = boxStyle.Copy().
+	BorderForeground(lipgloss.Color("#FF5F5F")).
+	Width(180)
+
+// provisioningFailure is returned by runProvisioningScript when the script exits non-zero
+type provisioningFailure struct {
+	Err                error
+	ExitCode           int
+	Tail               []string
+	KubefirstTail      []string
+	ScriptLog          string
+	KubefirstLogDir    string
+	LatestKubefirstLog string
+	// TimedOut is why the watchdog stopped the run, empty when the script failed on its own
+	TimedOut string
+}
+
+func (f *provisioningFailure) Error() string {
+	if f.TimedOut != "" {
+		return fmt.Sprintf("provisioning stopped: %s", f.TimedOut)
+	}
+	return fmt.Sprintf("error running script (exit code %d): %v", f.ExitCode, f.Err)
+}
+
+func (f *provisioningFailure) Unwrap() error {
+	return f.Err
+}
+
+func kubefirstLogDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".k1", "logs")
+}
+
+// latestKubefirstLog returns the most recently modified file in kubefirst's log directory
+func latestKubefirstLog(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	var latest string
+	var latestInfo os.FileInfo
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if latestInfo == nil || info.ModTime().After(latestInfo.ModTime()) {
+			latest = filepath.Join(dir, entry.Name())
+			latestInfo = info
+		}
+	}
+	return latest
+}
+
+func newProvisioningFailure(err error, output, kubefirstOutput *scrollingLog, scriptLog string) *provisioningFailure {
+	failure := &provisioningFailure{
+		Err:             err,
+		ExitCode:        -1,
+		Tail:            append([]string(nil), output.getLastN(failureTailLines)...),
+		KubefirstTail:   append([]string(nil), kubefirstOutput.getLastN(failureTailLines)...),
+		ScriptLog:       scriptLog,
+		KubefirstLogDir: kubefirstLogDir(),
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		failure.ExitCode = exitErr.ExitCode()
+	}
+	failure.LatestKubefirstLog = latestKubefirstLog(failure.KubefirstLogDir)
+	return failure
+}
+
+func (f *provisioningFailure) summary() string {
+	var sb strings.Builder
+	if f.TimedOut != "" {
+		sb.WriteString(fmt.Sprintf("Stopped:             %s\n", f.TimedOut))
+	}
+	sb.WriteString(fmt.Sprintf("Exit code:           %d\n", f.ExitCode))
+	sb.WriteString(fmt.Sprintf("Script log:          %s\n", f.ScriptLog))
+	sb.WriteString(fmt.Sprintf("kubefirst log dir:   %s\n", f.KubefirstLogDir))
+	if f.LatestKubefirstLog != "" {
+		sb.WriteString(fmt.Sprintf("Latest kubefirst log: %s\n", f.LatestKubefirstLog))
+	}
+	return sb.String()
+}
+
+// recordProvisioningFailure writes the diagnostics next to the script log so they are included in log exports
+func recordProvisioningFailure(f *provisioningFailure, logDir, timestamp string) (string, error) {
+	path := filepath.Join(logDir, fmt.Sprintf("failure-%s.log", timestamp))
+	content := f.summary() + "\nLast output:\n" + strings.Join(f.Tail, "\n") + "\n"
+	if len(f.KubefirstTail) > 0 {
+		content += "\nLast kubefirst log lines:\n" + strings.Join(f.KubefirstTail, "\n") + "\n"
+	}
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		return "", fmt.Errorf("error writing failure diagnostics: %w", err)
+	}
+	return path, nil
+}
+
+func renderProvisioningFailure(f *provisioningFailure) string {
+	var sb strings.Builder
+
+	title := "❌ Provisioning Failed"
+	if f.TimedOut != "" {
+		title = "⏱ Provisioning Timed Out"
+	}
+	sb.WriteString(failureStyle.Render(clusterTitleStyle.Render(title) + "\n\n" + f.summary()))
+	sb.WriteString("\n\n")
+
+	tail := "No output captured."
+	if len(f.Tail) > 0 {
+		tail = strings.Join(f.Tail, "\n")
+	}
+	sb.WriteString(boxStyle.Copy().Width(180).Render(
+		clusterTitleStyle.Render(fmt.Sprintf("Last %d lines of output", len(f.Tail))) + "\n\n" + tail,
+	))
+	sb.WriteString("\n\n")
+
+	sb.WriteString(titleStyle.Render("Next steps:") + "\n")
+	if f.LatestKubefirstLog != "" {
+		sb.WriteString(fmt.Sprintf("  - Inspect kubefirst's detailed log: less %s\n", f.LatestKubefirstLog))
+	}
+	sb.WriteString("  - Export the run with 'Cluster' -> 'Export Operation Logs' to share it\n")
+
+	return sb.String()
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+const (
+	queueStatusQueued  = "queued"
+	queueStatusRunning = "running"
+
+	provisionQueuePollInterval = 5 * time.Second
+
+	// Provider APIs rate limit per account, so by default only one run per provider at a time
+	defaultProvisionConcurrency = 1
+)
+
+// provisionQueueEntry is one provisioning run, shared with other k1space processes through ~/.ssot/k1space/.queue
+type provisionQueueEntry struct {
+	ID         string    `json:"id"`
+	Config     string    `json:"config"`
+	Provider   string    `json:"provider"`
+	PID        int       `json:"pid"`
+	Status     string    `json:"status"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+}
+
+func getProvisionQueueDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".queue")
+}
+
+func (e *provisionQueueEntry) path() string {
+	return filepath.Join(getProvisionQueueDir(), e.ID+".json")
+}
+
+// save writes the entry; callers hold the queue directory's lock
+func (e *provisionQueueEntry) save() error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding queue entry: %w", err)
+	}
+	err = writeFileAtomic(e.path(), data, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing queue entry: %w", err)
+	}
+	return nil
+}
+
+func (e *provisionQueueEntry) release() {
+	err := os.Remove(e.path())
+	if err != nil && !os.IsNotExist(err) {
+		log.Warn("Error removing provisioning queue entry", "path", e.path(), "error", err)
+	}
+}
+
+// provisionConcurrencyLimit returns provision_concurrency for the provider from settings.hcl
+func provisionConcurrencyLimit(provider string) int {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings, using default provisioning concurrency", "error", err)
+		return defaultProvisionConcurrency
+	}
+	if limit, ok := settings.ProvisionConcurrency[strings.ToLower(provider)]; ok && limit > 0 {
+		return limit
+	}
+	return defaultProvisionConcurrency
+}
+
+// loadProvisionQueue returns all entries in the order they were enqueued, removing those left behind by exited processes
+func loadProvisionQueue() ([]provisionQueueEntry, error) {
+	dir := getProvisionQueueDir()
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading provisioning queue: %w", err)
+	}
+
+	var entries []provisionQueueEntry
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var entry provisionQueueEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			log.Warn("Ignoring unreadable provisioning queue entry", "path", path, "error", err)
+			continue
+		}
+		if !processAlive(entry.PID) {
+			log.Info("Removing stale provisioning queue entry", "config", entry.Config, "pid", entry.PID)
+			os.Remove(path)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].EnqueuedAt.Equal(entries[j].EnqueuedAt) {
+			return entries[i].ID < entries[j].ID
+		}
+		return entries[i].EnqueuedAt.Before(entries[j].EnqueuedAt)
+	})
+	return entries, nil
+}
+
+// enqueueProvision adds a run to the queue. The time it's enqueued at is taken under the queue's lock, so an entry
+// is on disk before any later one exists.
+func enqueueProvision(configName, provider string) (*provisionQueueEntry, error) {
+	queueDir := getProvisionQueueDir()
+	err := os.MkdirAll(queueDir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("error creating provisioning queue directory: %w", err)
+	}
+
+	var entry *provisionQueueEntry
+	err = withFileLock(queueDir, func() error {
+		now := time.Now()
+		entry = &provisionQueueEntry{
+			ID:         fmt.Sprintf("%s-%d-%s", now.Format("20060102-150405.000000"), os.Getpid(), configName),
+			Config:     configName,
+			Provider:   strings.ToLower(provider),
+			PID:        os.Getpid(),
+			Status:     queueStatusQueued,
+			EnqueuedAt: now,
+		}
+		return entry.save()
+	})
+	return entry, err
+}
+
+// queuePosition returns how many earlier entries for the same provider are ahead of this one
+func (e *provisionQueueEntry) queuePosition(entries []provisionQueueEntry) int {
+	position := 0
+	for _, other := range entries {
+		if other.ID == e.ID {
+			break
+		}
+		if other.Provider == e.Provider {
+			position++
+		}
+	}
+	return position
+}
+
+// waitForProvisionSlot blocks until fewer than the provider's limit of earlier runs are still active. Slots are
+// handed out first come, first served: each check reads the queue under the lock entries are added under, so no
+// earlier entry can appear after this one has been given a slot.
+func waitForProvisionSlot(entry *provisionQueueEntry) error {
+	limit := provisionConcurrencyLimit(entry.Provider)
+
+	s := startSpinner("Checking provisioning queue...")
+	for {
+		position := 0
+		err := withFileLock(getProvisionQueueDir(), func() error {
+			entries, err := loadProvisionQueue()
+			if err != nil {
+				return err
+			}
+			position = entry.queuePosition(entries)
+			if position >= limit {
+				return nil
+			}
+			entry.Status = queueStatusRunning
+			entry.StartedAt = time.Now()
+			return entry.save()
+		})
+		if err != nil {
+			stopSpinner(s, false)
+			return err
+		}
+
+		if position < limit {
+			s.Lock()
+			s.Suffix = fmt.Sprintf(" Provisioning slot acquired for %s", entry.Config)
+			s.Unlock()
+			stopSpinner(s, true)
+			return nil
+		}
+
+		s.Lock()
+		s.Suffix = fmt.Sprintf(" Queued behind %d %s run(s), %d allowed at a time...", position, entry.Provider, limit)
+		s.Unlock()
+		time.Sleep(provisionQueuePollInterval)
+	}
+}
+
+// provisionQueueLabel summarizes the queue for the Cluster menu
+func provisionQueueLabel() string {
+	entries, err := loadProvisionQueue()
+	if err != nil || len(entries) == 0 {
+		return "Provisioning Queue"
+	}
+
+	running := 0
+	for _, entry := range entries {
+		if entry.Status == queueStatusRunning {
+			running++
+		}
+	}
+	return fmt.Sprintf("Provisioning Queue (%d running, %d queued)", running, len(entries)-running)
+}
+
+func showProvisionQueue() {
+	entries, err := loadProvisionQueue()
+	if err != nil {
+		log.Error("Error loading provisioning queue", "error", err)
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No provisioning runs are active or queued.")
+		return
+	}
+
+	summary := [][]string{{"Config", "Provider", "Status", "Waiting Since", "Started", "PID"}}
+	for _, entry := range entries {
+		started := "-"
+		if !entry.StartedAt.IsZero() {
+			started = entry.StartedAt.Format(time.Kitchen)
+		}
+		summary = append(summary, []string{entry.Config, entry.Provider, entry.Status, entry.EnqueuedAt.Format(time.Kitchen), started, fmt.Sprint(entry.PID)})
+	}
+	printSummaryTable("Provisioning Queue", summary)
+	// Structured output may be piped, with nobody to press Enter
+	if isStructuredOutput() {
+		return
+	}
+
+	fmt.Print("\nPress Enter to continue...")
+	fmt.Scanln()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+const (
+	defaultProvisionTimeout     = 2 * time.Hour
+	defaultProvisionIdleTimeout = 30 * time.Minute
+	watchdogInterval            = 30 * time.Second
+)
+
+// parseProvisionTimeout reads one of the provisioning timeouts from settings.hcl; "0" turns it off
+func parseProvisionTimeout(name, value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+	if value == "0" {
+		return 0
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		log.Warn("Invalid "+name+" in settings.hcl, using the default", name, value)
+		return fallback
+	}
+	return timeout
+}
+
+// getProvisionTimeouts returns provision_timeout, how long a whole run may take, and provision_idle_timeout, how
+// long it may go without writing any output, from settings.hcl
+func getProvisionTimeouts() (time.Duration, time.Duration) {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings, using the default provisioning timeouts", "error", err)
+		return defaultProvisionTimeout, defaultProvisionIdleTimeout
+	}
+	return parseProvisionTimeout("provision_timeout", settings.ProvisionTimeout, defaultProvisionTimeout),
+		parseProvisionTimeout("provision_idle_timeout", settings.ProvisionIdleTimeout, defaultProvisionIdleTimeout)
+}
+
+// watchProvisioning stops a run through cancel once it has taken longer than provision_timeout, or neither the
+// script nor kubefirst's logs have printed anything for provision_idle_timeout, the usual sign that kubefirst
+// create is stuck
+func watchProvisioning(cancel *provisioningCancel, startedAt time.Time, logs ...*scrollingLog) (stop func()) {
+	total, idle := getProvisionTimeouts()
+	done := make(chan struct{})
+	if total == 0 && idle == 0 {
+		return func() { close(done) }
+	}
+
+	go func() {
+		ticker := time.NewTicker(watchdogInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			if total > 0 && time.Since(startedAt) > total {
+				cancel.expire(fmt.Sprintf("timed out after %s (provision_timeout)", total))
+				return
+			}
+			lastOutput := startedAt
+			for _, l := range logs {
+				if at := l.lastActivity(); at.After(lastOutput) {
+					lastOutput = at
+				}
+			}
+			if idle > 0 && time.Since(lastOutput) > idle {
+				cancel.expire(fmt.Sprintf("no output for %s (provision_idle_timeout)", idle))
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const redactedPlaceholder = "[REDACTED]"
+
+// Environment variables whose values are always treated as secrets
+var knownSecretEnvVars = []string{
+	"CIVO_TOKEN",
+	"DO_TOKEN",
+	"GITHUB_TOKEN",
+	"GITLAB_TOKEN",
+	"K1_ACCESS_TOKEN",
+	"VAULT_TOKEN",
+	"OP_SERVICE_ACCOUNT_TOKEN",
+}
+
+// Suffixes that mark any other environment variable as secret
+var secretEnvVarSuffixes = []string{"_TOKEN", "_SECRET", "_PASSWORD", "_API_KEY", "_ACCESS_KEY"}
+
+// Patterns for well-known token formats that may be echoed by tools
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`),
+	regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{36,}`),
+	regexp.MustCompile(`github_pat_[A-Za-z0-9_]{22,}`),
+	regexp.MustCompile(`glpat-[A-Za-z0-9\-_]{20,}`),
+	regexp.MustCompile(`dop_v1_[a-f0-9]{64}`),
+	regexp.MustCompile(`hvs\.[A-Za-z0-9_\-]{24,}`),
+	regexp.MustCompile(`AKIA[0-9A-Z]{16}`),
+}
+
+// isSecretEnvVar reports whether an environment variable's value is treated as a secret
+func isSecretEnvVar(name string) bool {
+	if contains(knownSecretEnvVars, name) {
+		return true
+	}
+	for _, suffix := range secretEnvVarSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Values shorter than this are too likely to collide with ordinary output
+const minSecretLength = 8
+
+type redactor struct {
+	secrets []string
+}
+
+func newRedactor(extraSecrets ...string) *redactor {
+	r := &redactor{}
+
+	for _, name := range knownSecretEnvVars {
+		r.addSecret(os.Getenv(name))
+	}
+
+	for _, env := range os.Environ() {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		for _, suffix := range secretEnvVarSuffixes {
+			if strings.HasSuffix(parts[0], suffix) {
+				r.addSecret(parts[1])
+				break
+			}
+		}
+	}
+
+	for _, secret := range extraSecrets {
+		r.addSecret(secret)
+	}
+
+	// Replace longer secrets first so a secret containing another is fully masked
+	sort.Slice(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+
+	return r
+}
+
+func (r *redactor) addSecret(secret string) {
+	secret = strings.TrimSpace(secret)
+	if len(secret) < minSecretLength || strings.HasPrefix(secret, "op://") {
+		return
+	}
+	if !contains(r.secrets, secret) {
+		r.secrets = append(r.secrets, secret)
+	}
+}
+
+func (r *redactor) redact(line string) string {
+	for _, secret := range r.secrets {
+		line = strings.ReplaceAll(line, secret, redactedPlaceholder)
+	}
+	for _, pattern := range secretPatterns {
+		line = pattern.ReplaceAllStringFunc(line, func(match string) string {
+			// Keep the "Bearer " prefix so the line stays readable
+			if sub := pattern.FindStringSubmatch(match); len(sub) > 1 {
+				return sub[1] + redactedPlaceholder
+			}
+			return redactedPlaceholder
+		})
+	}
+	return line
+}
+
+func redactEnvContent(content string) string {
+	r := newRedactor()
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimSpace(parts[0]), "export ")
+		for _, suffix := range secretEnvVarSuffixes {
+			if strings.HasSuffix(name, suffix) && !strings.Contains(parts[1], "op://") {
+				lines[i] = parts[0] + "=\"" + redactedPlaceholder + "\""
+				break
+			}
+		}
+		lines[i] = r.redact(lines[i])
+	}
+	return strings.Join(lines, "\n")
+}
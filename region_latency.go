@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const regionProbeTimeout = 3 * time.Second
+
+// regionProbeHost returns a host that is physically located in the given region, or "" when the provider has none
+func regionProbeHost(cloudProvider, region string) string {
+	switch cloudProvider {
+	case "DigitalOcean":
+		return fmt.Sprintf("speedtest-%s.digitalocean.com", strings.ToLower(region))
+	}
+	return ""
+}
+
+func supportsRegionProbe(cloudProvider string) bool {
+	return regionProbeHost(cloudProvider, "probe") != ""
+}
+
+// probeRegionLatencies measures the TCP handshake time to each region's HTTPS endpoint in parallel.
+// Regions that cannot be reached within the timeout are left out of the result.
+func probeRegionLatencies(cloudProvider string, regions []string) map[string]time.Duration {
+	latencies := make(map[string]time.Duration)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, region := range regions {
+		host := regionProbeHost(cloudProvider, region)
+		if host == "" {
+			continue
+		}
+
+		wg.Add(1)
+		go func(region, host string) {
+			defer wg.Done()
+			start := time.Now()
+			conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, "443"), regionProbeTimeout)
+			if err != nil {
+				return
+			}
+			elapsed := time.Since(start)
+			conn.Close()
+
+			mu.Lock()
+			latencies[region] = elapsed
+			mu.Unlock()
+		}(region, host)
+	}
+
+	wg.Wait()
+	return latencies
+}
+
+func formatLatency(latencies map[string]time.Duration, region string) string {
+	if latencies == nil {
+		return ""
+	}
+	latency, ok := latencies[region]
+	if !ok {
+		return " (unreachable)"
+	}
+	return fmt.Sprintf(" (%d ms)", latency.Milliseconds())
+}
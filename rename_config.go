@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// Generated files that reference the config's env var prefix
+var prefixedConfigFiles = []string{".local.cloud.env", "01-kubefirst-cloud.sh"}
+
+func renameConfig() {
+	log.Info("Starting renameConfig function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations. Please ensure that the config.hcl file exists and is correctly formatted.")
+		return
+	}
+
+	oldName, err := promptConfigSelection(indexFile, "Select a configuration to rename")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if oldName == "" {
+		fmt.Println("No configurations found.")
+		return
+	}
+
+	parts := strings.Split(oldName, "_")
+	if len(parts) != 3 {
+		log.Error("Invalid config name format", "config", oldName)
+		fmt.Println("Invalid configuration name format. Rename cancelled.")
+		return
+	}
+	cloud, region, oldPrefix := parts[0], parts[1], parts[2]
+
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		return
+	}
+
+	var newPrefix string
+	err = runField(newInput(fmt.Sprintf("Enter the new static prefix for %s", oldName), &newPrefix).
+		Placeholder(oldPrefix).
+		Validate(func(prefix string) error {
+			if prefix == "" {
+				return fmt.Errorf("prefix cannot be empty")
+			}
+			if _, exists := indexFile.Configs[fmt.Sprintf("%s_%s_%s", cloud, region, prefix)]; exists {
+				return fmt.Errorf("configuration %s_%s_%s already exists", cloud, region, prefix)
+			}
+			return settings.NamingPolicy.validateStaticPrefix(prefix)
+		}))
+	if err != nil {
+		log.Error("Error in rename prompt", "error", err)
+		return
+	}
+
+	newName := fmt.Sprintf("%s_%s_%s", cloud, region, newPrefix)
+	err = moveConfig(oldName, newName)
+	if err != nil {
+		log.Error("Error renaming config", "from", oldName, "to", newName, "error", err)
+		fmt.Println("Failed to rename configuration:", err)
+		return
+	}
+
+	fmt.Printf("Configuration '%s' has been renamed to '%s'\n", oldName, newName)
+	log.Info("renameConfig function completed successfully", "from", oldName, "to", newName)
+}
+
+// moveConfig moves a config's directory and logs, rewrites the env var prefix in its generated files,
+// and re-keys its index entry. The directory is moved back if the index can't be written.
+func moveConfig(oldName, newName string) error {
+	oldParts := strings.Split(oldName, "_")
+	newParts := strings.Split(newName, "_")
+	baseDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space")
+	oldDir := filepath.Join(baseDir, oldParts[0], oldParts[1], oldParts[2])
+	newDir := filepath.Join(baseDir, newParts[0], newParts[1], newParts[2])
+
+	if _, err := os.Stat(newDir); err == nil {
+		return fmt.Errorf("%s already exists", newDir)
+	}
+	err := os.MkdirAll(filepath.Dir(newDir), 0755)
+	if err != nil {
+		return fmt.Errorf("error creating directory: %w", err)
+	}
+	err = os.Rename(oldDir, newDir)
+	if err != nil {
+		return fmt.Errorf("error moving config directory: %w", err)
+	}
+
+	oldEnvPrefix, newEnvPrefix := configEnvPrefix(oldName), configEnvPrefix(newName)
+	for _, file := range prefixedConfigFiles {
+		path := filepath.Join(newDir, file)
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err == nil {
+			info, statErr := os.Stat(path)
+			if statErr != nil {
+				err = statErr
+			} else {
+				err = os.WriteFile(path, []byte(strings.ReplaceAll(string(content), oldEnvPrefix, newEnvPrefix)), info.Mode())
+			}
+		}
+		if err != nil {
+			os.Rename(newDir, oldDir)
+			return fmt.Errorf("error rewriting %s: %w", file, err)
+		}
+	}
+
+	err = updateIndex(func(indexFile *IndexFile) error {
+		config, ok := indexFile.Configs[oldName]
+		if !ok {
+			return fmt.Errorf("config %s not found", oldName)
+		}
+		if _, exists := indexFile.Configs[newName]; exists {
+			return fmt.Errorf("configuration %s already exists", newName)
+		}
+		// Everything but the paths and env prefixes carries over, including the cluster's ID
+		renamed := config
+		renamed.Files = make([]string, len(config.Files))
+		renamed.Flags = make(map[string]string, len(config.Flags))
+		oldSlashDir, newSlashDir := filepath.ToSlash(oldDir), filepath.ToSlash(newDir)
+		for i, file := range config.Files {
+			renamed.Files[i] = strings.Replace(file, oldSlashDir, newSlashDir, 1)
+		}
+		for name, value := range config.Flags {
+			renamed.Flags[strings.Replace(name, oldEnvPrefix, newEnvPrefix, 1)] = value
+		}
+
+		// Keep the DR pair linked under the new name
+		if config.Failover != nil {
+			if peer, ok := indexFile.Configs[config.Failover.Peer]; ok && peer.Failover != nil && peer.Failover.Peer == oldName {
+				peer.Failover = &FailoverPair{Role: peer.Failover.Role, Peer: newName}
+				indexFile.Configs[config.Failover.Peer] = peer
+			}
+		}
+		delete(indexFile.Configs, oldName)
+		indexFile.Configs[newName] = renamed
+		return nil
+	})
+	if err != nil {
+		os.Rename(newDir, oldDir)
+		return fmt.Errorf("error updating index file: %w", err)
+	}
+
+	// Keep the run history with the config; losing it isn't worth failing the rename over
+	oldLogDir := filepath.Join(baseDir, ".logs", oldParts[0], oldParts[1], oldParts[2])
+	if _, err := os.Stat(oldLogDir); err == nil {
+		newLogDir := filepath.Join(baseDir, ".logs", newParts[0], newParts[1], newParts[2])
+		if err := os.Rename(oldLogDir, newLogDir); err != nil {
+			log.Warn("Error moving operation logs", "from", oldLogDir, "to", newLogDir, "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Requests above this share of a node's allocatable CPU or memory leave little room for workloads
+const nodeCapacityWarningPercent = 80
+
+// resourceSnapshot is what a cluster's nodes can offer and what its pods already ask for, taken right after
+// provisioning when nothing but the platform components is running
+type resourceSnapshot struct {
+	TakenAt    time.Time      `json:"taken_at" yaml:"taken_at"`
+	NodeType   string         `json:"node_type,omitempty" yaml:"node_type,omitempty"`
+	Nodes      []nodeUsage    `json:"nodes" yaml:"nodes"`
+	Namespaces map[string]int `json:"pods_per_namespace" yaml:"pods_per_namespace"`
+	Warnings   []string       `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+// nodeUsage is one node's allocatable resources against the requests of the pods scheduled on it, with CPU in
+// millicores and memory in bytes
+type nodeUsage struct {
+	Name              string `json:"name" yaml:"name"`
+	AllocatableCPU    int64  `json:"allocatable_cpu_millicores" yaml:"allocatable_cpu_millicores"`
+	RequestedCPU      int64  `json:"requested_cpu_millicores" yaml:"requested_cpu_millicores"`
+	AllocatableMemory int64  `json:"allocatable_memory_bytes" yaml:"allocatable_memory_bytes"`
+	RequestedMemory   int64  `json:"requested_memory_bytes" yaml:"requested_memory_bytes"`
+	Pods              int    `json:"pods" yaml:"pods"`
+}
+
+// cpuPercent and memoryPercent are how much of the node's allocatable resources are already requested
+func (n nodeUsage) cpuPercent() int64 {
+	return percentOf(n.RequestedCPU, n.AllocatableCPU)
+}
+
+func (n nodeUsage) memoryPercent() int64 {
+	return percentOf(n.RequestedMemory, n.AllocatableMemory)
+}
+
+func percentOf(part, whole int64) int64 {
+	if whole <= 0 {
+		return 0
+	}
+	return part * 100 / whole
+}
+
+// podRequests sums a pod's container requests the way the scheduler does: init containers run one at a time, so
+// only the largest of them counts if it's bigger than the regular containers together
+func podRequests(pod corev1.Pod) (cpu, memory int64) {
+	for _, container := range pod.Spec.Containers {
+		cpu += container.Resources.Requests.Cpu().MilliValue()
+		memory += container.Resources.Requests.Memory().Value()
+	}
+	for _, container := range pod.Spec.InitContainers {
+		cpu = max(cpu, container.Resources.Requests.Cpu().MilliValue())
+		memory = max(memory, container.Resources.Requests.Memory().Value())
+	}
+	return cpu, memory
+}
+
+// kubernetesClient builds a client-go clientset from a kubeconfig file
+func kubernetesClient(kubeconfig string) (*kubernetes.Clientset, error) {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("error loading kubeconfig %s: %w", kubeconfig, err)
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// takeResourceSnapshot reads node allocatable resources and pod requests through the Kubernetes API
+func takeResourceSnapshot(ctx context.Context, kubeconfig string) (*resourceSnapshot, error) {
+	client, err := kubernetesClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing nodes: %w", err)
+	}
+	pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %w", err)
+	}
+
+	snapshot := &resourceSnapshot{TakenAt: time.Now().UTC(), Namespaces: map[string]int{}}
+	usage := map[string]*nodeUsage{}
+	for _, node := range nodes.Items {
+		snapshot.Nodes = append(snapshot.Nodes, nodeUsage{
+			Name:              node.Name,
+			AllocatableCPU:    node.Status.Allocatable.Cpu().MilliValue(),
+			AllocatableMemory: node.Status.Allocatable.Memory().Value(),
+		})
+	}
+	for i := range snapshot.Nodes {
+		usage[snapshot.Nodes[i].Name] = &snapshot.Nodes[i]
+	}
+
+	for _, pod := range pods.Items {
+		// Finished pods no longer hold their requests
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		snapshot.Namespaces[pod.Namespace]++
+		node, ok := usage[pod.Spec.NodeName]
+		if !ok {
+			continue
+		}
+		cpu, memory := podRequests(pod)
+		node.RequestedCPU += cpu
+		node.RequestedMemory += memory
+		node.Pods++
+	}
+	sort.Slice(snapshot.Nodes, func(i, j int) bool { return snapshot.Nodes[i].Name < snapshot.Nodes[j].Name })
+	return snapshot, nil
+}
+
+// capacityWarnings flags nodes whose requests are already above nodeCapacityWarningPercent
+func capacityWarnings(snapshot *resourceSnapshot) []string {
+	var warnings []string
+	for _, node := range snapshot.Nodes {
+		if cpu := node.cpuPercent(); cpu >= nodeCapacityWarningPercent {
+			warnings = append(warnings, fmt.Sprintf("%s has %d%% of its CPU requested", node.Name, cpu))
+		}
+		if memory := node.memoryPercent(); memory >= nodeCapacityWarningPercent {
+			warnings = append(warnings, fmt.Sprintf("%s has %d%% of its memory requested", node.Name, memory))
+		}
+	}
+	return warnings
+}
+
+// resourceSnapshotPath keeps the snapshot next to the config's kubeconfig
+func resourceSnapshotPath(configName string) (string, error) {
+	kubeconfigPath, err := configKubeconfigPath(configName)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(kubeconfigPath), "resource-snapshot.json"), nil
+}
+
+func saveResourceSnapshot(configName string, snapshot *resourceSnapshot) error {
+	path, err := resourceSnapshotPath(configName)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// recordResourceSnapshot takes a resource snapshot of a config's cluster and saves it with the config's state.
+// It returns nil when the cluster can't be reached.
+func recordResourceSnapshot(configName string, config Config) *resourceSnapshot {
+	kubeconfig := healthCheckKubeconfig(configName, findConfigFlag(config.Flags, "cluster-name"))
+	if kubeconfig == "" {
+		log.Warn("No kubeconfig found, skipping the resource snapshot", "config", configName)
+		return nil
+	}
+
+	var snapshot *resourceSnapshot
+	err := runCancellable("Taking a resource snapshot...", func(ctx context.Context) (err error) {
+		snapshot, err = takeResourceSnapshot(ctx, kubeconfig)
+		return err
+	})
+	if err != nil {
+		log.Error("Error taking resource snapshot", "config", configName, "error", err)
+		return nil
+	}
+	snapshot.NodeType = findConfigFlag(config.Flags, "node-type")
+	snapshot.Warnings = capacityWarnings(snapshot)
+
+	err = saveResourceSnapshot(configName, snapshot)
+	if err != nil {
+		log.Error("Error saving resource snapshot", "config", configName, "error", err)
+	}
+	log.Info("Took resource snapshot", "config", configName, "nodes", len(snapshot.Nodes), "warnings", len(snapshot.Warnings))
+	return snapshot
+}
+
+// formatMemory shows bytes in GiB with one decimal
+func formatMemory(bytes int64) string {
+	return strconv.FormatFloat(float64(bytes)/(1<<30), 'f', 1, 64) + "Gi"
+}
+
+// printResourceSnapshot shows per-node usage and pod counts, and warns when the platform components alone fill
+// the nodes
+func printResourceSnapshot(snapshot *resourceSnapshot) {
+	nodes := [][]string{{"Node", "CPU Requested", "Memory Requested", "Pods"}}
+	for _, node := range snapshot.Nodes {
+		nodes = append(nodes, []string{
+			node.Name,
+			fmt.Sprintf("%dm / %dm (%d%%)", node.RequestedCPU, node.AllocatableCPU, node.cpuPercent()),
+			fmt.Sprintf("%s / %s (%d%%)", formatMemory(node.RequestedMemory), formatMemory(node.AllocatableMemory), node.memoryPercent()),
+			strconv.Itoa(node.Pods),
+		})
+	}
+	printSummaryTable("Node Resource Usage", nodes)
+
+	namespaces := make([]string, 0, len(snapshot.Namespaces))
+	for namespace := range snapshot.Namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	pods := [][]string{{"Namespace", "Pods"}}
+	for _, namespace := range namespaces {
+		pods = append(pods, []string{namespace, strconv.Itoa(snapshot.Namespaces[namespace])})
+	}
+	printSummaryTable("Pods per Namespace", pods)
+
+	if len(snapshot.Warnings) == 0 {
+		return
+	}
+	fmt.Println()
+	for _, warning := range snapshot.Warnings {
+		fmt.Println("Warning:", warning)
+	}
+	nodeType := snapshot.NodeType
+	if nodeType == "" {
+		nodeType = "the chosen node type"
+	}
+	fmt.Printf("The platform components alone nearly fill %s. Consider a larger node type or more nodes before deploying workloads.\n", nodeType)
+}
+
+func resourceSnapshotForConfig() {
+	log.Info("Starting resourceSnapshotForConfig function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations:", err)
+		return
+	}
+	selectedConfig, err := promptConfigSelection(indexFile, "Select the cluster to take a resource snapshot of")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations available. Please create a configuration first.")
+		return
+	}
+
+	snapshot := recordResourceSnapshot(selectedConfig, indexFile.Configs[selectedConfig])
+	if snapshot == nil {
+		fmt.Println("Could not take a resource snapshot. Check that the cluster is reachable with its kubeconfig.")
+		return
+	}
+	if isStructuredOutput() {
+		printStructured(snapshot)
+		return
+	}
+	printResourceSnapshot(snapshot)
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+const (
+	secretBackendOnePassword = "1password"
+	secretBackendDoppler     = "doppler"
+	secretBackendAWS         = "aws-secrets-manager"
+)
+
+// SecretProvider stores secrets and resolves the references generated env files hold in their place
+type SecretProvider interface {
+	Name() string
+	// Scheme prefixes the provider's references, e.g. "doppler" for doppler://project/config/NAME
+	Scheme() string
+	// Check makes sure the provider's CLI is installed and authenticated
+	Check() error
+	// Reference returns the reference stored in .local.cloud.env for the environment variable name
+	Reference(name string) string
+	Resolve(ref string) (string, error)
+	// Store saves a secret under name and returns its reference
+	Store(name, value string) (string, error)
+	// InitCommand is the line 00-init.sh uses to run 01-kubefirst-cloud.sh
+	InitCommand() string
+}
+
+// getSecretProvider returns the provider for secret_backend in settings.hcl; 1Password is the default,
+// since generated scripts have always run through `op run`
+func getSecretProvider(settings Settings) (SecretProvider, error) {
+	switch settings.SecretBackend {
+	case "", secretBackendOnePassword:
+		vault := settings.OnePasswordVault
+		if vault == "" {
+			vault = defaultOnePasswordVault
+		}
+		return onePasswordProvider{Vault: vault}, nil
+	case secretBackendDoppler:
+		return dopplerProvider{Project: settings.Doppler.Project, Config: settings.Doppler.Config}, nil
+	case secretBackendAWS:
+		return awsSecretsManagerProvider{Region: settings.AWSSecretsManager.Region, Prefix: settings.AWSSecretsManager.Prefix}, nil
+	default:
+		return nil, fmt.Errorf("unknown secret_backend %q in settings.hcl; use %s, %s or %s", settings.SecretBackend, secretBackendOnePassword, secretBackendDoppler, secretBackendAWS)
+	}
+}
+
+type onePasswordProvider struct {
+	Vault string
+}
+
+func (p onePasswordProvider) Name() string   { return "1Password" }
+func (p onePasswordProvider) Scheme() string { return "op" }
+func (p onePasswordProvider) Check() error   { return checkOnePasswordCLI() }
+
+func (p onePasswordProvider) Reference(name string) string {
+	return onePasswordRef(p.Vault, name)
+}
+
+func (p onePasswordProvider) Resolve(ref string) (string, error) {
+	return readOnePasswordSecret(ref)
+}
+
+func (p onePasswordProvider) Store(name, value string) (string, error) {
+	title := onePasswordItemTitle(name)
+	if onePasswordItemExists(p.Vault, title) {
+		return "", fmt.Errorf("1Password item %s already exists in vault %s", title, p.Vault)
+	}
+	err := createOnePasswordItem(p.Vault, title, value)
+	if err != nil {
+		return "", err
+	}
+	return p.Reference(name), nil
+}
+
+// op run already exports the resolved file; sourcing it again would put the raw op:// references back
+func (p onePasswordProvider) InitCommand() string {
+	return `K1_ENV_SOURCED=true op run --env-file="./.local.cloud.env" -- sh ./01-kubefirst-cloud.sh`
+}
+
+type dopplerProvider struct {
+	Project string
+	Config  string
+}
+
+func (p dopplerProvider) Name() string   { return "Doppler" }
+func (p dopplerProvider) Scheme() string { return "doppler" }
+
+func (p dopplerProvider) Check() error {
+	if p.Project == "" || p.Config == "" {
+		return fmt.Errorf("set project and config in the doppler block of settings.hcl")
+	}
+	if _, err := exec.LookPath("doppler"); err != nil {
+		return fmt.Errorf("the Doppler CLI could not be found; install it from https://docs.doppler.com/docs/install-cli")
+	}
+	output, err := exec.Command("doppler", "me").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("the Doppler CLI is not logged in (run 'doppler login'): %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (p dopplerProvider) Reference(name string) string {
+	return fmt.Sprintf("doppler://%s/%s/%s", p.Project, p.Config, name)
+}
+
+func (p dopplerProvider) Resolve(ref string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(ref, "doppler://"), "/")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("invalid Doppler reference %s, expected doppler://<project>/<config>/<name>", ref)
+	}
+	output, err := exec.Command("doppler", "secrets", "get", parts[2], "--plain", "--project", parts[0], "--config", parts[1]).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s does not resolve: %s", ref, commandStderr(err))
+	}
+	return strings.TrimSuffix(string(output), "\n"), nil
+}
+
+// Store passes the value on stdin, which doppler reads when no value argument is given
+func (p dopplerProvider) Store(name, value string) (string, error) {
+	cmd := exec.Command("doppler", "secrets", "set", name, "--project", p.Project, "--config", p.Config, "--silent")
+	cmd.Stdin = strings.NewReader(value)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("error setting Doppler secret %s: %w\nOutput: %s", name, err, string(output))
+	}
+	return p.Reference(name), nil
+}
+
+func (p dopplerProvider) InitCommand() string {
+	return resolvedByK1spaceInitCommand
+}
+
+type awsSecretsManagerProvider struct {
+	Region string
+	Prefix string
+}
+
+func (p awsSecretsManagerProvider) Name() string   { return "AWS Secrets Manager" }
+func (p awsSecretsManagerProvider) Scheme() string { return "aws-sm" }
+
+func (p awsSecretsManagerProvider) Check() error {
+	if _, err := exec.LookPath("aws"); err != nil {
+		return fmt.Errorf("the AWS CLI could not be found; install it from https://aws.amazon.com/cli/")
+	}
+	output, err := exec.Command("aws", p.regionArgs("sts", "get-caller-identity")...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("the AWS CLI has no valid credentials: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (p awsSecretsManagerProvider) regionArgs(args ...string) []string {
+	if p.Region != "" {
+		args = append(args, "--region", p.Region)
+	}
+	return args
+}
+
+func (p awsSecretsManagerProvider) Reference(name string) string {
+	prefix := p.Prefix
+	if prefix == "" {
+		prefix = "k1space/"
+	}
+	return "aws-sm://" + prefix + name
+}
+
+// Resolve reads aws-sm://<secret-id>, or one key of a JSON secret with aws-sm://<secret-id>#<key>
+func (p awsSecretsManagerProvider) Resolve(ref string) (string, error) {
+	secretID, key, _ := strings.Cut(strings.TrimPrefix(ref, "aws-sm://"), "#")
+	output, err := exec.Command("aws", p.regionArgs("secretsmanager", "get-secret-value", "--secret-id", secretID, "--query", "SecretString", "--output", "text")...).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s does not resolve: %s", ref, commandStderr(err))
+	}
+	value := strings.TrimSuffix(string(output), "\n")
+	if key == "" {
+		return value, nil
+	}
+
+	var fields map[string]interface{}
+	err = json.Unmarshal([]byte(value), &fields)
+	if err != nil {
+		return "", fmt.Errorf("%s is not a JSON secret, so #%s can't be read from it", secretID, key)
+	}
+	field, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("%s has no key %q", secretID, key)
+	}
+	return fmt.Sprint(field), nil
+}
+
+// Store creates the secret, or adds a new version if it exists; the value goes through a private temp file
+// so it doesn't show up in the process list
+func (p awsSecretsManagerProvider) Store(name, value string) (string, error) {
+	ref := p.Reference(name)
+	secretID := strings.TrimPrefix(ref, "aws-sm://")
+
+	tmpFile, err := os.CreateTemp("", "k1space-secret-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmpFile.Name())
+	_, err = tmpFile.WriteString(value)
+	tmpFile.Close()
+	if err != nil {
+		return "", err
+	}
+
+	valueArg := "file://" + tmpFile.Name()
+	output, err := exec.Command("aws", p.regionArgs("secretsmanager", "create-secret", "--name", secretID, "--secret-string", valueArg)...).CombinedOutput()
+	if err != nil && strings.Contains(string(output), "ResourceExistsException") {
+		output, err = exec.Command("aws", p.regionArgs("secretsmanager", "put-secret-value", "--secret-id", secretID, "--secret-string", valueArg)...).CombinedOutput()
+	}
+	if err != nil {
+		return "", fmt.Errorf("error storing %s in AWS Secrets Manager: %w\nOutput: %s", secretID, err, string(output))
+	}
+	return ref, nil
+}
+
+func (p awsSecretsManagerProvider) InitCommand() string {
+	return resolvedByK1spaceInitCommand
+}
+
+// Backends without an `op run` equivalent are resolved by k1space, which hands 00-init.sh the whole env file
+// with references replaced; running the script by hand would pass the raw references to kubefirst
+const resolvedByK1spaceInitCommand = `if [ -z "$K1_SECRETS_RESOLVED" ]; then
+    echo "Error: secret references in .local.cloud.env are resolved by k1space. Provision this config from k1space ('Cluster' -> 'Provision Cluster')."
+    exit 1
+fi
+sh ./01-kubefirst-cloud.sh`
+
+func commandStderr(err error) string {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return strings.TrimSpace(string(exitErr.Stderr))
+	}
+	return err.Error()
+}
+
+// addSecretReferences points the config's token variables at the configured backend, so generated env files
+// carry references instead of relying on tokens exported in the user's shell. Nothing changes until
+// secret_backend is set, since existing vaults may not hold the tokens yet.
+func addSecretReferences(config *CloudConfig, settings Settings, provider SecretProvider) {
+	if settings.SecretBackend == "" {
+		return
+	}
+
+	// Each variable is mapped to the secret it's read from; a credential profile has its own secret
+	tokenVars := map[string]string{}
+	if tokenVar := cloudTokenVar(config.CloudPrefix); tokenVar != "" {
+		tokenVars[tokenVar] = profileTokenVar(tokenVar, config.CredentialProfile)
+	}
+	if gitProvider, ok := config.Flags.Load("git-provider"); ok {
+		switch strings.ToLower(gitProvider.(string)) {
+		case "github":
+			tokenVars["GITHUB_TOKEN"] = "GITHUB_TOKEN"
+		case "gitlab":
+			tokenVars["GITLAB_TOKEN"] = "GITLAB_TOKEN"
+		}
+	}
+
+	for tokenVar, secretName := range tokenVars {
+		if _, ok := config.EnvOverrides[tokenVar]; !ok {
+			setEnvOverride(config, tokenVar, provider.Reference(secretName))
+		}
+	}
+}
+
+// secretProviderForRef picks the provider a reference belongs to, using settings for its defaults
+func secretProviderForRef(ref string, settings Settings) (SecretProvider, bool) {
+	for _, backend := range []string{secretBackendOnePassword, secretBackendDoppler, secretBackendAWS} {
+		settings.SecretBackend = backend
+		provider, err := getSecretProvider(settings)
+		if err == nil && strings.HasPrefix(ref, provider.Scheme()+"://") {
+			return provider, true
+		}
+	}
+	return nil, false
+}
+
+// resolveEnvFileSecrets reads a .local.cloud.env and resolves every secret reference in it, returning the
+// whole file as environment entries for 00-init.sh
+func resolveEnvFileSecrets(envPath string, settings Settings) ([]string, []string) {
+	content, err := os.ReadFile(envPath)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("error reading %s: %v", envPath, err)}
+	}
+
+	values := make(map[string]string)
+	for _, line := range strings.Split(string(content), "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(kv) != 2 || strings.HasPrefix(kv[0], "#") {
+			continue
+		}
+		values[strings.TrimPrefix(kv[0], "export ")] = strings.Trim(kv[1], "\"")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var env, problems []string
+	checked := make(map[string]error)
+	for _, name := range names {
+		value := values[name]
+		if provider, ok := secretProviderForRef(value, settings); ok {
+			checkErr, seen := checked[provider.Scheme()]
+			if !seen {
+				checkErr = provider.Check()
+				checked[provider.Scheme()] = checkErr
+				if checkErr != nil {
+					problems = append(problems, checkErr.Error())
+				}
+			}
+			if checkErr != nil {
+				continue
+			}
+			value, err = provider.Resolve(value)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+		}
+		env = append(env, name+"="+value)
+	}
+	return append(env, "K1_ENV_SOURCED=true", "K1_SECRETS_RESOLVED=true"), problems
+}
+
+// prepareProvisionSecrets runs before provisioning: 1Password references are left to `op run` in 00-init.sh,
+// other backends are resolved here and returned as the script's environment
+func prepareProvisionSecrets(envPath string) ([]string, bool) {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		fmt.Println("Failed to load settings:", err)
+		return nil, false
+	}
+	provider, err := getSecretProvider(settings)
+	if err != nil {
+		fmt.Println(err)
+		return nil, false
+	}
+	if _, ok := provider.(onePasswordProvider); ok {
+		return nil, confirmOnePasswordReady(envPath)
+	}
+
+	s := startSpinner(fmt.Sprintf("Resolving secrets from %s...", provider.Name()))
+	env, problems := resolveEnvFileSecrets(envPath, settings)
+	stopSpinner(s, len(problems) == 0)
+	if len(problems) > 0 {
+		log.Error("Error resolving secrets", "backend", provider.Name(), "problems", problems)
+		fmt.Println(style.Render("❌ Some secrets in .local.cloud.env could not be resolved"))
+		for _, problem := range problems {
+			fmt.Printf("  - %s\n", problem)
+		}
+		return nil, false
+	}
+	return env, true
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+// Thresholds for flagging a string as a token by its entropy, in bits per character. Hex tokens, like Linode's,
+// top out at 4 bits, so they're held to a lower bar but must be longer than a 40-character commit hash.
+const (
+	minHexEntropyTokenLength = 48
+	base64EntropyThreshold   = 4.5
+	hexEntropyThreshold      = 3.0
+)
+
+// Generated files larger than this aren't scripts or env files k1space wrote
+const maxScannedFileSize = 1 << 20
+
+var (
+	// Runs of token characters long enough to be checked for entropy
+	entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/=_\-.]{20,}`)
+	hexString        = regexp.MustCompile(`^[a-fA-F0-9]+$`)
+	envVarName       = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Variables tokens in a well-known format are moved to when they aren't assigned to one
+var tokenPrefixVars = map[string]string{
+	"ghp_":        "GITHUB_TOKEN",
+	"github_pat_": "GITHUB_TOKEN",
+	"glpat-":      "GITLAB_TOKEN",
+	"dop_v1_":     "DO_TOKEN",
+	"hvs.":        "VAULT_TOKEN",
+}
+
+// generatedSecret is a raw token found in a generated script or env file
+type generatedSecret struct {
+	Path string
+	Line int
+	// Name is the variable the token is assigned to, "" when it's embedded in a command
+	Name   string
+	Value  string
+	Reason string
+}
+
+// shannonEntropy is the average information per character of s, in bits
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	var entropy float64
+	length := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// looksRandom reports whether s has the entropy of a generated token rather than a name, path or digest
+func looksRandom(s string) bool {
+	if hexString.MatchString(s) {
+		return len(s) >= minHexEntropyTokenLength && shannonEntropy(s) >= hexEntropyThreshold
+	}
+	hasDigit := strings.ContainsAny(s, "0123456789")
+	hasLetter := strings.IndexFunc(s, func(r rune) bool { return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') }) >= 0
+	return hasDigit && hasLetter && shannonEntropy(s) >= base64EntropyThreshold
+}
+
+// scanSecretLine finds the raw tokens on one line: plaintext values of secret variables, well-known token formats,
+// and other high-entropy strings
+func scanSecretLine(line string, settings Settings) []generatedSecret {
+	var found []generatedSecret
+	seen := func(value string) bool {
+		for _, secret := range found {
+			if strings.Contains(secret.Value, value) || strings.Contains(value, secret.Value) {
+				return true
+			}
+		}
+		return false
+	}
+	// Any variable a token is assigned to names it, even one that isn't named like a secret
+	assignedTo := make(map[string]string)
+	for _, match := range secretAssignment.FindAllStringSubmatch(line, -1) {
+		assignedTo[match[2]] = match[1]
+	}
+
+	if name, value := plaintextSecretAssignment(line, settings); name != "" {
+		found = append(found, generatedSecret{Name: name, Value: value, Reason: "plaintext " + name})
+	}
+	for _, pattern := range secretPatterns {
+		for _, match := range pattern.FindAllStringSubmatch(line, -1) {
+			// Leave out a "Bearer " prefix
+			value := match[0]
+			if len(match) > 1 {
+				value = strings.TrimPrefix(value, match[1])
+			}
+			if len(value) < minSecretLength || seen(value) {
+				continue
+			}
+			found = append(found, generatedSecret{Name: assignedTo[value], Value: value, Reason: "token format"})
+		}
+	}
+	for _, index := range entropyCandidate.FindAllStringIndex(line, -1) {
+		value := line[index[0]:index[1]]
+		if seen(value) || strings.HasSuffix(line[:index[0]], "sha256:") || !looksRandom(value) {
+			continue
+		}
+		if _, ok := secretProviderForRef(value, settings); ok {
+			continue
+		}
+		found = append(found, generatedSecret{Name: assignedTo[value], Value: value, Reason: "high entropy"})
+	}
+	return found
+}
+
+// scanGeneratedFile scans a text file line by line, skipping binaries and anything too large to be generated
+func scanGeneratedFile(path string, settings Settings) ([]generatedSecret, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxScannedFileSize {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(content, 0) >= 0 {
+		return nil, nil
+	}
+
+	var findings []generatedSecret
+	for i, line := range strings.Split(string(content), "\n") {
+		for _, finding := range scanSecretLine(line, settings) {
+			finding.Path, finding.Line = path, i+1
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+// generatedFiles lists what k1space writes that may end up holding a token: every file in a config's directory
+// and the kubefirst-api setup script
+func generatedFiles(workspace string) []string {
+	var files []string
+	envFiles, _ := filepath.Glob(filepath.Join(workspace, "*", "*", "*", ".local.cloud.env"))
+	for _, envFile := range envFiles {
+		files = append(files, configDirFiles(filepath.Dir(envFile))...)
+	}
+	setupScript := filepath.Join(workspace, ".repositories", "kubefirst-api", "setup_and_run.sh")
+	if _, err := os.Stat(setupScript); err == nil {
+		files = append(files, setupScript)
+	}
+	return files
+}
+
+// configDirFiles lists the files in a config's directory, leaving out logs
+func configDirFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && !strings.HasSuffix(entry.Name(), ".log") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return files
+}
+
+func scanGeneratedFiles(paths []string, settings Settings) []generatedSecret {
+	var findings []generatedSecret
+	for _, path := range paths {
+		fileFindings, err := scanGeneratedFile(path, settings)
+		if err != nil {
+			log.Warn("Error scanning file for secrets", "path", path, "error", err)
+			continue
+		}
+		findings = append(findings, fileFindings...)
+	}
+	return findings
+}
+
+// maskSecret keeps enough of a token to recognise it, like the prefix of a GitHub token
+func maskSecret(value string) string {
+	shown := 4
+	if len(value) < 12 {
+		shown = 2
+	}
+	return value[:shown] + strings.Repeat("*", 8) + fmt.Sprintf(" (%d chars)", len(value))
+}
+
+// configEnvFile returns the .local.cloud.env next to a generated file, "" when the file isn't in a config's
+// directory and so has nowhere to read a reference from
+func configEnvFile(path string) string {
+	envFile := filepath.Join(filepath.Dir(path), ".local.cloud.env")
+	if _, err := os.Stat(envFile); err != nil {
+		return ""
+	}
+	return envFile
+}
+
+// secretVarName picks the variable a token is moved to: the one it's assigned to, or the usual one for its format
+func secretVarName(finding generatedSecret) string {
+	if finding.Name != "" {
+		return finding.Name
+	}
+	for prefix, name := range tokenPrefixVars {
+		if strings.HasPrefix(finding.Value, prefix) {
+			return name
+		}
+	}
+	return ""
+}
+
+func displayPath(workspace, path string) string {
+	if rel, err := filepath.Rel(workspace, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// warnGeneratedSecrets runs after a config's files are generated and points out any raw tokens in them
+func warnGeneratedSecrets(baseDir string, settings Settings) {
+	findings := scanGeneratedFiles(configDirFiles(baseDir), settings)
+	if len(findings) == 0 {
+		return
+	}
+	fmt.Println(style.Render("⚠️  The generated files hold raw tokens"))
+	for _, finding := range findings {
+		fmt.Printf("  - %s:%d: %s %s\n", displayPath(getWorkspaceDir(), finding.Path), finding.Line, finding.Reason, maskSecret(finding.Value))
+	}
+	fmt.Println("Move them into your secret backend with 'Config' -> 'Scan Generated Files for Secrets'.")
+}
+
+// scanGeneratedSecrets is the 'Scan Generated Files for Secrets' menu item. It lists the raw tokens in generated
+// files and offers to store them in the secret backend, leaving references in their place.
+func scanGeneratedSecrets() {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		fmt.Println("Failed to load settings:", err)
+		return
+	}
+	workspace := getWorkspaceDir()
+	paths := generatedFiles(workspace)
+	findings := scanGeneratedFiles(paths, settings)
+	if len(findings) == 0 {
+		fmt.Printf("No raw tokens found in %d generated files.\n", len(paths))
+		return
+	}
+
+	rows := [][]string{{"File", "Line", "Variable", "Found", "Value"}}
+	for _, finding := range findings {
+		rows = append(rows, []string{displayPath(workspace, finding.Path), strconv.Itoa(finding.Line), finding.Name, finding.Reason, maskSecret(finding.Value)})
+	}
+	printSummaryTable("Secrets in Generated Files", rows)
+	if isStructuredOutput() {
+		return
+	}
+
+	// A token that shows up in several places is stored once
+	var values []string
+	byValue := make(map[string][]generatedSecret)
+	unmovable := 0
+	for _, finding := range findings {
+		if configEnvFile(finding.Path) == "" {
+			unmovable++
+			continue
+		}
+		if _, ok := byValue[finding.Value]; !ok {
+			values = append(values, finding.Value)
+		}
+		byValue[finding.Value] = append(byValue[finding.Value], finding)
+	}
+	if unmovable > 0 {
+		fmt.Printf("\n%d of these are outside a config's directory, so there's no .local.cloud.env to hold a reference; remove them by hand.\n", unmovable)
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	provider, err := getSecretProvider(settings)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	s := startSpinner(fmt.Sprintf("Checking %s...", provider.Name()))
+	err = provider.Check()
+	stopSpinner(s, err == nil)
+	if err != nil {
+		log.Error("Secret backend unavailable", "backend", provider.Name(), "error", err)
+		fmt.Println(err)
+		return
+	}
+
+	options := make([]huh.Option[string], 0, len(values))
+	for _, value := range values {
+		first := byValue[value][0]
+		label := fmt.Sprintf("%s:%d %s %s", displayPath(workspace, first.Path), first.Line, first.Reason, maskSecret(value))
+		if len(byValue[value]) > 1 {
+			label += fmt.Sprintf(" (+%d more)", len(byValue[value])-1)
+		}
+		options = append(options, huh.NewOption(label, value).Selected(true))
+	}
+	var selected []string
+	err = runField(newMultiSelect(fmt.Sprintf("Move these tokens into %s?", provider.Name()), &selected, options...).
+		Description("Each is stored in the backend and replaced with a reference to it."))
+	if err != nil {
+		log.Error("Error selecting secrets to move", "error", err)
+		return
+	}
+
+	summary := [][]string{{"Variable", "Reference", "Status"}}
+	editedEnvFiles := make(map[string]bool)
+	for _, value := range selected {
+		name, ref, err := moveSecretToBackend(byValue[value], provider)
+		if err != nil {
+			log.Error("Error moving secret to backend", "variable", name, "error", err)
+			summary = append(summary, []string{name, ref, "Failed: " + err.Error()})
+			continue
+		}
+		summary = append(summary, []string{name, ref, fmt.Sprintf("Moved from %d places", len(byValue[value]))})
+		for _, finding := range byValue[value] {
+			editedEnvFiles[configEnvFile(finding.Path)] = true
+		}
+	}
+	if len(summary) > 1 {
+		printSummaryTable("Moved Secrets", summary)
+	}
+
+	// config.hcl keeps the flags from .local.cloud.env, which may have held a moved token
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Warn("Error loading index file", "error", err)
+	} else {
+		for _, envFile := range sortedKeys(editedEnvFiles) {
+			rel, _ := filepath.Rel(workspace, filepath.Dir(envFile))
+			configName := strings.ReplaceAll(rel, string(filepath.Separator), "_")
+			if _, ok := indexFile.Configs[configName]; ok {
+				reindexConfigFlags(configName, envFile)
+			}
+		}
+	}
+	// Env files that no longer hold plaintext secrets drop out of the workspace .gitignore
+	maintainWorkspaceGit()
+}
+
+// moveSecretToBackend stores a token and replaces each place it was found: in .local.cloud.env with the reference,
+// and in scripts with the variable, whose reference is added to the config's .local.cloud.env
+func moveSecretToBackend(findings []generatedSecret, provider SecretProvider) (string, string, error) {
+	name := ""
+	for _, finding := range findings {
+		if name = secretVarName(finding); name != "" {
+			break
+		}
+	}
+	if name == "" {
+		first := findings[0]
+		err := runField(newInput(fmt.Sprintf("Variable to hold the token on %s:%d", filepath.Base(first.Path), first.Line), &name).
+			Description(maskSecret(first.Value)).
+			Validate(func(s string) error {
+				if !envVarName.MatchString(s) {
+					return fmt.Errorf("use letters, digits and underscores, e.g. API_TOKEN")
+				}
+				return nil
+			}))
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	value := findings[0].Value
+	ref, err := provider.Store(name, value)
+	if err != nil {
+		return name, "", err
+	}
+
+	for _, finding := range findings {
+		envFile := configEnvFile(finding.Path)
+		replacement := ref
+		if finding.Path != envFile {
+			replacement = "${" + name + "}"
+			err = setEnvFileExport(envFile, name, ref)
+			if err != nil {
+				return name, ref, fmt.Errorf("error adding %s to %s: %w", name, envFile, err)
+			}
+		}
+		err = replaceInFile(finding.Path, value, replacement)
+		if err != nil {
+			return name, ref, err
+		}
+		log.Info("Replaced secret with reference", "path", finding.Path, "line", finding.Line, "variable", name)
+	}
+	return name, ref, nil
+}
+
+// replaceInFile rewrites every occurrence of old in a file, keeping its permissions
+func replaceInFile(path, old, replacement string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	updated := strings.ReplaceAll(string(content), old, replacement)
+	if updated == string(content) {
+		return nil
+	}
+	return os.WriteFile(path, []byte(updated), info.Mode().Perm())
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Log levels the dashboard can restart a service with
+var serviceLogLevels = []string{"debug", "info", "warn", "error"}
+
+// How long a service gets to stop after SIGTERM before its process group is killed
+const serviceStopGrace = 10 * time.Second
+
+// localService is a service run by 'Run Kubefirst Repositories'. It runs in its own process group, so a restart
+// also stops what it started, like air or the binary `go run` built.
+type localService struct {
+	name string
+
+	mu  sync.Mutex
+	cmd *exec.Cmd
+	// level is the log level the service was last restarted with, "" for its own default
+	level      string
+	restarting bool
+	stopped    bool
+}
+
+func newLocalService(name string) *localService {
+	return &localService{name: name}
+}
+
+// logLevelEnv is layered on top of the service's environment. LOG_LEVEL is read by kubefirst-api and kubefirst,
+// and DEBUG turns on the console's debug logging.
+func (s *localService) logLevelEnv() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.level == "" {
+		return nil
+	}
+	return []string{"LOG_LEVEL=" + s.level, fmt.Sprintf("DEBUG=%t", s.level == "debug")}
+}
+
+func (s *localService) describeLevel() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.level == "" {
+		return "default"
+	}
+	return s.level
+}
+
+// pid is the running process's ID, which is also its process group's, or 0 while the service isn't running
+func (s *localService) pid() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cmd == nil || s.cmd.Process == nil {
+		return 0
+	}
+	return s.cmd.Process.Pid
+}
+
+func (s *localService) setRunning(cmd *exec.Cmd) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cmd = cmd
+}
+
+// terminate stops the running process group, killing it if it's still up after serviceStopGrace
+func (s *localService) terminate(cmd *exec.Cmd) {
+	pid := cmd.Process.Pid
+	if err := signalProcessGroup(pid, syscall.SIGTERM); err != nil {
+		log.Warn("Error stopping service", "service", s.name, "error", err)
+	}
+	go func() {
+		time.Sleep(serviceStopGrace)
+		s.mu.Lock()
+		stillRunning := s.cmd == cmd
+		s.mu.Unlock()
+		if stillRunning {
+			log.Warn("Killing service that didn't stop", "service", s.name, "pid", pid)
+			signalProcessGroup(pid, syscall.SIGKILL)
+		}
+	}()
+}
+
+// restartWithLevel stops the service so its runner starts it again with level
+func (s *localService) restartWithLevel(level string) error {
+	if !contains(serviceLogLevels, level) {
+		return fmt.Errorf("unknown log level %q, use %s", level, strings.Join(serviceLogLevels, ", "))
+	}
+	s.mu.Lock()
+	cmd := s.cmd
+	if cmd == nil || s.stopped {
+		s.mu.Unlock()
+		return fmt.Errorf("%s isn't running", s.name)
+	}
+	s.level, s.restarting = level, true
+	s.mu.Unlock()
+
+	log.Info("Restarting service with log level", "service", s.name, "level", level)
+	s.terminate(cmd)
+	return nil
+}
+
+// takeRestart reports whether the service exited because a restart was requested
+func (s *localService) takeRestart() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	restarting := s.restarting
+	s.restarting = false
+	return restarting && !s.stopped
+}
+
+func (s *localService) isStopped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopped
+}
+
+// stop ends the service for good
+func (s *localService) stop() {
+	s.mu.Lock()
+	s.stopped = true
+	cmd := s.cmd
+	s.mu.Unlock()
+	if cmd != nil {
+		s.terminate(cmd)
+	}
+}
+
+// stopServicesAndWait stops the services and waits for their process groups to exit, killing the ones still
+// running after serviceStopGrace. terminate's own fallback can't be relied on when k1space exits right after.
+func stopServicesAndWait(services []*localService) {
+	pids := make(map[*localService]int)
+	for _, service := range services {
+		if pid := service.pid(); pid != 0 {
+			pids[service] = pid
+		}
+		service.stop()
+	}
+
+	deadline := time.Now().Add(serviceStopGrace)
+	for service, pid := range pids {
+		for processGroupAlive(pid) && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if processGroupAlive(pid) {
+			log.Warn("Killing service that didn't stop", "service", service.name, "pid", pid)
+			signalProcessGroup(pid, syscall.SIGKILL)
+		}
+	}
+}
+
+// stopServicesOnInterrupt stops the services before k1space exits on Ctrl+C, SIGTERM or a hangup, since their
+// process groups don't get the terminal's signals. The returned function stops watching.
+func stopServicesOnInterrupt(services []*localService) func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		select {
+		case sig := <-signals:
+			log.Warn("Stopping services", "signal", sig)
+			stopServicesAndWait(services)
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// How often the dashboard samples the services' processes, and when a service counts as runaway: its CPU has
+// stayed above runawayCPUPercent (100 is one core) for runawayCPUSamples samples in a row, or its memory is above
+// runawayMemory
+const (
+	serviceUsageInterval = 5 * time.Second
+	runawayCPUPercent    = 200
+	runawayCPUSamples    = 3
+	runawayMemory        = 2 << 30
+)
+
+// processGroupUsage is what a process group's live processes use
+type processGroupUsage struct {
+	processes int
+	memory    int64
+	cpuTime   time.Duration
+}
+
+func (u processGroupUsage) add(other processGroupUsage) processGroupUsage {
+	return processGroupUsage{
+		processes: u.processes + other.processes,
+		memory:    u.memory + other.memory,
+		cpuTime:   u.cpuTime + other.cpuTime,
+	}
+}
+
+// sampleProcess reads one process's resident memory and CPU time. It's false for processes that exited while
+// being sampled, or that can't be read.
+func sampleProcess(p *process.Process) (processGroupUsage, bool) {
+	memory, err := p.MemoryInfo()
+	if err != nil {
+		return processGroupUsage{}, false
+	}
+	times, err := p.Times()
+	if err != nil {
+		return processGroupUsage{}, false
+	}
+	return processGroupUsage{
+		processes: 1,
+		memory:    int64(memory.RSS),
+		cpuTime:   time.Duration((times.User + times.System) * float64(time.Second)),
+	}, true
+}
+
+// serviceUsage is a service's resource usage as of the last sample. Each service runs in its own process group,
+// so it includes what the service started, like yarn's node processes or the binary air rebuilt.
+type serviceUsage struct {
+	Name      string
+	Running   bool
+	Processes int
+	Memory    int64
+	// CPUPercent is -1 until two samples of the same run have been taken
+	CPUPercent float64
+	// The highest usage seen since the service was last started
+	PeakCPUPercent float64
+	PeakMemory     int64
+	// Runaway says why the service looks runaway, "" when it doesn't
+	Runaway string
+}
+
+// serviceRun is the last sample of a service's current run
+type serviceRun struct {
+	pid        int
+	cpuTime    time.Duration
+	at         time.Time
+	peakCPU    float64
+	peakMemory int64
+}
+
+// serviceUsageSampler keeps sampling the local services' process groups for the dashboard
+type serviceUsageSampler struct {
+	services []*localService
+
+	mu    sync.Mutex
+	usage []serviceUsage
+	last  map[string]serviceRun
+	// hot counts the samples in a row a service's CPU has been above runawayCPUPercent
+	hot map[string]int
+}
+
+func newServiceUsageSampler(services []*localService) *serviceUsageSampler {
+	return &serviceUsageSampler{services: services, last: make(map[string]serviceRun), hot: make(map[string]int)}
+}
+
+// run samples the services until stop is closed, or until it finds processes can't be sampled on this system
+func (s *serviceUsageSampler) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(serviceUsageInterval)
+	defer ticker.Stop()
+	for {
+		groups, err := sampleProcessGroups()
+		if err != nil {
+			log.Warn("Not showing the services' resource usage", "error", err)
+			return
+		}
+		s.sample(groups, time.Now())
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *serviceUsageSampler) sample(groups map[int]processGroupUsage, now time.Time) {
+	usage := make([]serviceUsage, 0, len(s.services))
+	for _, service := range s.services {
+		current := serviceUsage{Name: service.name, CPUPercent: -1}
+		pid := service.pid()
+		group, ok := groups[pid]
+		if pid == 0 || !ok {
+			delete(s.last, service.name)
+			s.hot[service.name] = 0
+			usage = append(usage, current)
+			continue
+		}
+		current.Running, current.Processes, current.Memory = true, group.processes, group.memory
+
+		run, ok := s.last[service.name]
+		if !ok || run.pid != pid {
+			run = serviceRun{pid: pid}
+		} else if now.After(run.at) {
+			// CPU time only grows, unless a process in the group exited and took its share with it
+			used := group.cpuTime - run.cpuTime
+			if used < 0 {
+				used = 0
+			}
+			current.CPUPercent = float64(used) / float64(now.Sub(run.at)) * 100
+		}
+		run.cpuTime, run.at = group.cpuTime, now
+		run.peakCPU = max(run.peakCPU, current.CPUPercent)
+		run.peakMemory = max(run.peakMemory, current.Memory)
+		current.PeakCPUPercent, current.PeakMemory = run.peakCPU, run.peakMemory
+		s.last[service.name] = run
+
+		if current.CPUPercent >= runawayCPUPercent {
+			s.hot[service.name]++
+		} else {
+			s.hot[service.name] = 0
+		}
+		switch {
+		case current.Memory >= runawayMemory:
+			current.Runaway = fmt.Sprintf("using over %s of memory", formatFileSize(runawayMemory))
+		case s.hot[service.name] >= runawayCPUSamples:
+			current.Runaway = fmt.Sprintf("above %d%% CPU for %s", runawayCPUPercent, time.Duration(s.hot[service.name])*serviceUsageInterval)
+		}
+		usage = append(usage, current)
+	}
+
+	s.mu.Lock()
+	s.usage = usage
+	s.mu.Unlock()
+}
+
+func (s *serviceUsageSampler) snapshot() []serviceUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]serviceUsage(nil), s.usage...)
+}
+
+// renderServiceUsage lists each service's CPU and memory for the dashboard summary, flagging runaway ones
+func renderServiceUsage(usage []serviceUsage) string {
+	width := 0
+	for _, service := range usage {
+		width = max(width, len(service.Name))
+	}
+	lines := make([]string, 0, len(usage))
+	for _, service := range usage {
+		if !service.Running {
+			lines = append(lines, fmt.Sprintf("   %-*s  not running", width, service.Name))
+			continue
+		}
+		cpu := "measuring"
+		if service.CPUPercent >= 0 {
+			cpu = fmt.Sprintf("%.0f%% (peak %.0f%%)", service.CPUPercent, service.PeakCPUPercent)
+		}
+		memory := fmt.Sprintf("%s (peak %s)", formatFileSize(service.Memory), formatFileSize(service.PeakMemory))
+		line := fmt.Sprintf("%-*s  CPU %-18s  Memory %-22s  %d processes", width, service.Name, cpu, memory, service.Processes)
+		if service.Runaway != "" {
+			lines = append(lines, "⚠️ "+line+" ("+service.Runaway+")")
+		} else {
+			lines = append(lines, "   "+line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
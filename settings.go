@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func getSettingsPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "settings.hcl")
+}
+
+// loadSettings reads settings.hcl, falling back to defaults when it doesn't exist
+func loadSettings() (Settings, error) {
+	settingsPath := getSettingsPath()
+	settings := Settings{}
+
+	data, err := os.ReadFile(settingsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return settings, fmt.Errorf("error reading settings.hcl: %w", err)
+	}
+
+	if err == nil {
+		file, diags := hclsyntax.ParseConfig(data, settingsPath, hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return settings, fmt.Errorf("error parsing settings.hcl: %s", diags)
+		}
+
+		diags = gohcl.DecodeBody(file.Body, nil, &settings)
+		if diags.HasErrors() {
+			return settings, fmt.Errorf("error decoding settings.hcl: %s", diags)
+		}
+	}
+
+	if settings.SharedCache == nil {
+		settings.SharedCache = &SharedCache{}
+	}
+	if settings.Doppler == nil {
+		settings.Doppler = &DopplerSettings{}
+	}
+	if settings.AWSSecretsManager == nil {
+		settings.AWSSecretsManager = &AWSSecretsManager{}
+	}
+	if settings.NamingPolicy == nil {
+		settings.NamingPolicy = &NamingPolicy{}
+	}
+	err = settings.NamingPolicy.compile()
+	if err != nil {
+		return settings, fmt.Errorf("invalid naming_policy in settings.hcl: %w", err)
+	}
+
+	return settings, nil
+}
+
+// saveSetting sets a top-level attribute in settings.hcl, keeping the rest of the file (and its comments) intact
+func saveSetting(name string, value cty.Value) error {
+	settingsPath := getSettingsPath()
+	return withFileLock(settingsPath, func() error {
+		data, err := os.ReadFile(settingsPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error reading settings.hcl: %w", err)
+		}
+
+		f, diags := hclwrite.ParseConfig(data, settingsPath, hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			return fmt.Errorf("error parsing settings.hcl: %s", diags)
+		}
+		f.Body().SetAttributeValue(name, value)
+
+		err = writeFileAtomic(settingsPath, f.Bytes(), 0644)
+		if err != nil {
+			return fmt.Errorf("error writing settings.hcl: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// expandHome resolves a leading ~/ so cache paths in settings.hcl can be written portably
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(os.Getenv("HOME"), strings.TrimPrefix(path, "~"))
+	}
+	return path
+}
+
+// sharedCacheEnv returns GOMODCACHE, GOCACHE and TF_PLUGIN_CACHE_DIR for commands that build kubefirst
+// components or run terraform, so jump hosts and CI runners can share warm caches between workspaces
+func sharedCacheEnv() []string {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings, using default caches", "error", err)
+		settings.SharedCache = &SharedCache{}
+	}
+
+	env := []string{"TF_PLUGIN_CACHE_DIR=" + getTerraformPluginCacheDir()}
+	for name, path := range map[string]string{
+		"GOMODCACHE": settings.SharedCache.GoModCache,
+		"GOCACHE":    settings.SharedCache.GoBuildCache,
+	} {
+		if path == "" {
+			continue
+		}
+		path = expandHome(path)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			log.Warn("Error creating shared cache directory, using the default", "env", name, "path", path, "error", err)
+			continue
+		}
+		env = append(env, name+"="+path)
+	}
+	return env
+}
@@ -0,0 +1,52 @@
+package main
+
+import "fmt"
+
+// spotCapacity describes how kubefirst requests spot/preemptible capacity for a provider
+type spotCapacity struct {
+	// Flags are the exact create flag names that toggle spot node pools
+	Flags          []string
+	EvictionNotice string
+}
+
+// Providers with spot capacity, and the kubefirst flags that request it. The prompt only appears when the
+// selected binary has one of the flags; k1space doesn't request spot through terraform overrides kubefirst's
+// templates don't read.
+var spotCapacitySupport = map[string]spotCapacity{
+	"Google": {
+		Flags:          []string{"preemptible", "spot"},
+		EvictionNotice: "Google Cloud can preempt spot VMs at any time with a 30-second warning.",
+	},
+}
+
+// findSpotFlag returns the kubefirst flag that toggles spot capacity for a provider, if the binary exposes one
+func findSpotFlag(cloudProvider string, flags map[string]string) string {
+	return findKubefirstFlag(flags, spotCapacitySupport[cloudProvider].Flags)
+}
+
+// promptSpotCapacity asks whether to use spot node pools and sets the kubefirst flag that requests them.
+// It returns the flag, so the caller can leave it out of the flag form.
+func promptSpotCapacity(config *CloudConfig, flags map[string]string) (string, error) {
+	spotFlag := findSpotFlag(config.CloudPrefix, flags)
+	if spotFlag == "" {
+		return "", nil
+	}
+	evictionNotice := spotCapacitySupport[config.CloudPrefix].EvictionNotice
+
+	var useSpot bool
+	err := runField(newConfirm("Do you want to run worker nodes on spot/preemptible capacity?", &useSpot).
+		Description(fmt.Sprintf("Spot nodes are much cheaper but can be evicted without notice. %s\nWorkloads on evicted nodes are rescheduled, so avoid spot for single-replica stateful services and the management cluster.", evictionNotice)))
+	if err != nil {
+		return "", err
+	}
+
+	if !useSpot {
+		return spotFlag, nil
+	}
+	config.Spot = true
+	config.Flags.Store(spotFlag, "true")
+
+	fmt.Println(style.Render("⚠️  Spot capacity enabled"))
+	fmt.Println(evictionNotice)
+	return spotFlag, nil
+}
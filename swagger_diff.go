@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Where `make updateswagger` (swag init) writes the kubefirst-api spec
+const kubefirstAPISwaggerFile = "docs/swagger.json"
+
+// swaggerChange is one difference in the API surface between the committed and regenerated swagger.json
+type swaggerChange struct {
+	Kind      string `json:"kind" yaml:"kind"` // added, removed or changed
+	Operation string `json:"operation" yaml:"operation"`
+	Detail    string `json:"detail,omitempty" yaml:"detail,omitempty"`
+	Breaking  bool   `json:"breaking" yaml:"breaking"`
+}
+
+// swaggerOperation is the part of an operation that clients depend on
+type swaggerOperation struct {
+	// Parameter "in:name" to whether it's required
+	Params    map[string]bool
+	Responses map[string]bool
+}
+
+func parseSwaggerOperations(data []byte) (map[string]swaggerOperation, error) {
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Parameters []struct {
+				Name     string `json:"name"`
+				In       string `json:"in"`
+				Required bool   `json:"required"`
+			} `json:"parameters"`
+			Responses map[string]json.RawMessage `json:"responses"`
+		} `json:"paths"`
+	}
+	err := json.Unmarshal(data, &spec)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing swagger.json: %w", err)
+	}
+
+	operations := make(map[string]swaggerOperation)
+	for path, methods := range spec.Paths {
+		for method, op := range methods {
+			operation := swaggerOperation{Params: make(map[string]bool), Responses: make(map[string]bool)}
+			for _, param := range op.Parameters {
+				operation.Params[param.In+":"+param.Name] = param.Required
+			}
+			for code := range op.Responses {
+				operation.Responses[code] = true
+			}
+			operations[strings.ToUpper(method)+" "+path] = operation
+		}
+	}
+	return operations, nil
+}
+
+// diffSwagger compares two specs; removed operations, removed or newly required parameters
+// and removed response codes are flagged as breaking
+func diffSwagger(committed, current []byte) ([]swaggerChange, error) {
+	before, err := parseSwaggerOperations(committed)
+	if err != nil {
+		return nil, err
+	}
+	after, err := parseSwaggerOperations(current)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []swaggerChange
+	for name, old := range before {
+		updated, ok := after[name]
+		if !ok {
+			changes = append(changes, swaggerChange{Kind: "removed", Operation: name, Breaking: true})
+			continue
+		}
+		for param, required := range old.Params {
+			nowRequired, ok := updated.Params[param]
+			switch {
+			case !ok:
+				changes = append(changes, swaggerChange{Kind: "changed", Operation: name, Detail: "removed parameter " + param, Breaking: true})
+			case nowRequired && !required:
+				changes = append(changes, swaggerChange{Kind: "changed", Operation: name, Detail: "parameter " + param + " is now required", Breaking: true})
+			}
+		}
+		for param, required := range updated.Params {
+			if _, ok := old.Params[param]; !ok {
+				changes = append(changes, swaggerChange{Kind: "changed", Operation: name, Detail: "added parameter " + param, Breaking: required})
+			}
+		}
+		for code := range old.Responses {
+			if !updated.Responses[code] {
+				changes = append(changes, swaggerChange{Kind: "changed", Operation: name, Detail: "removed response " + code, Breaking: true})
+			}
+		}
+		for code := range updated.Responses {
+			if !old.Responses[code] {
+				changes = append(changes, swaggerChange{Kind: "changed", Operation: name, Detail: "added response " + code})
+			}
+		}
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			changes = append(changes, swaggerChange{Kind: "added", Operation: name})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Breaking != changes[j].Breaking {
+			return changes[i].Breaking
+		}
+		if changes[i].Operation != changes[j].Operation {
+			return changes[i].Operation < changes[j].Operation
+		}
+		return changes[i].Detail < changes[j].Detail
+	})
+	return changes, nil
+}
+
+// apiSwaggerChanges diffs kubefirst-api's working copy of swagger.json against the one committed at HEAD
+func apiSwaggerChanges(apiDir string) ([]swaggerChange, error) {
+	current, err := os.ReadFile(filepath.Join(apiDir, kubefirstAPISwaggerFile))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", kubefirstAPISwaggerFile, err)
+	}
+	committed, err := exec.Command("git", "-C", apiDir, "show", "HEAD:"+kubefirstAPISwaggerFile).Output()
+	if err != nil {
+		return nil, fmt.Errorf("error reading committed %s: %w", kubefirstAPISwaggerFile, err)
+	}
+	return diffSwagger(committed, current)
+}
+
+// swaggerDiffCache recomputes the diff only when swagger.json is rewritten, e.g. by make updateswagger
+type swaggerDiffCache struct {
+	apiDir  string
+	modTime time.Time
+	changes []swaggerChange
+	err     error
+}
+
+func (c *swaggerDiffCache) refresh() {
+	info, err := os.Stat(filepath.Join(c.apiDir, kubefirstAPISwaggerFile))
+	if err != nil {
+		c.changes, c.err = nil, nil
+		return
+	}
+	if info.ModTime().Equal(c.modTime) {
+		return
+	}
+	c.modTime = info.ModTime()
+	c.changes, c.err = apiSwaggerChanges(c.apiDir)
+}
+
+func renderSwaggerSection(c *swaggerDiffCache, height int) string {
+	var body strings.Builder
+	switch {
+	case c.err != nil:
+		body.WriteString(c.err.Error())
+	case c.modTime.IsZero():
+		body.WriteString("Waiting for make updateswagger...")
+	case len(c.changes) == 0:
+		body.WriteString("No API surface changes")
+	default:
+		for i, change := range c.changes {
+			if i == height {
+				body.WriteString(fmt.Sprintf("... and %d more (Kubefirst -> Show Swagger Changes)", len(c.changes)-height))
+				break
+			}
+			body.WriteString(truncateOrWrap(formatSwaggerChange(change), 178) + "\n")
+		}
+	}
+	return swaggerStyle.Render(
+		titleStyle.Render("API Surface Changes") + "\n" +
+			pathStyle.Render(filepath.Join(c.apiDir, kubefirstAPISwaggerFile)) + "\n" +
+			strings.TrimRight(body.String(), "\n"),
+	)
+}
+
+func formatSwaggerChange(change swaggerChange) string {
+	marker := map[string]string{"added": "+", "removed": "-", "changed": "~"}[change.Kind]
+	line := marker + " " + change.Operation
+	if change.Detail != "" {
+		line += ": " + change.Detail
+	}
+	if change.Breaking {
+		line += " (breaking)"
+	}
+	return line
+}
+
+func showSwaggerChanges() {
+	apiDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".repositories", "kubefirst-api")
+	changes, err := apiSwaggerChanges(apiDir)
+	if err != nil {
+		log.Error("Error diffing swagger", "error", err)
+		fmt.Println("Failed to compare swagger.json:", err)
+		fmt.Println("Run 'Setup Kubefirst' or 'make updateswagger' in kubefirst-api first.")
+		return
+	}
+
+	if isStructuredOutput() {
+		printStructured(changes)
+		return
+	}
+	if len(changes) == 0 {
+		fmt.Println(style.Render("No API surface changes against HEAD."))
+		return
+	}
+
+	breaking := 0
+	summary := [][]string{{"Change", "Operation", "Detail", "Breaking"}}
+	for _, change := range changes {
+		flag := ""
+		if change.Breaking {
+			flag = "yes"
+			breaking++
+		}
+		summary = append(summary, []string{change.Kind, change.Operation, change.Detail, flag})
+	}
+	printSummaryTable("kubefirst-api Swagger Changes", summary)
+	fmt.Printf("\n%d changes, %d potentially breaking\n", len(changes), breaking)
+}
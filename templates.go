@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Template names become block names in config.hcl, so they must be valid HCL identifiers
+var templateNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+
+// Flags that only make sense for the cloud a template was saved from; everything else is reused across clouds
+var cloudSpecificTemplateFlags = []string{"cloud-region", "cloud-zone", "node-type"}
+
+// Flags that identify a single cluster and are never saved in a template
+var excludedTemplateFlags = []string{"cluster-name", "KUBEFIRST_PATH"}
+
+// templateDefault returns the template's value for a kubefirst flag when creating a config for cloud
+func (t ConfigTemplate) templateDefault(cloud, flag string) (string, bool) {
+	if contains(cloudSpecificTemplateFlags, flag) && !strings.EqualFold(t.Cloud, cloud) {
+		return "", false
+	}
+	value, ok := t.Flags[flag]
+	return value, ok
+}
+
+func newConfigTemplate(config *CloudConfig) ConfigTemplate {
+	template := ConfigTemplate{
+		Cloud: config.CloudPrefix,
+		Flags: make(map[string]string),
+	}
+	config.Flags.Range(func(k, v interface{}) bool {
+		flag := k.(string)
+		value := v.(string)
+		if value != "" && !contains(excludedTemplateFlags, flag) {
+			template.Flags[flag] = value
+		}
+		return true
+	})
+	return template
+}
+
+// promptTemplateSelection asks which saved template to start from, returning "" to start without one
+func promptTemplateSelection(indexFile IndexFile) (string, error) {
+	if len(indexFile.Templates) == 0 {
+		return "", nil
+	}
+
+	names := make([]string, 0, len(indexFile.Templates))
+	for name := range indexFile.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	options := []huh.Option[string]{huh.NewOption("Don't use a template", "")}
+	for _, name := range names {
+		label := name
+		if cloud := indexFile.Templates[name].Cloud; cloud != "" {
+			label = fmt.Sprintf("%s (%s)", name, cloud)
+		}
+		options = append(options, huh.NewOption(label, name))
+	}
+
+	var selected string
+	err := runField(newSelect("Start from a saved template?", &selected, options...))
+	return selected, err
+}
+
+// promptSaveTemplate offers to save the config's values as a named template in indexFile
+func promptSaveTemplate(config *CloudConfig, indexFile IndexFile) error {
+	var save bool
+	err := runField(newConfirm("Do you want to save these values as a template for future configs?", &save))
+	if err != nil || !save {
+		return err
+	}
+
+	var name string
+	err = runField(newInput("Enter a template name", &name).
+		Placeholder(strings.ToLower(config.CloudPrefix) + "-dev-small").
+		Validate(func(name string) error {
+			if !templateNamePattern.MatchString(name) {
+				return fmt.Errorf("use letters, digits, '-' and '_', starting with a letter")
+			}
+			return nil
+		}))
+	if err != nil {
+		return err
+	}
+
+	if _, exists := indexFile.Templates[name]; exists {
+		var overwrite bool
+		err = runField(newConfirm(fmt.Sprintf("Template '%s' already exists. Overwrite it?", name), &overwrite))
+		if err != nil || !overwrite {
+			return err
+		}
+	}
+
+	template := newConfigTemplate(config)
+	err = updateIndex(func(indexFile *IndexFile) error {
+		if indexFile.Templates == nil {
+			indexFile.Templates = make(map[string]ConfigTemplate)
+		}
+		indexFile.Templates[name] = template
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Info("Saved config template", "name", name, "cloud", config.CloudPrefix)
+	return nil
+}
+
+func manageTemplates() {
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations. Please ensure that the config.hcl file exists and is correctly formatted.")
+		return
+	}
+
+	if len(indexFile.Templates) == 0 {
+		fmt.Println("No templates found. You can save one at the end of 'Create Config'.")
+		return
+	}
+
+	names := make([]string, 0, len(indexFile.Templates))
+	for name := range indexFile.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	summary := [][]string{{"Template", "Cloud", "Flags"}}
+	for _, name := range names {
+		template := indexFile.Templates[name]
+		flags := make([]string, 0, len(template.Flags))
+		for flag := range template.Flags {
+			flags = append(flags, flag)
+		}
+		sort.Strings(flags)
+		summary = append(summary, []string{name, template.Cloud, strings.Join(flags, ", ")})
+	}
+	printSummaryTable("Config Templates", summary)
+
+	options := []huh.Option[string]{huh.NewOption("Back", "")}
+	for _, name := range names {
+		options = append(options, huh.NewOption("Delete "+name, name))
+	}
+
+	var selected string
+	err = runField(newSelect("Select a template to delete", &selected, options...))
+	if err != nil {
+		log.Error("Error in template selection", "error", err)
+		return
+	}
+	if selected == "" {
+		return
+	}
+
+	err = updateIndex(func(indexFile *IndexFile) error {
+		delete(indexFile.Templates, selected)
+		return nil
+	})
+	if err != nil {
+		log.Error("Error updating index file", "error", err)
+		return
+	}
+	fmt.Printf("Template '%s' has been deleted.\n", selected)
+}
+
+func writeTemplatesBlock(rootBody *hclwrite.Body, templates map[string]ConfigTemplate) {
+	if len(templates) == 0 {
+		return
+	}
+
+	templatesBody := rootBody.AppendNewBlock("templates", nil).Body()
+	for name, template := range templates {
+		templateBody := templatesBody.AppendNewBlock(name, nil).Body()
+		if template.Cloud != "" {
+			templateBody.SetAttributeValue("cloud", cty.StringVal(template.Cloud))
+		}
+		flagsBody := templateBody.AppendNewBlock("flags", nil).Body()
+		for flag, value := range template.Flags {
+			flagsBody.SetAttributeValue(flag, cty.StringVal(value))
+		}
+	}
+}
+
+type templateBlock struct {
+	Cloud string      `hcl:"cloud,optional"`
+	Flags *flagsBlock `hcl:"flags,block"`
+}
+
+// decodeTemplateBlocks reads the templates block of config.hcl, the counterpart of writeTemplatesBlock
+func decodeTemplateBlocks(body *hclsyntax.Body) (map[string]ConfigTemplate, error) {
+	templates := make(map[string]ConfigTemplate)
+	for _, block := range body.Blocks {
+		var decoded templateBlock
+		diags := gohcl.DecodeBody(block.Body, nil, &decoded)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("error decoding template %s: %s", block.Type, diags)
+		}
+		template := ConfigTemplate{Cloud: decoded.Cloud, Flags: make(map[string]string)}
+		if decoded.Flags != nil {
+			for name, value := range decoded.Flags.Values {
+				template.Flags[name] = value
+			}
+		}
+		templates[block.Type] = template
+	}
+	return templates, nil
+}
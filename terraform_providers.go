@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const gitopsTemplateRepo = "github.com/konstructio/gitops-template"
+
+// terraformProvider is a provider requirement found in the gitops template
+type terraformProvider struct {
+	Name        string
+	Source      string
+	Constraints []string
+	// Exact version recorded in the lock file once the provider has been downloaded. It's advisory: kubefirst's
+	// terraform resolves versions from the template's constraints and never reads k1space's lock file.
+	PinnedVersion string
+}
+
+// getTerraformPluginCacheDir returns shared_cache.terraform_plugin_cache from settings.hcl, or k1space's own cache
+func getTerraformPluginCacheDir() string {
+	settings, err := loadSettings()
+	if err == nil && settings.SharedCache.TerraformPluginCache != "" {
+		return expandHome(settings.SharedCache.TerraformPluginCache)
+	}
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".cache", "terraform-plugins")
+}
+
+func getTerraformPinDir(cloud string) string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".cache", "terraform-providers", strings.ToLower(cloud))
+}
+
+func cacheTerraformProviders() {
+	log.Info("Starting cacheTerraformProviders function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations. Please ensure that the config.hcl file exists and is correctly formatted.")
+		return
+	}
+
+	selectedConfig, err := promptConfigSelection(indexFile, "Select a configuration to cache terraform providers for")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations found.")
+		return
+	}
+	cloud := strings.Split(selectedConfig, "_")[0]
+
+	if _, err := exec.LookPath("terraform"); err != nil {
+		fmt.Println("terraform could not be found. Please install it and try again.")
+		return
+	}
+
+	s := startSpinner("Fetching the kubefirst gitops template...")
+	templateDir, err := fetchGitopsTemplate()
+	stopSpinner(s, err == nil)
+	if err != nil {
+		fmt.Println("Failed to fetch the gitops template:", err)
+		return
+	}
+
+	providers, err := findTerraformProviders(templateDir, kubefirstCloudCommand(cloud))
+	if err != nil {
+		log.Error("Error reading terraform providers", "error", err)
+		fmt.Println("Failed to read terraform providers from the gitops template:", err)
+		return
+	}
+	if len(providers) == 0 {
+		fmt.Printf("No terraform providers found for %s in the gitops template.\n", cloud)
+		return
+	}
+
+	s = startSpinner(fmt.Sprintf("Downloading %d terraform providers into the plugin cache...", len(providers)))
+	err = pinTerraformProviders(providers, getTerraformPinDir(cloud))
+	stopSpinner(s, err == nil)
+	if err != nil {
+		fmt.Println("Failed to download terraform providers:", err)
+		return
+	}
+
+	summary := [][]string{{"Provider", "Source", "Constraint", "Pinned Version"}}
+	var unpinned []string
+	for _, provider := range providers {
+		constraint := strings.Join(provider.Constraints, ", ")
+		if constraint == "" {
+			constraint = "(none)"
+			unpinned = append(unpinned, provider.Source)
+		}
+		summary = append(summary, []string{provider.Name, provider.Source, constraint, provider.PinnedVersion})
+	}
+	printSummaryTable("Terraform Providers", summary)
+
+	if len(unpinned) > 0 {
+		fmt.Printf("\nWarning: the gitops template does not constrain %s; kubefirst will use whatever version is newest.\n", strings.Join(unpinned, ", "))
+	}
+	fmt.Printf("\nPlugin cache: %s\n", getTerraformPluginCacheDir())
+	fmt.Printf("Lock file: %s\n", filepath.Join(getTerraformPinDir(cloud), ".terraform.lock.hcl"))
+	fmt.Println("Generated 00-init.sh scripts set TF_PLUGIN_CACHE_DIR, so provisioning reuses these downloads.")
+	fmt.Println("The pinned versions are advisory: kubefirst's terraform doesn't read this lock file. It picks versions from the template's constraints itself, and uses the cache only when it picks a cached version.")
+}
+
+// fetchGitopsTemplate clones the gitops template next to the kubefirst repositories, or syncs it if it's already there
+func fetchGitopsTemplate() (string, error) {
+	repoDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", ".repositories")
+	repoPath := filepath.Join(repoDir, filepath.Base(gitopsTemplateRepo))
+
+	if _, err := os.Stat(repoPath); err == nil {
+		if status := syncRepository(context.Background(), repoPath, "main"); strings.HasPrefix(status, "Failed") {
+			log.Warn("Could not sync gitops template, using local copy", "path", repoPath, "status", status)
+		}
+		return repoPath, nil
+	}
+
+	err := os.MkdirAll(repoDir, 0755)
+	if err != nil {
+		return "", fmt.Errorf("error creating repositories directory: %w", err)
+	}
+	output, err := exec.Command("git", "clone", "--depth", "1", "https://"+gitopsTemplateRepo+".git", repoPath).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("error cloning %s: %w\nOutput: %s", gitopsTemplateRepo, err, string(output))
+	}
+	return repoPath, nil
+}
+
+// findTerraformProviders collects required_providers from the template directories for a cloud, e.g. civo-github and civo-gitlab
+func findTerraformProviders(templateDir, cloud string) ([]terraformProvider, error) {
+	providers := make(map[string]*terraformProvider)
+
+	dirs, err := filepath.Glob(filepath.Join(templateDir, cloud+"-*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || filepath.Ext(path) != ".tf" {
+				return err
+			}
+			return parseRequiredProviders(path, providers)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]terraformProvider, 0, len(providers))
+	for _, provider := range providers {
+		sort.Strings(provider.Constraints)
+		result = append(result, *provider)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Source < result[j].Source
+	})
+	return result, nil
+}
+
+func parseRequiredProviders(path string, providers map[string]*terraformProvider) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	// The template contains placeholders kubefirst fills in later, so skip files that aren't valid HCL yet
+	file, diags := hclsyntax.ParseConfig(data, path, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		log.Debug("Skipping unparseable terraform file", "path", path, "error", diags)
+		return nil
+	}
+
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "terraform" {
+			continue
+		}
+		for _, inner := range block.Body.Blocks {
+			if inner.Type != "required_providers" {
+				continue
+			}
+			for name, attr := range inner.Body.Attributes {
+				value, diags := attr.Expr.Value(nil)
+				if diags.HasErrors() {
+					continue
+				}
+
+				source, version := "hashicorp/"+name, ""
+				switch {
+				case value.Type() == cty.String:
+					// Legacy form: name = "version constraint"
+					version = value.AsString()
+				case value.Type().IsObjectType():
+					if s := stringAttr(value, "source"); s != "" {
+						source = s
+					}
+					version = stringAttr(value, "version")
+				}
+
+				source = strings.TrimPrefix(strings.ToLower(source), "registry.terraform.io/")
+				provider, ok := providers[source]
+				if !ok {
+					provider = &terraformProvider{Name: name, Source: source}
+					providers[source] = provider
+				}
+				if version != "" && !contains(provider.Constraints, version) {
+					provider.Constraints = append(provider.Constraints, version)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func stringAttr(value cty.Value, name string) string {
+	if !value.Type().HasAttribute(name) {
+		return ""
+	}
+	attr := value.GetAttr(name)
+	if attr.IsNull() || !attr.IsKnown() || attr.Type() != cty.String {
+		return ""
+	}
+	return attr.AsString()
+}
+
+// pinTerraformProviders runs terraform init against the providers in dir, filling the shared plugin cache
+// and recording the resolved versions in dir's .terraform.lock.hcl. Versions already in the lock file are kept,
+// so the pins only move once the lock file is deleted.
+func pinTerraformProviders(providers []terraformProvider, dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return fmt.Errorf("error creating provider directory: %w", err)
+	}
+	err = os.MkdirAll(getTerraformPluginCacheDir(), 0755)
+	if err != nil {
+		return fmt.Errorf("error creating plugin cache directory: %w", err)
+	}
+
+	var tf strings.Builder
+	tf.WriteString("terraform {\n  required_providers {\n")
+	for _, provider := range providers {
+		tf.WriteString(fmt.Sprintf("    %s = {\n      source = %q\n", provider.Name, provider.Source))
+		if len(provider.Constraints) > 0 {
+			tf.WriteString(fmt.Sprintf("      version = %q\n", strings.Join(provider.Constraints, ", ")))
+		}
+		tf.WriteString("    }\n")
+	}
+	tf.WriteString("  }\n}\n")
+	err = os.WriteFile(filepath.Join(dir, "providers.tf"), []byte(tf.String()), 0644)
+	if err != nil {
+		return fmt.Errorf("error writing providers.tf: %w", err)
+	}
+
+	lockPath := filepath.Join(dir, ".terraform.lock.hcl")
+	cmd := exec.Command("terraform", "init", "-backend=false", "-input=false")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), sharedCacheEnv()...)
+	cmd.Env = append(cmd.Env, airgapEnv()...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		// Usually the template's constraints have moved past a pinned version
+		return fmt.Errorf("terraform init failed: %w\nOutput: %s\nIf the constraints changed, delete %s to pin new versions", err, string(output), lockPath)
+	}
+
+	versions, err := readLockedProviderVersions(lockPath)
+	if err != nil {
+		return err
+	}
+	for i := range providers {
+		providers[i].PinnedVersion = versions[providers[i].Source]
+	}
+	return nil
+}
+
+// readLockedProviderVersions maps provider sources (e.g. civo/civo) to the versions in a terraform lock file
+func readLockedProviderVersions(lockPath string) (map[string]string, error) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading lock file: %w", err)
+	}
+	file, diags := hclsyntax.ParseConfig(data, lockPath, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("error parsing lock file: %s", diags)
+	}
+
+	versions := make(map[string]string)
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "provider" || len(block.Labels) != 1 {
+			continue
+		}
+		attr, ok := block.Body.Attributes["version"]
+		if !ok {
+			continue
+		}
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() || value.Type() != cty.String {
+			continue
+		}
+		source := strings.TrimPrefix(block.Labels[0], "registry.terraform.io/")
+		versions[source] = value.AsString()
+	}
+	return versions, nil
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+	"github.com/civo/civogo"
+	"github.com/digitalocean/godo"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// Where each provider's tokens are issued, shown when one is missing or rejected
+var cloudTokenInstructions = map[string]string{
+	"Akamai":       "You can create a new Linode personal access token at https://cloud.linode.com/profile/tokens",
+	"Civo":         "You can create a new Civo API token at https://www.civo.com/account/security",
+	"DigitalOcean": "You can create a new DigitalOcean API token at https://cloud.digitalocean.com/account/api/tokens",
+	"Google":       "Point it at a service account key file, which you can create at https://console.cloud.google.com/iam-admin/serviceaccounts",
+	"Vultr":        "You can create a new Vultr API key at https://my.vultr.com/settings/#settingsapi",
+}
+
+var invalidTokenBannerStyle = lipgloss.NewStyle().
+	Bold(true).
+	Foreground(lipgloss.Color("#FF5F87")).
+	BorderStyle(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("#FF5F87")).
+	Padding(0, 2)
+
+// invalidToken is a cloud token a provider answered with 401 or 403
+type invalidToken struct {
+	Provider string `json:"provider"`
+	Profile  string `json:"profile,omitempty"`
+	// Fingerprint is the token's hash, so the mark goes away once the token is replaced, in k1space or not
+	Fingerprint string    `json:"fingerprint"`
+	StatusCode  int       `json:"status_code"`
+	Error       string    `json:"error"`
+	DetectedAt  time.Time `json:"detected_at"`
+}
+
+// tokenStatusFile is token_status.json, keyed by the variable the token is read from, e.g. CIVO_TOKEN_WORK
+type tokenStatusFile struct {
+	Invalid map[string]invalidToken `json:"invalid"`
+}
+
+func getTokenStatusPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space", "token_status.json")
+}
+
+func loadTokenStatus() (tokenStatusFile, error) {
+	status := tokenStatusFile{Invalid: make(map[string]invalidToken)}
+	data, err := os.ReadFile(getTokenStatusPath())
+	if os.IsNotExist(err) {
+		return status, nil
+	}
+	if err != nil {
+		return status, fmt.Errorf("error reading token_status.json: %w", err)
+	}
+	err = json.Unmarshal(data, &status)
+	if err != nil {
+		return status, fmt.Errorf("error parsing token_status.json: %w", err)
+	}
+	if status.Invalid == nil {
+		status.Invalid = make(map[string]invalidToken)
+	}
+	return status, nil
+}
+
+// updateTokenStatus applies change to token_status.json and writes it back, holding the file's lock throughout
+func updateTokenStatus(change func(status *tokenStatusFile)) error {
+	return withFileLock(getTokenStatusPath(), func() error {
+		status, err := loadTokenStatus()
+		if err != nil {
+			return err
+		}
+		change(&status)
+		data, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding token status: %w", err)
+		}
+		return writeFileAtomic(getTokenStatusPath(), data, 0600)
+	})
+}
+
+// authErrorStatus returns the status of a provider response that rejected the token: a 401 or 403 from any of
+// the provider clients, or a failed exchange of a Google service account key
+func authErrorStatus(err error) (int, bool) {
+	var statusErr *httpStatusError
+	var godoErr *godo.ErrorResponse
+	var civoErr civogo.HTTPError
+	var retrieveErr *oauth2.RetrieveError
+	var googleErr *googleapi.Error
+	code := 0
+	switch {
+	case errors.As(err, &statusErr):
+		code = statusErr.StatusCode
+	case errors.As(err, &godoErr) && godoErr.Response != nil:
+		code = godoErr.Response.StatusCode
+	case errors.As(err, &civoErr):
+		code = civoErr.Code
+	case errors.Is(err, civogo.AuthenticationFailedError):
+		code = http.StatusUnauthorized
+	case errors.As(err, &googleErr):
+		code = googleErr.Code
+	case errors.As(err, &retrieveErr) && retrieveErr.Response != nil:
+		// A revoked or deleted key is refused with 400 invalid_grant
+		if retrieveErr.Response.StatusCode < 500 {
+			code = http.StatusUnauthorized
+		}
+	}
+	return code, code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+func isAuthError(err error) bool {
+	_, ok := authErrorStatus(err)
+	return ok
+}
+
+// tokenFingerprint identifies a token without storing it. Google's is a path, so the key file it points at is
+// hashed too, catching a key replaced in place.
+func tokenFingerprint(provider, value string) string {
+	if provider == "Google" {
+		if data, err := os.ReadFile(value); err == nil {
+			value += "\n" + string(data)
+		}
+	}
+	return hashAccessToken(value)[:16]
+}
+
+// recordTokenError marks the token a provider call used as invalid when the provider rejected it, so the main
+// menu can say so instead of the next config failing the same way. Other errors are ignored.
+func recordTokenError(provider string, err error) {
+	code, ok := authErrorStatus(err)
+	if !ok {
+		return
+	}
+	baseVar := cloudCredentialVar(provider)
+	if baseVar == "" {
+		return
+	}
+	tokenVar := activeTokenVar(baseVar)
+	log.Warn("Cloud token rejected", "cloud", provider, "token", tokenVar, "status", code)
+
+	token := invalidToken{
+		Provider:    provider,
+		Profile:     activeProfiles[baseVar],
+		Fingerprint: tokenFingerprint(provider, os.Getenv(tokenVar)),
+		StatusCode:  code,
+		Error:       err.Error(),
+		DetectedAt:  time.Now().UTC(),
+	}
+	saveErr := updateTokenStatus(func(status *tokenStatusFile) {
+		status.Invalid[tokenVar] = token
+	})
+	if saveErr != nil {
+		log.Warn("Could not record the rejected token", "error", saveErr)
+	}
+}
+
+// clearTokenError removes a provider's token from the invalid ones after a call with it succeeded
+func clearTokenError(provider string) {
+	tokenVar := activeTokenVar(cloudCredentialVar(provider))
+	// Most successful calls have nothing to clear, so the file is only locked and rewritten when they do
+	status, err := loadTokenStatus()
+	if err != nil {
+		return
+	}
+	if _, ok := status.Invalid[tokenVar]; !ok {
+		return
+	}
+	err = updateTokenStatus(func(status *tokenStatusFile) {
+		delete(status.Invalid, tokenVar)
+	})
+	if err != nil {
+		log.Warn("Could not update token status", "error", err)
+	}
+}
+
+// currentInvalidTokens returns the tokens still marked invalid, leaving out those replaced since they were
+// rejected. A token that can't be found any more stays listed, as it still needs replacing.
+func currentInvalidTokens() map[string]invalidToken {
+	status, err := loadTokenStatus()
+	if err != nil {
+		log.Warn("Error loading token status", "error", err)
+		return nil
+	}
+	current := make(map[string]invalidToken)
+	for tokenVar, token := range status.Invalid {
+		value := os.Getenv(tokenVar)
+		if value == "" {
+			value, _ = keyringGet(tokenVar)
+		}
+		if value != "" && tokenFingerprint(token.Provider, value) != token.Fingerprint {
+			continue
+		}
+		current[tokenVar] = token
+	}
+	return current
+}
+
+func (t invalidToken) describe() string {
+	return fmt.Sprintf("rejected by %s with %d %s, %s", t.Provider, t.StatusCode, http.StatusText(t.StatusCode), formatCloudDataAge(time.Since(t.DetectedAt)))
+}
+
+// printInvalidTokenBanner warns above the main menu about tokens a provider rejected, and returns whether there
+// were any
+func printInvalidTokenBanner() bool {
+	invalid := currentInvalidTokens()
+	if len(invalid) == 0 {
+		return false
+	}
+	lines := []string{"Invalid cloud tokens"}
+	for _, tokenVar := range sortedKeys(invalid) {
+		lines = append(lines, fmt.Sprintf("%s: %s", tokenVar, invalid[tokenVar].describe()))
+	}
+	lines = append(lines, "Choose 'Replace Invalid Tokens' to issue and store new ones.")
+	fmt.Println(invalidTokenBannerStyle.Render(strings.Join(lines, "\n")))
+	return true
+}
+
+// replaceInvalidTokens is the main menu's 'Replace Invalid Tokens', shown while any token is marked invalid
+func replaceInvalidTokens() {
+	invalid := currentInvalidTokens()
+	if len(invalid) == 0 {
+		fmt.Println("No cloud tokens are marked invalid.")
+		return
+	}
+	for _, tokenVar := range sortedKeys(invalid) {
+		token := invalid[tokenVar]
+		baseVar := cloudCredentialVar(token.Provider)
+		previous := activeProfiles[baseVar]
+		useCredentialProfile(token.Provider, token.Profile)
+		replaced := promptReplaceCloudToken(token.Provider)
+		useCredentialProfile(token.Provider, previous)
+		if !replaced {
+			return
+		}
+	}
+}
+
+// promptReplaceCloudToken walks through issuing a new token for a provider whose token was rejected: where to
+// create it, entering it, checking it with the provider and saving it to the keychain. It returns whether a
+// working token is now in use.
+func promptReplaceCloudToken(provider string) bool {
+	tokenVar := activeTokenVar(cloudCredentialVar(provider))
+	fmt.Printf("\n%s was rejected by %s. It may have expired or been revoked.\n%s\n\n", tokenVar, provider, cloudTokenInstructions[provider])
+
+	previous, hadPrevious := os.LookupEnv(tokenVar)
+	// lookupToken exports tokens it finds in the keychain, so only a value that differs came from the shell
+	saved, _ := keyringGet(tokenVar)
+	fromShell := hadPrevious && previous != saved
+	restore := func() {
+		if hadPrevious {
+			os.Setenv(tokenVar, previous)
+		} else {
+			os.Unsetenv(tokenVar)
+		}
+	}
+
+	for {
+		var value string
+		title := fmt.Sprintf("Enter a new %s", tokenVar)
+		if provider == "Google" {
+			title = "Path to a new service account key file"
+		}
+		input := newPasswordInput
+		if provider == "Google" {
+			input = newInput
+		}
+		err := runField(input(title, &value).
+			Description("Leave empty to skip"))
+		if err != nil {
+			log.Error("Error in token input", "error", err)
+			return false
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			fmt.Println("No token entered; nothing changed.")
+			return false
+		}
+
+		os.Setenv(tokenVar, value)
+		err = runCancellable(fmt.Sprintf("Checking the new %s with %s...", tokenVar, provider), func(ctx context.Context) error {
+			return validateCloudToken(ctx, provider)
+		})
+		if err != nil {
+			restore()
+			if !isAuthError(err) {
+				err = describeNetworkError("Checking "+tokenVar, err)
+				fmt.Printf("Could not check the new token: %v\n", err)
+				return false
+			}
+			retry := true
+			err = runField(newConfirm(fmt.Sprintf("%s rejected the new token too. Try another?", provider), &retry).
+				Description(err.Error()))
+			if err != nil || !retry {
+				return false
+			}
+			continue
+		}
+
+		clearTokenError(provider)
+		err = keyringSet(tokenVar, value)
+		if err != nil {
+			log.Error("Error saving token to keychain", "token", tokenVar, "error", err)
+			fmt.Printf("The new %s works but couldn't be saved to the keychain (%v); it's only used until k1space exits.\n", tokenVar, err)
+			return true
+		}
+		fmt.Printf("✅ The new %s works and is saved to the keychain.\n", tokenVar)
+		if fromShell {
+			fmt.Printf("%s is also set in your environment, which k1space reads first; update it there too, e.g. in your shell profile.\n", tokenVar)
+		}
+		return true
+	}
+}
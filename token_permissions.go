@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/digitalocean/godo"
+	"google.golang.org/api/cloudresourcemanager/v1"
+	"google.golang.org/api/option"
+)
+
+const (
+	permissionGranted = "Granted"
+	permissionMissing = "Missing"
+)
+
+// Scopes kubefirst asks for on a classic GitHub personal access token
+var githubRequiredScopes = []string{"admin:org", "admin:org_hook", "admin:public_key", "admin:repo_hook", "delete_repo", "repo", "user", "workflow", "write:packages"}
+
+// Scopes kubefirst asks for on a GitLab personal access token
+var gitlabRequiredScopes = []string{"api", "read_repository", "write_repository", "read_registry", "write_registry"}
+
+// Linode resources kubefirst's terraform creates, each needing the <resource>:read_write scope
+var linodeRequiredScopes = []string{"linodes", "lke", "domains", "nodebalancers", "volumes", "object_storage"}
+
+// IAM permissions kubefirst's terraform needs on the Google Cloud project
+var googleRequiredPermissions = []string{
+	"container.clusters.create",
+	"compute.networks.create",
+	"compute.subnetworks.create",
+	"dns.managedZones.list",
+	"dns.changes.create",
+	"storage.buckets.create",
+	"iam.serviceAccounts.create",
+	"resourcemanager.projects.setIamPolicy",
+	"cloudkms.keyRings.create",
+}
+
+// tokenPermission is one row of the permission pre-flight
+type tokenPermission struct {
+	Token      string
+	Permission string
+	Status     string
+}
+
+func permissionStatus(granted bool) string {
+	if granted {
+		return permissionGranted
+	}
+	return permissionMissing
+}
+
+// githubScopeGranted treats admin:X as covering write:X and read:X, the way GitHub does
+func githubScopeGranted(granted []string, scope string) bool {
+	_, name, hasPrefix := strings.Cut(scope, ":")
+	for _, g := range granted {
+		if g == scope || (hasPrefix && g == "admin:"+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitScopes parses scope headers like "repo, workflow" or "linodes:read_write domains:read_only"
+func splitScopes(header string) []string {
+	return strings.FieldsFunc(header, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+// scopedGet makes an authenticated GET and returns the response headers and body
+func scopedGet(ctx context.Context, reqURL string, headers map[string]string) (http.Header, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := newHTTPClient().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := readResponseBody(resp)
+	return resp.Header, body, err
+}
+
+func checkGitHubPermissions(ctx context.Context) []tokenPermission {
+	token := lookupToken("GITHUB_TOKEN")
+	if token == "" {
+		return []tokenPermission{{"GITHUB_TOKEN", "Authenticate", "Not set"}}
+	}
+	header, _, err := scopedGet(ctx, "https://api.github.com/user", map[string]string{"Authorization": "Bearer " + token})
+	if err != nil {
+		return []tokenPermission{{"GITHUB_TOKEN", "Authenticate", "Failed: " + err.Error()}}
+	}
+	// Only classic tokens report their scopes
+	if _, ok := header["X-Oauth-Scopes"]; !ok {
+		return []tokenPermission{{"GITHUB_TOKEN", "Scopes", "Unverified: fine-grained tokens don't report their permissions"}}
+	}
+
+	granted := splitScopes(header.Get("X-OAuth-Scopes"))
+	rows := make([]tokenPermission, 0, len(githubRequiredScopes))
+	for _, scope := range githubRequiredScopes {
+		rows = append(rows, tokenPermission{"GITHUB_TOKEN", scope, permissionStatus(githubScopeGranted(granted, scope))})
+	}
+	return rows
+}
+
+func checkGitLabPermissions(ctx context.Context) []tokenPermission {
+	token := lookupToken("GITLAB_TOKEN")
+	if token == "" {
+		return []tokenPermission{{"GITLAB_TOKEN", "Authenticate", "Not set"}}
+	}
+	baseURL := os.Getenv("GITLAB_URL")
+	if baseURL == "" {
+		baseURL = defaultGitLabURL
+	}
+	_, body, err := scopedGet(ctx, strings.TrimSuffix(baseURL, "/")+"/api/v4/personal_access_tokens/self", map[string]string{"PRIVATE-TOKEN": token})
+	if err != nil {
+		return []tokenPermission{{"GITLAB_TOKEN", "Authenticate", "Failed: " + err.Error()}}
+	}
+	var self struct {
+		Scopes []string `json:"scopes"`
+	}
+	err = json.Unmarshal(body, &self)
+	if err != nil {
+		return []tokenPermission{{"GITLAB_TOKEN", "Scopes", "Unverified: " + err.Error()}}
+	}
+
+	rows := make([]tokenPermission, 0, len(gitlabRequiredScopes))
+	for _, scope := range gitlabRequiredScopes {
+		rows = append(rows, tokenPermission{"GITLAB_TOKEN", scope, permissionStatus(contains(self.Scopes, scope))})
+	}
+	return rows
+}
+
+func checkLinodePermissions(ctx context.Context, tokenVar string) []tokenPermission {
+	token := lookupToken("LINODE_TOKEN")
+	if token == "" {
+		return []tokenPermission{{tokenVar, "Authenticate", "Not set"}}
+	}
+	header, _, err := scopedGet(ctx, linodeAPI+"/profile", map[string]string{"Authorization": "Bearer " + token})
+	if err != nil {
+		return []tokenPermission{{tokenVar, "Authenticate", "Failed: " + err.Error()}}
+	}
+	if _, ok := header["X-Oauth-Scopes"]; !ok {
+		return []tokenPermission{{tokenVar, "Scopes", "Unverified: Linode did not report the token's scopes"}}
+	}
+
+	granted := splitScopes(header.Get("X-OAuth-Scopes"))
+	rows := make([]tokenPermission, 0, len(linodeRequiredScopes))
+	for _, resource := range linodeRequiredScopes {
+		scope := resource + ":read_write"
+		rows = append(rows, tokenPermission{tokenVar, scope, permissionStatus(contains(granted, "*") || contains(granted, scope))})
+	}
+	return rows
+}
+
+// checkDigitalOceanPermissions can only probe read access: DigitalOcean doesn't report a token's scopes,
+// and checking write access would mean creating resources
+func checkDigitalOceanPermissions(ctx context.Context, tokenVar string) []tokenPermission {
+	client, err := getDigitalOceanClient()
+	if err != nil {
+		return []tokenPermission{{tokenVar, "Authenticate", "Not set"}}
+	}
+	opt := &godo.ListOptions{PerPage: 1}
+	probes := []struct {
+		Permission string
+		Probe      func() error
+	}{
+		{"kubernetes:read", func() error { _, _, err := client.Kubernetes.List(ctx, opt); return err }},
+		{"domain:read", func() error { _, _, err := client.Domains.List(ctx, opt); return err }},
+		{"vpc:read", func() error { _, _, err := client.VPCs.List(ctx, opt); return err }},
+		{"load_balancer:read", func() error { _, _, err := client.LoadBalancers.List(ctx, opt); return err }},
+	}
+
+	rows := make([]tokenPermission, 0, len(probes))
+	for _, probe := range probes {
+		err := probe.Probe()
+		var errResp *godo.ErrorResponse
+		switch {
+		case err == nil:
+			rows = append(rows, tokenPermission{tokenVar, probe.Permission, permissionGranted})
+		case errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusForbidden:
+			rows = append(rows, tokenPermission{tokenVar, probe.Permission, permissionMissing})
+		default:
+			rows = append(rows, tokenPermission{tokenVar, probe.Permission, "Failed: " + err.Error()})
+		}
+	}
+	rows = append(rows, tokenPermission{tokenVar, "Write access", "Unverified: DigitalOcean doesn't report token scopes"})
+	return rows
+}
+
+func checkGooglePermissions(ctx context.Context) []tokenPermission {
+	const tokenVar = "GOOGLE_APPLICATION_CREDENTIALS"
+	httpClient, projectID, err := getGoogleClient(cloudresourcemanager.CloudPlatformReadOnlyScope)
+	if err != nil {
+		return []tokenPermission{{tokenVar, "Authenticate", "Failed: " + err.Error()}}
+	}
+	service, err := cloudresourcemanager.NewService(ctx, option.WithHTTPClient(httpClient))
+	if err != nil {
+		return []tokenPermission{{tokenVar, "Permissions", "Failed: " + err.Error()}}
+	}
+
+	result, err := service.Projects.TestIamPermissions(projectID, &cloudresourcemanager.TestIamPermissionsRequest{
+		Permissions: googleRequiredPermissions,
+	}).Context(ctx).Do()
+	if err != nil {
+		return []tokenPermission{{tokenVar, "Permissions", "Failed: " + err.Error()}}
+	}
+	rows := make([]tokenPermission, 0, len(googleRequiredPermissions))
+	for _, permission := range googleRequiredPermissions {
+		rows = append(rows, tokenPermission{tokenVar, permission, permissionStatus(contains(result.Permissions, permission))})
+	}
+	return rows
+}
+
+// checkTokenPermissions checks the cloud and git tokens a config provisions with
+func checkTokenPermissions(ctx context.Context, cloud string, config Config) []tokenPermission {
+	useCredentialProfile(cloud, configCredentialProfile(config))
+	tokenVar := profileTokenVar(cloudTokenVar(cloud), configCredentialProfile(config))
+
+	var rows []tokenPermission
+	switch strings.ToLower(cloud) {
+	case "akamai":
+		rows = checkLinodePermissions(ctx, tokenVar)
+	case "digitalocean":
+		rows = checkDigitalOceanPermissions(ctx, tokenVar)
+	case "google":
+		rows = checkGooglePermissions(ctx)
+	case "civo", "vultr":
+		// API keys on these clouds carry the account's full access
+		status := "Granted (keys are not scoped)"
+		if err := validateCloudToken(ctx, cloudProviderName(cloud)); err != nil {
+			status = "Failed: " + err.Error()
+		}
+		rows = []tokenPermission{{tokenVar, "All resources", status}}
+	}
+
+	switch strings.ToLower(findConfigFlag(config.Flags, "git-provider")) {
+	case "github":
+		rows = append(rows, checkGitHubPermissions(ctx)...)
+	case "gitlab":
+		rows = append(rows, checkGitLabPermissions(ctx)...)
+	}
+	return rows
+}
+
+// cloudProviderName maps a config's lowercase cloud back to its display name, e.g. digitalocean -> DigitalOcean
+func cloudProviderName(cloud string) string {
+	for _, provider := range cloudProviders {
+		if strings.EqualFold(provider, cloud) {
+			return provider
+		}
+	}
+	return cloud
+}
+
+// runTokenPermissionCheck runs the checks and prints every row that isn't granted
+func runTokenPermissionCheck(cloud string, config Config) ([]tokenPermission, error) {
+	var rows []tokenPermission
+	err := runCancellable("Checking token permissions...", func(ctx context.Context) error {
+		rows = checkTokenPermissions(ctx, cloud, config)
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, describeNetworkError("Checking token permissions", err)
+	}
+
+	problems := [][]string{{"Token", "Permission", "Status"}}
+	for _, row := range rows {
+		if !strings.HasPrefix(row.Status, permissionGranted) {
+			problems = append(problems, []string{row.Token, row.Permission, row.Status})
+		}
+	}
+	if len(problems) == 1 {
+		fmt.Println("All tokens have the permissions kubefirst needs.")
+	} else {
+		printSummaryTable("Missing or Unverified Permissions", problems)
+	}
+	return rows, nil
+}
+
+func hasMissingPermission(rows []tokenPermission) bool {
+	for _, row := range rows {
+		if row.Status == permissionMissing || strings.HasPrefix(row.Status, "Failed") {
+			return true
+		}
+	}
+	return false
+}
+
+func checkTokenPermissionsForConfig() {
+	log.Info("Starting checkTokenPermissionsForConfig function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations:", err)
+		return
+	}
+	selectedConfig, err := promptConfigSelection(indexFile, "Select a config to check token permissions for")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations available. Please create a configuration first.")
+		return
+	}
+
+	cloud := strings.Split(selectedConfig, "_")[0]
+	_, err = runTokenPermissionCheck(cloud, indexFile.Configs[selectedConfig])
+	if err != nil {
+		log.Error("Error checking token permissions", "error", err)
+		fmt.Println(err)
+	}
+}
+
+// confirmTokenPermissions runs the permission pre-flight and asks whether to continue when a permission is missing
+func confirmTokenPermissions(cloud string, config Config) bool {
+	rows, err := runTokenPermissionCheck(cloud, config)
+	if err != nil {
+		log.Warn("Error checking token permissions", "error", err)
+		fmt.Println("Warning:", err)
+		return true
+	}
+	if !hasMissingPermission(rows) {
+		return true
+	}
+
+	fmt.Println(style.Render("⚠️  kubefirst is likely to fail partway through provisioning with these tokens"))
+	var proceed bool
+	err = runField(newConfirm("Continue provisioning anyway?", &proceed))
+	if err != nil {
+		log.Error("Error in confirmation prompt", "error", err)
+		return false
+	}
+	return proceed
+}
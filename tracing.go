@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// How long flushing spans to the collector may hold up k1space
+const tracingFlushTimeout = 5 * time.Second
+
+// span is one timed phase of an operation. The batch span processor exports spans in the background; a trace's
+// spans are flushed once its root ends, so a collector sees each operation as soon as it's done.
+type span struct {
+	trace.Span
+	root bool
+}
+
+// tracerProvider exports spans. It's nil, and spans cost next to nothing, when no collector is configured.
+var (
+	tracerProvider *sdktrace.TracerProvider
+	tracingOnce    sync.Once
+)
+
+// settingsTracesEndpoint returns otlp_endpoint from settings.hcl with /v1/traces appended as the OTLP spec asks.
+// The exporter reads the standard OTEL_EXPORTER_OTLP_* variables itself, which take precedence.
+func settingsTracesEndpoint() string {
+	settings, err := loadSettings()
+	if err != nil || settings.OTLPEndpoint == "" {
+		return ""
+	}
+	return strings.TrimSuffix(settings.OTLPEndpoint, "/") + "/v1/traces"
+}
+
+func tracingEnabled() bool {
+	tracingOnce.Do(func() {
+		if os.Getenv("OTEL_SDK_DISABLED") == "true" {
+			return
+		}
+		var options []otlptracehttp.Option
+		endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+		if endpoint == "" {
+			endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		}
+		if endpoint == "" {
+			endpoint = settingsTracesEndpoint()
+			if endpoint == "" {
+				return
+			}
+			options = append(options, otlptracehttp.WithEndpointURL(endpoint))
+		}
+
+		exporter, err := otlptracehttp.New(context.Background(), options...)
+		if err != nil {
+			log.Warn("Error creating the trace exporter", "error", err)
+			return
+		}
+		host, _ := os.Hostname()
+		// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override these defaults
+		res, err := resource.New(context.Background(),
+			resource.WithAttributes(
+				attribute.String("service.name", "k1space"),
+				attribute.String("service.version", getVersion()),
+				attribute.String("host.name", host),
+			),
+			resource.WithFromEnv(),
+		)
+		if err != nil {
+			log.Warn("Error reading trace resource attributes", "error", err)
+		}
+		tracerProvider = sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(res),
+		)
+		log.Info("Exporting traces", "endpoint", endpoint)
+	})
+	return tracerProvider != nil
+}
+
+// startSpan starts a span named name as a child of the span in ctx, or as a new trace's root. attributes are
+// key/value pairs. The returned context carries the span for its children.
+func startSpan(ctx context.Context, name string, attributes ...string) (context.Context, *span) {
+	if !tracingEnabled() {
+		return ctx, nil
+	}
+	attrs := make([]attribute.KeyValue, 0, len(attributes)/2)
+	for i := 0; i+1 < len(attributes); i += 2 {
+		attrs = append(attrs, attribute.String(attributes[i], attributes[i+1]))
+	}
+	root := !trace.SpanContextFromContext(ctx).IsValid()
+	ctx, s := tracerProvider.Tracer("k1space").Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, &span{Span: s, root: root}
+}
+
+// setAttribute records a detail learned while the span ran; a nil span ignores it
+func (s *span) setAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.SetAttributes(attribute.String(key, value))
+}
+
+// end finishes the span, marking it failed when err isn't nil; a nil span ignores it
+func (s *span) end(err error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.RecordError(err)
+		s.SetStatus(codes.Error, err.Error())
+	} else {
+		s.SetStatus(codes.Ok, "")
+	}
+	s.End()
+
+	if s.root {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer cancel()
+		if err := tracerProvider.ForceFlush(ctx); err != nil {
+			log.Warn("Error exporting traces", "error", err)
+		}
+	}
+}
+
+// shutdownTracing exports any spans still queued before k1space exits
+func shutdownTracing() {
+	if tracerProvider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+	defer cancel()
+	if err := tracerProvider.Shutdown(ctx); err != nil {
+		log.Warn("Error shutting down tracing", "error", err)
+	}
+}
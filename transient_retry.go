@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Bounded so a persistent outage doesn't keep re-running terraform forever
+const maxProvisioningRetries = 2
+
+// transientFailure is a failure that usually clears up on its own, so re-running kubefirst is worth it
+type transientFailure struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Delay   time.Duration
+}
+
+var transientFailures = []transientFailure{
+	{
+		Name:    "cloud provider API error (5xx)",
+		Pattern: regexp.MustCompile(`(?i)(status(\s*code)?[:= ]+5\d\d\b|\b50[0234]\b.*(internal server error|bad gateway|service unavailable|gateway timeout)|internal server error|service unavailable)`),
+		Delay:   30 * time.Second,
+	},
+	{
+		Name:    "image pull timeout",
+		Pattern: regexp.MustCompile(`(?i)(ImagePullBackOff|ErrImagePull|pulling image.*(timeout|deadline exceeded)|TLS handshake timeout)`),
+		Delay:   60 * time.Second,
+	},
+	{
+		Name:    "DNS propagation not ready",
+		Pattern: regexp.MustCompile(`(?i)(no such host|NXDOMAIN|could not resolve host|dns.*propagat)`),
+		Delay:   2 * time.Minute,
+	},
+}
+
+// detectTransientFailure scans the output of a failed run, newest lines first, for a known transient cause
+func detectTransientFailure(failure *provisioningFailure) (transientFailure, bool) {
+	for _, lines := range [][]string{failure.Tail, failure.KubefirstTail} {
+		for i := len(lines) - 1; i >= 0; i-- {
+			for _, transient := range transientFailures {
+				if transient.Pattern.MatchString(lines[i]) {
+					return transient, true
+				}
+			}
+		}
+	}
+	return transientFailure{}, false
+}
+
+// runProvisioningWithRetry runs the provisioning script and offers to re-run it when it fails for a transient reason.
+// kubefirst resumes from its last completed phase, so a re-run only repeats the phase that failed.
+func runProvisioningWithRetry(scriptPath, cloud, region, prefix string, secretEnv []string) error {
+	for attempt := 0; ; attempt++ {
+		err := runProvisioningScript(scriptPath, cloud, region, prefix, secretEnv)
+		if err == nil {
+			return nil
+		}
+
+		var failure *provisioningFailure
+		// Nobody is left to answer the retry prompt after a hangup, and a run the watchdog stopped would likely hang again
+		if !errors.As(err, &failure) || attempt >= maxProvisioningRetries || hungUp.Load() || failure.TimedOut != "" {
+			return err
+		}
+
+		transient, ok := detectTransientFailure(failure)
+		if !ok {
+			return err
+		}
+
+		log.Warn("Transient provisioning failure detected", "cause", transient.Name, "attempt", attempt+1)
+		retry := true
+		err = runField(newConfirm(fmt.Sprintf("This looks like a transient failure (%s). Retry in %s?", transient.Name, transient.Delay), &retry).
+			Description(fmt.Sprintf("Retry %d of %d. kubefirst resumes from the phase that failed.", attempt+1, maxProvisioningRetries)))
+		if err != nil || !retry {
+			return failure
+		}
+
+		s := startSpinner(fmt.Sprintf("Waiting %s before retrying...", transient.Delay))
+		time.Sleep(transient.Delay)
+		stopSpinner(s, true)
+	}
+}
@@ -23,6 +23,24 @@ type CloudConfig struct {
 	Region           string
 	Flags            *sync.Map
 	SelectedNodeType string
+	Architecture     string
+	Spot             bool
+	APIAllowlist     []string
+	PrivateAPI       bool
+	// Raw environment variables (e.g. terraform overrides) written to .local.cloud.env as-is
+	EnvOverrides map[string]string
+	// Nodes to install K3s on, only set for the K3s provider
+	K3s *K3sInventory
+	// Named credential profile the cloud token is read from, "" for the default token
+	CredentialProfile string
+	Topology          *NodeTopology
+}
+
+type K3sInventory struct {
+	Servers    []string
+	Agents     []string
+	SSHUser    string
+	SSHKeyPath string
 }
 
 func NewCloudConfig() *CloudConfig {
@@ -36,27 +54,123 @@ func NewCloudConfig() *CloudConfig {
 }
 
 type IndexFile struct {
-	Version     int               `hcl:"version"`
-	LastUpdated string            `hcl:"last_updated"`
-	Configs     map[string]Config `hcl:"configs"`
+	Version     int                       `hcl:"version"`
+	LastUpdated string                    `hcl:"last_updated"`
+	Configs     map[string]Config         `hcl:"configs"`
+	Templates   map[string]ConfigTemplate `hcl:"templates,omitempty"`
 }
 
 type Config struct {
-	Files []string          `hcl:"files"`
+	Files    []string          `hcl:"files"`
+	Flags    map[string]string `hcl:"flags,omitempty"`
+	State    *ConfigState      `hcl:"state,block"`
+	Topology *NodeTopology     `hcl:"topology,block"`
+	Alerts   *ClusterAlerts    `hcl:"alerts,block"`
+	// Maintenance windows during which the daemon doesn't alert on the cluster
+	Maintenance []MaintenanceWindow `hcl:"maintenance,block"`
+	// Failover links the config to its disaster recovery counterpart
+	Failover *FailoverPair `hcl:"failover,block"`
+	// Bootstrap lists the packs applied after kubefirst finishes
+	Bootstrap *BootstrapPacks `hcl:"bootstrap,block"`
+	// ClusterID is the cloud's ID for the cluster, recorded while it exists so its leftovers can be found later
+	ClusterID string `hcl:"cluster_id,optional"`
+}
+
+// ConfigTemplate is a reusable set of kubefirst flag values saved from a previous createConfig run
+type ConfigTemplate struct {
+	Cloud string            `hcl:"cloud,optional"`
 	Flags map[string]string `hcl:"flags,omitempty"`
 }
 
+// Settings holds user preferences from settings.hcl
+type Settings struct {
+	LocalClusterBackend  string                `hcl:"local_cluster_backend,optional"`
+	OutputFormat         string                `hcl:"output_format,optional"`
+	ProvisionConcurrency map[string]int        `hcl:"provision_concurrency,optional"`
+	AirgapBundle         string                `hcl:"airgap_bundle,optional"`
+	LocalDNS             string                `hcl:"local_dns,optional"`
+	LocalStateStore      string                `hcl:"local_state_store,optional"`
+	OnePasswordVault     string                `hcl:"onepassword_vault,optional"`
+	RequireCommitSigning bool                  `hcl:"require_commit_signing,optional"`
+	SecretBackend        string                `hcl:"secret_backend,optional"`
+	CredentialProfiles   map[string][]string   `hcl:"credential_profiles,optional"`
+	HTTPTimeout          string                `hcl:"http_timeout,optional"`
+	CloudDataTTL         string                `hcl:"cloud_data_ttl,optional"`
+	LogBufferLines       map[string]int        `hcl:"log_buffer_lines,optional"`
+	DetachOnHangup       bool                  `hcl:"detach_on_hangup,optional"`
+	KubefirstFlagAliases map[string]string     `hcl:"kubefirst_flag_aliases,optional"`
+	HealthCheckTimeout   string                `hcl:"health_check_timeout,optional"`
+	ProvisionTimeout     string                `hcl:"provision_timeout,optional"`
+	ProvisionIdleTimeout string                `hcl:"provision_idle_timeout,optional"`
+	PolicyFile           string                `hcl:"policy_file,optional"`
+	MetricsAddress       string                `hcl:"metrics_address,optional"`
+	OTLPEndpoint         string                `hcl:"otlp_endpoint,optional"`
+	NamingPolicy         *NamingPolicy         `hcl:"naming_policy,block"`
+	SharedCache          *SharedCache          `hcl:"shared_cache,block"`
+	Doppler              *DopplerSettings      `hcl:"doppler,block"`
+	AWSSecretsManager    *AWSSecretsManager    `hcl:"aws_secrets_manager,block"`
+	Notifications        *NotificationSettings `hcl:"notifications,block"`
+	Alerts               *AlertSettings        `hcl:"alerts,block"`
+	LogRetention         *LogRetentionSettings `hcl:"log_retention,block"`
+	Webhooks             []WebhookSettings     `hcl:"webhook,block"`
+}
+
+// DopplerSettings selects the Doppler project and config secret references point at
+type DopplerSettings struct {
+	Project string `hcl:"project,optional"`
+	Config  string `hcl:"config,optional"`
+}
+
+// AWSSecretsManager selects where secret references are looked up in AWS Secrets Manager
+type AWSSecretsManager struct {
+	Region string `hcl:"region,optional"`
+	// Prepended to the variable name to form the secret ID, e.g. "k1space/" gives k1space/CIVO_TOKEN
+	Prefix string `hcl:"prefix,optional"`
+}
+
+// SharedCache points build and terraform caches at locations shared between workspaces
+type SharedCache struct {
+	GoModCache           string `hcl:"go_mod_cache,optional"`
+	GoBuildCache         string `hcl:"go_build_cache,optional"`
+	TerraformPluginCache string `hcl:"terraform_plugin_cache,optional"`
+}
+
+type NamingPolicy struct {
+	ClusterNamePattern string   `hcl:"cluster_name_pattern,optional"`
+	PrefixPattern      string   `hcl:"prefix_pattern,optional"`
+	MaxLength          int      `hcl:"max_length,optional"`
+	AllowedPrefixes    []string `hcl:"allowed_prefixes,optional"`
+}
+
 type CloudsFile struct {
 	LastUpdated    string                        `hcl:"last_updated"`
 	CloudRegions   map[string][]string           `hcl:"cloud_regions"`
+	CloudZones     map[string][]string           `hcl:"cloud_zones"`
 	CloudNodeTypes map[string][]InstanceSizeInfo `hcl:"cloud_node_types"`
+	// When each provider's regions and node types were last fetched, in RFC 3339
+	CloudLastUpdated map[string]string `hcl:"cloud_last_updated"`
 }
 
 type InstanceSizeInfo struct {
-	Name          string
-	CPUCores      int
-	RAMMegabytes  int
-	DiskGigabytes int
+	Name             string
+	CPUCores         int
+	RAMMegabytes     int
+	DiskGigabytes    int
+	GPUCount         int
+	GPUModel         string
+	GPUVRAMGigabytes int
+	Architecture     string
+	// In USD, as reported by the provider's API; zero when the provider doesn't report prices
+	PriceHourly  float64
+	PriceMonthly float64
+}
+
+func (i InstanceSizeInfo) HasGPU() bool {
+	return i.GPUCount > 0
+}
+
+func (i InstanceSizeInfo) IsARM() bool {
+	return i.Architecture == archARM64
 }
 
 // GitHubRelease represents the structure of a GitHub release
@@ -67,12 +181,12 @@ type GitHubRelease struct {
 }
 
 var cloudProviders = []string{
-	// "Akamai",
+	"Akamai",
 	// "AWS",
 	"Civo",
 	"DigitalOcean",
-	// "Google Cloud",
-	// "Vultr",
-	// "K3s",
+	"Google",
+	"Vultr",
+	"K3s",
 	"K3d",
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -74,7 +75,7 @@ func printVersionInfo(logger *log.Logger) {
 	// Remote/latest version information
 	remoteRelease, err := getLatestGitHubRelease("ssotops", "k1space")
 	if err != nil {
-		logger.Error("Error fetching remote version info", "error", err)
+		logger.Error("Error fetching remote version info", "error", describeNetworkError("Fetching the latest release", err))
 		return
 	}
 
@@ -142,9 +143,13 @@ func upgradeK1space(logger *log.Logger) {
 
 	// Fetch the latest release information
 	logger.Info("Fetching latest release information...")
-	releaseInfo, err := fetchLatestReleaseInfo(repo)
+	var releaseInfo *GitHubRelease
+	err := runCancellable("Fetching latest release information...", func(ctx context.Context) (err error) {
+		releaseInfo, err = fetchLatestReleaseInfo(ctx, repo)
+		return err
+	})
 	if err != nil {
-		logger.Error("Failed to fetch latest release information", "error", err)
+		logger.Error("Failed to fetch latest release information", "error", describeNetworkError("Fetching the latest release", err))
 		return
 	}
 
@@ -160,9 +165,13 @@ func upgradeK1space(logger *log.Logger) {
 
 	// Download the binary
 	logger.Info("Downloading new version", "version", version, "os", osName, "arch", arch)
-	tempFile, err := downloadBinary(downloadURL)
+	var tempFile string
+	err = runCancellable(fmt.Sprintf("Downloading k1space %s...", version), func(ctx context.Context) (err error) {
+		tempFile, err = downloadBinary(ctx, downloadURL)
+		return err
+	})
 	if err != nil {
-		logger.Error("Failed to download binary", "error", err)
+		logger.Error("Failed to download binary", "error", describeNetworkError("Downloading k1space "+version, err))
 		return
 	}
 	defer os.Remove(tempFile)
@@ -190,12 +199,17 @@ func upgradeK1space(logger *log.Logger) {
 		return
 	}
 
+	err = recordProvenance(binary, execPath, version, downloadURL)
+	if err != nil {
+		logger.Warn("Failed to record binary provenance", "error", err)
+	}
+
 	logger.Info("k1space has been successfully upgraded!", "version", version)
 }
 
 func getLatestGitHubRelease(owner, repo string) (*GitHubRelease, error) {
 	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", owner, repo)
-	resp, err := http.Get(url)
+	resp, err := newHTTPClient().Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -210,6 +224,32 @@ func getLatestGitHubRelease(owner, repo string) (*GitHubRelease, error) {
 	return &release, nil
 }
 
+// httpStatusError is a response readResponseBody rejected, kept so callers can tell a 401 from a 500
+type httpStatusError struct {
+	Host       string
+	Status     string
+	StatusCode int
+	Body       string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("request to %s failed with status %s: %s", e.Host, e.Status, e.Body)
+}
+
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, &httpStatusError{Host: resp.Request.URL.Host, Status: resp.Status, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	}
+	return body, nil
+}
+
 func extractCommitHash(releaseBody string) string {
 	lines := strings.Split(releaseBody, "\n")
 	for _, line := range lines {
@@ -220,9 +260,13 @@ func extractCommitHash(releaseBody string) string {
 	return ""
 }
 
-func fetchLatestReleaseInfo(repo string) (*GitHubRelease, error) {
+func fetchLatestReleaseInfo(ctx context.Context, repo string) (*GitHubRelease, error) {
 	url := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", repo)
-	resp, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := newHTTPClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -237,12 +281,20 @@ func fetchLatestReleaseInfo(repo string) (*GitHubRelease, error) {
 	return &release, nil
 }
 
-func downloadBinary(url string) (string, error) {
-	resp, err := http.Get(url)
+func downloadBinary(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := newDownloadClient().Do(req)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
+	// Don't install (and record a digest for) an error page
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("download of %s failed with status %s", url, resp.Status)
+	}
 
 	tempFile, err := os.CreateTemp("", "k1space-*")
 	if err != nil {
@@ -413,9 +465,10 @@ func getCurrentBranch(repoPath string) (string, error) {
 // Add any other utility functions here as needed
 
 func logOutput(serviceName string, reader io.Reader, logFile *os.File, printer *color.Color, logs *scrollingLog) {
+	redactor := newRedactor()
 	scanner := bufio.NewScanner(reader)
 	for scanner.Scan() {
-		line := scanner.Text()
+		line := redactor.redact(scanner.Text())
 		timestamp := time.Now().Format("15:04:05")
 		formattedLine := fmt.Sprintf("[%s] %s: %s", timestamp, printer.Sprint(serviceName), line)
 		logFile.WriteString(formattedLine + "\n")
@@ -423,20 +476,6 @@ func logOutput(serviceName string, reader io.Reader, logFile *os.File, printer *
 	}
 }
 
-func waitForQuit() {
-	reader := bufio.NewReader(os.Stdin)
-	for {
-		char, _, err := reader.ReadRune()
-		if err != nil {
-			fmt.Println("Error reading input:", err)
-			return
-		}
-		if char == 'q' || char == 'Q' {
-			return
-		}
-	}
-}
-
 func getGlobalKubefirstPath() (string, error) {
 	path, err := exec.LookPath("kubefirst")
 	if err != nil {
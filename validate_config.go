@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"net/mail"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// Flags kubefirst can't create a cluster without, checked when the binary offers them
+var requiredKubefirstFlags = []string{"alerts-email", "cluster-name", "cloud-region", "domain-name"}
+
+var (
+	domainPattern    = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,}$`)
+	subdomainPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+)
+
+// validationResult is the outcome of a single check in the validation report
+type validationResult struct {
+	Flag    string `json:"flag" yaml:"flag"`
+	Check   string `json:"check" yaml:"check"`
+	Passed  bool   `json:"passed" yaml:"passed"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+func validateConfig() {
+	log.Info("Starting validateConfig function")
+
+	indexFile, err := loadIndexFile()
+	if err != nil {
+		log.Error("Error loading index file", "error", err)
+		fmt.Println("Failed to load configurations. Please ensure that the config.hcl file exists and is correctly formatted.")
+		return
+	}
+
+	selectedConfig, err := promptConfigSelection(indexFile, "Select a configuration to validate")
+	if err != nil {
+		log.Error("Error in config selection", "error", err)
+		return
+	}
+	if selectedConfig == "" {
+		fmt.Println("No configurations found.")
+		return
+	}
+
+	reportConfigValidation(selectedConfig, indexFile.Configs[selectedConfig])
+}
+
+// reportConfigValidation checks a config's flags against its kubefirst binary and prints the results
+func reportConfigValidation(selectedConfig string, config Config) {
+	kubefirstPath := config.Flags["KUBEFIRST_PATH"]
+	if kubefirstPath == "" {
+		fmt.Println("This configuration has no kubefirst binary set. Use 'Edit Kubefirst Binary Used for Config' first.")
+		return
+	}
+	if _, err := os.Stat(kubefirstPath); err != nil {
+		fmt.Printf("The kubefirst binary for this configuration no longer exists: %s\n", kubefirstPath)
+		return
+	}
+
+	cloudProvider := strings.Split(selectedConfig, "_")[0]
+	for _, provider := range cloudProviders {
+		if strings.EqualFold(provider, cloudProvider) {
+			cloudProvider = provider
+			break
+		}
+	}
+
+	s := startSpinner("Reading flags from the kubefirst binary...")
+	knownFlags, err := fetchKubefirstFlags(kubefirstPath, cloudProvider)
+	stopSpinner(s, err == nil)
+	if err != nil {
+		log.Error("Error fetching kubefirst flags", "error", err)
+		fmt.Println("Failed to read flags from the kubefirst binary:", err)
+		return
+	}
+
+	cloudsFile, err := loadCloudsFile()
+	if err != nil {
+		log.Warn("Error loading clouds file, skipping region and node type checks", "error", err)
+	}
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings", "error", err)
+		return
+	}
+
+	results := checkConfigFlags(cloudProvider, storedConfigFlags(selectedConfig, config), knownFlags, cloudsFile, settings)
+	policies, err := evaluatePolicies(selectedConfig, config)
+	if err != nil {
+		results = append(results, validationResult{Flag: "policies", Check: "readable", Message: err.Error()})
+	}
+	for _, policy := range policies {
+		// Warnings are reported but don't fail validation, just as they don't block provisioning
+		results = append(results, validationResult{
+			Flag:    "policy",
+			Check:   fmt.Sprintf("%s (%s)", policy.Policy, policy.Enforcement),
+			Passed:  policy.Passed || policy.Enforcement == policyWarn,
+			Message: policy.Message,
+		})
+	}
+	if isStructuredOutput() {
+		printStructured(results)
+		return
+	}
+
+	failed := 0
+	summary := [][]string{{"Flag", "Check", "Result", "Details"}}
+	for _, result := range results {
+		status := "✅ Pass"
+		if !result.Passed {
+			status = "❌ Fail"
+			failed++
+		}
+		summary = append(summary, []string{result.Flag, result.Check, status, result.Message})
+	}
+	printSummaryTable(fmt.Sprintf("Validation Report: %s", selectedConfig), summary)
+
+	if failed > 0 {
+		fmt.Printf("\n%d of %d checks failed. Recreate or edit the config before provisioning.\n", failed, len(results))
+	} else {
+		fmt.Println(style.Render(fmt.Sprintf("\nAll %d checks passed.", len(results))))
+	}
+}
+
+// storedConfigFlags maps a config's index flags back to kubefirst flag names, e.g. K1_CIVO_NYC1_DOMAIN_NAME to domain-name
+func storedConfigFlags(configName string, config Config) map[string]string {
+	envPrefix := configEnvPrefix(configName)
+	flags := make(map[string]string, len(config.Flags))
+	for name, value := range config.Flags {
+		if name == "KUBEFIRST_PATH" || strings.HasSuffix(name, "_KUBEFIRST_PATH") || !strings.HasPrefix(name, envPrefix) {
+			continue
+		}
+		flags[strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(name, envPrefix), "_", "-"))] = value
+	}
+	return flags
+}
+
+// checkConfigFlags checks stored flags against the flags the kubefirst binary accepts, then checks required and formatted values
+func checkConfigFlags(cloudProvider string, stored, known map[string]string, cloudsFile CloudsFile, settings Settings) []validationResult {
+	var results []validationResult
+
+	names := make([]string, 0, len(stored))
+	for name := range stored {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Flags stored under a name this binary has renamed are passed under the new name, see kubefirst_compat.go
+	aliases := flagAliases()
+	storedAs := make(map[string]string, len(stored))
+	for _, name := range names {
+		mapped := mapKubefirstFlag(name, known, aliases)
+		storedAs[mapped] = name
+		if _, ok := known[mapped]; !ok {
+			results = append(results, validationResult{Flag: name, Check: "exists", Message: "no longer accepted by this kubefirst binary"})
+		} else if mapped != name {
+			results = append(results, validationResult{Flag: name, Check: "exists", Passed: true, Message: fmt.Sprintf("passed as --%s", mapped)})
+		} else {
+			results = append(results, validationResult{Flag: name, Check: "exists", Passed: true})
+		}
+	}
+
+	for _, required := range requiredKubefirstFlags {
+		name := mapKubefirstFlag(required, known, aliases)
+		if _, ok := known[name]; !ok {
+			continue
+		}
+		if strings.TrimSpace(stored[storedAs[name]]) == "" {
+			results = append(results, validationResult{Flag: name, Check: "required", Message: "value is empty"})
+		} else {
+			results = append(results, validationResult{Flag: name, Check: "required", Passed: true})
+		}
+	}
+
+	for _, name := range names {
+		value := stored[name]
+		if value == "" {
+			continue
+		}
+		var err error
+		switch mapKubefirstFlag(name, known, aliases) {
+		case "alerts-email":
+			_, err = mail.ParseAddress(value)
+		case "domain-name":
+			if !domainPattern.MatchString(strings.ToLower(value)) {
+				err = fmt.Errorf("%q is not a valid domain", value)
+			}
+		case "subdomain":
+			if !subdomainPattern.MatchString(strings.ToLower(value)) {
+				err = fmt.Errorf("%q is not a valid subdomain", value)
+			}
+		case "cluster-name":
+			err = settings.NamingPolicy.validateClusterName(cloudProvider, value)
+		case "cloud-region":
+			if regions := cloudsFile.CloudRegions[cloudProvider]; len(regions) > 0 && !contains(regions, value) {
+				err = fmt.Errorf("%q is not a known %s region", value, cloudProvider)
+			}
+		case "node-type":
+			if len(cloudsFile.CloudNodeTypes[cloudProvider]) > 0 {
+				if _, ok := findInstanceSize(cloudProvider, value, cloudsFile); !ok {
+					err = fmt.Errorf("%q is not a known %s node type", value, cloudProvider)
+				}
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			results = append(results, validationResult{Flag: name, Check: "format", Message: err.Error()})
+		} else {
+			results = append(results, validationResult{Flag: name, Check: "format", Passed: true})
+		}
+	}
+	return results
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+var makeLintTarget = regexp.MustCompile(`(?m)^lint\s*:`)
+
+// verifyCheck is one lint or test command run in a repository before pushing
+type verifyCheck struct {
+	Name string
+	Args []string
+}
+
+// repoVerifyChecks picks the checks a repository supports from its Makefile, go.mod and package.json
+func repoVerifyChecks(repoPath string) []verifyCheck {
+	var checks []verifyCheck
+
+	if makefile, err := os.ReadFile(filepath.Join(repoPath, "Makefile")); err == nil && makeLintTarget.Match(makefile) {
+		checks = append(checks, verifyCheck{Name: "make lint", Args: []string{"make", "lint"}})
+	}
+	if _, err := os.Stat(filepath.Join(repoPath, "go.mod")); err == nil {
+		checks = append(checks, verifyCheck{Name: "go test", Args: []string{"go", "test", "./..."}})
+	}
+	if packageJSON, err := os.ReadFile(filepath.Join(repoPath, "package.json")); err == nil {
+		var pkg struct {
+			Scripts map[string]string `json:"scripts"`
+		}
+		if json.Unmarshal(packageJSON, &pkg) == nil && pkg.Scripts["lint"] != "" {
+			checks = append(checks, verifyCheck{Name: "yarn lint", Args: []string{"yarn", "lint"}})
+		}
+	}
+	return checks
+}
+
+// describeLocalChanges summarizes what a push would include: uncommitted files and commits ahead of upstream
+func describeLocalChanges(repoPath string) (string, bool) {
+	var changes []string
+
+	output, err := exec.Command("git", "-C", repoPath, "status", "--porcelain").Output()
+	if err == nil {
+		if lines := strings.TrimSpace(string(output)); lines != "" {
+			changes = append(changes, fmt.Sprintf("%d uncommitted", len(strings.Split(lines, "\n"))))
+		}
+	}
+
+	output, err = exec.Command("git", "-C", repoPath, "rev-list", "--count", "@{u}..HEAD").Output()
+	if err != nil {
+		changes = append(changes, "no upstream")
+	} else if ahead := strings.TrimSpace(string(output)); ahead != "0" {
+		changes = append(changes, ahead+" unpushed")
+	}
+
+	if len(changes) == 0 {
+		return "none", false
+	}
+	return strings.Join(changes, ", "), true
+}
+
+func verifyBeforePush() {
+	log.Info("Starting verifyBeforePush function")
+
+	baseDir := filepath.Join(os.Getenv("HOME"), ".ssot", "k1space")
+	repoDir := filepath.Join(baseDir, ".repositories")
+	logsDir := filepath.Join(baseDir, ".logs")
+
+	entries, err := os.ReadDir(repoDir)
+	if err != nil {
+		log.Error("Error reading repositories directory", "error", err)
+		fmt.Println("No repositories found. Run 'Clone Repositories' first.")
+		return
+	}
+
+	var options []huh.Option[string]
+	var selected []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		changes, changed := describeLocalChanges(filepath.Join(repoDir, entry.Name()))
+		options = append(options, huh.NewOption(fmt.Sprintf("%s (local changes: %s)", entry.Name(), changes), entry.Name()))
+		if changed {
+			selected = append(selected, entry.Name())
+		}
+	}
+	if len(options) == 0 {
+		fmt.Println("No repositories found. Run 'Clone Repositories' first.")
+		return
+	}
+
+	err = runField(newMultiSelect("Select repositories to verify (repos with local changes are preselected)", &selected, options...))
+	if err != nil {
+		log.Error("Error in repository selection", "error", err)
+		return
+	}
+	if len(selected) == 0 {
+		fmt.Println("No repositories selected.")
+		return
+	}
+
+	err = os.MkdirAll(logsDir, 0755)
+	if err != nil {
+		log.Error("Error creating logs directory", "error", err)
+		return
+	}
+	timestamp := time.Now().Format("2006-01-02-150405")
+
+	failed := 0
+	summary := [][]string{{"Repository", "Check", "Result", "Duration", "Log"}}
+	for _, repo := range selected {
+		repoPath := filepath.Join(repoDir, repo)
+		checks := repoVerifyChecks(repoPath)
+		if len(checks) == 0 {
+			summary = append(summary, []string{repo, "-", "No lint or test targets found", "", ""})
+			continue
+		}
+
+		for _, check := range checks {
+			if _, err := exec.LookPath(check.Args[0]); err != nil {
+				summary = append(summary, []string{repo, check.Name, check.Args[0] + " not installed", "", ""})
+				failed++
+				continue
+			}
+
+			logPath := filepath.Join(logsDir, fmt.Sprintf("verify-%s-%s-%s.log", repo, strings.ReplaceAll(check.Name, " ", "-"), timestamp))
+			cmd := exec.Command(check.Args[0], check.Args[1:]...)
+			cmd.Dir = repoPath
+			cmd.Env = append(os.Environ(), sharedCacheEnv()...)
+
+			s := startSpinner(fmt.Sprintf("Running %s in %s...", check.Name, repo))
+			startedAt := time.Now()
+			output, err := cmd.CombinedOutput()
+			duration := time.Since(startedAt).Round(time.Second)
+			stopSpinner(s, err == nil)
+
+			if writeErr := os.WriteFile(logPath, output, 0644); writeErr != nil {
+				log.Warn("Error writing verify log", "path", logPath, "error", writeErr)
+			}
+
+			result := "Passed"
+			if err != nil {
+				log.Error("Check failed", "repo", repo, "check", check.Name, "error", err)
+				result = "Failed"
+				failed++
+			}
+			summary = append(summary, []string{repo, check.Name, result, duration.String(), logPath})
+		}
+	}
+
+	printSummaryTable("Verify Before Push", summary)
+	if failed > 0 {
+		fmt.Printf("\n%d checks failed; see the logs above before pushing.\n", failed)
+		return
+	}
+	fmt.Println("\nAll checks passed.")
+}
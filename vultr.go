@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/vultr/govultr/v2"
+	"golang.org/x/oauth2"
+)
+
+// Vultr's largest page size, so most lists take a single call
+const vultrPageSize = 500
+
+func getVultrClient() (*govultr.Client, error) {
+	apiKey := lookupToken("VULTR_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("VULTR_API_KEY not found in environment or keychain. Please set it and try again")
+	}
+	// govultr authenticates through the HTTP client it's given, which also carries the HTTP timeout
+	httpClient := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(&oauth2.Token{AccessToken: strings.TrimSpace(apiKey)}))
+	httpClient.Timeout = getHTTPTimeout()
+	return govultr.NewClient(httpClient), nil
+}
+
+// vultrError restores the status of a failed Vultr call. govultr reports only the response body, which for Vultr
+// carries the status, so a rejected key is still recognised as one.
+func vultrError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var body struct {
+		Error  string `json:"error"`
+		Status int    `json:"status"`
+	}
+	if json.Unmarshal([]byte(err.Error()), &body) != nil || body.Status == 0 {
+		return err
+	}
+	return &httpStatusError{
+		Host:       "api.vultr.com",
+		Status:     fmt.Sprintf("%d %s", body.Status, http.StatusText(body.Status)),
+		StatusCode: body.Status,
+		Body:       body.Error,
+	}
+}
+
+// nextVultrCursor returns the cursor for the page after meta, or "" on the last page
+func nextVultrCursor(meta *govultr.Meta) string {
+	if meta == nil || meta.Links == nil {
+		return ""
+	}
+	return meta.Links.Next
+}
+
+func updateVultrRegions(ctx context.Context, cloudsFile *CloudsFile) error {
+	client, err := getVultrClient()
+	if err != nil {
+		return err
+	}
+
+	var regionIDs []string
+	options := &govultr.ListOptions{PerPage: vultrPageSize}
+	for {
+		regions, meta, err := client.Region.List(ctx, options)
+		if err != nil {
+			return vultrError(err)
+		}
+		for _, region := range regions {
+			if contains(region.Options, "kubernetes") {
+				regionIDs = append(regionIDs, region.ID)
+			}
+		}
+		if options.Cursor = nextVultrCursor(meta); options.Cursor == "" {
+			break
+		}
+	}
+
+	cloudsFile.CloudRegions["Vultr"] = regionIDs
+	return nil
+}
+
+func updateVultrNodeTypes(ctx context.Context, cloudsFile *CloudsFile) error {
+	client, err := getVultrClient()
+	if err != nil {
+		return err
+	}
+
+	var sizeInfos []InstanceSizeInfo
+	options := &govultr.ListOptions{PerPage: vultrPageSize}
+	for {
+		plans, meta, err := client.Plan.List(ctx, "", options)
+		if err != nil {
+			return vultrError(err)
+		}
+		for _, plan := range plans {
+			monthlyCost := float64(plan.MonthlyCost)
+			info := InstanceSizeInfo{
+				Name:          plan.ID,
+				CPUCores:      plan.VCPUCount,
+				RAMMegabytes:  plan.RAM,
+				DiskGigabytes: plan.Disk,
+				Architecture:  detectArchitecture(plan.ID),
+				// Vultr bills hourly up to the monthly price, over a 730 hour month
+				PriceHourly:  monthlyCost / hoursPerMonth,
+				PriceMonthly: monthlyCost,
+			}
+			if plan.GPUType != "" {
+				info.GPUCount = 1
+				info.GPUModel = plan.GPUType
+				info.GPUVRAMGigabytes = plan.GPUVRAM
+			}
+			sizeInfos = append(sizeInfos, info)
+		}
+		if options.Cursor = nextVultrCursor(meta); options.Cursor == "" {
+			break
+		}
+	}
+
+	cloudsFile.CloudNodeTypes["Vultr"] = sizeInfos
+	return nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Events k1space posts to webhooks
+const (
+	eventConfigCreated       = "config.created"
+	eventProvisionStarted    = "provision.started"
+	eventProvisionFinished   = "provision.finished"
+	eventDeprovisionFinished = "deprovision.finished"
+)
+
+var webhookEvents = []string{eventConfigCreated, eventProvisionStarted, eventProvisionFinished, eventDeprovisionFinished}
+
+// WebhookSettings is a webhook block in settings.hcl. k1space POSTs every event named in Events, or all of them
+// when it's empty, to URL as JSON.
+type WebhookSettings struct {
+	URL    string   `hcl:"url"`
+	Events []string `hcl:"events,optional"`
+	// Secret signs each body with HMAC-SHA256, sent as "sha256=<hex>" in X-K1space-Signature
+	Secret string `hcl:"secret,optional"`
+}
+
+// webhookEvent is the JSON body of a webhook. The finished events carry the outcome and, for provisioning, how
+// long the run took and its log.
+type webhookEvent struct {
+	Event       string    `json:"event"`
+	Timestamp   time.Time `json:"timestamp"`
+	Host        string    `json:"host,omitempty"`
+	Config      string    `json:"config"`
+	Cloud       string    `json:"cloud"`
+	Region      string    `json:"region"`
+	Prefix      string    `json:"prefix"`
+	ClusterName string    `json:"cluster_name,omitempty"`
+	// Status is succeeded or failed, and for provisioning also cancelled or timed-out
+	Status          string `json:"status,omitempty"`
+	DurationSeconds int64  `json:"duration_seconds,omitempty"`
+	Error           string `json:"error,omitempty"`
+	ScriptLog       string `json:"script_log,omitempty"`
+}
+
+func newWebhookEvent(event, configName, clusterName string) webhookEvent {
+	host, _ := os.Hostname()
+	payload := webhookEvent{
+		Event:       event,
+		Timestamp:   time.Now().UTC(),
+		Host:        host,
+		Config:      configName,
+		ClusterName: clusterName,
+	}
+	if parts := strings.Split(configName, "_"); len(parts) == 3 {
+		payload.Cloud, payload.Region, payload.Prefix = parts[0], parts[1], parts[2]
+	}
+	return payload
+}
+
+func (w WebhookSettings) wants(event string) bool {
+	return len(w.Events) == 0 || contains(w.Events, event)
+}
+
+func (w WebhookSettings) post(ctx context.Context, event string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "k1space")
+	req.Header.Set("X-K1space-Event", event)
+	if w.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.Secret))
+		mac.Write(body)
+		req.Header.Set("X-K1space-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := newHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	_, err = readResponseBody(resp)
+	return err
+}
+
+// emitWebhookEvent posts an event to the webhooks in settings.hcl that want it. Endpoints that can't be reached
+// are logged; they never fail what k1space was doing.
+func emitWebhookEvent(payload webhookEvent) {
+	settings, err := loadSettings()
+	if err != nil {
+		log.Error("Error loading settings for webhooks", "error", err)
+		return
+	}
+	if len(settings.Webhooks) == 0 {
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Error("Error encoding webhook event", "event", payload.Event, "error", err)
+		return
+	}
+	for _, webhook := range settings.Webhooks {
+		for _, event := range webhook.Events {
+			if !contains(webhookEvents, event) {
+				log.Warn("Unknown event in webhook block of settings.hcl", "event", event, "known", strings.Join(webhookEvents, ", "))
+			}
+		}
+		if !webhook.wants(payload.Event) {
+			continue
+		}
+		err := webhook.post(context.Background(), payload.Event, body)
+		if err != nil {
+			log.Error("Error posting webhook", "event", payload.Event, "url", redactWebhookURL(webhook.URL), "error", err)
+		}
+	}
+}
+
+// redactWebhookURL drops the path and query from a URL for logging, since many services put the token there
+func redactWebhookURL(url string) string {
+	scheme, rest, ok := strings.Cut(url, "://")
+	if !ok {
+		return "<invalid url>"
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	host, _, _ = strings.Cut(host, "?")
+	return fmt.Sprintf("%s://%s/...", scheme, host)
+}
+
+// emitConfigCreated announces a config that wasn't in the index before
+func emitConfigCreated(config *CloudConfig) {
+	clusterName := ""
+	if value, ok := config.Flags.Load("cluster-name"); ok {
+		clusterName = value.(string)
+	}
+	emitWebhookEvent(newWebhookEvent(eventConfigCreated, cloudConfigKey(config), clusterName))
+}
+
+// emitProvisionFinished reports a provisioning run's outcome, duration and script log
+func emitProvisionFinished(configName string, config Config, logDir string, startedAt time.Time, err error) {
+	payload := newWebhookEvent(eventProvisionFinished, configName, findConfigFlag(config.Flags, "cluster-name"))
+	payload.Status = provisioningStatus(err)
+	payload.DurationSeconds = int64(time.Since(startedAt).Seconds())
+	payload.ScriptLog = provisioningScriptLog(logDir, err)
+	if err != nil {
+		payload.Error = err.Error()
+	}
+	emitWebhookEvent(payload)
+}
+
+// emitDeprovisionFinished reports whether a config's deprovision script succeeded
+func emitDeprovisionFinished(configName string, config Config, startedAt time.Time, err error) {
+	payload := newWebhookEvent(eventDeprovisionFinished, configName, findConfigFlag(config.Flags, "cluster-name"))
+	payload.Status = "succeeded"
+	payload.DurationSeconds = int64(time.Since(startedAt).Seconds())
+	if err != nil {
+		payload.Status = "failed"
+		payload.Error = err.Error()
+	}
+	emitWebhookEvent(payload)
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// Markers around the part of the workspace's .gitignore that k1space rewrites
+const (
+	gitignoreBlockStart = "# BEGIN k1space (managed automatically, edits inside this block are overwritten)"
+	gitignoreBlockEnd   = "# END k1space"
+	prePushHookMarker   = "# Installed by k1space"
+)
+
+// Ignored in a git-synced workspace: what's local to a machine, like state file locks, and files that hold
+// credentials in plaintext wherever they are, like the console's .env, kubeconfigs and the local TLS key
+var workspaceIgnoredPaths = []string{
+	".logs/",
+	".cache/",
+	".repositories/",
+	".certs/",
+	"access_tokens.json",
+	"kubeconfig",
+	".env",
+	"*.lock",
+}
+
+// Matches an assignment like CIVO_TOKEN=..., export DO_TOKEN="..." or GITHUB_TOKEN = "..." in config.hcl
+var secretAssignment = regexp.MustCompile(`\b([A-Z][A-Z0-9_]*)\s*[=:]\s*["']?([^"'\s]+)`)
+
+const prePushHook = `#!/bin/sh
+` + prePushHookMarker + `: refuses pushes that add tokens or other credentials to the workspace
+K1SPACE="%s"
+[ -x "$K1SPACE" ] || K1SPACE=k1space
+exec "$K1SPACE" scan-push "$@"
+`
+
+func getWorkspaceDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssot", "k1space")
+}
+
+// workspaceInGit reports whether the workspace is inside a git working tree, e.g. a repository synced between
+// machines or a dotfiles repository
+func workspaceInGit(workspace string) bool {
+	output, err := exec.Command("git", "-C", workspace, "rev-parse", "--is-inside-work-tree").Output()
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+// plaintextEnvFiles returns the configs' .local.cloud.env files, relative to the workspace, that hold a secret
+// in plaintext rather than as a reference to a secret backend
+func plaintextEnvFiles(workspace string, settings Settings) []string {
+	paths, _ := filepath.Glob(filepath.Join(workspace, "*", "*", "*", ".local.cloud.env"))
+	var plaintext []string
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			if name, _ := plaintextSecretAssignment(line, settings); name != "" {
+				rel, _ := filepath.Rel(workspace, path)
+				plaintext = append(plaintext, filepath.ToSlash(rel))
+				break
+			}
+		}
+	}
+	return plaintext
+}
+
+// plaintextSecretAssignment returns the variable line assigns a plaintext secret to and the secret, or "" if it
+// doesn't assign one
+func plaintextSecretAssignment(line string, settings Settings) (string, string) {
+	for _, match := range secretAssignment.FindAllStringSubmatch(line, -1) {
+		name, value := match[1], match[2]
+		if !isSecretEnvVar(name) || len(value) < minSecretLength || strings.HasPrefix(value, "$") {
+			continue
+		}
+		if _, ok := secretProviderForRef(value, settings); ok {
+			continue
+		}
+		return name, value
+	}
+	return "", ""
+}
+
+// renderManagedGitignore replaces k1space's block in a .gitignore, or appends it, leaving the user's own
+// entries alone
+func renderManagedGitignore(existing string, entries []string) string {
+	block := gitignoreBlockStart + "\n" + strings.Join(entries, "\n") + "\n" + gitignoreBlockEnd + "\n"
+	start := strings.Index(existing, gitignoreBlockStart)
+	end := strings.Index(existing, gitignoreBlockEnd)
+	if start >= 0 && end > start {
+		rest := strings.TrimPrefix(existing[end+len(gitignoreBlockEnd):], "\n")
+		return existing[:start] + block + rest
+	}
+	if existing != "" && !strings.HasSuffix(existing, "\n") {
+		existing += "\n"
+	}
+	if existing != "" {
+		existing += "\n"
+	}
+	return existing + block
+}
+
+// maintainWorkspaceGit keeps the workspace's .gitignore and pre-push hook up to date when the workspace is
+// synced with git, and does nothing otherwise
+func maintainWorkspaceGit() {
+	workspace := getWorkspaceDir()
+	if !workspaceInGit(workspace) {
+		return
+	}
+	settings, err := loadSettings()
+	if err != nil {
+		log.Warn("Error loading settings, not updating the workspace .gitignore", "error", err)
+		return
+	}
+
+	plaintext := plaintextEnvFiles(workspace, settings)
+	entries := append(append([]string(nil), workspaceIgnoredPaths...), plaintext...)
+	gitignorePath := filepath.Join(workspace, ".gitignore")
+	err = updateWorkspaceGitignore(gitignorePath, entries)
+	if err != nil {
+		log.Warn("Error updating the workspace .gitignore", "path", gitignorePath, "error", err)
+	}
+
+	// .gitignore doesn't untrack what's already committed
+	if output, err := exec.Command("git", "-C", workspace, "ls-files", "--cached", "--ignored", "--exclude-standard").Output(); err == nil {
+		for _, tracked := range strings.Fields(string(output)) {
+			log.Warn("Committed file is now ignored, remove it with 'git rm --cached'", "path", tracked)
+		}
+	}
+
+	err = installPrePushHook(workspace)
+	if err != nil {
+		log.Warn("Error installing the workspace pre-push hook", "error", err)
+	}
+}
+
+// updateWorkspaceGitignore rewrites k1space's block of the .gitignore under its lock, so processes updating it at
+// the same time don't lose each other's changes or the user's entries
+func updateWorkspaceGitignore(gitignorePath string, entries []string) error {
+	return withFileLock(gitignorePath, func() error {
+		existing, err := os.ReadFile(gitignorePath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		updated := renderManagedGitignore(string(existing), entries)
+		if updated == string(existing) {
+			return nil
+		}
+		err = writeFileAtomic(gitignorePath, []byte(updated), 0644)
+		if err != nil {
+			return err
+		}
+		log.Info("Updated the workspace .gitignore", "path", gitignorePath, "entries", len(entries))
+		return nil
+	})
+}
+
+// installPrePushHook installs the hook that runs 'k1space scan-push', leaving a pre-push hook of the user's own
+// in place
+func installPrePushHook(workspace string) error {
+	// --git-path follows core.hooksPath, and is relative to the workspace unless it's set to an absolute path
+	output, err := exec.Command("git", "-C", workspace, "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return fmt.Errorf("error finding the hooks directory: %w", err)
+	}
+	hooksDir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(workspace, hooksDir)
+	}
+	hookPath := filepath.Join(hooksDir, "pre-push")
+	return withFileLock(hookPath, func() error {
+		return writePrePushHook(hookPath)
+	})
+}
+
+// writePrePushHook writes the hook to hookPath, whose lock the caller holds
+func writePrePushHook(hookPath string) error {
+	existing, err := os.ReadFile(hookPath)
+	if err == nil && !bytes.Contains(existing, []byte(prePushHookMarker)) {
+		log.Warn("A pre-push hook is already installed, not adding k1space's secret scan; call 'k1space scan-push' from it", "path", hookPath)
+		return nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		executable = "k1space"
+	}
+	hook := fmt.Sprintf(prePushHook, executable)
+	if string(existing) == hook {
+		return nil
+	}
+	err = writeFileAtomic(hookPath, []byte(hook), 0755)
+	if err != nil {
+		return err
+	}
+	log.Info("Installed the workspace pre-push hook", "path", hookPath)
+	return nil
+}
+
+// secretFinding is a line a push would add that looks like a credential
+type secretFinding struct {
+	Commit string
+	File   string
+	What   string
+}
+
+// scanPushedSecrets reads the refs being pushed, in the format git gives a pre-push hook, and looks for secrets
+// in the lines their new commits add
+func scanPushedSecrets(refs io.Reader, settings Settings) ([]secretFinding, error) {
+	var findings []secretFinding
+	scanner := bufio.NewScanner(refs)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 {
+			continue
+		}
+		localSHA, remoteSHA := fields[1], fields[3]
+		if strings.Trim(localSHA, "0") == "" {
+			// Deleting a remote branch adds nothing
+			continue
+		}
+		args := []string{"log", "-p", "--no-color", "--no-ext-diff", "--format=commit %H"}
+		if strings.Trim(remoteSHA, "0") == "" {
+			args = append(args, localSHA, "--not", "--remotes")
+		} else {
+			args = append(args, remoteSHA+".."+localSHA)
+		}
+		output, err := exec.Command("git", args...).Output()
+		if err != nil {
+			return nil, fmt.Errorf("error reading the commits to push: %w", err)
+		}
+		findings = append(findings, scanPatch(string(output), settings)...)
+	}
+	return findings, scanner.Err()
+}
+
+// scanPatch looks for secrets in the added lines of `git log -p` output
+func scanPatch(patch string, settings Settings) []secretFinding {
+	var findings []secretFinding
+	var commit, file string
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "commit "):
+			commit = strings.TrimPrefix(line, "commit ")
+		case strings.HasPrefix(line, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+		case strings.HasPrefix(line, "+"):
+			added := line[1:]
+			if name, _ := plaintextSecretAssignment(added, settings); name != "" {
+				findings = append(findings, secretFinding{Commit: commit, File: file, What: "plaintext " + name})
+				continue
+			}
+			for _, pattern := range secretPatterns {
+				if pattern.MatchString(added) {
+					findings = append(findings, secretFinding{Commit: commit, File: file, What: "a token"})
+					break
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// runPrePushScan is 'k1space scan-push', run by the workspace's pre-push hook. It fails the push when the commits
+// being pushed add a token.
+func runPrePushScan(refs io.Reader) int {
+	settings, err := loadSettings()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "k1space: error loading settings:", err)
+		return 1
+	}
+	findings, err := scanPushedSecrets(refs, settings)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "k1space:", err)
+		return 1
+	}
+	if len(findings) == 0 {
+		return 0
+	}
+	for _, finding := range findings {
+		fmt.Fprintf(os.Stderr, "k1space: %s in %s (commit %s)\n", finding.What, finding.File, shortDigest(finding.Commit))
+	}
+	fmt.Fprintln(os.Stderr, "k1space: push refused. Remove the secrets from these commits and push again, or push with --no-verify if they aren't secrets.")
+	return 1
+}